@@ -3,7 +3,13 @@
 package attribute
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"runtime"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/attribute"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
@@ -20,7 +26,8 @@ func new[T any](key string, value T, convert func(string, T) attribute.KeyValue)
 
 // New creates an attribute with automatic type detection.
 // Supported types: bool, []bool, float64, []float64, int, []int, int64, []int64, string, []string.
-// Other types are converted using fmt.Stringer or formatted with %v.
+// Other types are converted using Normalizer, a function registered by RegisterNormalizer, or fmt.Stringer, or
+// formatted with %v.
 func New(key string, value any) Attr {
 	switch v := value.(type) {
 	case bool:
@@ -43,6 +50,15 @@ func New(key string, value any) Attr {
 		return new(key, v, attribute.String)
 	case []string:
 		return new(key, v, attribute.StringSlice)
+	case Normalizer:
+		return new(key, v.Normalize(), attribute.String)
+	}
+
+	if normalized, ok := registeredNormalization(value); ok {
+		return Attr{KeyValue: attribute.String(key, normalized)}
+	}
+
+	switch v := value.(type) {
 	case fmt.Stringer:
 		return new(key, v.String(), attribute.String)
 	default:
@@ -70,3 +86,115 @@ func ToKeyValues(attrs []Attr) []attribute.KeyValue {
 
 	return result
 }
+
+type hashConfig struct {
+	salt string
+	keys map[string]struct{}
+}
+
+var hashing atomic.Pointer[hashConfig]
+
+func hashValue(value string, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	_, _ = mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashedID creates an attribute holding a salted SHA-256 hash of an identifier.
+// The same value and salt always produce the same hash, so users can be correlated without storing raw identifiers.
+func HashedID(key string, value string, salt string) Attr {
+	return Attr{KeyValue: attribute.String(key, hashValue(value, salt))}
+}
+
+// HashKeys configures attribute keys whose values are replaced with a salted hash in logs and spans.
+// Calling HashKeys with no keys disables hashing.
+func HashKeys(salt string, keys ...string) {
+	if len(keys) == 0 {
+		hashing.Store(nil)
+		return
+	}
+
+	config := &hashConfig{salt: salt, keys: make(map[string]struct{}, len(keys))}
+	for _, key := range keys {
+		config.keys[key] = struct{}{}
+	}
+
+	hashing.Store(config)
+}
+
+// IsHashed reports whether values of the key are hashed by HashKeys.
+func IsHashed(key string) bool {
+	config := hashing.Load()
+	if config == nil {
+		return false
+	}
+
+	_, ok := config.keys[key]
+
+	return ok
+}
+
+// ApplyHashing returns the attributes with the values of keys configured by HashKeys hashed.
+// The input slice is returned unchanged when no configured keys are present.
+func ApplyHashing(attrs []Attr) []Attr {
+	config := hashing.Load()
+	if config == nil {
+		return attrs
+	}
+
+	var result []Attr
+
+	for i, attr := range attrs {
+		if _, ok := config.keys[string(attr.Key)]; !ok {
+			continue
+		}
+
+		if result == nil {
+			result = make([]Attr, len(attrs))
+			copy(result, attrs)
+		}
+
+		result[i] = HashedID(string(attr.Key), attr.Value.Emit(), config.salt)
+	}
+
+	if result == nil {
+		return attrs
+	}
+
+	return result
+}
+
+// ErrorFingerprintKey is the attribute key of ErrorFingerprint.
+const ErrorFingerprintKey = "error.fingerprint"
+
+// fingerprintFrames is the number of stack frames hashed into an error fingerprint.
+const fingerprintFrames = 5
+
+// ErrorFingerprint creates an error.fingerprint attribute identifying a failure by the type of the root error and the
+// functions at the top of the stack, so identical failures group together across services and releases.
+// Line numbers are left out so the fingerprint survives unrelated edits. Skip is the number of stack frames to skip
+// above the caller, such as logging helpers.
+func ErrorFingerprint(err error, skip int) Attr {
+	root := err
+	for unwrapped := errors.Unwrap(root); unwrapped != nil; unwrapped = errors.Unwrap(root) {
+		root = unwrapped
+	}
+
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%T\n", root)
+
+	pcs := make([]uintptr, fingerprintFrames)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip+2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+		_, _ = fmt.Fprintln(hash, frame.Function)
+
+		if !more {
+			break
+		}
+	}
+
+	return Attr{KeyValue: attribute.String(ErrorFingerprintKey, hex.EncodeToString(hash.Sum(nil))[:16])}
+}
@@ -0,0 +1,92 @@
+package attribute
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashedID(t *testing.T) {
+	first := HashedID("user.id", "user-123", "salt")
+	second := HashedID("user.id", "user-123", "salt")
+	otherSalt := HashedID("user.id", "user-123", "pepper")
+
+	assert.Equal(t, "user.id", string(first.Key))
+	assert.NotEqual(t, "user-123", first.Value.AsString())
+	assert.Len(t, first.Value.AsString(), 64, "expected hex encoded SHA-256")
+	assert.Equal(t, first.Value.AsString(), second.Value.AsString(), "hash should be deterministic")
+	assert.NotEqual(t, first.Value.AsString(), otherSalt.Value.AsString(), "salt should change the hash")
+}
+
+func TestApplyHashing(t *testing.T) {
+	HashKeys("salt", "user.id")
+	t.Cleanup(func() { HashKeys("") })
+
+	attrs := []Attr{New("user.id", "user-123"), New("plan", "pro")}
+	hashed := ApplyHashing(attrs)
+
+	require.Len(t, hashed, 2)
+	assert.Equal(t, HashedID("user.id", "user-123", "salt").Value.AsString(), hashed[0].Value.AsString())
+	assert.Equal(t, "pro", hashed[1].Value.AsString())
+	assert.Equal(t, "user-123", attrs[0].Value.AsString(), "input should not be modified")
+	assert.True(t, IsHashed("user.id"))
+	assert.False(t, IsHashed("plan"))
+}
+
+func TestApplyHashing_Disabled(t *testing.T) {
+	attrs := []Attr{New("user.id", "user-123")}
+
+	assert.Equal(t, attrs, ApplyHashing(attrs))
+}
+
+type customError struct{}
+
+func (customError) Error() string { return "custom" }
+
+func fingerprintAt(err error) string {
+	return ErrorFingerprint(err, 0).Value.AsString()
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	first := fingerprintAt(errors.New("first"))
+	second := fingerprintAt(errors.New("second message"))
+	wrapped := fingerprintAt(fmt.Errorf("context: %w", customError{}))
+	custom := fingerprintAt(customError{})
+
+	assert.Equal(t, ErrorFingerprintKey, string(ErrorFingerprint(customError{}, 0).Key))
+	assert.Len(t, first, 16)
+	assert.Equal(t, first, second, "messages don't change the fingerprint")
+	assert.Equal(t, custom, wrapped, "the root error type is fingerprinted")
+	assert.NotEqual(t, first, custom)
+	assert.NotEqual(t, custom, ErrorFingerprint(customError{}, 0).Value.AsString(), "different call sites differ")
+}
+
+type orderStatus int
+
+func (s orderStatus) Normalize() string {
+	return []string{"pending", "shipped"}[s]
+}
+
+type paymentState string
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "shipped", New("order.status", orderStatus(1)).Value.AsString())
+
+	RegisterNormalizer(func(s paymentState) string { return Canonical(string(s)) })
+	assert.Equal(t, "payment_failed", New("payment.state", paymentState("PaymentFailed")).Value.AsString())
+}
+
+func TestCanonical(t *testing.T) {
+	for name, expected := range map[string]string{
+		"NotFound":          "not_found",
+		"NOT_FOUND":         "not_found",
+		"OK":                "ok",
+		"HTTPServerError":   "http_server_error",
+		"deadline-exceeded": "deadline_exceeded",
+	} {
+		assert.Equal(t, expected, Canonical(name), name)
+	}
+}
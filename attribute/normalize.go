@@ -0,0 +1,60 @@
+package attribute
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Normalizer is implemented by enum types, such as status codes, whose attribute value should be a canonical
+// lowercase string. New uses Normalize in place of String.
+type Normalizer interface {
+	Normalize() string
+}
+
+var normalizers sync.Map
+
+// RegisterNormalizer makes New convert values of type T with normalize, for enum types declared in other packages
+// that can't implement Normalizer. It is typically called once at startup; see Canonical for a default.
+func RegisterNormalizer[T any](normalize func(T) string) {
+	normalizers.Store(reflect.TypeFor[T](), func(value any) string {
+		v, _ := value.(T)
+
+		return normalize(v)
+	})
+}
+
+// registeredNormalization returns the value converted by the normalizer registered for its type.
+func registeredNormalization(value any) (string, bool) {
+	normalize, ok := normalizers.Load(reflect.TypeOf(value))
+	if !ok {
+		return "", false
+	}
+
+	normalizeFunc, _ := normalize.(func(any) string)
+
+	return normalizeFunc(value), true
+}
+
+// Canonical returns name in lowercase snake case, e.g. "not_found" for "NotFound" or "NOT_FOUND", the canonical
+// form of enum values.
+func Canonical(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	sb := strings.Builder{}
+
+	for i, r := range runes {
+		if r == '-' || r == ' ' {
+			r = '_'
+		}
+
+		// Split words at lowercase to uppercase changes, and before the last capital of an acronym
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+			_, _ = sb.WriteRune('_')
+		}
+
+		_, _ = sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+package gotel
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+// maxBatchSampleErrors bounds the errors recorded on the span, however many items failed.
+const maxBatchSampleErrors = 10
+
+// RecordBatchOutcome records the outcome of a batch operation on the current span and the batch_items counter,
+// for batches too large to trace each item. The span gets batch.size, batch.failed, and batch.success_ratio
+// attributes and an exception event for each of up to 10 sample errors, and its status is set to Error only if
+// every item failed. The counter is incremented by the succeeded and failed items, with batch.name and
+// batch.outcome attributes.
+func RecordBatchOutcome(ctx context.Context, name string, total int, failed int, sampleErrors ...error) {
+	failed = min(max(failed, 0), total)
+	succeeded := total - failed
+
+	ratio := 1.0
+	if total > 0 {
+		ratio = float64(succeeded) / float64(total)
+	}
+
+	span := tracing.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.New("batch.size", total),
+		attribute.New("batch.failed", failed),
+		attribute.New("batch.success_ratio", ratio),
+	)
+
+	for _, err := range sampleErrors[:min(len(sampleErrors), maxBatchSampleErrors)] {
+		span.RecordError(err)
+	}
+
+	if total > 0 && failed == total {
+		span.SetStatus(tracing.StatusError, "all batch items failed")
+	}
+
+	instruments := getMetrics()
+	nameAttr := attribute.New("batch.name", name)
+
+	if succeeded > 0 {
+		instruments.BatchItems.Add(ctx, int64(succeeded), nameAttr, attribute.New("batch.outcome", "success"))
+	}
+
+	if failed > 0 {
+		instruments.BatchItems.Add(ctx, int64(failed), nameAttr, attribute.New("batch.outcome", "failure"))
+	}
+}
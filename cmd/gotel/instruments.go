@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/tinybluerobots/gotel/metrics"
+)
+
+var errStructNotFound = errors.New("struct not found")
+
+const metricsImport = "github.com/tinybluerobots/gotel/metrics"
+
+// instrumentTypes are the names of the metrics package instrument types.
+var instrumentTypes = map[string]bool{
+	"Int64Counter":                   true,
+	"Float64Counter":                 true,
+	"Int64UpDownCounter":             true,
+	"Float64UpDownCounter":           true,
+	"Int64ObservableCounter":         true,
+	"Float64ObservableCounter":       true,
+	"Int64ObservableUpDownCounter":   true,
+	"Float64ObservableUpDownCounter": true,
+	"Int64Gauge":                     true,
+	"Float64Gauge":                   true,
+	"Int64ObservableGauge":           true,
+	"Float64ObservableGauge":         true,
+	"Int64Histogram":                 true,
+	"Float64Histogram":               true,
+	"PreAggregatedHistogram":         true,
+}
+
+// semconvTypes are the metrics package structs that can be embedded in a metrics struct.
+var semconvTypes = map[string]reflect.Type{
+	"SemconvHTTPServer": reflect.TypeFor[metrics.SemconvHTTPServer](),
+	"SemconvHTTPClient": reflect.TypeFor[metrics.SemconvHTTPClient](),
+	"SemconvRPCServer":  reflect.TypeFor[metrics.SemconvRPCServer](),
+	"SemconvRPCClient":  reflect.TypeFor[metrics.SemconvRPCClient](),
+	"SemconvDBClient":   reflect.TypeFor[metrics.SemconvDBClient](),
+	"SemconvMessaging":  reflect.TypeFor[metrics.SemconvMessaging](),
+}
+
+// statement creates an instrument field, or allocates a pointer to a nested metrics struct when Group is set.
+// Family statements create a metrics.Family of the instrument, and Map statements a map holding one per key. Name is
+// the Go expression of the instrument name, and Options the Go expressions of its options.
+type statement struct {
+	Path       string
+	Group      string
+	Family     bool
+	Map        bool
+	Instrument string
+	Name       string
+	Options    []string
+	Tag        string
+}
+
+type initializer struct {
+	Package    string
+	Type       string
+	Statements []statement
+	Meter      bool
+}
+
+var initializerTemplate = template.Must(template.New("initializer").Parse(`// Code generated by gotel metrics. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/tinybluerobots/gotel/metrics"
+{{- if .Meter}}
+	"go.opentelemetry.io/otel/metric"
+{{- end}}
+)
+
+// InitInstruments creates the instruments of m on the meter of b, named and configured by their struct tags as
+// metrics.InitMetrics would, without reflection. InitMetrics, Reinit, NewInstance, and InitScoped call it.
+func (m *{{.Type}}) InitInstruments(b *metrics.Builder) error {
+{{- if .Meter}}
+	meter := b.Meter()
+{{- end}}
+
+	var err error
+{{range .Statements}}
+{{- if .Group}}
+	if m.{{.Path}} == nil {
+		m.{{.Path}} = &{{.Group}}{}
+	}
+{{- else if .Family}}
+	if m.{{.Path}}, err = metrics.WrapFamily[metrics.{{.Instrument}}](b, {{printf "%q" .Path}}, {{.Name}}, {{printf "%q" .Tag}}); err != nil {
+		return err
+	}
+{{- else if .Map}}
+	if m.{{.Path}}, err = metrics.WrapMap[metrics.{{.Instrument}}](b, {{printf "%q" .Path}}, {{.Name}}, {{printf "%q" .Tag}}, m.{{.Path}}); err != nil {
+		return err
+	}
+{{- else if eq .Instrument "PreAggregatedHistogram"}}
+	if m.{{.Path}}, err = metrics.Wrap[metrics.PreAggregatedHistogram](b, {{printf "%q" .Path}}, {{.Name}}, nil, {{printf "%q" .Tag}}); err != nil {
+		return err
+	}
+{{- else}}
+	{
+		name := {{.Name}}
+
+		var inst metric.{{.Instrument}}
+		if inst, err = meter.{{.Instrument}}(name{{range .Options}}, {{.}}{{end}}); err != nil {
+			return err
+		}
+
+		if m.{{.Path}}, err = metrics.Wrap[metrics.{{.Instrument}}](b, {{printf "%q" .Path}}, name, inst, {{printf "%q" .Tag}}); err != nil {
+			return err
+		}
+	}
+{{- end}}
+{{end}}
+	return nil
+}
+`))
+
+// structDecl is a struct declared in the package, with the name its file imports the metrics package as.
+type structDecl struct {
+	fields  *ast.FieldList
+	metrics string
+}
+
+// packageStructs returns the name of the package in dir and the structs it declares.
+func packageStructs(dir string) (string, map[string]structDecl, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	packageName := ""
+	structs := map[string]structDecl{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, err
+		}
+
+		packageName = file.Name.Name
+		metricsName := ""
+
+		for _, spec := range file.Imports {
+			if spec.Path.Value == strconv.Quote(metricsImport) {
+				metricsName = importName(spec)
+			}
+		}
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec, _ := spec.(*ast.TypeSpec)
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+					structs[typeSpec.Name.Name] = structDecl{fields: structType.Fields, metrics: metricsName}
+				}
+			}
+		}
+	}
+
+	return packageName, structs, nil
+}
+
+// instrumentStatement returns the statement creating the instrument field at path, checking its buckets,
+// max_cardinality, and max_keys tags. Fields without a metric tag are named at runtime by metrics.Builder.Name, in the
+// name style of the InitMetrics call.
+func instrumentStatement(path string, fieldName string, instrument string, tag reflect.StructTag, prefix string) (statement, error) {
+	name := "b.Name(" + strconv.Quote(fieldName) + ")"
+	if prefix != "" {
+		name = strconv.Quote(prefix) + " + " + name
+	}
+
+	if metricName := tag.Get("metric"); metricName != "" {
+		name = strconv.Quote(prefix + metricName)
+	}
+
+	options := []string{}
+
+	if unit := tag.Get("unit"); unit != "" {
+		options = append(options, "metric.WithUnit("+strconv.Quote(unit)+")")
+	}
+
+	if description := tag.Get("description"); description != "" {
+		options = append(options, "metric.WithDescription("+strconv.Quote(description)+")")
+	}
+
+	if buckets := tag.Get("buckets"); buckets != "" {
+		bounds := []string{}
+
+		for bucket := range strings.SplitSeq(buckets, ",") {
+			bound, err := strconv.ParseFloat(strings.TrimSpace(bucket), 64)
+			if err != nil {
+				return statement{}, fmt.Errorf("invalid bucket boundary for metric field %s: %w", path, err)
+			}
+
+			bounds = append(bounds, strconv.FormatFloat(bound, 'g', -1, 64))
+		}
+
+		// Only histograms take bucket boundaries, InitMetrics ignores them on other instruments
+		if strings.HasSuffix(instrument, "Histogram") && instrument != "PreAggregatedHistogram" {
+			options = append(options, "metric.WithExplicitBucketBoundaries("+strings.Join(bounds, ", ")+")")
+		}
+	}
+
+	if maxCardinality := tag.Get("max_cardinality"); maxCardinality != "" {
+		if limit, err := strconv.Atoi(maxCardinality); err != nil || limit < 1 {
+			return statement{}, fmt.Errorf("%w: invalid max_cardinality %q for metric field %s", errUnsupported, maxCardinality, path)
+		}
+	}
+
+	if maxKeys := tag.Get("max_keys"); maxKeys != "" {
+		if limit, err := strconv.Atoi(maxKeys); err != nil || limit < 1 {
+			return statement{}, fmt.Errorf("%w: invalid max_keys %q for metric field %s", errUnsupported, maxKeys, path)
+		}
+	}
+
+	return statement{Path: path, Instrument: instrument, Name: name, Options: options, Tag: string(tag)}, nil
+}
+
+// generator collects the statements initializing a metrics struct.
+type generator struct {
+	structs    map[string]structDecl
+	visiting   map[string]bool
+	statements []statement
+}
+
+// addStruct adds the statements for the fields of the named struct, descending into nested structs like InitMetrics.
+// Fields InitMetrics would skip are errors.
+func (g *generator) addStruct(name string, path string, prefix string) error {
+	decl := g.structs[name]
+
+	g.visiting[name] = true
+	defer delete(g.visiting, name)
+
+	for _, field := range decl.fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+
+		fieldType := field.Type
+
+		pointer := false
+		if star, ok := fieldType.(*ast.StarExpr); ok {
+			fieldType, pointer = star.X, true
+		}
+
+		names := []string{}
+
+		for _, ident := range field.Names {
+			if ident.IsExported() {
+				names = append(names, ident.Name)
+			}
+		}
+
+		// Embedded fields are named after their type
+		if field.Names == nil {
+			switch typ := fieldType.(type) {
+			case *ast.SelectorExpr:
+				names = append(names, typ.Sel.Name)
+			case *ast.Ident:
+				if ast.IsExported(typ.Name) {
+					names = append(names, typ.Name)
+				}
+			}
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		switch typ := fieldType.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := typ.X.(*ast.Ident); !ok || ident.Name != decl.metrics {
+				return fmt.Errorf("%w: field %s%s is not an instrument or metrics struct", errUnsupported, path, names[0])
+			}
+
+			for _, fieldName := range names {
+				if err := g.addMetricsType(typ.Sel.Name, pointer, tag, path+fieldName, fieldName, prefix); err != nil {
+					return err
+				}
+			}
+		case *ast.IndexExpr:
+			instrument, ok := familyInstrument(typ, decl.metrics)
+			if !ok || !pointer {
+				return fmt.Errorf("%w: field %s%s is not a pointer to a metrics.Family of an instrument type", errUnsupported, path, names[0])
+			}
+
+			for _, fieldName := range names {
+				s, err := instrumentStatement(path+fieldName, fieldName, instrument, tag, prefix)
+				if err != nil {
+					return err
+				}
+
+				s.Family = true
+				g.statements = append(g.statements, s)
+			}
+		case *ast.MapType:
+			instrument, ok := mapInstrument(typ, decl.metrics)
+			if !ok || pointer {
+				return fmt.Errorf("%w: field %s%s is not a map from string to an instrument pointer", errUnsupported, path, names[0])
+			}
+
+			for _, fieldName := range names {
+				s, err := instrumentStatement(path+fieldName, fieldName, instrument, tag, prefix)
+				if err != nil {
+					return err
+				}
+
+				s.Map = true
+				g.statements = append(g.statements, s)
+			}
+		case *ast.Ident:
+			if _, ok := g.structs[typ.Name]; !ok {
+				return fmt.Errorf("%w: field %s%s is not an instrument or metrics struct", errUnsupported, path, names[0])
+			}
+
+			for _, fieldName := range names {
+				// Self references are skipped, as InitMetrics does
+				if pointer && g.visiting[typ.Name] {
+					continue
+				}
+
+				if pointer {
+					g.statements = append(g.statements, statement{Path: path + fieldName, Group: typ.Name})
+				}
+
+				if err := g.addStruct(typ.Name, path+fieldName+".", prefix+tag.Get("prefix")); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: field %s%s is not an instrument or metrics struct", errUnsupported, path, names[0])
+		}
+	}
+
+	return nil
+}
+
+// familyInstrument returns the instrument type of a metrics.Family[T] type expression, with the metrics package
+// imported as metricsName.
+func familyInstrument(expr *ast.IndexExpr, metricsName string) (string, bool) {
+	isMetrics := func(expr ast.Expr, name string) bool {
+		selector, ok := expr.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+
+		ident, ok := selector.X.(*ast.Ident)
+
+		return ok && ident.Name == metricsName && (name == "" || selector.Sel.Name == name)
+	}
+
+	if !isMetrics(expr.X, "Family") || !isMetrics(expr.Index, "") {
+		return "", false
+	}
+
+	instrument, _ := expr.Index.(*ast.SelectorExpr)
+
+	return instrument.Sel.Name, instrumentTypes[instrument.Sel.Name] && instrument.Sel.Name != "PreAggregatedHistogram"
+}
+
+// mapInstrument returns the instrument type of a map[string]*metrics.T type expression, with the metrics package
+// imported as metricsName.
+func mapInstrument(expr *ast.MapType, metricsName string) (string, bool) {
+	if key, ok := expr.Key.(*ast.Ident); !ok || key.Name != "string" {
+		return "", false
+	}
+
+	star, ok := expr.Value.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+
+	selector, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+
+	if ident, ok := selector.X.(*ast.Ident); !ok || ident.Name != metricsName {
+		return "", false
+	}
+
+	return selector.Sel.Name, instrumentTypes[selector.Sel.Name] && selector.Sel.Name != "PreAggregatedHistogram"
+}
+
+// addMetricsType adds the statements for a field of a metrics package type: an instrument or an embedded
+// Semconv struct.
+func (g *generator) addMetricsType(typeName string, pointer bool, tag reflect.StructTag, path string, fieldName string, prefix string) error {
+	if instrumentTypes[typeName] {
+		if !pointer {
+			return fmt.Errorf("%w: instrument field %s is not a pointer", errUnsupported, path)
+		}
+
+		s, err := instrumentStatement(path, fieldName, typeName, tag, prefix)
+		if err != nil {
+			return err
+		}
+
+		g.statements = append(g.statements, s)
+
+		return nil
+	}
+
+	semconv, ok := semconvTypes[typeName]
+	if !ok || pointer {
+		return fmt.Errorf("%w: field %s has unsupported type metrics.%s", errUnsupported, path, typeName)
+	}
+
+	prefix += tag.Get("prefix")
+
+	for i := range semconv.NumField() {
+		field := semconv.Field(i)
+
+		s, err := instrumentStatement(path+"."+field.Name, field.Name, field.Type.Elem().Name(), field.Tag, prefix)
+		if err != nil {
+			return err
+		}
+
+		g.statements = append(g.statements, s)
+	}
+
+	return nil
+}
+
+// generateInstruments generates the source of an InitInstruments method creating the instruments of the named
+// metrics struct declared in the package in dir.
+func generateInstruments(dir string, name string) ([]byte, error) {
+	packageName, structs, err := packageStructs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := structs[name]; !ok {
+		return nil, fmt.Errorf("%w: %s in %s", errStructNotFound, name, dir)
+	}
+
+	g := &generator{structs: structs, visiting: map[string]bool{}}
+	if err := g.addStruct(name, "", ""); err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(g.statements, func(s statement) bool { return s.Instrument != "" }) {
+		return nil, fmt.Errorf("%w: %s has no instrument fields", errUnsupported, name)
+	}
+
+	meter := slices.ContainsFunc(g.statements, func(s statement) bool {
+		return s.Instrument != "" && !s.Family && s.Instrument != "PreAggregatedHistogram"
+	})
+
+	var buf bytes.Buffer
+	if err := initializerTemplate.Execute(&buf, initializer{Package: packageName, Type: name, Statements: g.statements, Meter: meter}); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,72 @@
+// Command gotel generates instrumentation code.
+//
+// The wrap subcommand generates a decorator for a Go interface that implements the same interface, recording a span
+// and a duration metric for each method call and logging errors, in place of a hand-written instrumentation layer:
+//
+//	//go:generate go run github.com/tinybluerobots/gotel/cmd/gotel wrap -type Store
+//
+// The metrics subcommand generates an InitInstruments method creating the instruments of a metrics struct, named and
+// configured by their struct tags as InitMetrics would, which InitMetrics calls instead of walking the struct by
+// reflection at startup. Fields InitMetrics would skip, such as instruments that aren't pointers and invalid buckets
+// tags, fail generation instead:
+//
+//	//go:generate go run github.com/tinybluerobots/gotel/cmd/gotel metrics -type AppMetrics
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errUsage = errors.New("usage: gotel wrap -type <interface> | metrics -type <struct> [-dir <package dir>] [-o <output file>]")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "gotel:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 || (args[0] != "wrap" && args[0] != "metrics") {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	typeName := flags.String("type", "", "name of the interface to wrap, or of the metrics struct")
+	dir := flags.String("dir", ".", "directory of the package declaring the type")
+	output := flags.String("o", "", "output file, by default <type>_gotel.go in the package directory")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *typeName == "" {
+		return errUsage
+	}
+
+	var (
+		source []byte
+		err    error
+	)
+
+	if args[0] == "metrics" {
+		source, err = generateInstruments(*dir, *typeName)
+	} else {
+		source, err = wrap(*dir, *typeName)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = filepath.Join(*dir, strings.ToLower(*typeName)+"_gotel.go")
+	}
+
+	return os.WriteFile(*output, source, 0o644)
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"sync"
+
+	gotelmetrics "github.com/tinybluerobots/gotel/metrics"
+)
+
+type AppMetrics struct {
+	gotelmetrics.SemconvHTTPServer
+
+	RequestDuration *gotelmetrics.Float64Histogram `unit:"s" buckets:"0.1,1,10"`
+	OrdersPlaced    *gotelmetrics.Int64Counter     `metric:"orders.placed"`
+	Queue           QueueMetrics                   `prefix:"queue_"`
+	Cache           *CacheMetrics
+	TopicMessages   *gotelmetrics.Family[gotelmetrics.Int64Counter] `family_key:"topic"`
+	ShardLag        map[string]*gotelmetrics.Int64Gauge             `keys:"0,1"`
+
+	mu sync.Mutex
+}
+
+type QueueMetrics struct {
+	Depth *gotelmetrics.Int64UpDownCounter
+}
+
+type CacheMetrics struct {
+	HTTPHits *gotelmetrics.Int64Counter
+	Parent   *CacheMetrics
+}
+
+type Invalid struct {
+	Count int
+}
+
+type InvalidBuckets struct {
+	Latency *gotelmetrics.Float64Histogram `buckets:"0.1,fast"`
+}
+
+type InvalidCardinality struct {
+	Requests *gotelmetrics.Int64Counter `max_cardinality:"0"`
+}
+
+type InvalidFamily struct {
+	Requests *gotelmetrics.Family[gotelmetrics.PreAggregatedHistogram]
+}
+
+type InvalidMap struct {
+	Requests map[int]*gotelmetrics.Int64Counter
+}
+
+type NotPointer struct {
+	Requests gotelmetrics.Int64Counter
+}
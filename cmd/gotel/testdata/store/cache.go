@@ -0,0 +1,6 @@
+package store
+
+type Cache interface {
+	Store
+	Purge()
+}
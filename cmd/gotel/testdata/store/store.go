@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+type Store interface {
+	GetUser(ctx context.Context, id int) (*User, error)
+	ListUsers(ctx context.Context, limit, offset int, tags ...string) ([]User, int, error)
+	Export(w io.Writer) error
+	Touch(at time.Time)
+}
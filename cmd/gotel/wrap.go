@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	errInterfaceNotFound = errors.New("interface not found")
+	errUnsupported       = errors.New("unsupported interface")
+)
+
+const gotelImport = "github.com/tinybluerobots/gotel"
+
+// method is an interface method, with its parameters and results as source.
+type method struct {
+	Name       string
+	Params     []string
+	Args       []string
+	Results    []string
+	HasContext bool
+	HasError   bool
+}
+
+// Signature returns the parameter and result lists of the method, naming the results r0, r1, and so on for the
+// deferred end of the call to read the error result.
+func (m method) Signature() string {
+	results := make([]string, len(m.Results))
+	for i, result := range m.Results {
+		results[i] = "r" + strconv.Itoa(i) + " " + result
+	}
+
+	signature := "(" + strings.Join(m.Params, ", ") + ")"
+	if len(results) > 0 {
+		signature += " (" + strings.Join(results, ", ") + ")"
+	}
+
+	return signature
+}
+
+// Call returns the call to the wrapped method.
+func (m method) Call() string {
+	return "w.next." + m.Name + "(" + strings.Join(m.Args, ", ") + ")"
+}
+
+// ErrorResult returns a pointer to the error result, or nil.
+func (m method) ErrorResult() string {
+	if !m.HasError {
+		return "nil"
+	}
+
+	return "&r" + strconv.Itoa(len(m.Results)-1)
+}
+
+type decorator struct {
+	Package   string
+	Interface string
+	// StdImports and Imports are the standard library and other imports.
+	StdImports []string
+	Imports    []string
+	Methods    []method
+}
+
+var decoratorTemplate = template.Must(template.New("decorator").Parse(`// Code generated by gotel wrap. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{.}}
+{{- end}}
+{{if .StdImports}}
+{{end}}
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+
+// Instrumented{{.Interface}} wraps a {{.Interface}}, recording a span and a duration metric for each method call and logging errors.
+type Instrumented{{.Interface}} struct {
+	next {{.Interface}}
+}
+
+var _ {{.Interface}} = (*Instrumented{{.Interface}})(nil)
+
+// NewInstrumented{{.Interface}} returns next wrapped with instrumentation.
+func NewInstrumented{{.Interface}}(next {{.Interface}}) *Instrumented{{.Interface}} {
+	return &Instrumented{{.Interface}}{next: next}
+}
+{{range .Methods}}
+// {{.Name}} calls {{.Name}} on the wrapped {{$.Interface}}.
+func (w *Instrumented{{$.Interface}}) {{.Name}}{{.Signature}} {
+	{{if .HasContext}}ctx{{else}}_{{end}}, end := gotel.StartCall({{if .HasContext}}ctx{{else}}context.Background(){{end}}, "{{$.Interface}}.{{.Name}}")
+	defer end({{.ErrorResult}})
+
+	{{if .Results}}return {{end}}{{.Call}}
+}
+{{end}}`))
+
+// findInterface returns the file of the package in dir declaring the named interface, and the interface.
+func findInterface(dir string, name string) (*token.FileSet, *ast.File, *ast.InterfaceType, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec, _ := spec.(*ast.TypeSpec)
+				if typeSpec.Name.Name != name {
+					continue
+				}
+
+				iface, ok := typeSpec.Type.(*ast.InterfaceType)
+				if !ok || typeSpec.TypeParams != nil {
+					return nil, nil, nil, fmt.Errorf("%w: %s is not a non-generic interface", errUnsupported, name)
+				}
+
+				return fset, file, iface, nil
+			}
+		}
+	}
+
+	return nil, nil, nil, fmt.Errorf("%w: %s in %s", errInterfaceNotFound, name, dir)
+}
+
+func source(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+
+	_ = printer.Fprint(&buf, fset, node)
+
+	return buf.String()
+}
+
+// importName returns the name an import is referred to by, assuming packages are named after the last element of their path.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	name := path.Base(importPath)
+
+	if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	return name
+}
+
+func newMethod(fset *token.FileSet, name string, funcType *ast.FuncType) method {
+	m := method{Name: name}
+
+	for _, field := range funcType.Params.List {
+		typ := source(fset, field.Type)
+
+		for range max(len(field.Names), 1) {
+			if len(m.Params) == 0 && typ == "context.Context" {
+				m.HasContext = true
+				m.Params = append(m.Params, "ctx context.Context")
+				m.Args = append(m.Args, "ctx")
+
+				continue
+			}
+
+			param := "p" + strconv.Itoa(len(m.Params))
+			m.Params = append(m.Params, param+" "+typ)
+
+			if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+				param += "..."
+			}
+
+			m.Args = append(m.Args, param)
+		}
+	}
+
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			for range max(len(field.Names), 1) {
+				m.Results = append(m.Results, source(fset, field.Type))
+			}
+		}
+	}
+
+	m.HasError = len(m.Results) > 0 && m.Results[len(m.Results)-1] == "error"
+
+	return m
+}
+
+// wrap generates the source of a decorator for the named interface declared in the package in dir.
+func wrap(dir string, name string) ([]byte, error) {
+	fset, file, iface, err := findInterface(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	d := decorator{Package: file.Name.Name, Interface: name}
+	used := map[string]bool{"gotel": true}
+
+	for _, field := range iface.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return nil, fmt.Errorf("%w: embedded interface %s in %s", errUnsupported, source(fset, field.Type), name)
+		}
+
+		ast.Inspect(funcType, func(node ast.Node) bool {
+			if selector, ok := node.(*ast.SelectorExpr); ok {
+				if ident, ok := selector.X.(*ast.Ident); ok {
+					used[ident.Name] = true
+				}
+			}
+
+			return true
+		})
+
+		for _, methodName := range field.Names {
+			m := newMethod(fset, methodName.Name, funcType)
+			if !m.HasContext {
+				used["context"] = true
+			}
+
+			d.Methods = append(d.Methods, m)
+		}
+	}
+
+	d.Imports = []string{strconv.Quote(gotelImport)}
+
+	if used["context"] {
+		d.StdImports = append(d.StdImports, strconv.Quote("context"))
+	}
+
+	for _, spec := range file.Imports {
+		if !used[importName(spec)] || spec.Path.Value == strconv.Quote("context") || spec.Path.Value == strconv.Quote(gotelImport) {
+			continue
+		}
+
+		importSpec := spec.Path.Value
+		if spec.Name != nil {
+			importSpec = spec.Name.Name + " " + importSpec
+		}
+
+		// Standard library paths have no dot in their first element
+		if importPath, _ := strconv.Unquote(spec.Path.Value); !strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			d.StdImports = append(d.StdImports, importSpec)
+		} else {
+			d.Imports = append(d.Imports, importSpec)
+		}
+	}
+
+	slices.Sort(d.StdImports)
+	slices.Sort(d.Imports)
+
+	var buf bytes.Buffer
+	if err := decoratorTemplate.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
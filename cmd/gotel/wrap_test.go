@@ -0,0 +1,151 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	source, err := wrap("testdata/store", "Store")
+	require.NoError(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "store_gotel.go", source, 0)
+	require.NoError(t, err, "the generated source should parse")
+
+	generated := string(source)
+	assert.Contains(t, generated, "// Code generated by gotel wrap. DO NOT EDIT.")
+	assert.Contains(t, generated, "func NewInstrumentedStore(next Store) *InstrumentedStore {")
+	assert.Contains(t, generated, "GetUser(ctx context.Context, p1 int) (r0 *User, r1 error) {")
+	assert.Contains(t, generated, "ctx, end := gotel.StartCall(ctx, \"Store.GetUser\")\n\tdefer end(&r1)\n")
+	assert.Contains(t, generated, "return w.next.ListUsers(ctx, p1, p2, p3...)")
+	assert.Contains(t, generated, `_, end := gotel.StartCall(context.Background(), "Store.Export")`)
+	assert.Contains(t, generated, "\tdefer end(nil)\n\n\tw.next.Touch(p0)\n}")
+	assert.Contains(t, generated, "\t\"io\"\n\t\"time\"\n\n\t\"github.com/tinybluerobots/gotel\"\n")
+
+	buildGenerated(t, "testdata/store", "store_gotel.go", source)
+}
+
+// buildGenerated compiles the package in dir with the generated file added, in a temporary module requiring this one.
+func buildGenerated(t *testing.T, dir string, name string, source []byte) {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is needed to compile the generated code")
+	}
+
+	root, err := filepath.Abs("../..")
+	require.NoError(t, err)
+
+	module := t.TempDir()
+	pkg := filepath.Join(module, filepath.Base(dir))
+	require.NoError(t, os.Mkdir(pkg, 0o755))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(pkg, entry.Name()), data, 0o600))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(pkg, name), source, 0o600))
+
+	goMod := "module example.com/generated\n\ngo 1.25\n\nrequire github.com/tinybluerobots/gotel v0.0.0\n\n" +
+		"replace github.com/tinybluerobots/gotel => " + root + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(module, "go.mod"), []byte(goMod), 0o600))
+
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(module, "go.sum"), goSum, 0o600))
+
+	cmd := exec.Command(goBin, "build", "-mod=mod", "./...")
+	cmd.Dir = module
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "the generated code should compile:\n%s", output)
+}
+
+func TestWrap_Unsupported(t *testing.T) {
+	_, err := wrap("testdata/store", "Cache")
+	require.ErrorIs(t, err, errUnsupported, "embedded interfaces are not supported")
+
+	_, err = wrap("testdata/store", "User")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = wrap("testdata/store", "Missing")
+	require.ErrorIs(t, err, errInterfaceNotFound)
+}
+
+func TestRun(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "store_gotel.go")
+
+	require.NoError(t, run([]string{"wrap", "-type", "Store", "-dir", "testdata/store", "-o", output}))
+
+	source, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "type InstrumentedStore struct")
+
+	require.ErrorIs(t, run([]string{"wrap"}), errUsage)
+
+	require.NoError(t, run([]string{"metrics", "-type", "AppMetrics", "-dir", "testdata/app", "-o", output}))
+
+	source, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "func (m *AppMetrics) InitInstruments(b *metrics.Builder) error {")
+}
+
+func TestGenerateInstruments(t *testing.T) {
+	source, err := generateInstruments("testdata/app", "AppMetrics")
+	require.NoError(t, err)
+
+	generated := string(source)
+	assert.Contains(t, generated, "// Code generated by gotel metrics. DO NOT EDIT.")
+	assert.Contains(t, generated, "func (m *AppMetrics) InitInstruments(b *metrics.Builder) error {")
+	assert.Contains(t, generated, `name := "http.server.request.duration"`)
+	assert.Contains(t, generated, `m.SemconvHTTPServer.HTTPServerRequestDuration, err = metrics.Wrap[metrics.Float64Histogram](b, "SemconvHTTPServer.HTTPServerRequestDuration", name, inst, `)
+	assert.Contains(t, generated, `name := b.Name("RequestDuration")`)
+	assert.Contains(t, generated, `inst, err = meter.Float64Histogram(name, metric.WithUnit("s"), metric.WithExplicitBucketBoundaries(0.1, 1, 10))`)
+	assert.Contains(t, generated, `name := "orders.placed"`)
+	assert.Contains(t, generated, `inst, err = meter.Int64Counter(name)`)
+	assert.Contains(t, generated, `name := "queue_" + b.Name("Depth")`)
+	assert.Contains(t, generated, "m.Cache = &CacheMetrics{}")
+	assert.Contains(t, generated, `m.Cache.HTTPHits, err = metrics.Wrap[metrics.Int64Counter](b, "Cache.HTTPHits", name, inst, "")`)
+	assert.NotContains(t, generated, "m.Cache.Parent", "self references are skipped")
+	assert.Contains(t, generated, `m.TopicMessages, err = metrics.WrapFamily[metrics.Int64Counter](b, "TopicMessages", b.Name("TopicMessages"), "family_key:\"topic\"")`)
+	assert.Contains(t, generated, `m.ShardLag, err = metrics.WrapMap[metrics.Int64Gauge](b, "ShardLag", b.Name("ShardLag"), "keys:\"0,1\"", m.ShardLag)`)
+	assert.NotContains(t, generated, "NewField", "instruments are created on the meter directly")
+
+	buildGenerated(t, "testdata/app", "appmetrics_gotel.go", source)
+}
+
+func TestGenerateInstruments_Unsupported(t *testing.T) {
+	_, err := generateInstruments("testdata/app", "Invalid")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = generateInstruments("testdata/app", "InvalidBuckets")
+	require.ErrorContains(t, err, "invalid bucket boundary for metric field Latency")
+
+	_, err = generateInstruments("testdata/app", "InvalidCardinality")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = generateInstruments("testdata/app", "InvalidFamily")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = generateInstruments("testdata/app", "InvalidMap")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = generateInstruments("testdata/app", "NotPointer")
+	require.ErrorIs(t, err, errUnsupported)
+
+	_, err = generateInstruments("testdata/app", "Missing")
+	require.ErrorIs(t, err, errStructNotFound)
+}
@@ -0,0 +1,144 @@
+package gotel
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/log"
+)
+
+// Config is the configuration gotel resolved from the environment and the arguments to Init.
+type Config struct {
+	ServiceName string
+	// Endpoint is the OTLP endpoint, or empty when export is disabled.
+	Endpoint string
+	// TracesEndpoint, MetricsEndpoint, and LogsEndpoint are the per-signal endpoints, which default to Endpoint.
+	TracesEndpoint  string
+	MetricsEndpoint string
+	LogsEndpoint    string
+	// Protocol is grpc, http, http/json, or file.
+	Protocol string
+	// FilePath is the directory signals are written to when Protocol is file.
+	FilePath string
+	Insecure bool
+	// Sampler is the OTEL_TRACES_SAMPLER sampler, with its optional argument in SamplerArg.
+	Sampler              string
+	SamplerArg           string
+	MetricExportInterval time.Duration
+	// MetricTemporality is cumulative, delta, or lowmemory.
+	MetricTemporality string
+	// ExemplarFilter is always_on, always_off, or trace_based, unless WithExemplarFilter overrides it.
+	ExemplarFilter     string
+	TraceBatchDelay    time.Duration
+	LogBatchDelay      time.Duration
+	ResourceAttributes []attribute.Attr
+}
+
+var (
+	initMu            sync.Mutex
+	initServiceName   string
+	initResourceAttrs []attribute.Attr
+)
+
+func recordInit(serviceName string, resourceAttrs []attribute.Attr) {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	initServiceName = serviceName
+	initResourceAttrs = append([]attribute.Attr(nil), resourceAttrs...)
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func envMillis(key string, fallback time.Duration) time.Duration {
+	ms, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || ms <= 0 {
+		return fallback
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+// EffectiveConfig returns the configuration gotel loaded, so operators can verify endpoints, sampling, and intervals.
+// The service name and resource attributes are those passed to the last call to Init.
+func EffectiveConfig() Config {
+	initMu.Lock()
+	serviceName, resourceAttrs := initServiceName, append([]attribute.Attr(nil), initResourceAttrs...)
+	initMu.Unlock()
+
+	endpoint := export.Endpoint()
+	signalEndpoint := func(key string) string {
+		if endpoint == "" {
+			return ""
+		}
+
+		return envOr(key, endpoint)
+	}
+
+	protocol := export.Protocol()
+	if protocol != "http" && protocol != "http/json" && protocol != "file" {
+		protocol = "grpc"
+	}
+
+	return Config{
+		ServiceName:          serviceName,
+		Endpoint:             endpoint,
+		TracesEndpoint:       signalEndpoint("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		MetricsEndpoint:      signalEndpoint("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"),
+		LogsEndpoint:         signalEndpoint("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"),
+		Protocol:             protocol,
+		FilePath:             export.FileDirectory(),
+		Insecure:             os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true",
+		Sampler:              envOr("OTEL_TRACES_SAMPLER", "parentbased_always_on"),
+		SamplerArg:           os.Getenv("OTEL_TRACES_SAMPLER_ARG"),
+		MetricExportInterval: envMillis("OTEL_METRIC_EXPORT_INTERVAL", time.Minute),
+		MetricTemporality:    strings.ToLower(envOr("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "cumulative")),
+		ExemplarFilter:       envOr("OTEL_METRICS_EXEMPLAR_FILTER", "trace_based"),
+		TraceBatchDelay:      envMillis("OTEL_BSP_SCHEDULE_DELAY", 5*time.Second),
+		LogBatchDelay:        envMillis("OTEL_BLRP_SCHEDULE_DELAY", time.Second),
+		ResourceAttributes:   resourceAttrs,
+	}
+}
+
+// Attributes returns the configuration as log attributes, with resource attributes prefixed by "resource.".
+func (c Config) Attributes() []attribute.Attr {
+	attrs := []attribute.Attr{
+		attribute.New("service.name", c.ServiceName),
+		attribute.New("endpoint", c.Endpoint),
+		attribute.New("traces_endpoint", c.TracesEndpoint),
+		attribute.New("metrics_endpoint", c.MetricsEndpoint),
+		attribute.New("logs_endpoint", c.LogsEndpoint),
+		attribute.New("protocol", c.Protocol),
+		attribute.New("file_path", c.FilePath),
+		attribute.New("insecure", c.Insecure),
+		attribute.New("sampler", c.Sampler),
+		attribute.New("sampler_arg", c.SamplerArg),
+		attribute.New("metric_export_interval_ms", c.MetricExportInterval.Milliseconds()),
+		attribute.New("metric_temporality", c.MetricTemporality),
+		attribute.New("exemplar_filter", c.ExemplarFilter),
+		attribute.New("trace_batch_delay_ms", c.TraceBatchDelay.Milliseconds()),
+		attribute.New("log_batch_delay_ms", c.LogBatchDelay.Milliseconds()),
+	}
+
+	for _, attr := range c.ResourceAttributes {
+		attrs = append(attrs, attribute.New("resource."+string(attr.Key), attr.Value.AsInterface()))
+	}
+
+	return attrs
+}
+
+func logConfig(ctx context.Context) {
+	log.Info(ctx, "effective configuration", EffectiveConfig().Attributes()...)
+}
@@ -0,0 +1,51 @@
+package gotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+)
+
+var errPanic = errors.New("panic")
+
+// panicError wraps a recovered panic value in errPanic.
+func panicError(r any) error {
+	if panicErr, ok := r.(error); ok {
+		return fmt.Errorf("%w: %w", errPanic, panicErr)
+	}
+
+	return fmt.Errorf("%w: %v", errPanic, r)
+}
+
+// CapturePanics reports a panic before the process crashes: it logs the panic with its stack, increments the
+// crashes counter, force-flushes traces, metrics, and logs within timeout, and then re-panics.
+// Defer it directly at the top of main and of goroutines whose crashes must be reported, as Go only recovers
+// panics in the goroutine that deferred the call:
+//
+//	defer gotel.CapturePanics(ctx, 5*time.Second)
+func CapturePanics(ctx context.Context, timeout time.Duration) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	err := panicError(r)
+
+	getMetrics().Crashes.Add(ctx, 1)
+	log.Error(ctx, err, attribute.New("crash", true))
+
+	flushCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := ForceFlush(flushCtx); err != nil {
+		log.Error(ctx, fmt.Errorf("failed to flush telemetry after panic: %w", err))
+	}
+
+	panic(r)
+}
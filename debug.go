@@ -0,0 +1,110 @@
+package gotel
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+type debugExportError struct {
+	Signal     export.Signal `json:"signal"`
+	Count      int           `json:"count"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      string        `json:"error"`
+	Time       time.Time     `json:"time"`
+}
+
+type debugState struct {
+	Config             map[string]any                 `json:"config"`
+	Exports            map[export.Signal]export.Stats `json:"exports"`
+	RecentErrors       []debugExportError             `json:"recent_errors"`
+	Sampling           tracing.SamplingCounts         `json:"sampling"`
+	Instruments        map[string]string              `json:"instruments"`
+	SkippedInstruments map[string]string              `json:"skipped_instruments"`
+}
+
+func newDebugState() debugState {
+	config := map[string]any{}
+	for _, attr := range EffectiveConfig().Attributes() {
+		config[string(attr.Key)] = attr.Value.AsInterface()
+	}
+
+	recent := []debugExportError{}
+	for _, result := range export.RecentErrors() {
+		recent = append(recent, debugExportError{
+			Signal:     result.Signal,
+			Count:      result.Count,
+			DurationMs: result.Duration.Milliseconds(),
+			Error:      result.Err.Error(),
+			Time:       result.Time,
+		})
+	}
+
+	report := metrics.Report()
+
+	return debugState{
+		Config:             config,
+		Exports:            export.Totals(),
+		RecentErrors:       recent,
+		Sampling:           tracing.SamplingDecisions(),
+		Instruments:        report.Instruments,
+		SkippedInstruments: report.Skipped,
+	}
+}
+
+// DebugHandler returns a handler, to mount under /debug/gotel, reporting the telemetry state as JSON on GET:
+// the effective configuration, export totals and recent export errors, sampling decisions, and the instruments
+// initialized by InitMetrics. POST requests to .../flush force a flush of every provider, and to .../levels set
+// log levels from the "levels" form value as log.SetLevels does. POST requests must carry the token in an
+// "Authorization: Bearer" header; an empty token disables them. Serve it on an internal port, as the state
+// includes endpoints and resource attributes:
+//
+//	mux.Handle("/debug/gotel/", gotel.DebugHandler(os.Getenv("GOTEL_DEBUG_TOKEN")))
+func DebugHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(newDebugState())
+		case http.MethodPost:
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			debugAction(w, r)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func debugAction(w http.ResponseWriter, r *http.Request) {
+	switch path.Base(r.URL.Path) {
+	case "flush":
+		if err := ForceFlush(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "levels":
+		if err := log.SetLevels(r.FormValue("levels")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+//go:build gotel_disabled
+
+package gotel
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+// TestDisabled runs with go test -tags gotel_disabled, which leaves out the tests that expect the SDK providers
+func TestDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4317")
+
+	type testMetrics struct {
+		Requests *metrics.Int64Counter
+	}
+
+	m := &testMetrics{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	shutdown, err := Init(context.Background(), "test-service", resourceAttrs, m, nil)
+	require.NoError(t, err)
+
+	ctx, span := tracing.NewSpan(context.Background(), "test-span")
+	span.End()
+
+	assert.False(t, span.IsRecording())
+	assert.NotNil(t, ctx)
+
+	require.NotNil(t, m.Requests)
+	m.Requests.Add(ctx, 1)
+
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestDisabled_NoSDK(t *testing.T) {
+	info, ok := debug.ReadBuildInfo()
+	require.True(t, ok)
+
+	for _, dep := range info.Deps {
+		assert.False(t, strings.HasPrefix(dep.Path, "go.opentelemetry.io/otel/sdk"), dep.Path)
+		assert.False(t, strings.HasPrefix(dep.Path, "go.opentelemetry.io/otel/exporters"), dep.Path)
+	}
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var clockOffsets = map[Signal]*atomic.Int64{
+	SignalTraces:  {},
+	SignalMetrics: {},
+	SignalLogs:    {},
+}
+
+// SetClockOffset corrects the timestamps of a signal's telemetry by offset as it is exported by the wrapped
+// exporters, for devices whose clocks are known to be skewed, e.g. spans that appear to start before their remote
+// parents. A positive offset moves timestamps later. It can be changed at any time, e.g. after the device measures its
+// skew against an NTP server; zero disables the correction.
+func SetClockOffset(signal Signal, offset time.Duration) {
+	if o, ok := clockOffsets[signal]; ok {
+		o.Store(int64(offset))
+	}
+}
+
+// ClockOffset returns the correction set by SetClockOffset for a signal.
+func ClockOffset(signal Signal) time.Duration {
+	if o, ok := clockOffsets[signal]; ok {
+		return time.Duration(o.Load())
+	}
+
+	return 0
+}
+
+// shift returns t moved by offset, leaving unset times unset.
+func shift(t time.Time, offset time.Duration) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return t.Add(offset)
+}
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+var endpoint atomic.Pointer[string]
+
+// SetEndpoint sets the OTLP endpoint of the exporters created by tracing.InitTracing, metrics.InitMetrics, and
+// log.InitLogger, in place of OTEL_EXPORTER_OTLP_ENDPOINT, without changing the environment of the process.
+// gotel.WithDataResidency sets it to the endpoint of the region. An empty endpoint restores the environment variable.
+func SetEndpoint(url string) {
+	endpoint.Store(&url)
+}
+
+// Endpoint returns the OTLP endpoint set by SetEndpoint, or OTEL_EXPORTER_OTLP_ENDPOINT.
+func Endpoint() string {
+	if url := endpoint.Load(); url != nil && *url != "" {
+		return *url
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// SignalEndpoint returns the OTLP/HTTP URL of a signal under the endpoint set by SetEndpoint, e.g.
+// https://collector:4318/v1/traces, for the exporter's WithEndpointURL option. It returns false if no endpoint is
+// set, or if the signal's own OTEL_EXPORTER_OTLP_<SIGNAL>_ENDPOINT variable is, which the exporter reads itself.
+func SignalEndpoint(signal Signal) (string, bool) {
+	url := endpoint.Load()
+	if url == nil || *url == "" || os.Getenv("OTEL_EXPORTER_OTLP_"+strings.ToUpper(string(signal))+"_ENDPOINT") != "" {
+		return "", false
+	}
+
+	return SignalURL(*url, signal), true
+}
+
+// SignalURL returns the OTLP/HTTP URL of a signal under endpoint, e.g. https://collector:4318/v1/traces.
+func SignalURL(endpoint string, signal Signal) string {
+	return strings.TrimSuffix(endpoint, "/") + "/v1/" + string(signal)
+}
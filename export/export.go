@@ -0,0 +1,161 @@
+// Package export reports the outcome of each telemetry export, so applications can raise their own alarms or
+// degrade features when delivery to the collector is failing persistently.
+//
+//	export.SetCallbacks(nil, func(r export.Result) {
+//		failures.Add(1)
+//	})
+//
+// The OTLP exporters created by gotel are wrapped automatically; wrap custom exporters with WrapSpanExporter,
+// WrapMetricExporter, or WrapLogExporter. RouteSpans, RouteMetrics, and RouteLogs split telemetry between exporters
+// by attribute.
+package export
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SDKDisabled reports whether OTEL_SDK_DISABLED disables the SDK, as defined by the OpenTelemetry specification,
+// in which case gotel creates no providers or exporters for any signal.
+func SDKDisabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("OTEL_SDK_DISABLED")), "true")
+}
+
+// Signal identifies the type of telemetry exported.
+type Signal string
+
+const (
+	// SignalTraces is reported for span exports.
+	SignalTraces Signal = "traces"
+	// SignalMetrics is reported for metric exports.
+	SignalMetrics Signal = "metrics"
+	// SignalLogs is reported for log record exports.
+	SignalLogs Signal = "logs"
+)
+
+// Result describes a single export call.
+type Result struct {
+	Signal Signal
+	// Count is the number of spans, metric data points, or log records in the export.
+	Count    int
+	Duration time.Duration
+	// Err is the export error, or nil on success.
+	Err error
+	// Time is when the export finished.
+	Time time.Time
+}
+
+// Stats are the totals of a signal's exports since the process started.
+type Stats struct {
+	// Exported is the number of items delivered.
+	Exported int64
+	// Failed is the number of items in failed exports, which were dropped.
+	Failed int64
+	// Errors is the number of failed export calls.
+	Errors int64
+	// QueueDropped is the number of items dropped because the queue of their batch processor was full.
+	QueueDropped int64
+}
+
+type signalStats struct {
+	exported     atomic.Int64
+	failed       atomic.Int64
+	errors       atomic.Int64
+	queueDropped atomic.Int64
+}
+
+var stats = map[Signal]*signalStats{
+	SignalTraces:  {},
+	SignalMetrics: {},
+	SignalLogs:    {},
+}
+
+// Totals returns the export totals of each signal since the process started, for a session summary.
+func Totals() map[Signal]Stats {
+	totals := make(map[Signal]Stats, len(stats))
+	for signal, s := range stats {
+		totals[signal] = Stats{Exported: s.exported.Load(), Failed: s.failed.Load(), Errors: s.errors.Load(), QueueDropped: s.queueDropped.Load()}
+	}
+
+	return totals
+}
+
+// recentErrorsSize is the number of failed exports kept for RecentErrors.
+const recentErrorsSize = 16
+
+var (
+	recentMu     sync.Mutex
+	recentErrors []Result
+)
+
+// RecentErrors returns the most recent failed exports, oldest first, for diagnostics.
+func RecentErrors() []Result {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+
+	return append([]Result(nil), recentErrors...)
+}
+
+func recordError(result Result) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+
+	if len(recentErrors) == recentErrorsSize {
+		recentErrors = recentErrors[1:]
+	}
+
+	recentErrors = append(recentErrors, result)
+}
+
+type callbacks struct {
+	onSuccess func(Result)
+	onFailure func(Result)
+}
+
+var current atomic.Pointer[callbacks]
+
+// SetCallbacks registers functions called after each successful and failed export. Either may be nil.
+// Callbacks run on the exporting goroutine, so they should return quickly.
+func SetCallbacks(onSuccess func(Result), onFailure func(Result)) {
+	if onSuccess == nil && onFailure == nil {
+		current.Store(nil)
+		return
+	}
+
+	current.Store(&callbacks{onSuccess: onSuccess, onFailure: onFailure})
+}
+
+func report(signal Signal, count int, start time.Time, err error) {
+	now := time.Now()
+	result := Result{Signal: signal, Count: count, Duration: now.Sub(start), Err: err, Time: now}
+
+	pipeline.Load().record(result)
+
+	if err != nil {
+		stats[signal].failed.Add(int64(count))
+		stats[signal].errors.Add(1)
+		recordError(result)
+	} else {
+		stats[signal].exported.Add(int64(count))
+	}
+
+	c := current.Load()
+	if c == nil {
+		return
+	}
+
+	if err != nil {
+		if c.onFailure != nil {
+			c.onFailure(result)
+		}
+
+		return
+	}
+
+	if c.onSuccess != nil {
+		c.onSuccess(result)
+	}
+}
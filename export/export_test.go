@@ -0,0 +1,487 @@
+//go:build !gotel_disabled
+
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errUnavailable = errors.New("collector unavailable")
+
+type failingMetricExporter struct {
+	sdkmetric.Exporter
+}
+
+func (failingMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	return errUnavailable
+}
+
+type discardLogExporter struct {
+	sdklog.Exporter
+}
+
+func (discardLogExporter) Export(context.Context, []sdklog.Record) error {
+	return nil
+}
+
+func recordResults(t *testing.T) (*[]Result, *[]Result) {
+	t.Helper()
+
+	successes, failures := []Result{}, []Result{}
+
+	SetCallbacks(
+		func(r Result) { successes = append(successes, r) },
+		func(r Result) { failures = append(failures, r) },
+	)
+	t.Cleanup(func() { SetCallbacks(nil, nil) })
+
+	return &successes, &failures
+}
+
+func TestWrapSpanExporter(t *testing.T) {
+	successes, failures := recordResults(t)
+
+	exporter := WrapSpanExporter(tracetest.NewInMemoryExporter())
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := provider.Tracer("test").Start(t.Context(), "operation")
+	span.End()
+
+	require.Len(t, *successes, 1)
+	assert.Empty(t, *failures)
+	assert.Equal(t, SignalTraces, (*successes)[0].Signal)
+	assert.Equal(t, 1, (*successes)[0].Count)
+	assert.NoError(t, (*successes)[0].Err)
+}
+
+func TestWrapMetricExporter(t *testing.T) {
+	successes, failures := recordResults(t)
+
+	rm := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{
+			{Name: "requests", Data: metricdata.Sum[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}, {Value: 2}}}},
+			{Name: "latency", Data: metricdata.Histogram[float64]{DataPoints: []metricdata.HistogramDataPoint[float64]{{Count: 1}}}},
+		},
+	}}}
+
+	err := WrapMetricExporter(failingMetricExporter{}).Export(t.Context(), rm)
+	require.ErrorIs(t, err, errUnavailable)
+
+	assert.Empty(t, *successes)
+	require.Len(t, *failures, 1)
+	assert.Equal(t, SignalMetrics, (*failures)[0].Signal)
+	assert.Equal(t, 3, (*failures)[0].Count)
+	assert.ErrorIs(t, (*failures)[0].Err, errUnavailable)
+}
+
+func TestWrapLogExporter(t *testing.T) {
+	successes, _ := recordResults(t)
+
+	require.NoError(t, WrapLogExporter(discardLogExporter{}).Export(t.Context(), make([]sdklog.Record, 2)))
+
+	require.Len(t, *successes, 1)
+	assert.Equal(t, SignalLogs, (*successes)[0].Signal)
+	assert.Equal(t, 2, (*successes)[0].Count)
+}
+
+func TestSetCallbacks_Nil(t *testing.T) {
+	SetCallbacks(nil, func(Result) {})
+	t.Cleanup(func() { SetCallbacks(nil, nil) })
+
+	assert.NotPanics(t, func() {
+		_ = WrapLogExporter(discardLogExporter{}).Export(t.Context(), nil)
+	})
+}
+
+func TestTotals(t *testing.T) {
+	before := Totals()
+
+	require.NoError(t, WrapLogExporter(discardLogExporter{}).Export(t.Context(), make([]sdklog.Record, 3)))
+	require.Error(t, WrapMetricExporter(failingMetricExporter{}).Export(t.Context(), &metricdata.ResourceMetrics{}))
+
+	after := Totals()
+
+	assert.Equal(t, int64(3), after[SignalLogs].Exported-before[SignalLogs].Exported)
+	assert.Equal(t, int64(1), after[SignalMetrics].Errors-before[SignalMetrics].Errors)
+}
+
+func TestRecentErrors(t *testing.T) {
+	for range recentErrorsSize + 2 {
+		require.Error(t, WrapMetricExporter(failingMetricExporter{}).Export(t.Context(), &metricdata.ResourceMetrics{}))
+	}
+
+	recent := RecentErrors()
+	require.Len(t, recent, recentErrorsSize, "only the most recent errors are kept")
+	assert.ErrorIs(t, recent[len(recent)-1].Err, errUnavailable)
+	assert.False(t, recent[len(recent)-1].Time.IsZero())
+}
+
+type recordingLogExporter struct {
+	sdklog.Exporter
+
+	records []sdklog.Record
+}
+
+func (e *recordingLogExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.records = append(e.records, records...)
+	return nil
+}
+
+type recordingMetricExporter struct {
+	sdkmetric.Exporter
+
+	exported []*metricdata.ResourceMetrics
+}
+
+func (e *recordingMetricExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.exported = append(e.exported, rm)
+	return nil
+}
+
+var internalTenant = Match{Key: "tenant", Values: []string{"internal"}}
+
+func TestRouteSpans(t *testing.T) {
+	internal, rest := tracetest.NewInMemoryExporter(), tracetest.NewInMemoryExporter()
+
+	exporter := RouteSpans(rest, Route[sdktrace.SpanExporter]{Match: internalTenant, Exporter: internal})
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := provider.Tracer("test").Start(t.Context(), "internal", trace.WithAttributes(attribute.String("tenant", "internal")))
+	span.End()
+
+	_, span = provider.Tracer("test").Start(t.Context(), "customer", trace.WithAttributes(attribute.String("tenant", "acme")))
+	span.End()
+
+	require.Len(t, internal.GetSpans(), 1)
+	assert.Equal(t, "internal", internal.GetSpans()[0].Name)
+	require.Len(t, rest.GetSpans(), 1)
+	assert.Equal(t, "customer", rest.GetSpans()[0].Name)
+
+	require.NoError(t, exporter.Shutdown(t.Context()))
+}
+
+func TestRouteLogs(t *testing.T) {
+	internal, rest := &recordingLogExporter{}, &recordingLogExporter{}
+
+	exporter := RouteLogs(rest, Route[sdklog.Exporter]{Match: Match{Key: "tenant"}, Exporter: internal})
+
+	tenantRecord, plainRecord := sdklog.Record{}, sdklog.Record{}
+	tenantRecord.AddAttributes(otellog.String("tenant", "acme"))
+
+	require.NoError(t, exporter.Export(t.Context(), []sdklog.Record{tenantRecord, plainRecord}))
+
+	assert.Len(t, internal.records, 1, "an empty Values matches any value of the key")
+	assert.Len(t, rest.records, 1)
+}
+
+func TestRouteMetrics(t *testing.T) {
+	internal, rest := &recordingMetricExporter{}, &recordingMetricExporter{}
+
+	exporter := RouteMetrics(rest, Route[sdkmetric.Exporter]{Match: internalTenant, Exporter: internal})
+
+	rm := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{
+			{Name: "requests", Data: metricdata.Sum[int64]{IsMonotonic: true, DataPoints: []metricdata.DataPoint[int64]{
+				{Attributes: attribute.NewSet(attribute.String("tenant", "internal")), Value: 1},
+				{Attributes: attribute.NewSet(attribute.String("tenant", "acme")), Value: 2},
+			}}},
+			{Name: "latency", Data: metricdata.Histogram[float64]{DataPoints: []metricdata.HistogramDataPoint[float64]{{Count: 1}}}},
+		},
+	}}}
+
+	require.NoError(t, exporter.Export(t.Context(), rm))
+
+	require.Len(t, internal.exported, 1)
+	require.Len(t, internal.exported[0].ScopeMetrics, 1)
+	require.Len(t, internal.exported[0].ScopeMetrics[0].Metrics, 1)
+
+	sum, ok := internal.exported[0].ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+	assert.True(t, sum.IsMonotonic)
+
+	require.Len(t, rest.exported, 1)
+	assert.Len(t, rest.exported[0].ScopeMetrics[0].Metrics, 2)
+}
+
+type shutdownCountingExporter struct {
+	*tracetest.InMemoryExporter
+
+	shutdowns int
+}
+
+func (e *shutdownCountingExporter) Shutdown(context.Context) error {
+	e.shutdowns++
+	return nil
+}
+
+func TestRouteSpans_SharedExporter(t *testing.T) {
+	shared := &shutdownCountingExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+
+	exporter := RouteSpans(shared,
+		Route[sdktrace.SpanExporter]{Match: internalTenant, Exporter: shared},
+		Route[sdktrace.SpanExporter]{Match: Match{Key: "region"}, Exporter: shared},
+	)
+
+	require.NoError(t, exporter.Shutdown(t.Context()))
+	assert.Equal(t, 1, shared.shutdowns, "an exporter shared by routes is shut down once")
+}
+
+func TestParseEndpointRoutes(t *testing.T) {
+	routes, err := ParseEndpointRoutes("tenant=internal|ops -> https://internal.example.com:4318; region -> https://other.example.com:4318;")
+	require.NoError(t, err)
+	assert.Equal(t, []EndpointRoute{
+		{Match: Match{Key: "tenant", Values: []string{"internal", "ops"}}, Endpoint: "https://internal.example.com:4318"},
+		{Match: Match{Key: "region"}, Endpoint: "https://other.example.com:4318"},
+	}, routes)
+
+	routes, err = ParseEndpointRoutes("")
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	for _, spec := range []string{"tenant=internal", "tenant=internal -> internal:4318", "=internal -> https://internal.example.com:4318"} {
+		_, err = ParseEndpointRoutes(spec)
+		require.ErrorIs(t, err, errRoutes, spec)
+	}
+}
+
+func TestRouteEndpoints(t *testing.T) {
+	fallback := tracetest.NewInMemoryExporter()
+
+	exporter, err := RouteEndpoints(t.Context(), sdktrace.SpanExporter(fallback), nil, RouteSpans)
+	require.NoError(t, err)
+	assert.Equal(t, sdktrace.SpanExporter(fallback), exporter, "the fallback is used without routes")
+
+	SetEndpointRoutes(
+		EndpointRoute{Match: internalTenant, Endpoint: "https://internal.example.com:4318"},
+		EndpointRoute{Match: Match{Key: "ops"}, Endpoint: "https://internal.example.com:4318"},
+	)
+	t.Cleanup(func() { SetEndpointRoutes() })
+
+	var endpoints []string
+
+	newExporter := func(endpoint string) (sdktrace.SpanExporter, error) {
+		endpoints = append(endpoints, endpoint)
+		return tracetest.NewInMemoryExporter(), nil
+	}
+
+	exporter, err = RouteEndpoints(t.Context(), sdktrace.SpanExporter(fallback), newExporter, RouteSpans)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://internal.example.com:4318"}, endpoints, "routes to the same endpoint share an exporter")
+	assert.NotEqual(t, sdktrace.SpanExporter(fallback), exporter)
+}
+
+type capturingLogExporter struct {
+	sdklog.Exporter
+
+	records []sdklog.Record
+}
+
+func (e *capturingLogExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.records = append(e.records, records...)
+	return nil
+}
+
+func TestSetClockOffset(t *testing.T) {
+	const offset = 90 * time.Second
+
+	for _, signal := range []Signal{SignalTraces, SignalMetrics, SignalLogs} {
+		SetClockOffset(signal, offset)
+	}
+
+	t.Cleanup(func() {
+		for _, signal := range []Signal{SignalTraces, SignalMetrics, SignalLogs} {
+			SetClockOffset(signal, 0)
+		}
+	})
+
+	assert.Equal(t, offset, ClockOffset(SignalTraces))
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	spans := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(WrapSpanExporter(spans)))
+
+	_, span := provider.Tracer("test").Start(t.Context(), "operation", trace.WithTimestamp(start))
+	span.AddEvent("retry", trace.WithTimestamp(start.Add(time.Second)))
+	span.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	exported := spans.GetSpans()
+	require.Len(t, exported, 1)
+	assert.Equal(t, start.Add(offset), exported[0].StartTime)
+	assert.Equal(t, start.Add(2*time.Second+offset), exported[0].EndTime)
+	require.Len(t, exported[0].Events, 1)
+	assert.Equal(t, start.Add(time.Second+offset), exported[0].Events[0].Time)
+
+	rm := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{
+			{Name: "requests", Data: metricdata.Sum[int64]{DataPoints: []metricdata.DataPoint[int64]{{StartTime: start, Time: start.Add(time.Second)}}}},
+			{Name: "latency", Data: metricdata.Histogram[float64]{DataPoints: []metricdata.HistogramDataPoint[float64]{{StartTime: start, Time: start.Add(time.Second)}}}},
+		},
+	}}}
+
+	_ = WrapMetricExporter(failingMetricExporter{}).Export(t.Context(), rm)
+
+	sum, _ := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	assert.Equal(t, start.Add(offset), sum.DataPoints[0].StartTime)
+	assert.Equal(t, start.Add(time.Second+offset), sum.DataPoints[0].Time)
+
+	histogram, _ := rm.ScopeMetrics[0].Metrics[1].Data.(metricdata.Histogram[float64])
+	assert.Equal(t, start.Add(time.Second+offset), histogram.DataPoints[0].Time)
+
+	var record sdklog.Record
+	record.SetTimestamp(start)
+
+	records := []sdklog.Record{record}
+	logs := &capturingLogExporter{}
+
+	require.NoError(t, WrapLogExporter(logs).Export(t.Context(), records))
+	require.Len(t, logs.records, 1)
+	assert.Equal(t, start.Add(offset), logs.records[0].Timestamp())
+	assert.True(t, logs.records[0].ObservedTimestamp().IsZero())
+	assert.Equal(t, start, records[0].Timestamp(), "the exported batch is left intact")
+}
+
+func TestRecordMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, RecordMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	t.Cleanup(func() { pipeline.Store(nil) })
+
+	require.NoError(t, WrapLogExporter(discardLogExporter{}).Export(t.Context(), make([]sdklog.Record, 3)))
+	require.Error(t, WrapMetricExporter(failingMetricExporter{}).Export(t.Context(), &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{{Name: "requests", Data: metricdata.Sum[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}, {Value: 2}}}}},
+	}}}))
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, ScopeName, rm.ScopeMetrics[0].Scope.Name)
+
+	find := func(name string) metricdata.Aggregation {
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+
+		return nil
+	}
+
+	batchSize, ok := find("export_batch_size").(metricdata.Histogram[int64])
+	require.True(t, ok, "export_batch_size should be a histogram")
+	require.Len(t, batchSize.DataPoints, 2)
+
+	sums := map[string]int64{}
+	for _, dp := range batchSize.DataPoints {
+		signal, _ := dp.Attributes.Value("signal")
+		sums[signal.AsString()] = dp.Sum
+	}
+
+	assert.Equal(t, map[string]int64{"logs": 3, "metrics": 2}, sums)
+
+	failures, ok := find("export_failures").(metricdata.Sum[int64])
+	require.True(t, ok, "export_failures should be a counter")
+	require.Len(t, failures.DataPoints, 1)
+	assert.Equal(t, int64(1), failures.DataPoints[0].Value)
+
+	dropped, ok := find("export_dropped").(metricdata.Sum[int64])
+	require.True(t, ok, "export_dropped should be a counter")
+	require.Len(t, dropped.DataPoints, 1)
+	assert.Equal(t, int64(2), dropped.DataPoints[0].Value)
+
+	signal, _ := dropped.DataPoints[0].Attributes.Value("signal")
+	assert.Equal(t, "metrics", signal.AsString())
+
+	assert.NotNil(t, find("export_duration"))
+}
+
+// heldSpanProcessor keeps the spans passed to it, like a batch processor that hasn't exported them yet.
+type heldSpanProcessor struct {
+	sdktrace.SpanProcessor
+
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (p *heldSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.spans = append(p.spans, s)
+}
+
+func (p *heldSpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestNewBatchSpanProcessor(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	require.NoError(t, RecordMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	t.Cleanup(func() { pipeline.Store(nil) })
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewBatchSpanProcessor(exporter)))
+
+	_, span := provider.Tracer("test").Start(t.Context(), "exported")
+	span.End()
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	assert.Len(t, exporter.GetSpans(), 1)
+	assert.Zero(t, queues[SignalTraces].length.Load(), "exported spans leave the queue")
+
+	dropped := Totals()[SignalTraces].QueueDropped
+	held := &heldSpanProcessor{}
+	q := newQueue(SignalTraces, "TEST_QUEUE_SIZE")
+	q.max = 2
+	processor := queuedSpanProcessor{SpanProcessor: held, queue: q}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled})
+	for _, s := range (tracetest.SpanStubs{{Name: "a", SpanContext: sc}, {Name: "b", SpanContext: sc}, {Name: "c", SpanContext: sc}}).Snapshots() {
+		processor.OnEnd(s)
+	}
+
+	assert.Len(t, held.spans, 2, "spans beyond the queue size are dropped")
+	assert.Equal(t, dropped+1, Totals()[SignalTraces].QueueDropped)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	values := map[string]int64{}
+
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Gauge[int64]:
+			for _, dp := range data.DataPoints {
+				if signal, _ := dp.Attributes.Value("signal"); signal.AsString() == "traces" {
+					values[m.Name] = dp.Value
+				}
+			}
+		case metricdata.Sum[int64]:
+			for _, dp := range data.DataPoints {
+				values[m.Name] = dp.Value
+			}
+		}
+	}
+
+	assert.Equal(t, int64(2), values["export_queue_length"])
+	assert.Equal(t, int64(1), values["export_queue_dropped"])
+
+	require.NoError(t, queuedSpanExporter{SpanExporter: exporter, queue: q}.ExportSpans(t.Context(), held.spans[:1]))
+	assert.Equal(t, int64(1), q.length.Load(), "spans passed to the exporter leave the queue")
+
+	require.NoError(t, processor.Shutdown(t.Context()))
+	assert.Zero(t, queues[SignalTraces].length.Load(), "spans left at shutdown leave the queue")
+
+	processor.OnEnd(held.spans[0])
+	assert.Zero(t, q.length.Load(), "spans ended after shutdown are ignored")
+}
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+var fileDirectory atomic.Pointer[string]
+
+// SetFileExport makes the exporters created by tracing.InitTracing, metrics.InitMetrics, and log.InitLogger append
+// OTLP JSON lines to files in dir, as OTEL_EXPORTER_OTLP_PROTOCOL=file does with OTEL_EXPORTER_OTLP_FILE_PATH,
+// without changing the environment of the process. gotel.WithFileExport sets it. Pass "." for the working directory;
+// an empty dir restores the environment variables.
+func SetFileExport(dir string) {
+	fileDirectory.Store(&dir)
+}
+
+// Protocol returns the OTLP protocol of the exporters: file if SetFileExport is set, or OTEL_EXPORTER_OTLP_PROTOCOL.
+func Protocol() string {
+	if dir := fileDirectory.Load(); dir != nil && *dir != "" {
+		return "file"
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+}
+
+// FileDirectory returns the directory of the files exported to when Protocol is file: the directory set by
+// SetFileExport, or OTEL_EXPORTER_OTLP_FILE_PATH.
+func FileDirectory() string {
+	if dir := fileDirectory.Load(); dir != nil && *dir != "" {
+		return *dir
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_FILE_PATH")
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName is the instrumentation scope of the export pipeline's own metrics.
+const ScopeName = "github.com/tinybluerobots/gotel/export"
+
+// pipelineInstruments are the metrics recorded for each export by RecordMetrics.
+type pipelineInstruments struct {
+	batchSize    metric.Int64Histogram
+	duration     metric.Float64Histogram
+	failures     metric.Int64Counter
+	dropped      metric.Int64Counter
+	queueDropped metric.Int64Counter
+}
+
+var pipeline atomic.Pointer[pipelineInstruments]
+
+// signalQueues are the batch processors of NewBatchSpanProcessor or NewBatchLogProcessor of a signal not yet shut
+// down, and the items waiting in their queues.
+type signalQueues struct {
+	processors atomic.Int64
+	length     atomic.Int64
+}
+
+var queues = map[Signal]*signalQueues{
+	SignalTraces: {},
+	SignalLogs:   {},
+}
+
+// RecordMetrics records metrics about every export on provider, under the ScopeName scope, so operators can alert on
+// a broken collector connection from the metrics backend: the export_batch_size and export_duration histograms,
+// export_failures, the failed export calls, export_dropped, the spans, metric data points, and log records
+// discarded by failed exports, and for the batch processors of NewBatchSpanProcessor and NewBatchLogProcessor, the
+// export_queue_length gauge and export_queue_dropped, the items dropped from a full queue, each with a signal
+// attribute. InitMetrics calls it with its provider, replacing the
+// provider of an earlier call. Metric exports are recorded too, so a failing metrics connection is only visible
+// through another exporter, such as one routed by RouteMetrics, or Totals.
+func RecordMetrics(provider metric.MeterProvider) error {
+	meter := provider.Meter(ScopeName)
+
+	batchSize, err := meter.Int64Histogram("export_batch_size", metric.WithUnit("{item}"),
+		metric.WithDescription("Spans, metric data points, or log records per export."))
+	if err != nil {
+		return err
+	}
+
+	duration, err := meter.Float64Histogram("export_duration", metric.WithUnit("s"),
+		metric.WithDescription("Duration of exports, including retries."))
+	if err != nil {
+		return err
+	}
+
+	failures, err := meter.Int64Counter("export_failures", metric.WithUnit("{export}"),
+		metric.WithDescription("Failed export calls."))
+	if err != nil {
+		return err
+	}
+
+	dropped, err := meter.Int64Counter("export_dropped", metric.WithUnit("{item}"),
+		metric.WithDescription("Spans, metric data points, or log records discarded by failed exports."))
+	if err != nil {
+		return err
+	}
+
+	queueDropped, err := meter.Int64Counter("export_queue_dropped", metric.WithUnit("{item}"),
+		metric.WithDescription("Spans or log records dropped because the batch processor queue was full."))
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.Int64ObservableGauge("export_queue_length", metric.WithUnit("{item}"),
+		metric.WithDescription("Spans or log records waiting in batch processor queues."),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			for signal, q := range queues {
+				// Signals without a batch processor have no queue to report
+				if q.processors.Load() > 0 {
+					observer.Observe(q.length.Load(), metric.WithAttributeSet(attribute.NewSet(attribute.String("signal", string(signal)))))
+				}
+			}
+
+			return nil
+		}))
+	if err != nil {
+		return err
+	}
+
+	pipeline.Store(&pipelineInstruments{batchSize: batchSize, duration: duration, failures: failures, dropped: dropped, queueDropped: queueDropped})
+
+	return nil
+}
+
+// record records result on the instruments of RecordMetrics, if it has been called.
+func (i *pipelineInstruments) record(result Result) {
+	if i == nil {
+		return
+	}
+
+	ctx := context.Background()
+	signal := metric.WithAttributeSet(attribute.NewSet(attribute.String("signal", string(result.Signal))))
+
+	i.batchSize.Record(ctx, int64(result.Count), signal)
+	i.duration.Record(ctx, result.Duration.Seconds(), signal)
+
+	if result.Err != nil {
+		i.failures.Add(ctx, 1, signal)
+		i.dropped.Add(ctx, int64(result.Count), signal)
+	}
+}
+
+// recordQueueDrop counts an item dropped from the full queue of a batch processor.
+func recordQueueDrop(signal Signal) {
+	stats[signal].queueDropped.Add(1)
+
+	if i := pipeline.Load(); i != nil {
+		i.queueDropped.Add(context.Background(), 1, metric.WithAttributeSet(attribute.NewSet(attribute.String("signal", string(signal)))))
+	}
+}
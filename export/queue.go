@@ -0,0 +1,156 @@
+//go:build !gotel_disabled
+
+package export
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultQueueSize is the default queue size of the SDK's batch processors.
+const defaultQueueSize = 2048
+
+// queue counts the items of a batch processor waiting to be exported, bounded at max. Items leave the queue when the
+// processor passes them to its exporter, which the SDK does after taking them off its own queue, so the count never
+// falls below the SDK's and the SDK never drops items itself.
+type queue struct {
+	signal Signal
+	max    int64
+	length atomic.Int64
+	closed atomic.Bool
+}
+
+// newQueue returns a queue of the size set by the environment variable key, or of defaultQueueSize.
+func newQueue(signal Signal, key string) *queue {
+	size, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || size < 1 {
+		size = defaultQueueSize
+	}
+
+	queues[signal].processors.Add(1)
+
+	return &queue{signal: signal, max: int64(size)}
+}
+
+// enqueue counts an item and reports whether it fits in the queue, counting it as dropped if it doesn't.
+// Items are ignored once the processor has shut down, as it does.
+func (q *queue) enqueue() bool {
+	if q.closed.Load() {
+		return false
+	}
+
+	if q.length.Add(1) > q.max {
+		q.length.Add(-1)
+		recordQueueDrop(q.signal)
+
+		return false
+	}
+
+	queues[q.signal].length.Add(1)
+
+	return true
+}
+
+// dequeue removes n items passed to the exporter from the queue.
+func (q *queue) dequeue(n int) {
+	q.length.Add(-int64(n))
+	queues[q.signal].length.Add(-int64(n))
+}
+
+// clear removes the items left in the queue when the processor shuts down, which will never be exported.
+func (q *queue) clear() {
+	if q.closed.Swap(true) {
+		return
+	}
+
+	queues[q.signal].processors.Add(-1)
+	q.dequeue(int(q.length.Load()))
+}
+
+type queuedSpanProcessor struct {
+	sdktrace.SpanProcessor
+
+	queue *queue
+}
+
+// NewBatchSpanProcessor returns the SDK's batch span processor exporting to exporter, typically wrapped with
+// WrapSpanExporter, with its queue counted: RecordMetrics reports the spans waiting as export_queue_length, and spans
+// ended while OTEL_BSP_MAX_QUEUE_SIZE (2048) are waiting are dropped and counted as export_queue_dropped.
+func NewBatchSpanProcessor(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+	q := newQueue(SignalTraces, "OTEL_BSP_MAX_QUEUE_SIZE")
+
+	return queuedSpanProcessor{SpanProcessor: sdktrace.NewBatchSpanProcessor(queuedSpanExporter{SpanExporter: exporter, queue: q}), queue: q}
+}
+
+func (p queuedSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	// The batch processor ignores spans that weren't sampled
+	if !s.SpanContext().IsSampled() || !p.queue.enqueue() {
+		return
+	}
+
+	p.SpanProcessor.OnEnd(s)
+}
+
+func (p queuedSpanProcessor) Shutdown(ctx context.Context) error {
+	defer p.queue.clear()
+
+	return p.SpanProcessor.Shutdown(ctx)
+}
+
+type queuedSpanExporter struct {
+	sdktrace.SpanExporter
+
+	queue *queue
+}
+
+func (e queuedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.queue.dequeue(len(spans))
+
+	return e.SpanExporter.ExportSpans(ctx, spans)
+}
+
+type queuedLogProcessor struct {
+	sdklog.Processor
+
+	queue *queue
+}
+
+// NewBatchLogProcessor returns the SDK's batch log processor exporting to exporter, typically wrapped with
+// WrapLogExporter, with its queue counted: RecordMetrics reports the records waiting as export_queue_length, and
+// records emitted while OTEL_BLRP_MAX_QUEUE_SIZE (2048) are waiting are dropped and counted as export_queue_dropped.
+func NewBatchLogProcessor(exporter sdklog.Exporter) sdklog.Processor {
+	q := newQueue(SignalLogs, "OTEL_BLRP_MAX_QUEUE_SIZE")
+
+	return queuedLogProcessor{Processor: sdklog.NewBatchProcessor(queuedLogExporter{Exporter: exporter, queue: q}), queue: q}
+}
+
+func (p queuedLogProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	if !p.queue.enqueue() {
+		return nil
+	}
+
+	return p.Processor.OnEmit(ctx, record)
+}
+
+func (p queuedLogProcessor) Shutdown(ctx context.Context) error {
+	defer p.queue.clear()
+
+	return p.Processor.Shutdown(ctx)
+}
+
+type queuedLogExporter struct {
+	sdklog.Exporter
+
+	queue *queue
+}
+
+func (e queuedLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	e.queue.dequeue(len(records))
+
+	return e.Exporter.Export(ctx, records)
+}
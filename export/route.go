@@ -0,0 +1,456 @@
+//go:build !gotel_disabled
+
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Match selects telemetry whose Key attribute has one of Values, or any value if Values is empty.
+// The attributes of the span, log record, or metric data point are checked, then those of the resource.
+type Match struct {
+	Key    string
+	Values []string
+}
+
+// Route sends the telemetry selected by Match to Exporter.
+type Route[E any] struct {
+	Match    Match
+	Exporter E
+}
+
+func (m Match) matchesValue(key string, value string) bool {
+	return key == m.Key && (len(m.Values) == 0 || slices.Contains(m.Values, value))
+}
+
+func (m Match) matchesSet(set *attribute.Set) bool {
+	value, ok := set.Value(attribute.Key(m.Key))
+
+	return ok && m.matchesValue(m.Key, value.Emit())
+}
+
+func (m Match) matches(attrs []attribute.KeyValue, res *resource.Resource) bool {
+	for _, kv := range attrs {
+		if m.matchesValue(string(kv.Key), kv.Value.Emit()) {
+			return true
+		}
+	}
+
+	return res != nil && m.matchesSet(res.Set())
+}
+
+// route returns the index of the first route whose match selects an item, or len(routes) for the fallback.
+func route[E any](routes []Route[E], matches func(Match) bool) int {
+	for i, r := range routes {
+		if matches(r.Match) {
+			return i
+		}
+	}
+
+	return len(routes)
+}
+
+// exporters returns the exporters of routes followed by the fallback.
+func exporters[E any](fallback E, routes []Route[E]) []E {
+	all := make([]E, 0, len(routes)+1)
+	for _, r := range routes {
+		all = append(all, r.Exporter)
+	}
+
+	return append(all, fallback)
+}
+
+// distinct returns exporters without repeats, so an exporter shared by several routes, or serving as a route and the
+// fallback, is flushed and shut down once. Exporters of types that can't be compared are all kept.
+func distinct[E any](exporters []E) []E {
+	unique := make([]E, 0, len(exporters))
+
+	for _, exporter := range exporters {
+		if t := reflect.TypeOf(exporter); t != nil && t.Comparable() &&
+			slices.ContainsFunc(unique, func(other E) bool { return any(other) == any(exporter) }) {
+			continue
+		}
+
+		unique = append(unique, exporter)
+	}
+
+	return unique
+}
+
+type spanRouter struct {
+	routes    []Route[sdktrace.SpanExporter]
+	exporters []sdktrace.SpanExporter
+}
+
+// RouteSpans returns an exporter sending each span to the exporter of the first route matching it, or to fallback,
+// e.g. to keep spans of internal tenants in a separate backend with its own retention or region.
+func RouteSpans(fallback sdktrace.SpanExporter, routes ...Route[sdktrace.SpanExporter]) sdktrace.SpanExporter {
+	return spanRouter{routes: routes, exporters: exporters(fallback, routes)}
+}
+
+func (r spanRouter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	batches := make([][]sdktrace.ReadOnlySpan, len(r.exporters))
+
+	for _, span := range spans {
+		i := route(r.routes, func(m Match) bool { return m.matches(span.Attributes(), span.Resource()) })
+		batches[i] = append(batches[i], span)
+	}
+
+	var errs []error
+
+	for i, batch := range batches {
+		if len(batch) > 0 {
+			errs = append(errs, r.exporters[i].ExportSpans(ctx, batch))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r spanRouter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range distinct(r.exporters) {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+type logRouter struct {
+	routes    []Route[sdklog.Exporter]
+	exporters []sdklog.Exporter
+}
+
+// RouteLogs returns an exporter sending each log record to the exporter of the first route matching it, or to
+// fallback.
+func RouteLogs(fallback sdklog.Exporter, routes ...Route[sdklog.Exporter]) sdklog.Exporter {
+	return logRouter{routes: routes, exporters: exporters(fallback, routes)}
+}
+
+func (m Match) matchesRecord(record *sdklog.Record) bool {
+	matched := false
+
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		matched = m.matchesValue(kv.Key, kv.Value.String())
+		return !matched
+	})
+
+	return matched || m.matches(nil, record.Resource())
+}
+
+func (r logRouter) Export(ctx context.Context, records []sdklog.Record) error {
+	batches := make([][]sdklog.Record, len(r.exporters))
+
+	for i := range records {
+		route := route(r.routes, func(m Match) bool { return m.matchesRecord(&records[i]) })
+		batches[route] = append(batches[route], records[i])
+	}
+
+	var errs []error
+
+	for i, batch := range batches {
+		if len(batch) > 0 {
+			errs = append(errs, r.exporters[i].Export(ctx, batch))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r logRouter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range distinct(r.exporters) {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r logRouter) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range distinct(r.exporters) {
+		errs = append(errs, exporter.ForceFlush(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+type metricRouter struct {
+	sdkmetric.Exporter
+
+	routes    []Route[sdkmetric.Exporter]
+	exporters []sdkmetric.Exporter
+}
+
+// RouteMetrics returns an exporter sending each metric data point to the exporter of the first route matching it,
+// or to fallback. Temporality and aggregation are those of fallback.
+func RouteMetrics(fallback sdkmetric.Exporter, routes ...Route[sdkmetric.Exporter]) sdkmetric.Exporter {
+	return metricRouter{Exporter: fallback, routes: routes, exporters: exporters(fallback, routes)}
+}
+
+// partition splits data points by the route matching their attributes.
+func partition[D any](routes int, dataPoints []D, route func(D) int) [][]D {
+	parts := make([][]D, routes)
+	for _, dp := range dataPoints {
+		i := route(dp)
+		parts[i] = append(parts[i], dp)
+	}
+
+	return parts
+}
+
+// splitData splits the data points of a metric by route, returning nil data for routes without any.
+func splitData(data metricdata.Aggregation, routes int, route func(*attribute.Set) int) []metricdata.Aggregation {
+	split := make([]metricdata.Aggregation, routes)
+
+	switch data := data.(type) {
+	case metricdata.Gauge[int64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.DataPoint[int64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Gauge[int64]{DataPoints: dps}
+			}
+		}
+	case metricdata.Gauge[float64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.DataPoint[float64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Gauge[float64]{DataPoints: dps}
+			}
+		}
+	case metricdata.Sum[int64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.DataPoint[int64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Sum[int64]{DataPoints: dps, Temporality: data.Temporality, IsMonotonic: data.IsMonotonic}
+			}
+		}
+	case metricdata.Sum[float64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.DataPoint[float64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Sum[float64]{DataPoints: dps, Temporality: data.Temporality, IsMonotonic: data.IsMonotonic}
+			}
+		}
+	case metricdata.Histogram[int64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.HistogramDataPoint[int64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Histogram[int64]{DataPoints: dps, Temporality: data.Temporality}
+			}
+		}
+	case metricdata.Histogram[float64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.HistogramDataPoint[float64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Histogram[float64]{DataPoints: dps, Temporality: data.Temporality}
+			}
+		}
+	case metricdata.ExponentialHistogram[int64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.ExponentialHistogramDataPoint[int64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.ExponentialHistogram[int64]{DataPoints: dps, Temporality: data.Temporality}
+			}
+		}
+	case metricdata.ExponentialHistogram[float64]:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.ExponentialHistogramDataPoint[float64]) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.ExponentialHistogram[float64]{DataPoints: dps, Temporality: data.Temporality}
+			}
+		}
+	case metricdata.Summary:
+		for i, dps := range partition(routes, data.DataPoints, func(dp metricdata.SummaryDataPoint) int { return route(&dp.Attributes) }) {
+			if dps != nil {
+				split[i] = metricdata.Summary{DataPoints: dps}
+			}
+		}
+	default:
+		split[routes-1] = data
+	}
+
+	return split
+}
+
+func (r metricRouter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	batches := make([]*metricdata.ResourceMetrics, len(r.exporters))
+	for i := range batches {
+		batches[i] = &metricdata.ResourceMetrics{Resource: rm.Resource}
+	}
+
+	routeSet := func(set *attribute.Set) int {
+		return route(r.routes, func(m Match) bool {
+			return m.matchesSet(set) || (rm.Resource != nil && m.matchesSet(rm.Resource.Set()))
+		})
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		scopes := make([]*metricdata.ScopeMetrics, len(r.exporters))
+
+		for _, m := range sm.Metrics {
+			for i, data := range splitData(m.Data, len(r.exporters), routeSet) {
+				if data == nil {
+					continue
+				}
+
+				if scopes[i] == nil {
+					scopes[i] = &metricdata.ScopeMetrics{Scope: sm.Scope}
+				}
+
+				scopes[i].Metrics = append(scopes[i].Metrics, metricdata.Metrics{Name: m.Name, Description: m.Description, Unit: m.Unit, Data: data})
+			}
+		}
+
+		for i, scope := range scopes {
+			if scope != nil {
+				batches[i].ScopeMetrics = append(batches[i].ScopeMetrics, *scope)
+			}
+		}
+	}
+
+	var errs []error
+
+	for i, batch := range batches {
+		// The fallback always exports, so periodic exports keep reaching it
+		if len(batch.ScopeMetrics) > 0 || i == len(batches)-1 {
+			errs = append(errs, r.exporters[i].Export(ctx, batch))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r metricRouter) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range distinct(r.exporters) {
+		errs = append(errs, exporter.ForceFlush(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r metricRouter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range distinct(r.exporters) {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+var errRoutes = errors.New("invalid export routes")
+
+// EndpointRoute sends the telemetry selected by Match to the OTLP collector at Endpoint, e.g.
+// https://internal.example.com:4318, rather than to the endpoint set by SetEndpoint or OTEL_EXPORTER_OTLP_ENDPOINT.
+type EndpointRoute struct {
+	Match    Match
+	Endpoint string
+}
+
+var endpointRoutes atomic.Pointer[[]EndpointRoute]
+
+// SetEndpointRoutes routes the telemetry of the OTLP exporters created by tracing.InitTracing, metrics.InitMetrics,
+// and log.InitLogger to the endpoints of routes, as RouteSpans, RouteMetrics, and RouteLogs do, with the rest going
+// to the configured endpoint. Routes don't apply to the file protocol. gotel.WithExportRoutes and GOTEL_EXPORT_ROUTES
+// set them from gotel.Init.
+func SetEndpointRoutes(routes ...EndpointRoute) {
+	endpointRoutes.Store(&routes)
+}
+
+// EndpointRoutes returns the routes set by SetEndpointRoutes.
+func EndpointRoutes() []EndpointRoute {
+	if routes := endpointRoutes.Load(); routes != nil {
+		return *routes
+	}
+
+	return nil
+}
+
+// ParseEndpointRoutes parses routes separated by semicolons, each a key, optionally followed by = and values
+// separated by |, then -> and the endpoint, as set in GOTEL_EXPORT_ROUTES:
+//
+//	tenant=internal|ops -> https://internal.example.com:4318; region=eu -> https://eu.example.com:4318
+//
+// A key without values matches any value. Endpoints must be absolute URLs.
+func ParseEndpointRoutes(spec string) ([]EndpointRoute, error) {
+	var routes []EndpointRoute
+
+	for entry := range strings.SplitSeq(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		selector, endpoint, ok := strings.Cut(entry, "->")
+		if !ok {
+			return nil, fmt.Errorf("%w: route %q has no endpoint", errRoutes, strings.TrimSpace(entry))
+		}
+
+		endpoint = strings.TrimSpace(endpoint)
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w: endpoint %q is not an absolute URL", errRoutes, endpoint)
+		}
+
+		key, values, _ := strings.Cut(selector, "=")
+
+		match := Match{Key: strings.TrimSpace(key)}
+		if match.Key == "" {
+			return nil, fmt.Errorf("%w: route %q has no key", errRoutes, strings.TrimSpace(entry))
+		}
+
+		for value := range strings.SplitSeq(values, "|") {
+			if value = strings.TrimSpace(value); value != "" {
+				match.Values = append(match.Values, value)
+			}
+		}
+
+		routes = append(routes, EndpointRoute{Match: match, Endpoint: endpoint})
+	}
+
+	return routes, nil
+}
+
+// shutdowner is implemented by the span, metric, and log exporters.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// RouteEndpoints returns an exporter routing telemetry to the endpoints of the routes set by SetEndpointRoutes, with
+// the rest going to fallback, or fallback itself if none are set or the protocol is file. newExporter creates the
+// exporter of an endpoint, once for routes sharing it, and route is RouteSpans, RouteMetrics, or RouteLogs.
+func RouteEndpoints[E shutdowner](ctx context.Context, fallback E, newExporter func(endpoint string) (E, error), route func(E, ...Route[E]) E) (E, error) {
+	endpoints := EndpointRoutes()
+	if len(endpoints) == 0 || Protocol() == "file" {
+		return fallback, nil
+	}
+
+	created := map[string]E{}
+	routes := make([]Route[E], 0, len(endpoints))
+
+	for _, r := range endpoints {
+		exporter, ok := created[r.Endpoint]
+		if !ok {
+			var err error
+			if exporter, err = newExporter(r.Endpoint); err != nil {
+				for _, exporter := range created {
+					_ = exporter.Shutdown(ctx)
+				}
+
+				return fallback, err
+			}
+
+			created[r.Endpoint] = exporter
+		}
+
+		routes = append(routes, Route[E]{Match: r.Match, Exporter: exporter})
+	}
+
+	return route(fallback, routes...), nil
+}
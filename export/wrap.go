@@ -0,0 +1,204 @@
+//go:build !gotel_disabled
+
+package export
+
+import (
+	"context"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type spanExporter struct {
+	sdktrace.SpanExporter
+}
+
+// WrapSpanExporter reports each export of exporter to the registered callbacks, and applies SetClockOffset.
+func WrapSpanExporter(exporter sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return spanExporter{SpanExporter: exporter}
+}
+
+func (e spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, correctSpans(spans))
+	report(SignalTraces, len(spans), start, err)
+
+	return err
+}
+
+type metricExporter struct {
+	sdkmetric.Exporter
+}
+
+// WrapMetricExporter reports each export of exporter to the registered callbacks, and applies SetClockOffset.
+func WrapMetricExporter(exporter sdkmetric.Exporter) sdkmetric.Exporter {
+	return metricExporter{Exporter: exporter}
+}
+
+func (e metricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	start := time.Now()
+
+	correctMetrics(rm)
+	err := e.Exporter.Export(ctx, rm)
+	report(SignalMetrics, dataPoints(rm), start, err)
+
+	return err
+}
+
+func dataPoints(rm *metricdata.ResourceMetrics) int {
+	count := 0
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				count += len(data.DataPoints)
+			case metricdata.Gauge[float64]:
+				count += len(data.DataPoints)
+			case metricdata.Sum[int64]:
+				count += len(data.DataPoints)
+			case metricdata.Sum[float64]:
+				count += len(data.DataPoints)
+			case metricdata.Histogram[int64]:
+				count += len(data.DataPoints)
+			case metricdata.Histogram[float64]:
+				count += len(data.DataPoints)
+			case metricdata.ExponentialHistogram[int64]:
+				count += len(data.DataPoints)
+			case metricdata.ExponentialHistogram[float64]:
+				count += len(data.DataPoints)
+			case metricdata.Summary:
+				count += len(data.DataPoints)
+			}
+		}
+	}
+
+	return count
+}
+
+type logExporter struct {
+	sdklog.Exporter
+}
+
+// WrapLogExporter reports each export of exporter to the registered callbacks, and applies SetClockOffset.
+func WrapLogExporter(exporter sdklog.Exporter) sdklog.Exporter {
+	return logExporter{Exporter: exporter}
+}
+
+func (e logExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	start := time.Now()
+	err := e.Exporter.Export(ctx, correctRecords(records))
+	report(SignalLogs, len(records), start, err)
+
+	return err
+}
+
+// skewedSpan is a span with its timestamps corrected by offset.
+type skewedSpan struct {
+	sdktrace.ReadOnlySpan
+
+	offset time.Duration
+}
+
+func (s skewedSpan) StartTime() time.Time {
+	return shift(s.ReadOnlySpan.StartTime(), s.offset)
+}
+
+func (s skewedSpan) EndTime() time.Time {
+	return shift(s.ReadOnlySpan.EndTime(), s.offset)
+}
+
+func (s skewedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+
+	shifted := make([]sdktrace.Event, len(events))
+	for i, event := range events {
+		event.Time = shift(event.Time, s.offset)
+		shifted[i] = event
+	}
+
+	return shifted
+}
+
+func correctSpans(spans []sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	offset := ClockOffset(SignalTraces)
+	if offset == 0 {
+		return spans
+	}
+
+	corrected := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		corrected[i] = skewedSpan{ReadOnlySpan: span, offset: offset}
+	}
+
+	return corrected
+}
+
+// correctRecords returns copies of records with their timestamps corrected, leaving the batch being exported intact.
+func correctRecords(records []sdklog.Record) []sdklog.Record {
+	offset := ClockOffset(SignalLogs)
+	if offset == 0 {
+		return records
+	}
+
+	corrected := make([]sdklog.Record, len(records))
+	for i, record := range records {
+		record.SetTimestamp(shift(record.Timestamp(), offset))
+		record.SetObservedTimestamp(shift(record.ObservedTimestamp(), offset))
+		corrected[i] = record
+	}
+
+	return corrected
+}
+
+func shiftDataPoints[D any](dataPoints []D, offset time.Duration, times func(*D) (*time.Time, *time.Time)) {
+	for i := range dataPoints {
+		start, end := times(&dataPoints[i])
+		*start = shift(*start, offset)
+		*end = shift(*end, offset)
+	}
+}
+
+// correctMetrics corrects the timestamps of the data points of rm in place, as each collection fills rm anew.
+func correctMetrics(rm *metricdata.ResourceMetrics) {
+	offset := ClockOffset(SignalMetrics)
+	if offset == 0 {
+		return
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.DataPoint[int64]) (*time.Time, *time.Time) { return &dp.StartTime, &dp.Time })
+			case metricdata.Gauge[float64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.DataPoint[float64]) (*time.Time, *time.Time) { return &dp.StartTime, &dp.Time })
+			case metricdata.Sum[int64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.DataPoint[int64]) (*time.Time, *time.Time) { return &dp.StartTime, &dp.Time })
+			case metricdata.Sum[float64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.DataPoint[float64]) (*time.Time, *time.Time) { return &dp.StartTime, &dp.Time })
+			case metricdata.Histogram[int64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.HistogramDataPoint[int64]) (*time.Time, *time.Time) {
+					return &dp.StartTime, &dp.Time
+				})
+			case metricdata.Histogram[float64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.HistogramDataPoint[float64]) (*time.Time, *time.Time) {
+					return &dp.StartTime, &dp.Time
+				})
+			case metricdata.ExponentialHistogram[int64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.ExponentialHistogramDataPoint[int64]) (*time.Time, *time.Time) {
+					return &dp.StartTime, &dp.Time
+				})
+			case metricdata.ExponentialHistogram[float64]:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.ExponentialHistogramDataPoint[float64]) (*time.Time, *time.Time) {
+					return &dp.StartTime, &dp.Time
+				})
+			case metricdata.Summary:
+				shiftDataPoints(data.DataPoints, offset, func(dp *metricdata.SummaryDataPoint) (*time.Time, *time.Time) { return &dp.StartTime, &dp.Time })
+			}
+		}
+	}
+}
@@ -3,35 +3,253 @@
 package gotel
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
 	"github.com/tinybluerobots/gotel/log"
 	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/requestid"
 	"github.com/tinybluerobots/gotel/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type gotelMetrics struct {
+	WatchdogTimeouts *metrics.Int64Counter
+	Up               *metrics.Int64Gauge
+	Crashes          *metrics.Int64Counter
+	BatchItems       *metrics.Int64Counter
+	FunctionDuration *metrics.Float64Histogram `unit:"s"`
+}
+
+var getMetrics = metrics.Scoped[gotelMetrics]("github.com/tinybluerobots/gotel")
+
+type config struct {
+	tracingBridges   []func(trace.TracerProvider)
+	logConfig        bool
+	shutdownReport   bool
+	logScopeName     string
+	logScopeVersion  string
+	traceSampledLogs bool
+	metricOptions    []metrics.Option
+	tracerOptions    []tracing.Option
+	runtimeMetrics   bool
+	processMetrics   bool
+	residency        *residency
+	metricExporters  int
+	exporters        exporterConfig
+	clockOffset      time.Duration
+	fileDirectory    string
+	devChecks        bool
+	keepErrorsSlow   bool
+	disabled         bool
+}
+
+// Option configures Init.
+type Option func(*config)
+
+// WithTracingBridge calls install with the tracer provider once tracing is initialized,
+// so libraries instrumented with legacy APIs such as OpenTracing or OpenCensus contribute spans to the same traces:
+//
+//	gotel.WithTracingBridge(func(tp trace.TracerProvider) {
+//		bridgeTracer, wrapperProvider := otbridge.NewTracerPair(tp.Tracer("opentracing"))
+//		opentracing.SetGlobalTracer(bridgeTracer)
+//		otel.SetTracerProvider(wrapperProvider)
+//	})
+func WithTracingBridge(install func(tp trace.TracerProvider)) Option {
+	return func(c *config) {
+		c.tracingBridges = append(c.tracingBridges, install)
+	}
+}
+
+// WithConfigLog logs the EffectiveConfig at INFO level once initialization completes.
+func WithConfigLog() Option {
+	return func(c *config) {
+		c.logConfig = true
+	}
+}
+
+// WithShutdownReport logs a summary of the telemetry session when the shutdown function returned by Init completes:
+// the spans, metric data points, and log records exported and dropped, export errors, and how long the final flush took.
+// The summary is written after the providers shut down, so it only reaches the local log handler.
+func WithShutdownReport() Option {
+	return func(c *config) {
+		c.shutdownReport = true
+	}
+}
+
+// WithLogScope sets the instrumentation scope name and version of exported log records, instead of the service name and version.
+func WithLogScope(name string, version string) Option {
+	return func(c *config) {
+		c.logScopeName = name
+		c.logScopeVersion = version
+	}
+}
+
+// WithTraceSampledLogs exports DEBUG and INFO log records only when they are logged in a sampled trace, keeping log
+// volume proportional to trace volume, while WARN and ERROR records are always exported. See log.SetTraceSampledExport.
+func WithTraceSampledLogs() Option {
+	return func(c *config) {
+		c.traceSampledLogs = true
+	}
+}
+
+// WithRuntimeMetrics records Go runtime metrics, such as heap size, GC cycles and pauses, goroutines, and cgo calls,
+// on the same meter provider as the application's metrics. See metrics.InitRuntimeMetrics.
+func WithRuntimeMetrics() Option {
+	return func(c *config) {
+		c.runtimeMetrics = true
+	}
+}
+
+// WithProcessMetrics records the open file descriptors, file descriptor limit, and OS threads of the process on the
+// same meter provider as the application's metrics. See metrics.InitProcessMetrics.
+func WithProcessMetrics() Option {
+	return func(c *config) {
+		c.processMetrics = true
+	}
+}
+
+// WithDevChecks logs warnings for instrumentation mistakes, such as child spans ending after their parent, spans
+// never ended, and spans modified after End. See tracing.SetDevChecks; don't use it in production.
+func WithDevChecks() Option {
+	return func(c *config) {
+		c.devChecks = true
+	}
+}
+
+// WithClockOffset corrects the timestamps of exported spans, metrics, and logs by offset, for devices whose clocks
+// are known to be skewed. See export.SetClockOffset to correct a single signal or change the offset later.
+func WithClockOffset(offset time.Duration) Option {
+	return func(c *config) {
+		c.clockOffset = offset
+	}
+}
+
+// WithFileExport appends spans, metrics, and logs as OTLP JSON lines to traces.jsonl, metrics.jsonl, and logs.jsonl
+// in dir, or in the working directory if dir is empty, instead of sending them to a collector, as
+// OTEL_EXPORTER_OTLP_PROTOCOL=file does. See export.SetFileExport.
+func WithFileExport(dir string) Option {
+	return func(c *config) {
+		c.fileDirectory = cmp.Or(dir, ".")
+	}
+}
+
+// WithDisabled turns telemetry off, e.g. in one environment from configuration, as OTEL_SDK_DISABLED=true does:
+// spans only propagate trace context as with tracing.InitPropagation, the metrics struct is created on a no-op meter
+// as with metrics.WithDisabled, and logs are written to the local handler only. No provider or exporter is created.
+func WithDisabled() Option {
+	return func(c *config) {
+		c.disabled = true
+	}
+}
+
 // Init initializes all telemetry components (tracing, metrics, logging) with a single call.
 // Returns a shutdown function that gracefully closes all providers.
 // Pass a slog.Handler to enable local logging, or nil to log only to the OTEL collector.
-func Init[T any](ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct *T, logHandler slog.Handler) (func(context.Context) error, error) {
-	shutdownTracing, err := tracing.InitTracing(ctx, serviceName, resourceAttrs)
-	if err != nil {
+func Init[T any](ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct *T, logHandler slog.Handler, options ...Option) (func(context.Context) error, error) {
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+
+	if err := c.exporters.load(); err != nil {
 		return nil, err
 	}
 
-	shutdownMetrics, err := metrics.InitMetrics(ctx, serviceName, resourceAttrs, metricsStruct)
+	if c.residency != nil {
+		var err error
+		if resourceAttrs, err = c.residency.apply(resourceAttrs, c.metricExporters+c.exporters.custom(), c.exporters.endpoints()); err != nil {
+			return nil, err
+		}
+	}
+
+	c.exporters.install()
+
+	if c.fileDirectory != "" {
+		export.SetFileExport(c.fileDirectory)
+	}
+
+	if c.clockOffset != 0 {
+		for _, signal := range []export.Signal{export.SignalTraces, export.SignalMetrics, export.SignalLogs} {
+			export.SetClockOffset(signal, c.clockOffset)
+		}
+	}
+
+	if c.devChecks {
+		tracing.SetDevChecks(true)
+	}
+
+	if c.keepErrorsSlow {
+		tracing.SetKeepErrorsAndSlow(true)
+	}
+
+	started := time.Now()
+
+	recordInit(serviceName, resourceAttrs)
+
+	shutdownTracing := func(context.Context) error { return nil }
+
+	if c.disabled {
+		tracing.InitPropagation()
+		c.metricOptions = append(c.metricOptions, metrics.WithDisabled())
+	} else {
+		var err error
+		if shutdownTracing, err = tracing.InitTracing(ctx, serviceName, resourceAttrs, c.tracerOptions...); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, install := range c.tracingBridges {
+		install(tracing.TracerProvider())
+	}
+
+	shutdownMetrics, err := metrics.InitMetrics(ctx, serviceName, resourceAttrs, metricsStruct, c.metricOptions...)
 	if err != nil {
 		_ = shutdownTracing(ctx)
 		return nil, err
 	}
 
-	var shutdownLogger func(context.Context) error
+	if c.runtimeMetrics {
+		if err := metrics.InitRuntimeMetrics(); err != nil {
+			_ = shutdownMetrics(ctx)
+			_ = shutdownTracing(ctx)
+
+			return nil, err
+		}
+	}
+
+	if c.processMetrics {
+		if err := metrics.InitProcessMetrics(); err != nil {
+			_ = shutdownMetrics(ctx)
+			_ = shutdownTracing(ctx)
+
+			return nil, err
+		}
+	}
+
+	if c.logScopeName != "" {
+		log.SetScope(c.logScopeName, c.logScopeVersion)
+	}
+
+	if c.traceSampledLogs {
+		log.SetTraceSampledExport(true)
+	}
+
+	var handlers []slog.Handler
 	if logHandler != nil {
-		shutdownLogger, err = log.InitLogger(ctx, resourceAttrs, logHandler)
+		handlers = append(handlers, logHandler)
+	}
+
+	var shutdownLogger func(context.Context) error
+	if c.disabled {
+		shutdownLogger, err = log.InitLocalLogger(ctx, handlers...)
 	} else {
-		shutdownLogger, err = log.InitLogger(ctx, resourceAttrs)
+		shutdownLogger, err = log.InitLogger(ctx, resourceAttrs, handlers...)
 	}
 
 	if err != nil {
@@ -41,7 +259,12 @@ func Init[T any](ctx context.Context, serviceName string, resourceAttrs []attrib
 		return nil, err
 	}
 
+	if c.logConfig {
+		logConfig(ctx)
+	}
+
 	shutdown := func(ctx context.Context) error {
+		flushStart := time.Now()
 		firstErr := shutdownLogger(ctx)
 
 		if err := shutdownMetrics(ctx); err != nil && firstErr == nil {
@@ -52,8 +275,23 @@ func Init[T any](ctx context.Context, serviceName string, resourceAttrs []attrib
 			firstErr = err
 		}
 
+		if c.shutdownReport {
+			logShutdownReport(ctx, time.Since(started), time.Since(flushStart), firstErr)
+		}
+
 		return firstErr
 	}
 
 	return shutdown, nil
 }
+
+// ForceFlush exports pending telemetry from every provider without shutting them down, so cron jobs and serverless
+// handlers can flush at the end of each invocation and keep the providers for the next. Metrics are flushed first.
+func ForceFlush(ctx context.Context) error {
+	return errors.Join(metrics.ForceFlush(ctx), tracing.ForceFlush(ctx), log.ForceFlush(ctx))
+}
+
+// RequestID returns the ID of the request being handled, as set by the gotelhttp middleware, or an empty string.
+func RequestID(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
@@ -0,0 +1,720 @@
+//go:build !gotel_disabled
+
+package gotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+	"go.opentelemetry.io/otel"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errInvalidItem = errors.New("invalid item")
+
+// initTestMetrics initializes metrics on a manual reader for the test
+func initTestMetrics(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	shutdown, err := metrics.InitMetrics[struct{}](t.Context(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	return reader
+}
+
+// syncBuffer guards a buffer written by the watchdog timer goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes())
+}
+
+func TestWatch(t *testing.T) {
+	reader := initTestMetrics(t)
+	buf := &syncBuffer{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	require.NoError(t, err)
+
+	_, err = log.InitLogger(t.Context(), resourceAttrs, handler)
+	require.NoError(t, err)
+
+	done := Watch(t.Context(), "completed", time.Hour)
+	done()
+
+	Watch(t.Context(), "stuck", time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(buf.Bytes()) > 0 }, time.Second, 5*time.Millisecond)
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "watchdog timeout", logEntry["msg"])
+	assert.Equal(t, "stuck", logEntry["watch.name"])
+	assert.Contains(t, logEntry["goroutine_stacks"], "goroutine")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	found := false
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "watchdog_timeouts" {
+				found = true
+			}
+		}
+	}
+
+	assert.True(t, found, "watchdog_timeouts should be incremented")
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://metrics:4318/v1/metrics")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http")
+	t.Setenv("OTEL_TRACES_SAMPLER", "traceidratio")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.1")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "15000")
+
+	recordInit("test-service", attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost"))
+
+	config := EffectiveConfig()
+
+	assert.Equal(t, "test-service", config.ServiceName)
+	assert.Equal(t, "http://collector:4318", config.Endpoint)
+	assert.Equal(t, "http://collector:4318", config.TracesEndpoint)
+	assert.Equal(t, "http://metrics:4318/v1/metrics", config.MetricsEndpoint)
+	assert.Equal(t, "http", config.Protocol)
+	assert.False(t, config.Insecure)
+	assert.Equal(t, "traceidratio", config.Sampler)
+	assert.Equal(t, "0.1", config.SamplerArg)
+	assert.Equal(t, 15*time.Second, config.MetricExportInterval)
+	assert.Equal(t, 5*time.Second, config.TraceBatchDelay)
+	assert.Len(t, config.ResourceAttributes, 5)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	assert.Equal(t, "http/json", EffectiveConfig().Protocol)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "file")
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", "/var/log/otel")
+	assert.Equal(t, "file", EffectiveConfig().Protocol)
+	assert.Equal(t, "/var/log/otel", EffectiveConfig().FilePath)
+}
+
+func TestEffectiveConfig_Defaults(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://metrics:4318/v1/metrics")
+
+	config := EffectiveConfig()
+
+	assert.Empty(t, config.Endpoint)
+	assert.Empty(t, config.MetricsEndpoint, "export is disabled without an endpoint")
+	assert.Equal(t, "grpc", config.Protocol)
+	assert.Equal(t, "parentbased_always_on", config.Sampler)
+	assert.Equal(t, time.Minute, config.MetricExportInterval)
+	assert.Equal(t, "cumulative", config.MetricTemporality)
+}
+
+func TestInit_WithConfigLog(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+
+	buf := &syncBuffer{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	require.NoError(t, err)
+
+	shutdown, err := Init[struct{}](t.Context(), "test-service", resourceAttrs, nil, handler, WithConfigLog())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "effective configuration", logEntry["msg"])
+	assert.Equal(t, "grpc", logEntry["protocol"])
+	assert.Equal(t, "test", logEntry["resource.deployment.environment.name"])
+}
+
+func TestInit_WithDisabled(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "file")
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", dir)
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.TraceContext{}) })
+
+	type appMetrics struct {
+		Requests *metrics.Int64Counter
+	}
+
+	buf := &syncBuffer{}
+
+	handler, err := log.NewJSONHandler(buf, nil, "INFO")
+	require.NoError(t, err)
+
+	shutdown, err := Init(t.Context(), "test-service", nil, &appMetrics{}, handler, WithDisabled())
+	require.NoError(t, err)
+
+	m := metrics.Metrics[appMetrics]()
+	require.NotNil(t, m, "the metrics struct is still initialized")
+	m.Requests.Inc(t.Context())
+
+	_, span := tracing.NewSpan(t.Context(), "dropped")
+	span.End()
+
+	log.Info(t.Context(), "local only")
+	require.NoError(t, shutdown(t.Context()))
+
+	assert.Contains(t, string(buf.Bytes()), "local only", "logs still reach the local handler")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "no exporter is created")
+}
+
+func TestInit_WithFileExport(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+	t.Cleanup(func() { export.SetFileExport("") })
+
+	shutdown, err := Init[struct{}](t.Context(), "test-service", nil, nil, nil, WithFileExport(dir))
+	require.NoError(t, err)
+
+	assert.Equal(t, "file", EffectiveConfig().Protocol)
+	assert.Equal(t, dir, EffectiveConfig().FilePath)
+
+	_, span := tracing.NewSpan(t.Context(), "exported to a file")
+	span.End()
+
+	require.NoError(t, shutdown(t.Context()))
+
+	traces, err := os.ReadFile(filepath.Join(dir, "traces.jsonl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(traces), "exported to a file")
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	reader := initTestMetrics(t)
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	stop := StartHeartbeat(t.Context(), time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(exporter.GetSpans()) >= 2 }, time.Second, time.Millisecond)
+
+	upValue := func() int64 {
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(t.Context(), &rm))
+
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == "up" {
+					return gauge.DataPoints[0].Value
+				}
+			}
+		}
+
+		return -1
+	}
+
+	assert.Equal(t, int64(1), upValue())
+	assert.Equal(t, "heartbeat", exporter.GetSpans()[0].Name)
+
+	stop()
+
+	assert.Equal(t, int64(0), upValue(), "stopping records the instance as down")
+}
+
+func TestInit_WithShutdownReport(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+
+	buf := &syncBuffer{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	require.NoError(t, err)
+
+	shutdown, err := Init[struct{}](t.Context(), "test-service", resourceAttrs, nil, handler, WithShutdownReport())
+	require.NoError(t, err)
+	require.NoError(t, shutdown(t.Context()))
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "telemetry session summary", logEntry["msg"])
+	assert.Equal(t, "INFO", logEntry["level"])
+	assert.Contains(t, logEntry, "traces.exported")
+	assert.Contains(t, logEntry, "logs.export_errors")
+	assert.Contains(t, logEntry, "flush_duration_ms")
+}
+
+type recordingLogExporter struct {
+	sdklog.Exporter
+
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *recordingLogExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.records = append(e.records, records...)
+
+	return nil
+}
+
+func (*recordingLogExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (*recordingLogExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func TestInit_WithSpanAndLogExporter(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Cleanup(func() {
+		tracing.SetExporters()
+		log.SetExporters()
+	})
+
+	spans, records := tracetest.NewInMemoryExporter(), &recordingLogExporter{}
+
+	shutdown, err := Init[struct{}](t.Context(), "test-service", nil, nil, nil, WithSpanExporter(spans), WithLogExporter(records))
+	require.NoError(t, err)
+
+	_, span := tracing.NewSpan(t.Context(), "exported")
+	span.End()
+
+	log.Info(t.Context(), "exported")
+	require.NoError(t, ForceFlush(t.Context()))
+
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+
+	require.Len(t, spans.GetSpans(), 1, "spans reach the exporter without an OTLP endpoint")
+	assert.Equal(t, "exported", spans.GetSpans()[0].Name)
+	require.Len(t, records.records, 1)
+	assert.Equal(t, "exported", records.records[0].Body().AsString())
+
+	t.Setenv("GOTEL_EXPORT_ROUTES", "tenant=internal")
+
+	_, err = Init[struct{}](t.Context(), "test-service", nil, nil, nil)
+	require.Error(t, err, "routes without an endpoint are rejected")
+}
+
+func TestCapturePanics(t *testing.T) {
+	reader := initTestMetrics(t)
+	buf := &syncBuffer{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	require.NoError(t, err)
+
+	_, err = log.InitLogger(t.Context(), resourceAttrs, handler)
+	require.NoError(t, err)
+
+	crash := func() {
+		defer CapturePanics(t.Context(), time.Second)
+
+		panic("out of widgets")
+	}
+
+	assert.PanicsWithValue(t, "out of widgets", crash, "the panic should be re-raised")
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "panic: out of widgets", logEntry["msg"])
+	assert.Equal(t, "ERROR", logEntry["level"])
+	assert.Contains(t, logEntry["stack_trace"], "TestCapturePanics")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	found := false
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "crashes" {
+				found = true
+			}
+		}
+	}
+
+	assert.True(t, found, "crashes should be incremented")
+
+	assert.NotPanics(t, func() {
+		defer CapturePanics(t.Context(), time.Second)
+	})
+}
+
+func TestForceFlush(t *testing.T) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	shutdown, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Hour)))
+	require.NoError(t, err)
+
+	_, span := tracing.NewSpan(t.Context(), "invocation")
+	span.End()
+
+	assert.Empty(t, exporter.GetSpans(), "the span should wait for the batch")
+
+	require.NoError(t, ForceFlush(t.Context()))
+	assert.Len(t, exporter.GetSpans(), 1)
+
+	_, span = tracing.NewSpan(t.Context(), "next invocation")
+	span.End()
+
+	require.NoError(t, ForceFlush(t.Context()))
+	assert.Len(t, exporter.GetSpans(), 2, "the provider should stay alive after a flush")
+
+	require.NoError(t, shutdown(t.Context()))
+}
+
+func TestRecordBatchOutcome(t *testing.T) {
+	reader := initTestMetrics(t)
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	sampleErrors := make([]error, 20)
+	for i := range sampleErrors {
+		sampleErrors[i] = fmt.Errorf("item %d: %w", i, errInvalidItem)
+	}
+
+	ctx, span := tracing.NewSpan(t.Context(), "import")
+	RecordBatchOutcome(ctx, "import", 10000, 25, sampleErrors...)
+	span.End()
+
+	ctx, span = tracing.NewSpan(t.Context(), "import")
+	RecordBatchOutcome(ctx, "import", 2, 2)
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	assert.Contains(t, spans[0].Attributes, otelattribute.Int("batch.size", 10000))
+	assert.Contains(t, spans[0].Attributes, otelattribute.Int("batch.failed", 25))
+	assert.Contains(t, spans[0].Attributes, otelattribute.Float64("batch.success_ratio", 0.9975))
+	assert.Len(t, spans[0].Events, maxBatchSampleErrors, "sample errors are capped")
+	assert.Equal(t, "Unset", spans[0].Status.Code.String(), "partial failures leave the status unset")
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	counts := map[string]int64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "batch_items" {
+				for _, dp := range sum.DataPoints {
+					outcome, _ := dp.Attributes.Value("batch.outcome")
+					counts[outcome.AsString()] = dp.Value
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"success": 9975, "failure": 27}, counts)
+}
+
+func TestInstrument(t *testing.T) {
+	reader := initTestMetrics(t)
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	lookup := Instrument("lookup", func(ctx context.Context, id int) (string, error) {
+		switch id {
+		case 0:
+			return "", errInvalidItem
+		case -1:
+			panic("negative id")
+		}
+
+		return fmt.Sprintf("user-%d", id), nil
+	})
+
+	user, err := lookup(t.Context(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "user-7", user)
+
+	_, err = lookup(t.Context(), 0)
+	require.ErrorIs(t, err, errInvalidItem)
+
+	assert.PanicsWithValue(t, "negative id", func() { _, _ = lookup(t.Context(), -1) })
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "lookup", spans[0].Name)
+	assert.Equal(t, "Unset", spans[0].Status.Code.String())
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+	assert.Equal(t, "Error", spans[2].Status.Code.String())
+	assert.Equal(t, "panic: negative id", spans[2].Status.Description)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	outcomes := map[string]uint64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if histogram, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "function_duration" {
+				for _, dp := range histogram.DataPoints {
+					outcome, _ := dp.Attributes.Value("function.outcome")
+					outcomes[outcome.AsString()] = dp.Count
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]uint64{"success": 1, "error": 1, "panic": 1}, outcomes)
+}
+
+func TestStartCall(t *testing.T) {
+	buf := &syncBuffer{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	require.NoError(t, err)
+
+	_, err = log.InitLogger(t.Context(), resourceAttrs, handler)
+	require.NoError(t, err)
+
+	getUser := func() (err error) {
+		_, end := StartCall(t.Context(), "Store.GetUser")
+		defer end(&err)
+
+		return errInvalidItem
+	}
+
+	require.ErrorIs(t, getUser(), errInvalidItem)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Store.GetUser", spans[0].Name)
+	assert.Equal(t, "Error", spans[0].Status.Code.String())
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "invalid item", logEntry["msg"])
+	assert.Equal(t, "Store.GetUser", logEntry["function.name"])
+
+	touch := func() {
+		_, end := StartCall(t.Context(), "Store.Touch")
+		defer end(nil)
+
+		panic("boom")
+	}
+
+	assert.PanicsWithValue(t, "boom", touch, "the panic should be re-raised")
+
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 2, "the span should end when the call panics")
+	assert.Equal(t, "Store.Touch", spans[1].Name)
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+}
+
+func TestDebugHandler(t *testing.T) {
+	handler := DebugHandler("secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/debug/gotel/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var state map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Contains(t, state, "config")
+	assert.Contains(t, state, "exports")
+	assert.Contains(t, state, "recent_errors")
+	assert.Contains(t, state, "sampling")
+	assert.Contains(t, state, "instruments")
+
+	flush := func(token string) int {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/debug/gotel/flush", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, flush("wrong"))
+	assert.Equal(t, http.StatusNoContent, flush("secret"))
+
+	form := strings.NewReader(url.Values{"levels": {"default"}}.Encode())
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/debug/gotel/levels", form)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "invalid level specs are rejected")
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/debug/gotel/flush", nil)
+	DebugHandler("").ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "an empty token disables actions")
+}
+
+func TestGroup(t *testing.T) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	exporter := tracetest.NewInMemoryExporter()
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	ctx, span := tracing.NewSpan(t.Context(), "fan-out")
+	g, groupCtx := Group(ctx)
+
+	for i := range 3 {
+		g.Go(fmt.Sprintf("task %d", i), func(context.Context) error { return nil })
+	}
+
+	g.Go("failing", func(context.Context) error { return errInvalidItem })
+
+	require.ErrorIs(t, g.Wait(), errInvalidItem)
+	require.ErrorIs(t, context.Cause(groupCtx), errInvalidItem, "a failed task cancels the group's context")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 5)
+
+	parent := spans[4]
+	assert.Equal(t, "fan-out", parent.Name)
+	assert.Contains(t, parent.Attributes, otelattribute.Int("group.tasks", 4))
+	assert.Contains(t, parent.Attributes, otelattribute.Int("group.failed", 1))
+	assert.Len(t, parent.Events, 1)
+	assert.Equal(t, "Error", parent.Status.Code.String())
+
+	for _, task := range spans[:4] {
+		assert.Equal(t, parent.SpanContext.SpanID(), task.Parent.SpanID(), "tasks are children of the group's span")
+
+		if task.Name == "failing" {
+			assert.Equal(t, "Error", task.Status.Code.String())
+		}
+	}
+
+	g, _ = Group(t.Context())
+	g.Go("ok", func(context.Context) error { return nil })
+	assert.NoError(t, g.Wait())
+}
+
+func TestDataResidency(t *testing.T) {
+	endpoints := map[string]string{"eu": "https://otel.eu.example.com:4318", "us": "https://otel.us.example.com:4318"}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Cleanup(func() { export.SetEndpoint("") })
+
+	attrs, err := (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://otel.eu.example.com:4318", export.Endpoint())
+	assert.Empty(t, os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "the environment is left unchanged")
+	assert.Contains(t, attrs, attribute.New(DataResidencyKey, "eu"))
+	assert.Len(t, resourceAttrs, 5, "the caller's attributes are not modified")
+
+	tracesEndpoint, ok := export.SignalEndpoint(export.SignalTraces)
+	require.True(t, ok)
+	assert.Equal(t, "https://otel.eu.example.com:4318/v1/traces", tracesEndpoint)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otel.eu.example.com:4318")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.NoError(t, err, "the region's own endpoint is accepted")
+
+	_, err = (&residency{region: "ap", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.ErrorIs(t, err, errDataResidency)
+
+	_, err = (&residency{region: "us", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.ErrorIs(t, err, errDataResidency, "an endpoint of another region is rejected")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 1, nil)
+	require.ErrorIs(t, err, errDataResidency, "exporters added with WithMetricExporter are rejected")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, []string{"https://otel.eu.example.com:4318"})
+	require.NoError(t, err, "routes to the region's endpoint are accepted")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, []string{"https://otel.internal.example.com:4318"})
+	require.ErrorIs(t, err, errDataResidency, "routes to another endpoint are rejected")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://otel.eu.example.com:4318/v1/traces")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.NoError(t, err, "per-signal endpoints are compared by scheme and host")
+
+	_, ok = export.SignalEndpoint(export.SignalTraces)
+	assert.False(t, ok, "the exporter reads the per-signal endpoint itself")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "https://otel.us.example.com:4318/v1/logs")
+
+	_, err = (&residency{region: "eu", endpoints: endpoints}).apply(resourceAttrs, 0, nil)
+	require.ErrorIs(t, err, errDataResidency)
+
+	_, err = (&residency{region: "eu", endpoints: map[string]string{"eu": "otel.eu.example.com"}}).apply(resourceAttrs, 0, nil)
+	require.ErrorIs(t, err, errDataResidency)
+}
@@ -0,0 +1,161 @@
+// Package gotelcli instruments short-lived command line tools.
+// It wraps command execution in a root span, maps the exit code to the span status,
+// and flushes telemetry before the process exits. It works with cobra or any other CLI library:
+//
+//	os.Exit(gotelcli.Run(ctx, "mytool", os.Args[1:], rootCmd.ExecuteContext, gotelcli.WithShutdown(shutdown)))
+package gotelcli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+const redacted = "REDACTED"
+
+// DefaultScrubbedFlags lists the flags whose values are scrubbed when no others are configured.
+var DefaultScrubbedFlags = []string{"api-key", "key", "password", "secret", "token"}
+
+type config struct {
+	scrubbedFlags   map[string]struct{}
+	shutdown        func(context.Context) error
+	shutdownTimeout time.Duration
+}
+
+// Option configures Run.
+type Option func(*config)
+
+// WithScrubbedFlags replaces the default list of flags whose values are scrubbed from the recorded arguments.
+// Flags are given without leading dashes.
+func WithScrubbedFlags(flags ...string) Option {
+	return func(c *config) {
+		c.scrubbedFlags = toFlagSet(flags)
+	}
+}
+
+// WithShutdown sets the function called to flush telemetry before Run returns, typically the one returned by gotel.Init.
+func WithShutdown(shutdown func(context.Context) error) Option {
+	return func(c *config) {
+		c.shutdown = shutdown
+	}
+}
+
+// WithShutdownTimeout limits how long Run waits for telemetry to flush. The default is 5 seconds.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.shutdownTimeout = timeout
+	}
+}
+
+func toFlagSet(flags []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(flags))
+	for _, flag := range flags {
+		set[strings.ToLower(strings.TrimLeft(flag, "-"))] = struct{}{}
+	}
+
+	return set
+}
+
+// ScrubArgs returns a copy of args with the values of sensitive flags replaced.
+// Both "--flag=value" and "--flag value" forms are handled.
+func ScrubArgs(args []string, options ...Option) []string {
+	return newConfig(options...).scrubArgs(args)
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{scrubbedFlags: toFlagSet(DefaultScrubbedFlags), shutdownTimeout: 5 * time.Second}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+func (c *config) scrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	scrubNext := false
+
+	for i, arg := range args {
+		if scrubNext && !strings.HasPrefix(arg, "-") {
+			scrubbed[i] = redacted
+			scrubNext = false
+
+			continue
+		}
+
+		scrubNext = false
+		scrubbed[i] = arg
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if _, ok := c.scrubbedFlags[strings.ToLower(name)]; !ok {
+			continue
+		}
+
+		if hasValue {
+			scrubbed[i] = arg[:strings.Index(arg, "=")+1] + redacted
+		} else {
+			scrubNext = true
+		}
+	}
+
+	return scrubbed
+}
+
+// ExitCode returns the process exit code for an error.
+// Errors implementing ExitCode() int, such as *exec.ExitError, report their own code; other errors map to 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitCoder interface{ ExitCode() int }
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+
+	return 1
+}
+
+// Run executes fn within a root span named after the command path and returns the process exit code.
+// Arguments are recorded with sensitive flag values scrubbed, and telemetry is flushed before returning.
+func Run(ctx context.Context, commandPath string, args []string, fn func(ctx context.Context) error, options ...Option) int {
+	c := newConfig(options...)
+
+	ctx, span := tracing.NewSpan(ctx, commandPath,
+		attribute.Attr{KeyValue: semconv.ProcessCommand(commandPath)},
+		attribute.Attr{KeyValue: semconv.ProcessCommandArgs(c.scrubArgs(args)...)},
+	)
+
+	err := fn(ctx)
+	exitCode := ExitCode(err)
+
+	span.SetAttributes(attribute.Attr{KeyValue: semconv.ProcessExitCode(exitCode)})
+
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+		log.Error(ctx, err, attribute.New("process.exit.code", exitCode))
+	} else {
+		span.SetOk()
+	}
+
+	span.End()
+
+	if c.shutdown != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.shutdownTimeout)
+		defer cancel()
+
+		_ = c.shutdown(shutdownCtx)
+	}
+
+	return exitCode
+}
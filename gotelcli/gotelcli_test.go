@@ -0,0 +1,97 @@
+//go:build !gotel_disabled
+
+package gotelcli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracer creates a tracer with an in-memory exporter for testing
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	return exporter
+}
+
+type exitError struct{ code int }
+
+func (e exitError) Error() string { return "exit" }
+func (e exitError) ExitCode() int { return e.code }
+
+func TestScrubArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		options  []Option
+		expected []string
+	}{
+		{"No sensitive flags", []string{"deploy", "--env", "prod"}, nil, []string{"deploy", "--env", "prod"}},
+		{"Flag with equals", []string{"--token=abc", "deploy"}, nil, []string{"--token=REDACTED", "deploy"}},
+		{"Flag with separate value", []string{"--password", "abc", "deploy"}, nil, []string{"--password", "REDACTED", "deploy"}},
+		{"Boolean flag before flag", []string{"--token", "--verbose"}, nil, []string{"--token", "--verbose"}},
+		{"Custom flags", []string{"-u", "bob", "--token=abc"}, []Option{WithScrubbedFlags("u")}, []string{"-u", "REDACTED", "--token=abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ScrubArgs(tt.args, tt.options...))
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, 1, ExitCode(assert.AnError))
+	assert.Equal(t, 3, ExitCode(exitError{code: 3}))
+}
+
+func TestRun(t *testing.T) {
+	exporter := setupTestTracer(t)
+	shutdownCalled := false
+
+	code := Run(t.Context(), "tool deploy", []string{"--token=abc"}, func(ctx context.Context) error {
+		return exitError{code: 2}
+	}, WithShutdown(func(context.Context) error {
+		shutdownCalled = true
+		return nil
+	}))
+
+	assert.Equal(t, 2, code)
+	assert.True(t, shutdownCalled, "expected telemetry to be flushed")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "tool deploy", spans[0].Name)
+	assert.Equal(t, "Error", spans[0].Status.Code.String())
+
+	values := map[string]string{}
+	for _, attr := range spans[0].Attributes {
+		values[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	assert.Equal(t, "2", values["process.exit.code"])
+	assert.Equal(t, `["--token=REDACTED"]`, values["process.command_args"])
+}
+
+func TestRun_Success(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	code := Run(t.Context(), "tool", nil, func(ctx context.Context) error { return nil })
+
+	assert.Equal(t, 0, code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Ok", spans[0].Status.Code.String())
+}
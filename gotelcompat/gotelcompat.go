@@ -0,0 +1,332 @@
+// Package gotelcompat records metrics through the metrics package behind the method sets of go-kit metrics and the
+// Prometheus client, so shared libraries written against those APIs feed the gotel pipeline without rewrites.
+//
+// Counter, Gauge, and Histogram implement go-kit's metrics.Counter, metrics.Gauge, and metrics.Histogram, where With
+// takes alternating label names and values, and have Prometheus's Inc, Dec, Sub, and SetToCurrentTime. Vec adds
+// Prometheus's WithLabelValues and With for label names declared up front, as CounterVec and friends do.
+//
+// All of them implement prometheus.Collector as unchecked collectors that describe and collect nothing, so libraries
+// that register their metrics with a Prometheus registry accept them, while their values are only exported over OTLP.
+//
+// Instruments are created under this package's instrumentation scope on the provider of InitMetrics, with the name,
+// help, and buckets of Prometheus's Opts, and record nothing until InitMetrics is called. Measurements carry no
+// context, so they are not linked to spans.
+package gotelcompat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelcompat"
+
+// newInstrument creates the instrument named name with help as its description and buckets as its bucket boundaries.
+func newInstrument[T metrics.Instrument](name string, help string, buckets []float64) (*T, error) {
+	tag := ""
+	if help != "" {
+		tag = fmt.Sprintf("description:%q", help)
+	}
+
+	if len(buckets) > 0 {
+		bounds := make([]string, len(buckets))
+		for i, bucket := range buckets {
+			bounds[i] = strconv.FormatFloat(bucket, 'g', -1, 64)
+		}
+
+		tag += fmt.Sprintf(" buckets:%q", strings.Join(bounds, ","))
+	}
+
+	return metrics.NewField[T](metrics.Meter(scopeName), name, reflect.StructTag(strings.TrimSpace(tag)))
+}
+
+// unknownLabelValue is the value of a label name passed to With without a value, as go-kit records it.
+const unknownLabelValue = "unknown"
+
+// withLabels returns attrs with the label name and value pairs added.
+func withLabels(attrs []attribute.Attr, labelValues []string) []attribute.Attr {
+	if len(labelValues)%2 != 0 {
+		labelValues = append(slices.Clip(labelValues), unknownLabelValue)
+	}
+
+	labeled := make([]attribute.Attr, len(attrs), len(attrs)+len(labelValues)/2)
+	copy(labeled, attrs)
+
+	for i := 0; i < len(labelValues); i += 2 {
+		labeled = append(labeled, attribute.New(labelValues[i], labelValues[i+1]))
+	}
+
+	return labeled
+}
+
+// Counter is a monotonic float64 counter with the methods of go-kit and Prometheus counters.
+type Counter struct {
+	counter *metrics.Float64Counter
+	attrs   []attribute.Attr
+}
+
+// NewCounter creates the counter named name, described by help.
+func NewCounter(name string, help string) (*Counter, error) {
+	counter, err := newInstrument[metrics.Float64Counter](name, help, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Counter{counter: counter}, nil
+}
+
+// With returns the counter with the alternating label names and values added to its labels.
+func (c *Counter) With(labelValues ...string) kitmetrics.Counter {
+	return c.labeled(labelValues)
+}
+
+func (c *Counter) labeled(labelValues []string) *Counter {
+	return &Counter{counter: c.counter, attrs: withLabels(c.attrs, labelValues)}
+}
+
+// Add increments the counter by delta, which must not be negative.
+func (c *Counter) Add(delta float64) {
+	c.counter.Add(context.Background(), delta, c.attrs...)
+}
+
+// Inc increments the counter by 1.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Describe sends nothing, making the counter an unchecked Prometheus collector.
+func (c *Counter) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends nothing, as the counter is exported over OTLP.
+func (c *Counter) Collect(chan<- prometheus.Metric) {}
+
+// maxGaugeLabelSets is the number of label sets whose value a gauge tracks, the default cardinality limit of the
+// OpenTelemetry SDK.
+const maxGaugeLabelSets = 2000
+
+// gaugeValues holds the current value of each label set of a gauge, shared by the gauges returned by With,
+// so Add applies to the value set through any of them. Label sets beyond maxGaugeLabelSets share one value, with
+// their label values replaced by metrics.OverflowValue as max_cardinality does.
+type gaugeValues struct {
+	name   string
+	mu     sync.Mutex
+	values map[otelattribute.Distinct]*metrics.AtomicFloat64
+	warned bool
+}
+
+// labelSet returns the identity of the label set attrs.
+func labelSet(attrs []attribute.Attr) otelattribute.Distinct {
+	set := otelattribute.NewSet(attribute.ToKeyValues(attrs)...)
+
+	return set.Equivalent()
+}
+
+// value returns the value of the label set attrs, and the labels to record it with.
+func (g *gaugeValues) value(attrs []attribute.Attr) (*metrics.AtomicFloat64, []attribute.Attr) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	distinct := labelSet(attrs)
+	if value, ok := g.values[distinct]; ok {
+		return value, attrs
+	}
+
+	if len(g.values) >= maxGaugeLabelSets {
+		if !g.warned {
+			g.warned = true
+			slog.Warn("gauge label set limit reached, recording new label sets as "+metrics.OverflowValue, "metric", g.name, "limit", maxGaugeLabelSets)
+		}
+
+		overflow := make([]attribute.Attr, len(attrs))
+		for i, attr := range attrs {
+			overflow[i] = attribute.New(string(attr.Key), metrics.OverflowValue)
+		}
+
+		attrs = overflow
+		distinct = labelSet(attrs)
+
+		if value, ok := g.values[distinct]; ok {
+			return value, attrs
+		}
+	}
+
+	value := &metrics.AtomicFloat64{}
+	g.values[distinct] = value
+
+	return value, attrs
+}
+
+// Gauge is a float64 gauge with the methods of go-kit and Prometheus gauges. Its value starts at 0 for each label set.
+type Gauge struct {
+	gauge  *metrics.Float64Gauge
+	values *gaugeValues
+	value  *metrics.AtomicFloat64
+	attrs  []attribute.Attr
+}
+
+// NewGauge creates the gauge named name, described by help.
+func NewGauge(name string, help string) (*Gauge, error) {
+	gauge, err := newInstrument[metrics.Float64Gauge](name, help, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	values := &gaugeValues{name: name, values: map[otelattribute.Distinct]*metrics.AtomicFloat64{}}
+	value, _ := values.value(nil)
+
+	return &Gauge{gauge: gauge, values: values, value: value}, nil
+}
+
+// With returns the gauge with the alternating label names and values added to its labels.
+func (g *Gauge) With(labelValues ...string) kitmetrics.Gauge {
+	return g.labeled(labelValues)
+}
+
+func (g *Gauge) labeled(labelValues []string) *Gauge {
+	value, attrs := g.values.value(withLabels(g.attrs, labelValues))
+
+	return &Gauge{gauge: g.gauge, values: g.values, value: value, attrs: attrs}
+}
+
+// Set sets the gauge to value.
+func (g *Gauge) Set(value float64) {
+	g.value.Store(value)
+	g.gauge.Record(context.Background(), value, g.attrs...)
+}
+
+// Add adds delta to the gauge, which may be negative.
+func (g *Gauge) Add(delta float64) {
+	g.gauge.Record(context.Background(), g.value.Add(delta), g.attrs...)
+}
+
+// Sub subtracts delta from the gauge.
+func (g *Gauge) Sub(delta float64) {
+	g.Add(-delta)
+}
+
+// Inc increments the gauge by 1.
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge by 1.
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// SetToCurrentTime sets the gauge to the current Unix time in seconds.
+func (g *Gauge) SetToCurrentTime() {
+	g.Set(float64(time.Now().UnixNano()) / float64(time.Second))
+}
+
+// Describe sends nothing, making the gauge an unchecked Prometheus collector.
+func (g *Gauge) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends nothing, as the gauge is exported over OTLP.
+func (g *Gauge) Collect(chan<- prometheus.Metric) {}
+
+// Histogram is a float64 histogram with the methods of go-kit histograms and Prometheus observers.
+type Histogram struct {
+	histogram *metrics.Float64Histogram
+	attrs     []attribute.Attr
+}
+
+// NewHistogram creates the histogram named name, described by help, with buckets as its bucket boundaries, or the
+// OpenTelemetry default boundaries if buckets is empty.
+func NewHistogram(name string, help string, buckets []float64) (*Histogram, error) {
+	histogram, err := newInstrument[metrics.Float64Histogram](name, help, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Histogram{histogram: histogram}, nil
+}
+
+// With returns the histogram with the alternating label names and values added to its labels.
+func (h *Histogram) With(labelValues ...string) kitmetrics.Histogram {
+	return h.labeled(labelValues)
+}
+
+func (h *Histogram) labeled(labelValues []string) *Histogram {
+	return &Histogram{histogram: h.histogram, attrs: withLabels(h.attrs, labelValues)}
+}
+
+// Observe records value.
+func (h *Histogram) Observe(value float64) {
+	h.histogram.Record(context.Background(), value, h.attrs...)
+}
+
+// Describe sends nothing, making the histogram an unchecked Prometheus collector.
+func (h *Histogram) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends nothing, as the histogram is exported over OTLP.
+func (h *Histogram) Collect(chan<- prometheus.Metric) {}
+
+// Labeled is implemented by Counter, Gauge, and Histogram.
+type Labeled[T any] interface {
+	labeled(labelValues []string) T
+}
+
+// Vec is a Counter, Gauge, or Histogram with label names declared up front, with the methods of Prometheus's
+// CounterVec, GaugeVec, and HistogramVec.
+type Vec[T Labeled[T]] struct {
+	instrument T
+	labelNames []string
+}
+
+// NewVec declares the label names of instrument.
+func NewVec[T Labeled[T]](instrument T, labelNames ...string) *Vec[T] {
+	return &Vec[T]{instrument: instrument, labelNames: labelNames}
+}
+
+// WithLabelValues returns the instrument with the label names of the Vec set to values, in order. Missing values are
+// recorded as unknown, and extra values are ignored.
+func (v *Vec[T]) WithLabelValues(values ...string) T {
+	labelValues := make([]string, 0, 2*len(v.labelNames))
+
+	for i, name := range v.labelNames {
+		value := unknownLabelValue
+		if i < len(values) {
+			value = values[i]
+		}
+
+		labelValues = append(labelValues, name, value)
+	}
+
+	return v.instrument.labeled(labelValues)
+}
+
+// With returns the instrument with the label names of the Vec set to their values in labels, such as
+// prometheus.Labels. Labels not declared by the Vec are ignored.
+func (v *Vec[T]) With(labels map[string]string) T {
+	values := make([]string, len(v.labelNames))
+
+	for i, name := range v.labelNames {
+		value, ok := labels[name]
+		if !ok {
+			value = unknownLabelValue
+		}
+
+		values[i] = value
+	}
+
+	return v.WithLabelValues(values...)
+}
+
+// Describe sends nothing, making the Vec an unchecked Prometheus collector.
+func (v *Vec[T]) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends nothing, as the Vec is exported over OTLP.
+func (v *Vec[T]) Collect(chan<- prometheus.Metric) {}
@@ -0,0 +1,184 @@
+//go:build !gotel_disabled
+
+package gotelcompat
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	kitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var reader = sdkmetric.NewManualReader()
+
+var (
+	_ kitmetrics.Counter   = (*Counter)(nil)
+	_ kitmetrics.Gauge     = (*Gauge)(nil)
+	_ kitmetrics.Histogram = (*Histogram)(nil)
+
+	_ prometheus.Collector = (*Counter)(nil)
+	_ prometheus.Collector = (*Gauge)(nil)
+	_ prometheus.Collector = (*Histogram)(nil)
+	_ prometheus.Collector = (*Vec[*Counter])(nil)
+)
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func collect(t *testing.T) metricdata.ResourceMetrics {
+	t.Helper()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	return rm
+}
+
+func attrs(pairs ...string) otelattribute.Set {
+	kvs := []otelattribute.KeyValue{}
+	for i := 0; i < len(pairs); i += 2 {
+		kvs = append(kvs, otelattribute.String(pairs[i], pairs[i+1]))
+	}
+
+	return otelattribute.NewSet(kvs...)
+}
+
+func TestCounter(t *testing.T) {
+	counter, err := NewCounter("compat_requests", "Requests handled.")
+	require.NoError(t, err)
+
+	counter.With("method", "GET").Add(2)
+	counter.With("method", "GET").Add(1)
+	counter.With("method", "POST", "code").Add(1)
+
+	again, err := NewCounter("compat_requests", "Requests handled.")
+	require.NoError(t, err)
+	NewVec(again, "method").WithLabelValues("GET").Inc()
+
+	found := metrictest.Find(collect(t), "compat_requests")
+	require.NotNil(t, found, "compat_requests metric not found")
+	assert.Equal(t, "Requests handled.", found.Description)
+
+	sum, ok := found.Data.(metricdata.Sum[float64])
+	require.True(t, ok, "expected Sum[float64], got %T", found.Data)
+
+	values := map[otelattribute.Distinct]float64{}
+	for _, dp := range sum.DataPoints {
+		values[dp.Attributes.Equivalent()] = dp.Value
+	}
+
+	get, post := attrs("method", "GET"), attrs("method", "POST", "code", unknownLabelValue)
+	assert.InDelta(t, 4, values[get.Equivalent()], 0)
+	assert.InDelta(t, 1, values[post.Equivalent()], 0, "a label without a value is recorded as unknown")
+}
+
+func TestGauge(t *testing.T) {
+	gauge, err := NewGauge("compat_in_flight", "")
+	require.NoError(t, err)
+
+	vec := NewVec(gauge, "pool")
+	gauge.With("pool", "db").Set(5)
+	vec.WithLabelValues("db").Inc()
+	vec.WithLabelValues("db").Sub(3)
+	vec.WithLabelValues("cache").Dec()
+
+	found := metrictest.Find(collect(t), "compat_in_flight")
+	require.NotNil(t, found, "compat_in_flight metric not found")
+
+	data, ok := found.Data.(metricdata.Gauge[float64])
+	require.True(t, ok, "expected Gauge[float64], got %T", found.Data)
+
+	values := map[otelattribute.Distinct]float64{}
+	for _, dp := range data.DataPoints {
+		values[dp.Attributes.Equivalent()] = dp.Value
+	}
+
+	db, cache := attrs("pool", "db"), attrs("pool", "cache")
+	assert.InDelta(t, 3, values[db.Equivalent()], 0, "Add applies to the value set through another With")
+	assert.InDelta(t, -1, values[cache.Equivalent()], 0)
+}
+
+func TestVec(t *testing.T) {
+	histogram, err := NewHistogram("compat_latency", `Latency of "requests".`, []float64{0.1, 1})
+	require.NoError(t, err)
+
+	vec := NewVec(histogram, "route", "code")
+	vec.WithLabelValues("/orders", "200").Observe(0.5)
+	vec.With(map[string]string{"route": "/orders", "code": "200", "extra": "ignored"}).Observe(2)
+	vec.WithLabelValues("/health").Observe(0.01)
+
+	found := metrictest.Find(collect(t), "compat_latency")
+	require.NotNil(t, found, "compat_latency metric not found")
+
+	data, ok := found.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", found.Data)
+
+	counts := map[otelattribute.Distinct]uint64{}
+	for _, dp := range data.DataPoints {
+		assert.Equal(t, []float64{0.1, 1}, dp.Bounds)
+		counts[dp.Attributes.Equivalent()] = dp.Count
+	}
+
+	orders, health := attrs("route", "/orders", "code", "200"), attrs("route", "/health", "code", unknownLabelValue)
+	assert.Equal(t, uint64(2), counts[orders.Equivalent()])
+	assert.Equal(t, uint64(1), counts[health.Equivalent()])
+}
+
+func TestGauge_LabelSetLimit(t *testing.T) {
+	gauge, err := NewGauge("compat_queue_depth", "")
+	require.NoError(t, err)
+
+	for i := range maxGaugeLabelSets + 2 {
+		gauge.With("queue", strconv.Itoa(i)).Add(1)
+	}
+
+	gauge.With("queue", "0").Add(1)
+
+	found := metrictest.Find(collect(t), "compat_queue_depth")
+	require.NotNil(t, found, "compat_queue_depth metric not found")
+
+	data, ok := found.Data.(metricdata.Gauge[float64])
+	require.True(t, ok, "expected Gauge[float64], got %T", found.Data)
+
+	values := map[otelattribute.Distinct]float64{}
+	for _, dp := range data.DataPoints {
+		values[dp.Attributes.Equivalent()] = dp.Value
+	}
+
+	tracked, overflow := attrs("queue", "0"), attrs("queue", metrics.OverflowValue)
+	assert.Len(t, values, maxGaugeLabelSets)
+	assert.InDelta(t, 2, values[tracked.Equivalent()], 0)
+	assert.InDelta(t, 3, values[overflow.Equivalent()], 0, "label sets beyond the limit share one value")
+}
+
+func TestRegister(t *testing.T) {
+	counter, err := NewCounter("compat_registered", "")
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(counter))
+	require.NoError(t, registry.Register(NewVec(counter, "method")))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	assert.Empty(t, families, "values are exported over OTLP only")
+}
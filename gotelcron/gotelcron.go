@@ -0,0 +1,163 @@
+// Package gotelcron instruments scheduled jobs run by robfig/cron or standard tickers.
+// Each run gets a root span and a duration measurement, and overlapping or skipped runs are counted.
+//
+// Wrap works as a robfig/cron JobWrapper without gotel depending on the cron library:
+//
+//	c := cron.New(cron.WithChain(func(j cron.Job) cron.Job { return gotelcron.Wrap("cleanup", j) }))
+package gotelcron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelcron"
+
+// Job is a scheduled unit of work. It is satisfied by cron.Job.
+type Job interface {
+	Run()
+}
+
+// JobFunc adapts a function to the Job interface.
+type JobFunc func()
+
+// Run calls f.
+func (f JobFunc) Run() {
+	f()
+}
+
+var errPanic = errors.New("scheduled job panicked")
+
+type cronMetrics struct {
+	CronRunDuration *metrics.Float64Histogram `unit:"s"`
+	CronRunsSkipped *metrics.Int64Counter
+}
+
+var getMetrics = metrics.Scoped[cronMetrics](scopeName)
+
+type config struct {
+	skipOverlapping bool
+}
+
+// Option configures an instrumented job.
+type Option func(*config)
+
+// WithSkipOverlapping skips a run, and counts it as skipped, when the previous run is still in progress.
+// By default overlapping runs proceed and are flagged with the cron.overlap attribute.
+func WithSkipOverlapping() Option {
+	return func(c *config) {
+		c.skipOverlapping = true
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+type job struct {
+	name    string
+	config  *config
+	running atomic.Int32
+	fn      func(ctx context.Context) error
+}
+
+func newJob(name string, fn func(ctx context.Context) error, options ...Option) *job {
+	return &job{name: name, config: newConfig(options...), fn: fn}
+}
+
+func (j *job) recordSkipped(ctx context.Context, count int64, reason string) {
+	getMetrics().CronRunsSkipped.Add(ctx, count, attribute.New("cron.job", j.name), attribute.New("reason", reason))
+}
+
+func (j *job) finish(ctx context.Context, span tracing.Span, start time.Time, outcome string) {
+	span.End()
+	getMetrics().CronRunDuration.Record(ctx, time.Since(start).Seconds(), attribute.New("cron.job", j.name), attribute.New("outcome", outcome))
+}
+
+func (j *job) run(ctx context.Context) {
+	overlap := j.running.Add(1) > 1
+	defer j.running.Add(-1)
+
+	if overlap && j.config.skipOverlapping {
+		j.recordSkipped(ctx, 1, "overlap")
+		log.Warn(ctx, "skipped scheduled run, previous run still in progress", attribute.New("cron.job", j.name))
+
+		return
+	}
+
+	start := time.Now()
+	ctx, span := tracing.NewSpan(ctx, j.name, attribute.New("cron.job", j.name), attribute.New("cron.overlap", overlap))
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err := fmt.Errorf("%w: %v", errPanic, recovered)
+			span.RecordErrorAndSetStatus(err)
+			log.Error(ctx, err, attribute.New("cron.job", j.name))
+			j.finish(ctx, span, start, "panic")
+
+			panic(recovered)
+		}
+	}()
+
+	outcome := "ok"
+
+	if err := j.fn(ctx); err != nil {
+		span.RecordErrorAndSetStatus(err)
+		log.Error(ctx, err, attribute.New("cron.job", j.name))
+
+		outcome = "error"
+	}
+
+	j.finish(ctx, span, start, outcome)
+}
+
+// Wrap returns a Job that instruments each run of job with a root span named after the job.
+// Panics are recorded and re-raised so recovery wrappers further up the chain still see them.
+func Wrap(name string, job Job, options ...Option) Job {
+	j := newJob(name, func(context.Context) error {
+		job.Run()
+		return nil
+	}, options...)
+
+	return JobFunc(func() { j.run(context.Background()) })
+}
+
+// RunTicker runs fn every interval until ctx is cancelled, instrumenting each run.
+// Runs happen one at a time; ticks missed because a run took longer than the interval are counted as skipped.
+func RunTicker(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error, options ...Option) {
+	j := newJob(name, fn, options...)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				return
+			}
+
+			start := time.Now()
+			j.run(ctx)
+
+			if missed := int64(time.Since(start) / interval); missed > 0 {
+				j.recordSkipped(ctx, missed, "slow")
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+//go:build !gotel_disabled
+
+package gotelcron
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	exporter = tracetest.NewInMemoryExporter()
+	reader   = sdkmetric.NewManualReader()
+)
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// sumValue returns the total of an int64 sum metric, or 0 if it isn't found
+func sumValue(t *testing.T, name string) int64 {
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	var total int64
+
+	if m := metrictest.Find(rm, name); m != nil {
+		sum, _ := m.Data.(metricdata.Sum[int64])
+		for _, dp := range sum.DataPoints {
+			total += dp.Value
+		}
+	}
+
+	return total
+}
+
+func TestWrap(t *testing.T) {
+	exporter.Reset()
+
+	ran := false
+	Wrap("cleanup", JobFunc(func() { ran = true })).Run()
+
+	assert.True(t, ran, "expected wrapped job to run")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "cleanup", spans[0].Name)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, "cron_run_duration")
+	require.NotNil(t, m)
+	assert.Equal(t, "s", m.Unit)
+}
+
+func TestWrap_Panic(t *testing.T) {
+	exporter.Reset()
+
+	job := Wrap("panicky", JobFunc(func() { panic("boom") }))
+
+	assert.PanicsWithValue(t, "boom", job.Run)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Error", spans[0].Status.Code.String())
+}
+
+func TestWrap_SkipOverlapping(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	before := sumValue(t, "cron_runs_skipped")
+
+	job := Wrap("slow", JobFunc(func() {
+		close(started)
+		<-release
+	}), WithSkipOverlapping())
+
+	var wg sync.WaitGroup
+
+	wg.Go(job.Run)
+
+	<-started
+	job.Run()
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), sumValue(t, "cron_runs_skipped")-before)
+}
+
+func TestRunTicker(t *testing.T) {
+	exporter.Reset()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	runs := 0
+
+	RunTicker(ctx, "tick", time.Millisecond, func(ctx context.Context) error {
+		runs++
+		if runs == 2 {
+			cancel()
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, 2, runs)
+	assert.Len(t, exporter.GetSpans(), 2)
+}
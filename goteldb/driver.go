@@ -0,0 +1,368 @@
+package goteldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+type wrappedDriver struct {
+	driver.Driver
+	config *config
+}
+
+// Wrap returns a driver that instruments the connections of d. Register it under a new name with sql.Register,
+// or use Open.
+func Wrap(d driver.Driver, options ...Option) driver.Driver {
+	return wrappedDriver{Driver: d, config: newConfig(options...)}
+}
+
+func (d wrappedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedConn{Conn: conn, config: d.config}, nil
+}
+
+func (d wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return wrappedConnector{Connector: connector, driver: d}, nil
+	}
+
+	return wrappedConnector{Connector: dsnConnector{driver: d.Driver, name: name}, driver: d}, nil
+}
+
+// dsnConnector opens connections of drivers that don't implement driver.DriverContext.
+type dsnConnector struct {
+	driver driver.Driver
+	name   string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type wrappedConnector struct {
+	driver.Connector
+	driver wrappedDriver
+}
+
+// WrapConnector returns a connector that instruments the connections of c, for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, options ...Option) driver.Connector {
+	return wrappedConnector{Connector: c, driver: wrappedDriver{Driver: c.Driver(), config: newConfig(options...)}}
+}
+
+func (c wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrappedConn{Conn: conn, config: c.driver.config}, nil
+}
+
+func (c wrappedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Open opens a database with the registered driver driverName, instrumented as Wrap does.
+func Open(driverName string, dataSourceName string, options ...Option) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	connector, err := wrappedDriver{Driver: d, config: newConfig(options...)}.OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(connector), nil
+}
+
+type wrappedConn struct {
+	driver.Conn
+	config *config
+	// skipped is the query the driver skipped with driver.ErrSkip, which database/sql retries on the same connection
+	// by preparing a statement, so the statement continues its span rather than exporting a second one.
+	skipped *skippedQuery
+}
+
+// skippedQuery is a query whose span is still open after the driver skipped it.
+type skippedQuery struct {
+	query  string
+	ctx    context.Context
+	finish func(error)
+}
+
+// endSkipped ends the span of the skipped query, which database/sql didn't retry.
+func (c *wrappedConn) endSkipped() {
+	if c.skipped != nil {
+		c.skipped.finish(driver.ErrSkip)
+		c.skipped = nil
+	}
+}
+
+// takeSkipped returns the skipped query if it is query, ending the span of any other.
+func (c *wrappedConn) takeSkipped(query string) *skippedQuery {
+	if c.skipped == nil || c.skipped.query != query {
+		c.endSkipped()
+		return nil
+	}
+
+	skipped := c.skipped
+	c.skipped = nil
+
+	return skipped
+}
+
+// startQuery starts the span of query, which the driver may skip with driver.ErrSkip.
+func (c *wrappedConn) startQuery(ctx context.Context, query string) (context.Context, func(error)) {
+	c.endSkipped()
+
+	ctx, finish := c.config.startQuery(ctx, query)
+
+	return ctx, func(err error) {
+		if errors.Is(err, driver.ErrSkip) {
+			c.skipped = &skippedQuery{query: query, ctx: ctx, finish: finish}
+			return
+		}
+
+		finish(err)
+	}
+}
+
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+
+	skipped := c.takeSkipped(query)
+	if skipped != nil {
+		ctx = skipped.ctx
+	}
+
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		if skipped != nil {
+			skipped.finish(err)
+		}
+
+		return nil, err
+	}
+
+	wrapped := &wrappedStmt{Stmt: stmt, conn: c.Conn, query: query, config: c.config, skipped: skipped}
+	if _, ok := stmt.(driver.ColumnConverter); ok { //nolint:staticcheck // passed through for older drivers
+		return converterStmt{wrapped}, nil
+	}
+
+	return wrapped, nil
+}
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin() //nolint:staticcheck // the fallback database/sql uses for drivers without BeginTx
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, finish := c.startQuery(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	finish(err)
+
+	return result, err
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, finish := c.startQuery(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	finish(err)
+
+	return rows, err
+}
+
+// Ping pings the driver's connection, or, for drivers without driver.Pinger, reports whether the connection is
+// still valid, as database/sql only opens a connection to ping them.
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+
+	return nil
+}
+
+func (c *wrappedConn) Close() error {
+	c.endSkipped()
+
+	return c.Conn.Close()
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *wrappedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *wrappedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+type wrappedStmt struct {
+	driver.Stmt
+	// conn is the driver's connection, whose NamedValueChecker database/sql uses for statements without their own.
+	conn   driver.Conn
+	query  string
+	config *config
+	// skipped is the query database/sql prepared the statement to retry, whose span its first execution continues.
+	skipped *skippedQuery
+}
+
+// startQuery starts the span of an execution of the statement, or continues the span of the skipped query.
+func (s *wrappedStmt) startQuery(ctx context.Context) (context.Context, func(error)) {
+	if skipped := s.skipped; skipped != nil {
+		s.skipped = nil
+		return skipped.ctx, skipped.finish
+	}
+
+	return s.config.startQuery(ctx, s.query)
+}
+
+func (s *wrappedStmt) Close() error {
+	if s.skipped != nil {
+		s.skipped.finish(driver.ErrSkip)
+		s.skipped = nil
+	}
+
+	return s.Stmt.Close()
+}
+
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, finish := s.startQuery(ctx)
+
+	var (
+		result driver.Result
+		err    error
+	)
+
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args)) //nolint:staticcheck // the fallback database/sql uses for drivers without ExecContext
+	}
+
+	finish(err)
+
+	return result, err
+}
+
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, finish := s.startQuery(ctx)
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) //nolint:staticcheck // the fallback database/sql uses for drivers without QueryContext
+	}
+
+	finish(err)
+
+	return rows, err
+}
+
+// CheckNamedValue checks an argument with the statement's NamedValueChecker or, as database/sql does for statements
+// without one, the connection's.
+func (s *wrappedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+// converterStmt is a statement of a driver implementing driver.ColumnConverter, which database/sql only consults
+// when the statement implements it.
+type converterStmt struct {
+	*wrappedStmt
+}
+
+func (s converterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	converter, _ := s.Stmt.(driver.ColumnConverter) //nolint:staticcheck // passed through for older drivers
+
+	return converter.ColumnConverter(idx)
+}
+
+// values converts named arguments for drivers that only accept positional values.
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+
+	return vals
+}
@@ -0,0 +1,140 @@
+// Package goteldb instruments database/sql with spans and metrics on gotel's providers. Open wraps a registered
+// driver so every query and statement creates a client span with db.* semantic convention attributes and records
+// the db_client_operation_duration histogram, and RecordPoolStats reports the connection pool of a *sql.DB:
+//
+//	db, err := goteldb.Open("pgx", dsn, goteldb.WithSystem("postgresql"))
+//	if err != nil {
+//		return err
+//	}
+//
+//	unregister, err := goteldb.RecordPoolStats(db, "main")
+package goteldb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/goteldb"
+
+type dbMetrics struct {
+	DbClientOperationDuration      *metrics.Float64Histogram `unit:"s"`
+	DbClientConnectionCount        *metrics.Int64ObservableGauge
+	DbClientConnectionMax          *metrics.Int64ObservableGauge
+	DbClientConnectionWaitCount    *metrics.Int64ObservableCounter
+	DbClientConnectionWaitDuration *metrics.Float64ObservableCounter `unit:"s"`
+}
+
+var getMetrics = metrics.Scoped[dbMetrics](scopeName)
+
+type config struct {
+	system    string
+	namespace string
+	queryText bool
+}
+
+// Option configures database instrumentation.
+type Option func(*config)
+
+// WithSystem sets the db.system.name attribute, e.g. "postgresql" or "mysql".
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.system = system
+	}
+}
+
+// WithNamespace sets the db.namespace attribute, usually the database name.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithQueryText records the query as the db.query.text span attribute.
+// It's off by default as queries built without parameters may contain sensitive literal values.
+func WithQueryText() Option {
+	return func(c *config) {
+		c.queryText = true
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// operationName returns the first keyword of a query in upper case, e.g. "SELECT", or an empty string.
+func operationName(query string) string {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	for _, r := range keyword {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return ""
+		}
+	}
+
+	return strings.ToUpper(keyword)
+}
+
+func (c *config) attributes(operation string) []attribute.Attr {
+	attrs := []attribute.Attr{}
+	if c.system != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.DBSystemNameKey.String(c.system)})
+	}
+
+	if c.namespace != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.DBNamespace(c.namespace)})
+	}
+
+	if operation != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.DBOperationName(operation)})
+	}
+
+	return attrs
+}
+
+// startQuery starts a client span for query and returns a function that ends it and records the operation
+// duration. Queries the driver skipped with driver.ErrSkip that database/sql never retried end the span without
+// recording the duration.
+func (c *config) startQuery(ctx context.Context, query string) (context.Context, func(error)) {
+	start := time.Now()
+	operation := operationName(query)
+	attrs := c.attributes(operation)
+
+	name := operation
+	if name == "" {
+		name = "db.query"
+	}
+
+	ctx, span := tracing.NewSpanWithKind(ctx, tracing.SpanKindClient, name, attrs...)
+	if c.queryText && span.IsRecording() {
+		span.SetAttributes(attribute.Attr{KeyValue: semconv.DBQueryText(query)})
+	}
+
+	return ctx, func(err error) {
+		defer span.End()
+
+		if errors.Is(err, driver.ErrSkip) {
+			return
+		}
+
+		if err != nil {
+			span.RecordErrorAndSetStatus(err)
+			attrs = append(attrs, attribute.Attr{KeyValue: semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err))})
+		}
+
+		getMetrics().DbClientOperationDuration.Record(ctx, time.Since(start).Seconds(), attrs...)
+	}
+}
@@ -0,0 +1,246 @@
+//go:build !gotel_disabled
+
+package goteldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	exporter = tracetest.NewInMemoryExporter()
+	reader   = sdkmetric.NewManualReader()
+
+	errTableMissing = errors.New("table missing")
+)
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	sql.Register("goteldb-test", fakeDriver{})
+
+	os.Exit(m.Run())
+}
+
+// fakeDriver answers every query with one row and fails queries on the "missing" table
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == "DELETE FROM missing" {
+		return nil, errTableMissing
+	}
+
+	// database/sql retries skipped queries with a prepared statement
+	if strings.HasPrefix(query, "INSERT") {
+		return nil, driver.ErrSkip
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+// checkerConn accepts string slices, as drivers like pgx do with a connection-level driver.NamedValueChecker
+type checkerConn struct {
+	fakeConn
+}
+
+func (checkerConn) CheckNamedValue(value *driver.NamedValue) error {
+	if _, ok := value.Value.([]string); ok {
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
+type checkerConnector struct{}
+
+func (checkerConnector) Connect(context.Context) (driver.Conn, error) { return checkerConn{}, nil }
+func (checkerConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeStmt is used for queries, as fakeConn doesn't implement driver.QueryerContext
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeRows struct {
+	done bool
+}
+
+func (*fakeRows) Columns() []string { return []string{"id"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	r.done = true
+	dest[0] = int64(42)
+
+	return nil
+}
+
+func TestOperationName(t *testing.T) {
+	assert.Equal(t, "SELECT", operationName("  select id FROM users"))
+	assert.Equal(t, "COMMIT", operationName("commit"))
+	assert.Empty(t, operationName("/* comment */ SELECT 1"))
+}
+
+func TestOpen(t *testing.T) {
+	exporter.Reset()
+
+	db, err := Open("goteldb-test", "", WithSystem("sqlite"), WithNamespace("app"), WithQueryText())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	unregister, err := RecordPoolStats(db, "main")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = unregister() })
+
+	ctx, parent := tracing.NewSpan(t.Context(), "handler")
+
+	var id int64
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT id FROM users WHERE name = ?", "ada").Scan(&id))
+	assert.Equal(t, int64(42), id)
+
+	_, err = db.ExecContext(ctx, "DELETE FROM missing")
+	require.ErrorIs(t, err, errTableMissing)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "UPDATE users SET name = ?", "grace")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	names := []string{}
+
+	for _, span := range spans[:3] {
+		names = append(names, span.Name)
+		assert.Equal(t, "client", span.SpanKind.String())
+		assert.Equal(t, spans[3].SpanContext.SpanID(), span.Parent.SpanID())
+	}
+
+	assert.Equal(t, []string{"SELECT", "DELETE", "UPDATE"}, names)
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+	assert.Contains(t, spans[0].Attributes, attribute.New("db.query.text", "SELECT id FROM users WHERE name = ?").KeyValue)
+	assert.Contains(t, spans[0].Attributes, attribute.New("db.system.name", "sqlite").KeyValue)
+	assert.Contains(t, spans[0].Attributes, attribute.New("db.namespace", "app").KeyValue)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, "db_client_operation_duration")
+	require.NotNil(t, m, "db_client_operation_duration not found")
+
+	hist, ok := m.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+
+	count := uint64(0)
+	for _, dp := range hist.DataPoints {
+		count += dp.Count
+	}
+
+	assert.Equal(t, uint64(3), count)
+
+	m = metrictest.Find(rm, "db_client_connection_count")
+	require.NotNil(t, m, "db_client_connection_count not found")
+
+	gauge, ok := m.Data.(metricdata.Gauge[int64])
+	require.True(t, ok)
+
+	states := map[string]int64{}
+
+	for _, dp := range gauge.DataPoints {
+		state, _ := dp.Attributes.Value("db.client.connection.state")
+		states[state.AsString()] = dp.Value
+	}
+
+	assert.Equal(t, map[string]int64{"idle": 1, "used": 0}, states)
+	assert.NotNil(t, metrictest.Find(rm, "db_client_connection_wait_count"))
+}
+
+func TestOpen_Skipped(t *testing.T) {
+	exporter.Reset()
+
+	db, err := Open("goteldb-test", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.ExecContext(t.Context(), "INSERT INTO users (name) VALUES (?)", "ada")
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "the prepared statement continues the span of the skipped query")
+	assert.Equal(t, "INSERT", spans[0].Name)
+
+	require.NoError(t, db.PingContext(t.Context()))
+}
+
+func TestWrapConnector_ConnNamedValueChecker(t *testing.T) {
+	db := sql.OpenDB(WrapConnector(checkerConnector{}))
+	t.Cleanup(func() { _ = db.Close() })
+
+	stmt, err := db.PrepareContext(t.Context(), "UPDATE users SET tags = ?")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stmt.Close() })
+
+	_, err = stmt.ExecContext(t.Context(), []string{"a"})
+	require.NoError(t, err, "the connection's checker converts arguments of statements without their own")
+
+	conn, err := WrapConnector(checkerConnector{}).Connect(t.Context())
+	require.NoError(t, err)
+
+	driverStmt, err := conn.Prepare("UPDATE users SET tags = ?")
+	require.NoError(t, err)
+	assert.NotImplements(t, (*driver.ColumnConverter)(nil), driverStmt) //nolint:staticcheck // checking it isn't exposed
+}
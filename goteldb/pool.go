@@ -0,0 +1,39 @@
+package goteldb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// RecordPoolStats reports the connection pool of db as the db_client_connection_count gauge, with
+// db.client.connection.state attributes of idle and used, the db_client_connection_max gauge, and the
+// db_client_connection_wait_count and db_client_connection_wait_duration counters, all with a
+// db.client.connection.pool.name attribute of name. Call the returned function to stop reporting, e.g. when
+// closing db.
+func RecordPoolStats(db *sql.DB, name string) (func() error, error) {
+	m := getMetrics()
+	pool := attribute.Attr{KeyValue: semconv.DBClientConnectionPoolName(name)}
+	idle := []attribute.Attr{pool, {KeyValue: semconv.DBClientConnectionStateIdle}}
+	used := []attribute.Attr{pool, {KeyValue: semconv.DBClientConnectionStateUsed}}
+
+	registration, err := metrics.RegisterCallback(func(_ context.Context, o metrics.Observer) error {
+		stats := db.Stats()
+
+		o.ObserveInt64(m.DbClientConnectionCount, int64(stats.Idle), idle...)
+		o.ObserveInt64(m.DbClientConnectionCount, int64(stats.InUse), used...)
+		o.ObserveInt64(m.DbClientConnectionMax, int64(stats.MaxOpenConnections), pool)
+		o.ObserveInt64(m.DbClientConnectionWaitCount, stats.WaitCount, pool)
+		o.ObserveFloat64(m.DbClientConnectionWaitDuration, stats.WaitDuration.Seconds(), pool)
+
+		return nil
+	}, m.DbClientConnectionCount, m.DbClientConnectionMax, m.DbClientConnectionWaitCount, m.DbClientConnectionWaitDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return registration.Unregister, nil
+}
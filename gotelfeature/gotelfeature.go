@@ -0,0 +1,75 @@
+// Package gotelfeature records feature flag evaluations as span events and log records.
+// It has no dependency on a feature flag SDK. The openfeaturehook module registers it as an OpenFeature hook:
+//
+//	openfeature.AddHooks(openfeaturehook.New())
+//
+// Other SDKs can call RecordEvaluation when an evaluation completes.
+package gotelfeature
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// EventName is the name of the span event recorded for each evaluation.
+const EventName = "feature_flag.evaluation"
+
+// Evaluation describes the result of a feature flag evaluation.
+type Evaluation struct {
+	FlagKey  string
+	Provider string
+	Variant  string
+	Reason   string
+	Value    any
+	Err      error
+}
+
+// Attributes returns the feature_flag.* semantic convention attributes for the evaluation.
+func (e Evaluation) Attributes() []attribute.Attr {
+	attrs := []attribute.Attr{{KeyValue: semconv.FeatureFlagKey(e.FlagKey)}}
+
+	if e.Provider != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.FeatureFlagProviderName(e.Provider)})
+	}
+
+	if e.Variant != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.FeatureFlagResultVariant(e.Variant)})
+	}
+
+	if e.Reason != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.FeatureFlagResultReasonKey.String(e.Reason)})
+	}
+
+	if e.Value != nil {
+		attrs = append(attrs, attribute.New("feature_flag.result.value", e.Value))
+	}
+
+	if e.Err != nil {
+		attrs = append(attrs,
+			attribute.Attr{KeyValue: semconv.FeatureFlagEvaluationErrorMessage(e.Err.Error())},
+			attribute.Attr{KeyValue: semconv.ErrorTypeOther},
+		)
+	}
+
+	return attrs
+}
+
+// RecordEvaluation records a feature flag evaluation as an event on the current span and as a log record.
+// Successful evaluations are logged at DEBUG level and failed evaluations at WARN level.
+func RecordEvaluation(ctx context.Context, evaluation Evaluation) {
+	attrs := evaluation.Attributes()
+
+	span := tracing.SpanFromContext(ctx)
+	span.AddEvent(EventName, attrs...)
+
+	if evaluation.Err != nil {
+		log.Warn(ctx, "feature flag evaluation failed", attrs...)
+		return
+	}
+
+	log.Debug(ctx, "feature flag evaluated", attrs...)
+}
@@ -0,0 +1,71 @@
+//go:build !gotel_disabled
+
+package gotelfeature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracer creates a tracer with an in-memory exporter for testing
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func TestRecordEvaluation(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	ctx, span := tracing.NewSpan(t.Context(), "test-span")
+	RecordEvaluation(ctx, Evaluation{
+		FlagKey:  "new-checkout",
+		Provider: "flagd",
+		Variant:  "on",
+		Reason:   "TARGETING_MATCH",
+		Value:    true,
+	})
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events, 1, "expected evaluation event")
+
+	event := spans[0].Events[0]
+	assert.Equal(t, EventName, event.Name)
+
+	values := map[string]string{}
+	for _, attr := range event.Attributes {
+		values[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	assert.Equal(t, "new-checkout", values["feature_flag.key"])
+	assert.Equal(t, "flagd", values["feature_flag.provider_name"])
+	assert.Equal(t, "on", values["feature_flag.result.variant"])
+	assert.Equal(t, "TARGETING_MATCH", values["feature_flag.result.reason"])
+	assert.Equal(t, "true", values["feature_flag.result.value"])
+}
+
+func TestEvaluation_AttributesWithError(t *testing.T) {
+	attrs := Evaluation{FlagKey: "missing", Err: assert.AnError}.Attributes()
+
+	keys := make([]string, len(attrs))
+	for i, attr := range attrs {
+		keys[i] = string(attr.Key)
+	}
+
+	assert.Equal(t, []string{"feature_flag.key", "feature_flag.evaluation.error.message", "error.type"}, keys)
+}
+
+func TestRecordEvaluation_NoSpan(t *testing.T) {
+	assert.NotPanics(t, func() { RecordEvaluation(t.Context(), Evaluation{FlagKey: "flag"}) })
+}
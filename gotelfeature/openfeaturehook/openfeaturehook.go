@@ -0,0 +1,50 @@
+// Package openfeaturehook is an OpenFeature hook that records every flag evaluation with gotelfeature, as a
+// feature_flag.evaluation event on the current span and a log record:
+//
+//	openfeature.AddHooks(openfeaturehook.New())
+//
+// It is a separate module so that the gotel module doesn't depend on the OpenFeature SDK.
+package openfeaturehook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/tinybluerobots/gotel/gotelfeature"
+)
+
+var errEvaluation = errors.New("flag evaluation failed")
+
+// Hook records flag evaluations in its Finally stage, after the flag is resolved or the evaluation fails.
+type Hook struct {
+	openfeature.UnimplementedHook
+}
+
+// New returns a hook to register with openfeature.AddHooks, on a client, or for a single evaluation.
+func New() *Hook {
+	return &Hook{}
+}
+
+// Finally records the evaluation with gotelfeature.RecordEvaluation.
+func (h *Hook) Finally(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) {
+	gotelfeature.RecordEvaluation(ctx, evaluation(hookContext, details))
+}
+
+// evaluation converts the details of an evaluation to a gotelfeature.Evaluation.
+func evaluation(hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails) gotelfeature.Evaluation {
+	e := gotelfeature.Evaluation{
+		FlagKey:  hookContext.FlagKey(),
+		Provider: hookContext.ProviderMetadata().Name,
+		Variant:  details.Variant,
+		Reason:   string(details.Reason),
+		Value:    details.Value,
+	}
+
+	if details.ErrorCode != "" {
+		e.Err = fmt.Errorf("%w: %s: %s", errEvaluation, details.ErrorCode, details.ErrorMessage)
+	}
+
+	return e
+}
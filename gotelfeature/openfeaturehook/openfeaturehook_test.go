@@ -0,0 +1,43 @@
+package openfeaturehook
+
+import (
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHookContext() openfeature.HookContext {
+	return openfeature.NewHookContext("new-checkout", openfeature.Boolean, false,
+		openfeature.NewClientMetadata("checkout"), openfeature.Metadata{Name: "flagd"},
+		openfeature.NewEvaluationContext("", nil))
+}
+
+func TestEvaluation(t *testing.T) {
+	details := openfeature.InterfaceEvaluationDetails{Value: true}
+	details.Variant = "on"
+	details.Reason = openfeature.TargetingMatchReason
+
+	e := evaluation(newHookContext(), details)
+
+	assert.Equal(t, "new-checkout", e.FlagKey)
+	assert.Equal(t, "flagd", e.Provider)
+	assert.Equal(t, "on", e.Variant)
+	assert.Equal(t, "TARGETING_MATCH", e.Reason)
+	assert.Equal(t, true, e.Value)
+	assert.NoError(t, e.Err)
+}
+
+func TestEvaluation_Error(t *testing.T) {
+	details := openfeature.InterfaceEvaluationDetails{Value: false}
+	details.Reason = openfeature.ErrorReason
+	details.ErrorCode = openfeature.FlagNotFoundCode
+	details.ErrorMessage = "flag not found"
+
+	e := evaluation(newHookContext(), details)
+
+	require.ErrorIs(t, e.Err, errEvaluation)
+	assert.Contains(t, e.Err.Error(), "FLAG_NOT_FOUND")
+	assert.Contains(t, e.Err.Error(), "flag not found")
+}
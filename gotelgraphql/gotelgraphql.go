@@ -0,0 +1,158 @@
+// Package gotelgraphql instruments GraphQL servers with a span per operation and per resolver.
+// Because GraphQL serves every request from a single endpoint, spans are named after the operation and field
+// rather than the HTTP route, and errors returned in the response body are attached to the operation span.
+//
+// The package doesn't depend on a GraphQL library. The gotelgraphql/gqlgentracer module is a gqlgen handler extension
+// built on it:
+//
+//	srv.Use(gqlgentracer.New())
+//
+// Servers built on other libraries call StartOperation and StartResolver from their own hooks.
+package gotelgraphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelgraphql"
+
+type graphqlMetrics struct {
+	GraphqlResolverDuration *metrics.Float64Histogram `unit:"s"`
+}
+
+var getMetrics = metrics.Scoped[graphqlMetrics](scopeName)
+
+type config struct {
+	document      bool
+	resolverSpans bool
+}
+
+// Option configures GraphQL instrumentation.
+type Option func(*config)
+
+// WithDocument records the operation document as the graphql.document attribute.
+// It's off by default as documents can be large and may contain literal argument values.
+func WithDocument() Option {
+	return func(c *config) {
+		c.document = true
+	}
+}
+
+// WithoutResolverSpans stops StartResolver from creating spans, for schemas with many cheap resolvers.
+// Resolver latency is still recorded.
+func WithoutResolverSpans() Option {
+	return func(c *config) {
+		c.resolverSpans = false
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{resolverSpans: true}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Operation describes a GraphQL operation.
+type Operation struct {
+	Name string
+	// Type is query, mutation, or subscription.
+	Type     string
+	Document string
+}
+
+// Field describes a resolved GraphQL field.
+type Field struct {
+	// Object is the parent type, e.g. Query or User.
+	Object string
+	Name   string
+	// Path is the response path, e.g. users.0.orders.
+	Path string
+}
+
+func operationSpanName(op Operation) string {
+	switch {
+	case op.Type != "" && op.Name != "":
+		return op.Type + " " + op.Name
+	case op.Type != "":
+		return op.Type
+	default:
+		return "GraphQL Operation"
+	}
+}
+
+// StartOperation starts a span for a GraphQL operation and returns a function that ends it.
+// Errors passed to the returned function are recorded on the span and mark it as failed,
+// as GraphQL reports them in the response body rather than the HTTP status.
+func StartOperation(ctx context.Context, op Operation, options ...Option) (context.Context, func(errs ...error)) {
+	c := newConfig(options...)
+
+	attrs := []attribute.Attr{}
+	if op.Name != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.GraphQLOperationName(op.Name)})
+	}
+
+	if op.Type != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.GraphQLOperationTypeKey.String(op.Type)})
+	}
+
+	if c.document && op.Document != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.GraphQLDocument(op.Document)})
+	}
+
+	ctx, span := tracing.NewSpan(ctx, operationSpanName(op), attrs...)
+
+	return ctx, func(errs ...error) {
+		defer span.End()
+
+		if len(errs) == 0 {
+			return
+		}
+
+		for _, err := range errs {
+			span.RecordError(err)
+		}
+
+		span.SetAttributes(attribute.New("graphql.error.count", len(errs)))
+		span.SetStatus(tracing.StatusError, errs[0].Error())
+	}
+}
+
+// StartResolver starts a span for a field resolver and returns a function that ends it and records its latency
+// in the graphql_resolver_duration histogram.
+func StartResolver(ctx context.Context, field Field, options ...Option) (context.Context, func(err error)) {
+	c := newConfig(options...)
+	start := time.Now()
+
+	metricAttrs := []attribute.Attr{
+		attribute.New("graphql.field.parent_type", field.Object),
+		attribute.New("graphql.field.name", field.Name),
+	}
+
+	var span tracing.Span
+	if c.resolverSpans {
+		ctx, span = tracing.NewSpan(ctx, field.Object+"."+field.Name,
+			append(metricAttrs, attribute.New("graphql.field.path", field.Path))...,
+		)
+	}
+
+	return ctx, func(err error) {
+		if c.resolverSpans {
+			if err != nil {
+				span.RecordErrorAndSetStatus(err)
+			}
+
+			span.End()
+		}
+
+		getMetrics().GraphqlResolverDuration.Record(ctx, time.Since(start).Seconds(), metricAttrs...)
+	}
+}
@@ -0,0 +1,98 @@
+//go:build !gotel_disabled
+
+package gotelgraphql
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	exporter = tracetest.NewInMemoryExporter()
+	reader   = sdkmetric.NewManualReader()
+)
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestStartOperation(t *testing.T) {
+	exporter.Reset()
+
+	ctx, endOperation := StartOperation(t.Context(), Operation{Name: "GetUser", Type: "query", Document: "query GetUser { user { id } }"})
+	_, endResolver := StartResolver(ctx, Field{Object: "Query", Name: "user", Path: "user"})
+	endResolver(assert.AnError)
+	endOperation(assert.AnError)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	resolver, operation := spans[0], spans[1]
+	assert.Equal(t, "Query.user", resolver.Name)
+	assert.Equal(t, "Error", resolver.Status.Code.String())
+	assert.Equal(t, operation.SpanContext.SpanID(), resolver.Parent.SpanID(), "resolver should be a child of the operation")
+
+	assert.Equal(t, "query GetUser", operation.Name)
+	assert.Equal(t, "Error", operation.Status.Code.String())
+	require.Len(t, operation.Events, 1, "expected error event")
+
+	for _, kv := range operation.Attributes {
+		assert.NotEqual(t, "graphql.document", string(kv.Key), "document should not be recorded by default")
+	}
+}
+
+func TestStartOperation_NoErrors(t *testing.T) {
+	exporter.Reset()
+
+	_, end := StartOperation(t.Context(), Operation{Type: "mutation"}, WithDocument())
+	end()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "mutation", spans[0].Name)
+	assert.Equal(t, "Unset", spans[0].Status.Code.String())
+}
+
+func TestStartResolver_WithoutResolverSpans(t *testing.T) {
+	exporter.Reset()
+
+	_, end := StartResolver(t.Context(), Field{Object: "User", Name: "orders", Path: "user.orders"}, WithoutResolverSpans())
+	end(nil)
+
+	assert.Empty(t, exporter.GetSpans())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, "graphql_resolver_duration")
+	require.NotNil(t, m, "graphql_resolver_duration not found")
+	assert.Equal(t, "s", m.Unit)
+
+	hist, ok := m.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	assert.NotEmpty(t, hist.DataPoints)
+}
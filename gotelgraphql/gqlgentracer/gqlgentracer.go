@@ -0,0 +1,97 @@
+// Package gqlgentracer is a gqlgen handler extension that traces every operation and resolver with gotelgraphql:
+//
+//	srv := handler.New(generated.NewExecutableSchema(cfg))
+//	srv.Use(gqlgentracer.New())
+//
+// It is a separate module so that the gotel module doesn't depend on gqlgen.
+package gqlgentracer
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/tinybluerobots/gotel/gotelgraphql"
+)
+
+// Tracer starts an operation span around every response and a resolver span around every resolved field.
+type Tracer struct {
+	options []gotelgraphql.Option
+}
+
+var (
+	_ graphql.HandlerExtension    = Tracer{}
+	_ graphql.ResponseInterceptor = Tracer{}
+	_ graphql.FieldInterceptor    = Tracer{}
+)
+
+// New returns a tracer to register with the Use method of a gqlgen server, configured by the gotelgraphql options.
+func New(options ...gotelgraphql.Option) Tracer {
+	return Tracer{options: options}
+}
+
+// ExtensionName returns the name of the extension.
+func (Tracer) ExtensionName() string {
+	return "GotelTracer"
+}
+
+// Validate accepts every schema.
+func (Tracer) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse wraps the response in a gotelgraphql operation span, failed if the response has errors.
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	ctx, end := gotelgraphql.StartOperation(ctx, operation(graphql.GetOperationContext(ctx)), t.options...)
+	resp := next(ctx)
+	end(responseErrors(resp)...)
+
+	return resp
+}
+
+// InterceptField wraps fields with a resolver in a gotelgraphql resolver span. Fields read from their parent object
+// aren't traced.
+func (t Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, end := gotelgraphql.StartResolver(ctx, gotelgraphql.Field{Object: fc.Object, Name: fc.Field.Name, Path: fc.Path().String()}, t.options...)
+	res, err := next(ctx)
+	end(err)
+
+	return res, err
+}
+
+// operation describes the operation of oc, named after its definition if the request doesn't name it.
+func operation(oc *graphql.OperationContext) gotelgraphql.Operation {
+	op := gotelgraphql.Operation{Name: oc.OperationName, Document: oc.RawQuery}
+
+	if oc.Operation != nil {
+		op.Type = string(oc.Operation.Operation)
+
+		if op.Name == "" {
+			op.Name = oc.Operation.Name
+		}
+	}
+
+	return op
+}
+
+// responseErrors returns the errors of resp, which is nil when a subscription ends.
+func responseErrors(resp *graphql.Response) []error {
+	if resp == nil {
+		return nil
+	}
+
+	errs := make([]error, len(resp.Errors))
+	for i, err := range resp.Errors {
+		errs[i] = err
+	}
+
+	return errs
+}
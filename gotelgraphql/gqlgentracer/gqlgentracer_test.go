@@ -0,0 +1,88 @@
+package gqlgentracer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracer creates a tracer with an in-memory exporter for testing
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func operationContext(ctx context.Context) context.Context {
+	return graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		RawQuery:  "query GetUser { user { name } }",
+		Operation: &ast.OperationDefinition{Operation: ast.Query, Name: "GetUser"},
+	})
+}
+
+func TestInterceptResponse(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	resp := New().InterceptResponse(operationContext(t.Context()), func(context.Context) *graphql.Response {
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("user not found")}}
+	})
+	require.NotNil(t, resp)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "query GetUser", spans[0].Name, "the operation is named after its definition")
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "input: user not found", spans[0].Status.Description, "gqlerror prefixes errors without a path with input")
+}
+
+func TestInterceptResponse_WithoutOperation(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	New().InterceptResponse(t.Context(), func(context.Context) *graphql.Response { return nil })
+
+	assert.Empty(t, exporter.GetSpans())
+}
+
+func TestInterceptField(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	user := &graphql.FieldContext{
+		Object:     "Query",
+		IsResolver: true,
+		Field:      graphql.CollectedField{Field: &ast.Field{Name: "user", Alias: "user"}},
+	}
+	name := &graphql.FieldContext{
+		Parent: user,
+		Object: "User",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: "name", Alias: "name"}},
+	}
+
+	tracer := New()
+	ctx := graphql.WithFieldContext(operationContext(t.Context()), user)
+
+	res, err := tracer.InterceptField(ctx, func(ctx context.Context) (any, error) {
+		return tracer.InterceptField(graphql.WithFieldContext(ctx, name), func(context.Context) (any, error) {
+			return "Ada", nil
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", res)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "fields without a resolver aren't traced")
+	assert.Equal(t, "Query.user", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.New("graphql.field.path", "user").KeyValue)
+}
@@ -0,0 +1,134 @@
+// Package gotelgrpc provides gRPC server and client interceptors recording RPC metrics on the meter provider
+// initialized by gotel, without wiring the contrib stats handler:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(gotelgrpc.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(gotelgrpc.StreamServerInterceptor()),
+//	)
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithChainUnaryInterceptor(gotelgrpc.UnaryClientInterceptor()),
+//		grpc.WithChainStreamInterceptor(gotelgrpc.StreamClientInterceptor()),
+//	)
+package gotelgrpc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelgrpc"
+
+// NormalizeStatusCodes makes attribute.New convert gRPC status codes to their canonical names, e.g. "not_found" for
+// codes.NotFound, instead of their String form. The interceptors record status codes as numbers either way.
+func NormalizeStatusCodes() {
+	attribute.RegisterNormalizer(func(code codes.Code) string { return attribute.Canonical(code.String()) })
+}
+
+type rpcMetrics struct {
+	RpcServerDuration     *metrics.Float64Histogram `unit:"s"`
+	RpcServerCalls        *metrics.Int64Counter
+	RpcServerRequestSize  *metrics.Int64Histogram   `unit:"By"`
+	RpcServerResponseSize *metrics.Int64Histogram   `unit:"By"`
+	RpcClientDuration     *metrics.Float64Histogram `unit:"s"`
+	RpcClientCalls        *metrics.Int64Counter
+	RpcClientRequestSize  *metrics.Int64Histogram `unit:"By"`
+	RpcClientResponseSize *metrics.Int64Histogram `unit:"By"`
+}
+
+var getMetrics = metrics.Scoped[rpcMetrics](scopeName)
+
+// methodAttributes returns the rpc.system, rpc.service, and rpc.method attributes of a full method name
+// such as "/helloworld.Greeter/SayHello".
+func methodAttributes(fullMethod string) []attribute.Attr {
+	attrs := []attribute.Attr{{KeyValue: semconv.RPCSystemGRPC}}
+
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return append(attrs, attribute.Attr{KeyValue: semconv.RPCMethod(fullMethod)})
+	}
+
+	return append(attrs, attribute.Attr{KeyValue: semconv.RPCService(service)}, attribute.Attr{KeyValue: semconv.RPCMethod(method)})
+}
+
+// messageSize returns the encoded size of a protobuf message, or -1 for other messages, which aren't recorded.
+func messageSize(m any) int64 {
+	message, ok := m.(proto.Message)
+	if !ok {
+		return -1
+	}
+
+	return int64(proto.Size(message))
+}
+
+// rpc records the metrics of a single call on the server or client instruments.
+type rpc struct {
+	start    time.Time
+	attrs    []attribute.Attr
+	duration *metrics.Float64Histogram
+	calls    *metrics.Int64Counter
+	// sent and received record the sizes of outgoing and incoming messages.
+	sent     *metrics.Int64Histogram
+	received *metrics.Int64Histogram
+	once     sync.Once
+}
+
+func startServerRPC(fullMethod string) *rpc {
+	m := getMetrics()
+
+	return &rpc{
+		start:    time.Now(),
+		attrs:    methodAttributes(fullMethod),
+		duration: m.RpcServerDuration,
+		calls:    m.RpcServerCalls,
+		sent:     m.RpcServerResponseSize,
+		received: m.RpcServerRequestSize,
+	}
+}
+
+func startClientRPC(fullMethod string) *rpc {
+	m := getMetrics()
+
+	return &rpc{
+		start:    time.Now(),
+		attrs:    methodAttributes(fullMethod),
+		duration: m.RpcClientDuration,
+		calls:    m.RpcClientCalls,
+		sent:     m.RpcClientRequestSize,
+		received: m.RpcClientResponseSize,
+	}
+}
+
+func (r *rpc) messageSent(ctx context.Context, m any) {
+	if size := messageSize(m); size >= 0 {
+		r.sent.Record(ctx, size, r.attrs...)
+	}
+}
+
+func (r *rpc) messageReceived(ctx context.Context, m any) {
+	if size := messageSize(m); size >= 0 {
+		r.received.Record(ctx, size, r.attrs...)
+	}
+}
+
+// finish records the duration and count of the call with its status code. Only the first call has an effect,
+// as a client stream may end in several ways.
+func (r *rpc) finish(ctx context.Context, err error) {
+	r.once.Do(func() {
+		code := status.Code(err)
+		attrs := append(slices.Clip(r.attrs), attribute.Attr{KeyValue: semconv.RPCGRPCStatusCodeKey.Int(int(code))})
+
+		r.duration.Record(ctx, time.Since(r.start).Seconds(), attrs...)
+		r.calls.Add(ctx, 1, attrs...)
+	})
+}
@@ -0,0 +1,216 @@
+//go:build !gotel_disabled
+
+package gotelgrpc
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var reader = sdkmetric.NewManualReader()
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// calls returns the call counts of a method by status code
+func calls(t *testing.T, name string, method string) map[int64]int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, name)
+	require.NotNil(t, m, "%s not found", name)
+
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	counts := map[int64]int64{}
+
+	for _, dp := range sum.DataPoints {
+		if value, _ := dp.Attributes.Value("rpc.method"); value.AsString() != method {
+			continue
+		}
+
+		service, _ := dp.Attributes.Value("rpc.service")
+		assert.Equal(t, "test.Greeter", service.AsString())
+
+		code, _ := dp.Attributes.Value("rpc.grpc.status_code")
+		counts[code.AsInt64()] += dp.Value
+	}
+
+	return counts
+}
+
+// sizeSum returns the sum of the message sizes recorded for a method
+func sizeSum(t *testing.T, name string, method string) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, name)
+	require.NotNil(t, m, "%s not found", name)
+
+	hist, ok := m.Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+
+	sum := int64(0)
+
+	for _, dp := range hist.DataPoints {
+		if value, _ := dp.Attributes.Value("rpc.method"); value.AsString() == method {
+			sum += dp.Sum
+		}
+	}
+
+	return sum
+}
+
+func TestNormalizeStatusCodes(t *testing.T) {
+	NormalizeStatusCodes()
+
+	assert.Equal(t, "not_found", attribute.New("status", codes.NotFound).Value.AsString())
+	assert.Equal(t, "ok", attribute.New("status", codes.OK).Value.AsString())
+}
+
+func TestMethodAttributes(t *testing.T) {
+	attrs := methodAttributes("/helloworld.Greeter/SayHello")
+	require.Len(t, attrs, 3)
+	assert.Equal(t, "grpc", attrs[0].Value.AsString())
+	assert.Equal(t, "helloworld.Greeter", attrs[1].Value.AsString())
+	assert.Equal(t, "SayHello", attrs[2].Value.AsString())
+
+	assert.Len(t, methodAttributes("invalid"), 2)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Greeter/SayHello"}
+
+	req := wrapperspb.String("hello")
+	resp := wrapperspb.String("hello, world")
+
+	_, err := interceptor(t.Context(), req, info, func(context.Context, any) (any, error) {
+		return resp, nil
+	})
+	require.NoError(t, err)
+
+	_, err = interceptor(t.Context(), req, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	require.Error(t, err)
+
+	counts := calls(t, "rpc_server_calls", "SayHello")
+	assert.Equal(t, int64(1), counts[int64(codes.OK)])
+	assert.Equal(t, int64(1), counts[int64(codes.NotFound)])
+
+	assert.Equal(t, int64(2*proto.Size(req)), sizeSum(t, "rpc_server_request_size", "SayHello"))
+	assert.Equal(t, int64(proto.Size(resp)), sizeSum(t, "rpc_server_response_size", "SayHello"))
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+}
+
+func (fakeServerStream) Context() context.Context { return context.Background() }
+func (fakeServerStream) SendMsg(any) error        { return nil }
+func (fakeServerStream) RecvMsg(any) error        { return nil }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Greeter/Chat", IsClientStream: true, IsServerStream: true}
+	msg := wrapperspb.String("hi")
+
+	err := StreamServerInterceptor()(nil, fakeServerStream{}, info, func(_ any, stream grpc.ServerStream) error {
+		for range 3 {
+			if err := stream.RecvMsg(msg); err != nil {
+				return err
+			}
+
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), calls(t, "rpc_server_calls", "Chat")[int64(codes.OK)])
+	assert.Equal(t, int64(3*proto.Size(msg)), sizeSum(t, "rpc_server_request_size", "Chat"))
+	assert.Equal(t, int64(3*proto.Size(msg)), sizeSum(t, "rpc_server_response_size", "Chat"))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	req := wrapperspb.String("hello")
+
+	err := UnaryClientInterceptor()(t.Context(), "/test.Greeter/Lookup", req, wrapperspb.String(""), nil,
+		func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			return status.Error(codes.Unavailable, "down")
+		})
+	require.Error(t, err)
+
+	assert.Equal(t, int64(1), calls(t, "rpc_client_calls", "Lookup")[int64(codes.Unavailable)])
+	assert.Equal(t, int64(proto.Size(req)), sizeSum(t, "rpc_client_request_size", "Lookup"))
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	messages int
+}
+
+func (*fakeClientStream) Context() context.Context { return context.Background() }
+func (*fakeClientStream) SendMsg(any) error        { return nil }
+
+func (s *fakeClientStream) RecvMsg(any) error {
+	if s.messages == 0 {
+		return io.EOF
+	}
+
+	s.messages--
+
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	desc := &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+
+	cs, err := StreamClientInterceptor()(t.Context(), desc, nil, "/test.Greeter/Watch",
+		func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &fakeClientStream{messages: 2}, nil
+		})
+	require.NoError(t, err)
+
+	msg := wrapperspb.String("event")
+	require.NoError(t, cs.SendMsg(msg))
+
+	for cs.RecvMsg(msg) == nil {
+		assert.Empty(t, calls(t, "rpc_client_calls", "Watch"), "the call is recorded when the stream ends")
+	}
+
+	assert.Equal(t, int64(1), calls(t, "rpc_client_calls", "Watch")[int64(codes.OK)], "io.EOF ends the stream successfully")
+	assert.Equal(t, int64(2*proto.Size(msg)), sizeSum(t, "rpc_client_response_size", "Watch"))
+}
@@ -0,0 +1,134 @@
+package gotelgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor records the rpc_server_duration histogram, the rpc_server_calls counter, and the
+// rpc_server_request_size and rpc_server_response_size histograms of each unary call, with rpc.service,
+// rpc.method, and rpc.grpc.status_code attributes. Message sizes are recorded for protobuf messages.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		call := startServerRPC(info.FullMethod)
+		call.messageReceived(ctx, req)
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			call.messageSent(ctx, resp)
+		}
+
+		call.finish(ctx, err)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the metrics of UnaryServerInterceptor for streaming calls, with the size of
+// every message sent and received.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		call := startServerRPC(info.FullMethod)
+
+		err := handler(srv, &serverStream{ServerStream: ss, call: call})
+		call.finish(ss.Context(), err)
+
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	call *rpc
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.call.messageSent(s.Context(), m)
+	}
+
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.call.messageReceived(s.Context(), m)
+	}
+
+	return err
+}
+
+// UnaryClientInterceptor records the rpc_client_duration histogram, the rpc_client_calls counter, and the
+// rpc_client_request_size and rpc_client_response_size histograms of each unary call, with the attributes of
+// UnaryServerInterceptor.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := startClientRPC(method)
+		call.messageSent(ctx, req)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			call.messageReceived(ctx, reply)
+		}
+
+		call.finish(ctx, err)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor records the metrics of UnaryClientInterceptor for streaming calls. A call ends when
+// receiving a message fails, including with io.EOF at the end of a server stream, or when the single response of
+// a client stream is received, so streams must be read to the end to be recorded.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		call := startClientRPC(method)
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			call.finish(ctx, err)
+			return nil, err
+		}
+
+		return &clientStream{ClientStream: cs, call: call, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	call          *rpc
+	serverStreams bool
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.call.messageSent(s.Context(), m)
+	}
+
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		s.call.finish(s.Context(), nil)
+	case err != nil:
+		s.call.finish(s.Context(), err)
+	default:
+		s.call.messageReceived(s.Context(), m)
+
+		if !s.serverStreams {
+			s.call.finish(s.Context(), nil)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,111 @@
+// Package fasthttpmiddleware instruments fasthttp request handlers with gotelhttp, recording the same server spans,
+// request metrics, and access logs as gotelhttp.Middleware:
+//
+//	fasthttp.ListenAndServe(":8080", fasthttpmiddleware.Middleware(handler))
+//
+// Handlers get the request's context, which carries its span and request ID, with Context. fasthttp has no routes,
+// so handlers or routers set the route template with SetRoute. It is a separate module so that the gotel module
+// doesn't depend on fasthttp.
+package fasthttpmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/tinybluerobots/gotel/gotelhttp"
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+	"github.com/valyala/fasthttp"
+)
+
+var errPanic = errors.New("handler panicked")
+
+type (
+	contextKey struct{}
+	routeKey   struct{}
+)
+
+// Middleware instruments a fasthttp request handler with a server span, request duration metrics, and an access log
+// per request. It accepts an incoming X-Request-Id header, or generates one, and echoes it on the response.
+// A panicking handler is recorded as a failed request with status 500, and the panic is re-raised.
+func Middleware(next fasthttp.RequestHandler, options ...gotelhttp.Option) fasthttp.RequestHandler {
+	return func(rc *fasthttp.RequestCtx) {
+		ctx, finish := gotelhttp.StartServerRequest(rc, gotelhttp.ServerRequest{
+			Method:        string(rc.Method()),
+			URL:           requestURL(rc),
+			Headers:       headerCarrier(&rc.Request.Header),
+			UserAgent:     string(rc.UserAgent()),
+			ClientAddress: rc.RemoteAddr().String(),
+		}, options...)
+
+		rc.SetUserValue(contextKey{}, ctx)
+		rc.Response.Header.Set(requestid.Header, requestid.FromContext(ctx))
+
+		defer func() {
+			resp := gotelhttp.ServerResponse{StatusCode: rc.Response.StatusCode(), Size: responseSize(&rc.Response)}
+			resp.Route, _ = rc.UserValue(routeKey{}).(string)
+
+			if recovered := recover(); recovered != nil {
+				span := tracing.SpanFromContext(ctx)
+				span.RecordErrorAndSetStatus(fmt.Errorf("%w: %v", errPanic, recovered))
+
+				resp.StatusCode = fasthttp.StatusInternalServerError
+				finish(resp)
+
+				panic(recovered)
+			}
+
+			finish(resp)
+		}()
+
+		next(rc)
+	}
+}
+
+// Context returns the context of a request instrumented by Middleware, which carries its span and request ID, or
+// rc itself if the request isn't instrumented.
+func Context(rc *fasthttp.RequestCtx) context.Context {
+	if ctx, ok := rc.UserValue(contextKey{}).(context.Context); ok {
+		return ctx
+	}
+
+	return rc
+}
+
+// SetRoute sets the low-cardinality route template of a request, e.g. /users/{id}, which names its span and is
+// recorded on its metrics.
+func SetRoute(rc *fasthttp.RequestCtx, route string) {
+	rc.SetUserValue(routeKey{}, route)
+}
+
+func requestURL(rc *fasthttp.RequestCtx) *url.URL {
+	return &url.URL{
+		Scheme:   string(rc.URI().Scheme()),
+		Host:     string(rc.Host()),
+		Path:     string(rc.Path()),
+		RawQuery: string(rc.QueryArgs().QueryString()),
+	}
+}
+
+func headerCarrier(header *fasthttp.RequestHeader) map[string]string {
+	carrier := make(map[string]string, header.Len())
+	for key, value := range header.All() {
+		if _, ok := carrier[string(key)]; !ok {
+			carrier[string(key)] = string(value)
+		}
+	}
+
+	return carrier
+}
+
+// responseSize returns the size of the response body, reading the Content-Length of streamed bodies rather than
+// consuming the stream.
+func responseSize(resp *fasthttp.Response) int64 {
+	if resp.IsBodyStream() {
+		return max(int64(resp.Header.ContentLength()), 0)
+	}
+
+	return int64(len(resp.Body()))
+}
@@ -0,0 +1,112 @@
+package fasthttpmiddleware
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/gotelhttp"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+	"github.com/valyala/fasthttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	exporter = tracetest.NewInMemoryExporter()
+	reader   = sdkmetric.NewManualReader()
+)
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newRequest(method string, uri string) *fasthttp.RequestCtx {
+	rc := &fasthttp.RequestCtx{}
+	rc.Request.Header.SetMethod(method)
+	rc.Request.SetRequestURI(uri)
+
+	return rc
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter.Reset()
+
+	var handlerID string
+
+	handler := Middleware(func(rc *fasthttp.RequestCtx) {
+		SetRoute(rc, "/users/{id}")
+		handlerID = requestid.FromContext(Context(rc))
+
+		rc.SetStatusCode(fasthttp.StatusCreated)
+		rc.SetBodyString("created")
+	}, gotelhttp.WithoutAccessLog())
+
+	rc := newRequest(fasthttp.MethodPost, "http://example.com/users/42?page=2")
+	rc.Request.Header.Set(requestid.Header, "req-123")
+	handler(rc)
+
+	assert.Equal(t, "req-123", handlerID)
+	assert.Equal(t, "req-123", string(rc.Response.Header.Peek(requestid.Header)))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST /users/{id}", spans[0].Name)
+	assert.Equal(t, "server", spans[0].SpanKind.String())
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 201).KeyValue)
+	assert.Contains(t, spans[0].Attributes, attribute.New("url.path", "/users/42").KeyValue)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	m := metrictest.Find(rm, "http_server_response_body_size")
+	require.NotNil(t, m, "http_server_response_body_size not found")
+
+	hist, ok := m.Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+	require.NotEmpty(t, hist.DataPoints)
+	assert.Equal(t, int64(len("created")), hist.DataPoints[0].Sum)
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	exporter.Reset()
+
+	handler := Middleware(func(*fasthttp.RequestCtx) {
+		panic("boom")
+	}, gotelhttp.WithoutAccessLog())
+
+	assert.PanicsWithValue(t, "boom", func() {
+		handler(newRequest(fasthttp.MethodGet, "/panic"))
+	}, "the panic should be re-raised")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "the span should end when the handler panics")
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 500).KeyValue)
+	assert.Equal(t, "Error", spans[0].Status.Code.String())
+}
+
+func TestContext(t *testing.T) {
+	rc := newRequest(fasthttp.MethodGet, "/")
+
+	assert.Equal(t, context.Context(rc), Context(rc), "uninstrumented requests use the request context")
+}
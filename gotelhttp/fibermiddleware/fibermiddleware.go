@@ -0,0 +1,120 @@
+// Package fibermiddleware is Fiber middleware built on gotelhttp, recording the same server spans, request metrics,
+// and access logs as gotelhttp.Middleware:
+//
+//	app := fiber.New()
+//	app.Use(fibermiddleware.New())
+//
+// Handlers get the request's context, which carries its span and request ID, with c.UserContext(). It is a separate
+// module so that the gotel module doesn't depend on Fiber.
+package fibermiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tinybluerobots/gotel/gotelhttp"
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+var errPanic = errors.New("handler panicked")
+
+// New returns middleware that instruments requests with a server span, request duration metrics, and an access log
+// per request. It accepts an incoming X-Request-Id header, or generates one, and echoes it on the response.
+// The route is taken from the route that handled the request, so register it with app.Use before the routes.
+// A panicking handler is recorded as a failed request with status 500, and the panic is re-raised.
+func New(options ...gotelhttp.Option) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, finish := gotelhttp.StartServerRequest(c.UserContext(), gotelhttp.ServerRequest{
+			Method:        strings.Clone(c.Method()),
+			URL:           requestURL(c),
+			Headers:       headerCarrier(c),
+			UserAgent:     strings.Clone(c.Get(fiber.HeaderUserAgent)),
+			ClientAddress: c.Context().RemoteAddr().String(),
+		}, options...)
+
+		c.SetUserContext(ctx)
+		c.Set(requestid.Header, requestid.FromContext(ctx))
+
+		middleware := c.Route()
+
+		var err error
+
+		defer func() {
+			resp := gotelhttp.ServerResponse{StatusCode: statusCode(c, err), Size: responseSize(c)}
+
+			// The route is still the middleware's own if no route handled the request
+			if route := c.Route(); route != middleware {
+				resp.Route = route.Path
+			}
+
+			if recovered := recover(); recovered != nil {
+				span := tracing.SpanFromContext(ctx)
+				span.RecordErrorAndSetStatus(fmt.Errorf("%w: %v", errPanic, recovered))
+
+				resp.StatusCode = fiber.StatusInternalServerError
+				finish(resp)
+
+				panic(recovered)
+			}
+
+			finish(resp)
+		}()
+
+		err = c.Next()
+
+		return err
+	}
+}
+
+// requestURL returns the URL of the request, copied as Fiber reuses its buffers once the handler returns.
+func requestURL(c *fiber.Ctx) *url.URL {
+	return &url.URL{
+		Scheme:   c.Protocol(),
+		Host:     strings.Clone(c.Hostname()),
+		Path:     string(c.Request().URI().Path()),
+		RawQuery: string(c.Request().URI().QueryString()),
+	}
+}
+
+// headerCarrier returns the first value of each request header, copied as Fiber reuses its buffers once the handler
+// returns.
+func headerCarrier(c *fiber.Ctx) map[string]string {
+	headers := c.GetReqHeaders()
+
+	carrier := make(map[string]string, len(headers))
+	for key, values := range headers {
+		if len(values) > 0 {
+			carrier[strings.Clone(key)] = strings.Clone(values[0])
+		}
+	}
+
+	return carrier
+}
+
+// statusCode returns the status of the response, or for a handler error, the status Fiber's default error handler
+// responds with, as the app's error handler only writes the response once the middleware has returned.
+func statusCode(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	if fiberErr := (*fiber.Error)(nil); errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return fiber.StatusInternalServerError
+}
+
+// responseSize returns the size of the response body, reading the Content-Length of streamed bodies rather than
+// consuming the stream.
+func responseSize(c *fiber.Ctx) int64 {
+	if c.Response().IsBodyStream() {
+		return max(int64(c.Response().Header.ContentLength()), 0)
+	}
+
+	return int64(len(c.Response().Body()))
+}
@@ -0,0 +1,130 @@
+package fibermiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/gotelhttp"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var exporter = tracetest.NewInMemoryExporter()
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(sdkmetric.NewManualReader())); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newApp() *fiber.App {
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(New(gotelhttp.WithoutAccessLog()))
+
+	return app
+}
+
+func TestNew(t *testing.T) {
+	exporter.Reset()
+
+	var handlerID string
+
+	app := newApp()
+	app.Post("/users/:id", func(c *fiber.Ctx) error {
+		handlerID = requestid.FromContext(c.UserContext())
+
+		return c.Status(fiber.StatusCreated).SendString("created")
+	})
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/users/42?page=2", nil)
+	req.Header.Set(requestid.Header, "req-123")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	assert.Equal(t, "req-123", handlerID)
+	assert.Equal(t, "req-123", resp.Header.Get(requestid.Header))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST /users/:id", spans[0].Name)
+	assert.Equal(t, "server", spans[0].SpanKind.String())
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 201).KeyValue)
+	assert.Contains(t, spans[0].Attributes, attribute.New("url.path", "/users/42").KeyValue)
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.body.size", len("created")).KeyValue)
+}
+
+func TestNew_Error(t *testing.T) {
+	exporter.Reset()
+
+	app := newApp()
+	app.Get("/orders/:id", func(*fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	resp, err := app.Test(httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/orders/7", nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /orders/:id", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 404).KeyValue)
+}
+
+func TestNew_Unrouted(t *testing.T) {
+	exporter.Reset()
+
+	resp, err := newApp().Test(httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/missing", nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET", spans[0].Name, "requests no route handled have no route")
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 404).KeyValue)
+}
+
+func TestNew_Panic(t *testing.T) {
+	exporter.Reset()
+
+	app := newApp()
+	app.Get("/panic", func(*fiber.Ctx) error {
+		panic("boom")
+	})
+
+	resp, err := app.Test(httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/panic", nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode, "the panic should be re-raised to the recover middleware")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "the span should end when the handler panics")
+	assert.Contains(t, spans[0].Attributes, attribute.New("http.response.status_code", 500).KeyValue)
+	assert.Equal(t, "Error", spans[0].Status.Code.String())
+}
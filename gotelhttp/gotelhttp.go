@@ -0,0 +1,183 @@
+// Package gotelhttp provides OpenTelemetry instrumentation for HTTP servers.
+// It records HTTP semantic convention attributes with sensitive data scrubbed.
+//
+// Middleware instruments net/http handlers. The fibermiddleware and fasthttpmiddleware modules instrument Fiber and
+// fasthttp servers, and other servers call StartServerRequest from their own middleware to get the same spans,
+// metrics, and access logs.
+package gotelhttp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+const redacted = "REDACTED"
+
+// DefaultScrubbedQueryParams lists the query parameters scrubbed when no others are configured.
+var DefaultScrubbedQueryParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"auth",
+	"code",
+	"email",
+	"key",
+	"password",
+	"secret",
+	"sig",
+	"signature",
+	"token",
+}
+
+type config struct {
+	scrubbedQueryParams map[string]struct{}
+	hashQueryParams     bool
+	accessLog           bool
+	spanNameFormatter   SpanNameFormatter
+	debugTraceTrusted   func(ServerRequest) bool
+}
+
+// Option configures the HTTP helpers.
+type Option func(*config)
+
+// WithScrubbedQueryParams replaces the default deny-list of query parameters to scrub.
+// Parameter names are matched case-insensitively.
+func WithScrubbedQueryParams(params ...string) Option {
+	return func(c *config) {
+		c.scrubbedQueryParams = toParamSet(params)
+	}
+}
+
+// WithHashedQueryParams replaces scrubbed query parameter values with a SHA-256 hash instead of REDACTED.
+// Hashing keeps values correlatable across requests without recording them.
+func WithHashedQueryParams() Option {
+	return func(c *config) {
+		c.hashQueryParams = true
+	}
+}
+
+// WithoutAccessLog disables the INFO log record written for each request served by the middleware.
+func WithoutAccessLog() Option {
+	return func(c *config) {
+		c.accessLog = false
+	}
+}
+
+// WithSpanNameFormatter replaces the default server span name of "METHOD route", e.g. to match existing dashboards.
+// The formatter is called with an empty route until the route is known.
+func WithSpanNameFormatter(formatter SpanNameFormatter) Option {
+	return func(c *config) {
+		c.spanNameFormatter = formatter
+	}
+}
+
+// WithDebugTrace samples the traces of requests with the DebugTraceHeader set to "1" regardless of the sampler, when
+// trusted returns true for the request, e.g. because its ClientAddress is on an internal network or it carries an
+// operator's credentials. Without it the header is ignored, so clients can't force sampling and inflate trace volume.
+func WithDebugTrace(trusted func(req ServerRequest) bool) Option {
+	return func(c *config) {
+		c.debugTraceTrusted = trusted
+	}
+}
+
+func toParamSet(params []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		set[strings.ToLower(param)] = struct{}{}
+	}
+
+	return set
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{scrubbedQueryParams: toParamSet(DefaultScrubbedQueryParams), accessLog: true, spanNameFormatter: DefaultSpanNameFormatter}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+func (c *config) scrubValue(value string) string {
+	if !c.hashQueryParams {
+		return redacted
+	}
+
+	sum := sha256.Sum256([]byte(value))
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (c *config) scrubQuery(rawQuery string) string {
+	if rawQuery == "" || len(c.scrubbedQueryParams) == 0 {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		rawKey, rawValue, hasValue := strings.Cut(pair, "=")
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if _, ok := c.scrubbedQueryParams[strings.ToLower(key)]; !ok || !hasValue {
+			continue
+		}
+
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+
+		pairs[i] = rawKey + "=" + url.QueryEscape(c.scrubValue(value))
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// ScrubQuery returns the raw query string with the values of sensitive parameters replaced.
+// The order of parameters is preserved.
+func ScrubQuery(rawQuery string, options ...Option) string {
+	return newConfig(options...).scrubQuery(rawQuery)
+}
+
+// ScrubURL returns the URL as a string with sensitive query parameters and any password removed.
+func ScrubURL(u *url.URL, options ...Option) string {
+	return newConfig(options...).scrubURL(u)
+}
+
+func (c *config) scrubURL(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.RawQuery = c.scrubQuery(u.RawQuery)
+
+	return scrubbed.Redacted()
+}
+
+// URLAttributes returns the url.* semantic convention attributes for a URL with sensitive data scrubbed.
+func URLAttributes(u *url.URL, options ...Option) []attribute.Attr {
+	return newConfig(options...).urlAttributes(u)
+}
+
+func (c *config) urlAttributes(u *url.URL) []attribute.Attr {
+	attrs := []attribute.Attr{
+		{KeyValue: semconv.URLFull(c.scrubURL(u))},
+		{KeyValue: semconv.URLPath(u.Path)},
+	}
+
+	if u.Scheme != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.URLScheme(u.Scheme)})
+	}
+
+	if u.RawQuery != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.URLQuery(c.scrubQuery(u.RawQuery))})
+	}
+
+	return attrs
+}
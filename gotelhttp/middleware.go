@@ -0,0 +1,151 @@
+package gotelhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+var errPanic = errors.New("handler panicked")
+
+type responseWriter struct {
+	http.ResponseWriter
+
+	statusCode  int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter for use with http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func headerCarrier(header http.Header) map[string]string {
+	carrier := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) > 0 {
+			carrier[key] = values[0]
+		}
+	}
+
+	return carrier
+}
+
+// routeFromPattern strips the method and host from a ServeMux pattern such as "GET /users/{id}".
+func routeFromPattern(pattern string) string {
+	if _, route, ok := strings.Cut(pattern, " "); ok {
+		pattern = route
+	}
+
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	return pattern
+}
+
+func requestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+
+	return &u
+}
+
+// Middleware instruments an http.Handler with a server span, request duration metrics, and an access log per request.
+// It accepts an incoming X-Request-Id header, or generates one, and echoes it on the response.
+// The route is taken from the ServeMux pattern that matched the request, so wrap the mux rather than individual handlers.
+// A panicking handler is recorded as a failed request with status 500, and the panic is re-raised.
+func Middleware(next http.Handler, options ...Option) http.Handler {
+	c := newConfig(options...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, finish := c.startServerRequest(r.Context(), ServerRequest{
+			Method:        r.Method,
+			URL:           requestURL(r),
+			Headers:       headerCarrier(r.Header),
+			UserAgent:     r.UserAgent(),
+			ClientAddress: r.RemoteAddr,
+		})
+
+		w.Header().Set(requestid.Header, requestid.FromContext(ctx))
+
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		req := r.WithContext(ctx)
+
+		defer func() {
+			resp := ServerResponse{StatusCode: rw.statusCode, Size: rw.size, Route: routeFromPattern(req.Pattern)}
+
+			// The server aborts the response of a panicking handler, so the request failed whatever was written
+			if recovered := recover(); recovered != nil {
+				span := tracing.SpanFromContext(ctx)
+				span.RecordErrorAndSetStatus(fmt.Errorf("%w: %v", errPanic, recovered))
+
+				resp.StatusCode = http.StatusInternalServerError
+				finish(resp)
+
+				panic(recovered)
+			}
+
+			finish(resp)
+		}()
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// Metrics records RED metrics for an http.Handler without spans or access logs: the http_server_requests counter,
+// the http_server_request_duration histogram, the http_server_active_requests up/down counter, and the
+// http_server_response_body_size histogram, with HTTP semantic convention attributes. Middleware records the same
+// metrics, so use one or the other. Wrap the ServeMux so the route is taken from the matched pattern.
+// A panicking handler is recorded as a failed request with status 500, and the panic is re-raised.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		finish := startRequestMetrics(r.Context(), r.Method)
+
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				finish(r.Context(), http.StatusInternalServerError, routeFromPattern(r.Pattern), rw.size)
+
+				panic(recovered)
+			}
+
+			finish(r.Context(), rw.statusCode, routeFromPattern(r.Pattern), rw.size)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,200 @@
+package gotelhttp
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/requestid"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelhttp"
+
+// DebugTraceHeader is the request header that, set to "1", samples the request's trace regardless of the sampler,
+// for on-demand full traces of specific requests, when enabled with WithDebugTrace. See tracing.ForceSample.
+const DebugTraceHeader = "X-Debug-Trace"
+
+type serverMetrics struct {
+	HttpServerRequestDuration  *metrics.Float64Histogram `unit:"s"`
+	HttpServerRequests         *metrics.Int64Counter
+	HttpServerActiveRequests   *metrics.Int64UpDownCounter
+	HttpServerResponseBodySize *metrics.Int64Histogram `unit:"By"`
+}
+
+var getMetrics = metrics.Scoped[serverMetrics](scopeName)
+
+// ServerRequest describes an incoming HTTP request independently of the server framework.
+type ServerRequest struct {
+	Method string
+	// Route is the low-cardinality route template, e.g. /users/{id}. Leave it empty if it's only known after routing.
+	Route string
+	URL   *url.URL
+	// Headers holds the request headers carrying the propagated trace context.
+	Headers       map[string]string
+	UserAgent     string
+	ClientAddress string
+}
+
+// ServerResponse describes the outcome of an HTTP request.
+type ServerResponse struct {
+	StatusCode int
+	Size       int64
+	// Route sets the route template when it's only known after routing.
+	Route string
+}
+
+// SpanNameFormatter names a server span from the request method and route template.
+type SpanNameFormatter func(method string, route string) string
+
+// DefaultSpanNameFormatter names spans "METHOD route", e.g. "GET /users/{id}", or just the method when the route is unknown.
+func DefaultSpanNameFormatter(method string, route string) string {
+	if route == "" {
+		return method
+	}
+
+	return method + " " + route
+}
+
+// StartServerRequest starts a server span for an incoming request and returns a function that completes it.
+// The returned context carries the request's X-Request-Id, or a generated ID; echo it with requestid.FromContext.
+// Completing the request ends the span, records the request duration, and writes an access log.
+// URL and client attributes are only computed when the span is sampled; metrics are recorded either way.
+func StartServerRequest(ctx context.Context, req ServerRequest, options ...Option) (context.Context, func(ServerResponse)) {
+	return newConfig(options...).startServerRequest(ctx, req)
+}
+
+// requestID returns the incoming request ID if it's valid, or a new one.
+func requestID(headers map[string]string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, requestid.Header) && requestid.Valid(value) {
+			return value
+		}
+	}
+
+	return requestid.New()
+}
+
+// debugTrace reports whether the request asks for its trace to be sampled and WithDebugTrace trusts it to.
+func (c *config) debugTrace(req ServerRequest) bool {
+	if c.debugTraceTrusted == nil {
+		return false
+	}
+
+	for key, value := range req.Headers {
+		if strings.EqualFold(key, DebugTraceHeader) {
+			return value == "1" && c.debugTraceTrusted(req)
+		}
+	}
+
+	return false
+}
+
+func (c *config) requestAttributes(req ServerRequest) []attribute.Attr {
+	attrs := []attribute.Attr{}
+	if req.URL != nil {
+		attrs = append(attrs, c.urlAttributes(req.URL)...)
+	}
+
+	if req.UserAgent != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.UserAgentOriginal(req.UserAgent)})
+	}
+
+	if req.ClientAddress != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.ClientAddress(req.ClientAddress)})
+	}
+
+	return attrs
+}
+
+// startRequestMetrics counts an active request and returns a function that records its completion, returning the
+// metric attributes and the request duration.
+func startRequestMetrics(ctx context.Context, method string) func(ctx context.Context, statusCode int, route string, size int64) ([]attribute.Attr, time.Duration) {
+	start := time.Now()
+	methodAttr := attribute.Attr{KeyValue: semconv.HTTPRequestMethodKey.String(method)}
+
+	getMetrics().HttpServerActiveRequests.Inc(ctx, methodAttr)
+
+	return func(ctx context.Context, statusCode int, route string, size int64) ([]attribute.Attr, time.Duration) {
+		duration := time.Since(start)
+		m := getMetrics()
+
+		m.HttpServerActiveRequests.Dec(ctx, methodAttr)
+
+		metricAttrs := []attribute.Attr{methodAttr, {KeyValue: semconv.HTTPResponseStatusCode(statusCode)}}
+		if route != "" {
+			metricAttrs = append(metricAttrs, attribute.Attr{KeyValue: semconv.HTTPRoute(route)})
+		}
+
+		m.HttpServerRequestDuration.Record(ctx, duration.Seconds(), metricAttrs...)
+		m.HttpServerRequests.Add(ctx, 1, metricAttrs...)
+		m.HttpServerResponseBodySize.Record(ctx, size, metricAttrs...)
+
+		return metricAttrs, duration
+	}
+}
+
+func (c *config) startServerRequest(ctx context.Context, req ServerRequest) (context.Context, func(ServerResponse)) {
+	finishMetrics := startRequestMetrics(ctx, req.Method)
+
+	attrs := []attribute.Attr{{KeyValue: semconv.HTTPRequestMethodKey.String(req.Method)}}
+	if req.Route != "" {
+		attrs = append(attrs, attribute.Attr{KeyValue: semconv.HTTPRoute(req.Route)})
+	}
+
+	ctx = requestid.NewContext(ctx, requestID(req.Headers))
+	if c.debugTrace(req) {
+		ctx = tracing.ForceSample(ctx)
+	}
+
+	ctx, span := tracing.NewChildSpanWithKind(ctx, req.Headers, tracing.SpanKindServer, c.spanNameFormatter(req.Method, req.Route), attrs...)
+
+	// Unsampled spans drop their attributes, so only scrub and convert them for recorded spans.
+	recording := span.IsRecording()
+	if recording {
+		span.SetAttributes(c.requestAttributes(req)...)
+	}
+
+	finish := func(resp ServerResponse) {
+		route := req.Route
+		if resp.Route != "" {
+			route = resp.Route
+		}
+
+		if recording {
+			if route != req.Route {
+				span.SetName(c.spanNameFormatter(req.Method, route))
+				span.SetAttributes(attribute.Attr{KeyValue: semconv.HTTPRoute(route)})
+			}
+
+			span.SetAttributes(attribute.Attr{KeyValue: semconv.HTTPResponseBodySize(int(resp.Size))})
+			span.SetHTTPStatus(resp.StatusCode, tracing.SpanKindServer)
+		}
+
+		span.End()
+
+		metricAttrs, duration := finishMetrics(ctx, resp.StatusCode, route, resp.Size)
+
+		if c.accessLog {
+			path := ""
+			if req.URL != nil {
+				path = req.URL.Path
+			}
+
+			log.Info(ctx, "request",
+				append(metricAttrs,
+					attribute.Attr{KeyValue: semconv.URLPath(path)},
+					attribute.New("duration_ms", duration.Milliseconds()),
+					attribute.New("response_size", resp.Size),
+				)...,
+			)
+		}
+	}
+
+	return ctx, finish
+}
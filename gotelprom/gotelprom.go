@@ -0,0 +1,334 @@
+// Package gotelprom republishes metrics scraped from a Prometheus endpoint through the metrics package,
+// so applications with existing promhttp metrics, or libraries that expose their own /metrics, can consolidate on OTLP.
+//
+// Counters become Float64Counters, gauges and untyped metrics Float64Gauges, and histograms PreAggregatedHistograms.
+// Summary quantiles become gauges, with their _sum and _count series as counters.
+//
+//	bridge := gotelprom.New("http://localhost:2112/metrics")
+//	go bridge.Run(ctx, 15*time.Second)
+package gotelprom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/gotelprom"
+
+var errScrapeStatus = errors.New("unexpected scrape response status")
+
+type config struct {
+	prefix string
+	client *http.Client
+}
+
+// Option configures a Bridge.
+type Option func(*config)
+
+// WithPrefix prepends a prefix to every republished metric name.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithHTTPClient sets the client used to scrape the endpoint. The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{client: http.DefaultClient}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Bridge scrapes a Prometheus endpoint and republishes its samples.
+type Bridge struct {
+	endpoint string
+	config   *config
+
+	mu           sync.Mutex
+	counters     map[string]*metrics.Float64Counter
+	gauges       map[string]*metrics.Float64Gauge
+	histograms   map[string]*metrics.PreAggregatedHistogram
+	lastCounters map[string]float64
+}
+
+// New creates a Bridge for a Prometheus text format endpoint.
+// Instruments are created on first use, under the gotelprom instrumentation scope.
+func New(endpoint string, options ...Option) *Bridge {
+	return &Bridge{
+		endpoint:     endpoint,
+		config:       newConfig(options...),
+		counters:     map[string]*metrics.Float64Counter{},
+		gauges:       map[string]*metrics.Float64Gauge{},
+		histograms:   map[string]*metrics.PreAggregatedHistogram{},
+		lastCounters: map[string]float64{},
+	}
+}
+
+func instrument[T any](instruments map[string]*T, name string) (*T, error) {
+	if inst, ok := instruments[name]; ok {
+		return inst, nil
+	}
+
+	inst, err := metrics.New[T](scopeName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments[name] = inst
+
+	return inst, nil
+}
+
+func toAttrs(labels []label) []attribute.Attr {
+	attrs := make([]attribute.Attr, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, attribute.New(l.name, l.value))
+	}
+
+	return attrs
+}
+
+func seriesKey(name string, labels []label) string {
+	sb := strings.Builder{}
+	_, _ = sb.WriteString(name)
+
+	for _, l := range labels {
+		_, _ = sb.WriteString("," + l.name + "=" + l.value)
+	}
+
+	return sb.String()
+}
+
+// familyOf returns the metric family a sample belongs to, and the histogram or summary suffix of its name.
+func familyOf(types map[string]string, name string) (string, string) {
+	if _, ok := types[name]; ok {
+		return name, ""
+	}
+
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		base, ok := strings.CutSuffix(name, suffix)
+		if ok && (types[base] == "histogram" || types[base] == "summary") {
+			return base, suffix
+		}
+	}
+
+	return name, ""
+}
+
+type bucket struct {
+	le    float64
+	count float64
+}
+
+type histogramSeries struct {
+	name    string
+	labels  []label
+	buckets []bucket
+	sum     float64
+	count   float64
+}
+
+func (h *histogramSeries) summary() metrics.HistogramSummary {
+	slices.SortFunc(h.buckets, func(a, b bucket) int {
+		switch {
+		case a.le < b.le:
+			return -1
+		case a.le > b.le:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	summary := metrics.HistogramSummary{Count: uint64(h.count), Sum: h.sum, Min: math.NaN(), Max: math.NaN()}
+
+	previous := 0.0
+
+	for _, b := range h.buckets {
+		if math.IsInf(b.le, 1) {
+			break
+		}
+
+		summary.Bounds = append(summary.Bounds, b.le)
+		summary.BucketCounts = append(summary.BucketCounts, uint64(max(b.count-previous, 0)))
+		previous = b.count
+	}
+
+	summary.BucketCounts = append(summary.BucketCounts, uint64(max(h.count-previous, 0)))
+
+	return summary
+}
+
+func (b *Bridge) addCounter(ctx context.Context, s sample) error {
+	name := b.config.prefix + s.name
+
+	counter, err := instrument(b.counters, name)
+	if err != nil {
+		return err
+	}
+
+	// Prometheus counters are cumulative, so record the increase since the last scrape, or the whole value after a reset
+	key := seriesKey(name, s.labels)
+	increase := s.value
+
+	if last, ok := b.lastCounters[key]; ok && s.value >= last {
+		increase = s.value - last
+	}
+
+	b.lastCounters[key] = s.value
+
+	if increase > 0 {
+		counter.Add(ctx, increase, toAttrs(s.labels)...)
+	}
+
+	return nil
+}
+
+func (b *Bridge) recordGauge(ctx context.Context, s sample) error {
+	gauge, err := instrument(b.gauges, b.config.prefix+s.name)
+	if err != nil {
+		return err
+	}
+
+	gauge.Record(ctx, s.value, toAttrs(s.labels)...)
+
+	return nil
+}
+
+func (b *Bridge) publish(ctx context.Context, e exposition) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	histograms := map[string]*histogramSeries{}
+	order := []string{}
+	errs := []error{}
+
+	for _, s := range e.samples {
+		family, suffix := familyOf(e.types, s.name)
+
+		switch e.types[family] {
+		case "counter":
+			errs = append(errs, b.addCounter(ctx, s))
+		case "summary":
+			if suffix == "" {
+				errs = append(errs, b.recordGauge(ctx, s))
+			} else {
+				errs = append(errs, b.addCounter(ctx, s))
+			}
+		case "histogram":
+			labels := slices.DeleteFunc(slices.Clone(s.labels), func(l label) bool { return l.name == "le" })
+			key := seriesKey(family, labels)
+
+			series, ok := histograms[key]
+			if !ok {
+				series = &histogramSeries{name: family, labels: labels}
+				histograms[key] = series
+				order = append(order, key)
+			}
+
+			switch suffix {
+			case "_bucket":
+				for _, l := range s.labels {
+					if l.name == "le" {
+						le, err := parseValue(l.value)
+						if err != nil {
+							errs = append(errs, fmt.Errorf("%w: le=%s", errMalformed, strconv.Quote(l.value)))
+							continue
+						}
+
+						series.buckets = append(series.buckets, bucket{le: le, count: s.value})
+					}
+				}
+			case "_sum":
+				series.sum = s.value
+			case "_count":
+				series.count = s.value
+			}
+		default:
+			errs = append(errs, b.recordGauge(ctx, s))
+		}
+	}
+
+	for _, key := range order {
+		series := histograms[key]
+
+		histogram, err := instrument(b.histograms, b.config.prefix+series.name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		errs = append(errs, histogram.Set(ctx, series.summary(), toAttrs(series.labels)...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Scrape fetches the endpoint once and republishes its samples.
+func (b *Bridge) Scrape(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+
+	resp, err := b.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", errScrapeStatus, resp.Status)
+	}
+
+	e, err := parseExposition(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(ctx, e)
+}
+
+// Run scrapes the endpoint every interval until ctx is cancelled. Failed scrapes are logged as warnings.
+func (b *Bridge) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Scrape(ctx); err != nil && ctx.Err() == nil {
+			log.Warn(ctx, "prometheus scrape failed", attribute.New("endpoint", b.endpoint), attribute.New("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
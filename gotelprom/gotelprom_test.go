@@ -0,0 +1,102 @@
+//go:build !gotel_disabled
+
+package gotelprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var reader = sdkmetric.NewManualReader(sdkmetric.WithProducer(metrics.PreAggregatedProducer()))
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+const exposition1 = `# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",path="/a \"b\""} 10
+# TYPE queue_depth gauge
+queue_depth 3
+# TYPE request_seconds histogram
+request_seconds_bucket{le="0.1"} 2
+request_seconds_bucket{le="1"} 5
+request_seconds_bucket{le="+Inf"} 6
+request_seconds_sum 4.5
+request_seconds_count 6
+`
+
+func TestParseSample(t *testing.T) {
+	s, err := parseSample(`http_requests_total{code="200",path="/a \"b\""} 10 1700000000`)
+	require.NoError(t, err)
+	assert.Equal(t, "http_requests_total", s.name)
+	assert.Equal(t, []label{{"code", "200"}, {"path", `/a "b"`}}, s.labels)
+	assert.InDelta(t, 10.0, s.value, 0.001)
+
+	_, err = parseSample(`broken{code="200"`)
+	require.ErrorIs(t, err, errMalformed)
+}
+
+func TestScrape(t *testing.T) {
+	body := exposition1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	bridge := New(server.URL, WithPrefix("app."))
+	require.NoError(t, bridge.Scrape(t.Context()))
+
+	body = strings.Replace(exposition1, "} 10", "} 15", 1)
+	require.NoError(t, bridge.Scrape(t.Context()))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	counter := metrictest.Find(rm, "app.http_requests_total")
+	require.NotNil(t, counter, "app.http_requests_total not found")
+
+	sum, ok := counter.Data.(metricdata.Sum[float64])
+	require.True(t, ok, "expected Sum[float64], got %T", counter.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.InDelta(t, 15.0, sum.DataPoints[0].Value, 0.001, "counter should track the scraped total")
+
+	gauge := metrictest.Find(rm, "app.queue_depth")
+	require.NotNil(t, gauge, "app.queue_depth not found")
+
+	histogram := metrictest.Find(rm, "app.request_seconds")
+	require.NotNil(t, histogram, "app.request_seconds not found")
+
+	hist, ok := histogram.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", histogram.Data)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, []float64{0.1, 1}, hist.DataPoints[0].Bounds)
+	assert.Equal(t, []uint64{2, 3, 1}, hist.DataPoints[0].BucketCounts)
+	assert.Equal(t, uint64(6), hist.DataPoints[0].Count)
+}
+
+func TestScrape_Status(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	require.ErrorIs(t, New(server.URL).Scrape(t.Context()), errScrapeStatus)
+}
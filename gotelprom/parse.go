@@ -0,0 +1,152 @@
+package gotelprom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errMalformed = errors.New("malformed prometheus sample")
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	name   string
+	labels []label
+	value  float64
+}
+
+type exposition struct {
+	types   map[string]string
+	samples []sample
+}
+
+// parseExposition parses the Prometheus text exposition format.
+func parseExposition(r io.Reader) (exposition, error) {
+	e := exposition{types: map[string]string{}}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				e.types[fields[2]] = fields[3]
+			}
+
+			continue
+		}
+
+		s, err := parseSample(line)
+		if err != nil {
+			return exposition{}, err
+		}
+
+		e.samples = append(e.samples, s)
+	}
+
+	return e, scanner.Err()
+}
+
+// parseSample parses a line in the format name{label="value",...} value [timestamp].
+func parseSample(line string) (sample, error) {
+	s := sample{}
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return sample{}, fmt.Errorf("%w: %s", errMalformed, line)
+	}
+
+	s.name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseLabels(rest[1:])
+		if err != nil {
+			return sample{}, fmt.Errorf("%w: %s", err, line)
+		}
+
+		s.labels = labels
+		rest = remaining
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample{}, fmt.Errorf("%w: %s", errMalformed, line)
+	}
+
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return sample{}, fmt.Errorf("%w: %s", errMalformed, line)
+	}
+
+	s.value = value
+
+	return s, nil
+}
+
+func parseValue(value string) (float64, error) {
+	switch value {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	default:
+		return strconv.ParseFloat(value, 64)
+	}
+}
+
+// parseLabels parses the labels after the opening brace and returns the text after the closing brace.
+func parseLabels(text string) ([]label, string, error) {
+	labels := []label{}
+
+	for {
+		text = strings.TrimLeft(text, " ,")
+		if strings.HasPrefix(text, "}") {
+			return labels, text[1:], nil
+		}
+
+		name, rest, ok := strings.Cut(text, "=")
+		if !ok || !strings.HasPrefix(rest, `"`) {
+			return nil, "", errMalformed
+		}
+
+		value := strings.Builder{}
+		i := 1
+
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+
+				switch rest[i] {
+				case 'n':
+					_ = value.WriteByte('\n')
+				default:
+					_ = value.WriteByte(rest[i])
+				}
+
+				continue
+			}
+
+			_ = value.WriteByte(rest[i])
+		}
+
+		if i >= len(rest) {
+			return nil, "", errMalformed
+		}
+
+		labels = append(labels, label{name: strings.TrimSpace(name), value: value.String()})
+		text = rest[i+1:]
+	}
+}
@@ -0,0 +1,163 @@
+// Package gotelruntime reports Go garbage collector settings and memory use, to help diagnose behaviour close to
+// an out-of-memory kill. It records GOGC, GOMEMLIMIT, and the memory they govern as metrics, logs when the settings
+// change, and warns when memory use comes within a configurable percentage of GOMEMLIMIT.
+//
+//	go gotelruntime.Run(ctx, 15*time.Second)
+package gotelruntime
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"strconv"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	gotelmetrics "github.com/tinybluerobots/gotel/metrics"
+)
+
+const (
+	scopeName = "github.com/tinybluerobots/gotel/gotelruntime"
+
+	gogcMetric     = "/gc/gogc:percent"
+	memLimitMetric = "/gc/gomemlimit:bytes"
+	totalMetric    = "/memory/classes/total:bytes"
+	releasedMetric = "/memory/classes/heap/released:bytes"
+)
+
+type runtimeMetrics struct {
+	GoConfigGogc  *gotelmetrics.Int64Gauge `metric:"go.config.gogc" unit:"%" description:"Heap size target percentage configured by GOGC."`
+	GoMemoryLimit *gotelmetrics.Int64Gauge `metric:"go.memory.limit" unit:"By" description:"Go runtime memory limit configured by GOMEMLIMIT."`
+	GoMemoryUsed  *gotelmetrics.Int64Gauge `metric:"go.memory.used" unit:"By" description:"Memory used by the Go runtime, as governed by GOMEMLIMIT."`
+}
+
+var getMetrics = gotelmetrics.Scoped[runtimeMetrics](scopeName)
+
+type config struct {
+	warnPercent float64
+}
+
+// Option configures Run.
+type Option func(*config)
+
+// WithWarnPercent sets how close to GOMEMLIMIT, as a percentage of the limit, memory use must come to log a warning.
+// The default is 10.
+func WithWarnPercent(percent float64) Option {
+	return func(c *config) {
+		c.warnPercent = percent
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{warnPercent: 10}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+type snapshot struct {
+	gogc     int64
+	memLimit int64
+	used     int64
+}
+
+func (s snapshot) limited() bool {
+	return s.memLimit > 0 && s.memLimit < math.MaxInt64
+}
+
+func read() snapshot {
+	samples := []metrics.Sample{{Name: gogcMetric}, {Name: memLimitMetric}, {Name: totalMetric}, {Name: releasedMetric}}
+	metrics.Read(samples)
+
+	value := func(sample metrics.Sample) int64 {
+		if sample.Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+
+		return int64(min(sample.Value.Uint64(), math.MaxInt64))
+	}
+
+	return snapshot{
+		gogc:     value(samples[0]),
+		memLimit: value(samples[1]),
+		used:     value(samples[2]) - value(samples[3]),
+	}
+}
+
+func formatLimit(limit int64) string {
+	if limit >= math.MaxInt64 {
+		return "off"
+	}
+
+	return strconv.FormatInt(limit, 10)
+}
+
+// ResourceAttributes returns the GOGC and GOMEMLIMIT settings at startup, to add to the resource attributes passed to Init.
+func ResourceAttributes() []attribute.Attr {
+	s := read()
+
+	return []attribute.Attr{
+		attribute.New("go.config.gogc", s.gogc),
+		attribute.New("go.config.gomemlimit", formatLimit(s.memLimit)),
+	}
+}
+
+type monitor struct {
+	config  *config
+	last    snapshot
+	started bool
+	warned  bool
+}
+
+func (m *monitor) check(ctx context.Context, s snapshot) {
+	instruments := getMetrics()
+	instruments.GoConfigGogc.Record(ctx, s.gogc)
+	instruments.GoMemoryUsed.Record(ctx, s.used)
+
+	if s.limited() {
+		instruments.GoMemoryLimit.Record(ctx, s.memLimit)
+	}
+
+	if m.started && (s.gogc != m.last.gogc || s.memLimit != m.last.memLimit) {
+		log.Info(ctx, "go runtime settings changed",
+			attribute.New("go.config.gogc", s.gogc),
+			attribute.New("go.config.gomemlimit", formatLimit(s.memLimit)),
+		)
+	}
+
+	m.last = s
+	m.started = true
+
+	near := s.limited() && float64(s.used) >= float64(s.memLimit)*(1-m.config.warnPercent/100)
+
+	// Warn once each time memory use crosses the threshold
+	if near && !m.warned {
+		log.Warn(ctx, "go memory use is close to GOMEMLIMIT",
+			attribute.New("go.memory.used", s.used),
+			attribute.New("go.memory.limit", s.memLimit),
+		)
+	}
+
+	m.warned = near
+}
+
+// Run records the runtime settings and memory use every interval until ctx is cancelled.
+func Run(ctx context.Context, interval time.Duration, options ...Option) {
+	m := &monitor{config: newConfig(options...)}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx, read())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+//go:build !gotel_disabled
+
+package gotelruntime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	reader = sdkmetric.NewManualReader()
+	buf    = &bytes.Buffer{}
+)
+
+// TestMain initializes metrics and logging once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	handler, err := log.NewJSONHandler(buf, resourceAttrs, "INFO")
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := log.InitLogger(context.Background(), resourceAttrs, handler); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func logMessages(t *testing.T) []string {
+	t.Helper()
+
+	messages := []string{}
+
+	for line := range bytes.Lines(buf.Bytes()) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+
+		msg, _ := entry["msg"].(string)
+		messages = append(messages, msg)
+	}
+
+	buf.Reset()
+
+	return messages
+}
+
+func TestRead(t *testing.T) {
+	s := read()
+
+	assert.Positive(t, s.used)
+	assert.Positive(t, s.memLimit)
+}
+
+func TestCheck_Metrics(t *testing.T) {
+	m := &monitor{config: newConfig()}
+	m.check(t.Context(), snapshot{gogc: 100, memLimit: 1000, used: 100})
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	for name, expected := range map[string]int64{"go.config.gogc": 100, "go.memory.limit": 1000, "go.memory.used": 100} {
+		metric := metrictest.Find(rm, name)
+		require.NotNil(t, metric, name)
+
+		gauge, ok := metric.Data.(metricdata.Gauge[int64])
+		require.True(t, ok, name)
+		require.Len(t, gauge.DataPoints, 1, name)
+		assert.Equal(t, expected, gauge.DataPoints[0].Value, name)
+	}
+
+	logMessages(t)
+}
+
+func TestCheck_Logs(t *testing.T) {
+	logMessages(t)
+
+	m := &monitor{config: newConfig(WithWarnPercent(20))}
+
+	m.check(t.Context(), snapshot{gogc: 100, memLimit: 1000, used: 100})
+	assert.Empty(t, logMessages(t), "first check should not log")
+
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: 1000, used: 100})
+	assert.Equal(t, []string{"go runtime settings changed"}, logMessages(t))
+
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: 1000, used: 850})
+	assert.Equal(t, []string{"go memory use is close to GOMEMLIMIT"}, logMessages(t))
+
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: 1000, used: 900})
+	assert.Empty(t, logMessages(t), "should warn once per crossing")
+
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: 1000, used: 100})
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: 1000, used: 900})
+	assert.Equal(t, []string{"go memory use is close to GOMEMLIMIT"}, logMessages(t))
+
+	m.check(t.Context(), snapshot{gogc: 50, memLimit: math.MaxInt64, used: 900})
+	assert.Equal(t, []string{"go runtime settings changed"}, logMessages(t), "should not warn without a limit")
+}
+
+func TestResourceAttributes(t *testing.T) {
+	attrs := ResourceAttributes()
+
+	require.Len(t, attrs, 2)
+	assert.Equal(t, "go.config.gogc", string(attrs[0].Key))
+	assert.Equal(t, "go.config.gomemlimit", string(attrs[1].Key))
+}
@@ -0,0 +1,312 @@
+// Package gotelstatsd bridges StatsD and DogStatsD metrics into OpenTelemetry, for legacy components
+// that can't be instrumented directly. It listens for UDP packets and records each metric through the metrics package.
+//
+// Counters become Float64Counters, gauges Float64Gauges, and timers, histograms, and distributions Float64Histograms.
+// DogStatsD tags become attributes. Sets aren't supported and are dropped.
+//
+//	bridge := gotelstatsd.New(gotelstatsd.WithPrefix("legacy."))
+//	go bridge.ListenAndServe(ctx, ":8125")
+package gotelstatsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	scopeName     = "github.com/tinybluerobots/gotel/gotelstatsd"
+	maxPacketSize = 65535
+
+	// DefaultMaxMetrics is the default number of metric names bridged for each of counters, gauges, and histograms.
+	DefaultMaxMetrics = 1000
+	// DefaultMaxGaugeSeries is the default number of gauge name and tag combinations whose last value is kept.
+	DefaultMaxGaugeSeries = 10000
+)
+
+var (
+	errMalformed       = errors.New("malformed statsd line")
+	errUnsupportedType = errors.New("unsupported statsd metric type")
+	errTooManyMetrics  = errors.New("statsd metric limit reached")
+	errTooManySeries   = errors.New("statsd gauge series limit reached")
+)
+
+type bridgeMetrics struct {
+	StatsdLinesDropped *metrics.Int64Counter
+}
+
+var getMetrics = metrics.Scoped[bridgeMetrics](scopeName)
+
+type config struct {
+	prefix         string
+	maxMetrics     int
+	maxGaugeSeries int
+}
+
+// Option configures a Bridge.
+type Option func(*config)
+
+// WithPrefix prepends a prefix to every bridged metric name.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithMaxMetrics sets the number of metric names bridged for each of counters, gauges, and histograms,
+// DefaultMaxMetrics by default. Packets come from the network, so lines for further names are dropped
+// and counted in statsd_lines_dropped rather than creating instruments without bound.
+func WithMaxMetrics(n int) Option {
+	return func(c *config) {
+		c.maxMetrics = n
+	}
+}
+
+// WithMaxGaugeSeries sets the number of gauge name and tag combinations whose last value is kept for relative
+// updates, DefaultMaxGaugeSeries by default. Lines for further combinations are dropped and counted in
+// statsd_lines_dropped.
+func WithMaxGaugeSeries(n int) Option {
+	return func(c *config) {
+		c.maxGaugeSeries = n
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{maxMetrics: DefaultMaxMetrics, maxGaugeSeries: DefaultMaxGaugeSeries}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+type sample struct {
+	name       string
+	value      float64
+	relative   bool
+	metricType string
+	sampleRate float64
+	attrs      []attribute.Attr
+}
+
+// parseLine parses a line in the format name:value|type|@rate|#tag:value,tag.
+func parseLine(line string) (sample, error) {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" {
+		return sample{}, errMalformed
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return sample{}, errMalformed
+	}
+
+	s := sample{name: name, metricType: fields[1], sampleRate: 1}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("%w: %w", errMalformed, err)
+	}
+
+	s.value = value
+	s.relative = strings.HasPrefix(fields[0], "+") || strings.HasPrefix(fields[0], "-")
+
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			rate, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return sample{}, errMalformed
+			}
+
+			s.sampleRate = rate
+		case strings.HasPrefix(field, "#"):
+			for tag := range strings.SplitSeq(field[1:], ",") {
+				key, tagValue, _ := strings.Cut(tag, ":")
+				if key != "" {
+					s.attrs = append(s.attrs, attribute.New(key, tagValue))
+				}
+			}
+		}
+	}
+
+	return s, nil
+}
+
+type gaugeKey struct {
+	name string
+	tags string
+}
+
+// Bridge converts StatsD metrics into OpenTelemetry instruments.
+type Bridge struct {
+	config *config
+
+	mu         sync.Mutex
+	counters   map[string]*metrics.Float64Counter
+	gauges     map[string]*metrics.Float64Gauge
+	histograms map[string]*metrics.Float64Histogram
+	lastGauges map[gaugeKey]float64
+}
+
+// New creates a Bridge. Instruments are created on first use, under the gotelstatsd instrumentation scope.
+func New(options ...Option) *Bridge {
+	return &Bridge{
+		config:     newConfig(options...),
+		counters:   map[string]*metrics.Float64Counter{},
+		gauges:     map[string]*metrics.Float64Gauge{},
+		histograms: map[string]*metrics.Float64Histogram{},
+		lastGauges: map[gaugeKey]float64{},
+	}
+}
+
+func instrument[T any](instruments map[string]*T, limit int, name string, options ...metric.InstrumentOption) (*T, error) {
+	if inst, ok := instruments[name]; ok {
+		return inst, nil
+	}
+
+	if len(instruments) >= limit {
+		return nil, fmt.Errorf("%w: %s", errTooManyMetrics, name)
+	}
+
+	inst, err := metrics.New[T](scopeName, name, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments[name] = inst
+
+	return inst, nil
+}
+
+func tagKey(attrs []attribute.Attr) string {
+	sb := strings.Builder{}
+
+	for _, attr := range attrs {
+		_, _ = sb.WriteString(string(attr.Key) + "=" + attr.Value.Emit() + ",")
+	}
+
+	return sb.String()
+}
+
+func (b *Bridge) record(ctx context.Context, s sample) error {
+	name := b.config.prefix + s.name
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch s.metricType {
+	case "c":
+		counter, err := instrument(b.counters, b.config.maxMetrics, name)
+		if err != nil {
+			return err
+		}
+
+		// Negative counts can't be recorded by a monotonic counter
+		if s.value >= 0 {
+			counter.Add(ctx, s.value/s.sampleRate, s.attrs...)
+		}
+	case "g":
+		gauge, err := instrument(b.gauges, b.config.maxMetrics, name)
+		if err != nil {
+			return err
+		}
+
+		key := gaugeKey{name: name, tags: tagKey(s.attrs)}
+
+		last, ok := b.lastGauges[key]
+		if !ok && len(b.lastGauges) >= b.config.maxGaugeSeries {
+			return fmt.Errorf("%w: %s", errTooManySeries, name)
+		}
+
+		if s.relative {
+			s.value += last
+		}
+
+		b.lastGauges[key] = s.value
+		gauge.Record(ctx, s.value, s.attrs...)
+	case "ms", "h", "d":
+		options := []metric.InstrumentOption{}
+		if s.metricType == "ms" {
+			options = append(options, metric.WithUnit("ms"))
+		}
+
+		histogram, err := instrument(b.histograms, b.config.maxMetrics, name, options...)
+		if err != nil {
+			return err
+		}
+
+		histogram.Record(ctx, s.value, s.attrs...)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedType, s.metricType)
+	}
+
+	return nil
+}
+
+// HandlePacket records each newline-separated metric in a packet.
+// Lines that can't be parsed are dropped and counted in statsd_lines_dropped.
+func (b *Bridge) HandlePacket(ctx context.Context, packet []byte) {
+	for line := range strings.SplitSeq(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		s, err := parseLine(line)
+		if err == nil {
+			err = b.record(ctx, s)
+		}
+
+		if err != nil {
+			getMetrics().StatsdLinesDropped.Add(ctx, 1)
+			log.Debug(ctx, "dropped statsd line", attribute.New("line", line), attribute.New("error", err.Error()))
+		}
+	}
+}
+
+// ListenAndServe listens for StatsD packets on a UDP address, such as ":8125", until ctx is cancelled.
+func (b *Bridge) ListenAndServe(ctx context.Context, addr string) error {
+	conn, err := (&net.ListenConfig{}).ListenPacket(ctx, "udp", addr)
+	if err != nil {
+		return err
+	}
+
+	return b.Serve(ctx, conn)
+}
+
+// Serve reads StatsD packets from conn until ctx is cancelled or a read fails, and closes conn when it returns.
+func (b *Bridge) Serve(ctx context.Context, conn net.PacketConn) error {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	buf := make([]byte, maxPacketSize)
+
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		b.HandlePacket(ctx, buf[:n])
+	}
+}
@@ -0,0 +1,156 @@
+//go:build !gotel_disabled
+
+package gotelstatsd
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var reader = sdkmetric.NewManualReader()
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func collect(t *testing.T) metricdata.ResourceMetrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	return rm
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected sample
+		wantErr  bool
+	}{
+		{"Counter", "requests:1|c", sample{name: "requests", value: 1, metricType: "c", sampleRate: 1}, false},
+		{"Sample rate", "requests:1|c|@0.5", sample{name: "requests", value: 1, metricType: "c", sampleRate: 0.5}, false},
+		{"Relative gauge", "queue:-2|g", sample{name: "queue", value: -2, relative: true, metricType: "g", sampleRate: 1}, false},
+		{
+			"DogStatsD tags", "latency:12|ms|#env:prod,canary",
+			sample{name: "latency", value: 12, metricType: "ms", sampleRate: 1, attrs: []attribute.Attr{attribute.New("env", "prod"), attribute.New("canary", "")}},
+			false,
+		},
+		{"Missing type", "requests:1", sample{}, true},
+		{"Invalid value", "requests:x|c", sample{}, true},
+		{"Invalid rate", "requests:1|c|@2", sample{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseLine(tt.line)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errMalformed)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, s)
+		})
+	}
+}
+
+func TestHandlePacket(t *testing.T) {
+	bridge := New(WithPrefix("legacy."))
+
+	bridge.HandlePacket(t.Context(), []byte("requests:1|c|@0.5\nqueue:10|g\nqueue:-3|g\nlatency:12|ms|#env:prod\nusers:42|s\nbroken"))
+
+	rm := collect(t)
+
+	counter := metrictest.Find(rm, "legacy.requests")
+	require.NotNil(t, counter, "legacy.requests not found")
+
+	sum, ok := counter.Data.(metricdata.Sum[float64])
+	require.True(t, ok, "expected Sum[float64], got %T", counter.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.InDelta(t, 2.0, sum.DataPoints[0].Value, 0.001, "counts should be scaled by the sample rate")
+
+	gauge := metrictest.Find(rm, "legacy.queue")
+	require.NotNil(t, gauge, "legacy.queue not found")
+
+	gaugeData, ok := gauge.Data.(metricdata.Gauge[float64])
+	require.True(t, ok, "expected Gauge[float64], got %T", gauge.Data)
+	require.Len(t, gaugeData.DataPoints, 1)
+	assert.InDelta(t, 7.0, gaugeData.DataPoints[0].Value, 0.001, "relative gauges should adjust the last value")
+
+	histogram := metrictest.Find(rm, "legacy.latency")
+	require.NotNil(t, histogram, "legacy.latency not found")
+	assert.Equal(t, "ms", histogram.Unit)
+
+	dropped := metrictest.Find(rm, "statsd_lines_dropped")
+	require.NotNil(t, dropped, "statsd_lines_dropped not found")
+
+	droppedSum, ok := dropped.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", dropped.Data)
+	assert.Equal(t, int64(2), droppedSum.DataPoints[0].Value, "sets and malformed lines should be dropped")
+}
+
+func TestRecord_Limits(t *testing.T) {
+	bridge := New(WithPrefix("limited."), WithMaxMetrics(1), WithMaxGaugeSeries(1))
+
+	record := func(line string) error {
+		s, err := parseLine(line)
+		require.NoError(t, err)
+
+		return bridge.record(t.Context(), s)
+	}
+
+	require.NoError(t, record("first:1|c"))
+	require.NoError(t, record("first:1|c"), "known names should still be recorded at the limit")
+	require.ErrorIs(t, record("second:1|c"), errTooManyMetrics)
+
+	require.NoError(t, record("depth:1|g|#queue:a"))
+	require.NoError(t, record("depth:+1|g|#queue:a"))
+	require.ErrorIs(t, record("depth:1|g|#queue:b"), errTooManySeries)
+}
+
+func TestServe(t *testing.T) {
+	conn, err := (&net.ListenConfig{}).ListenPacket(t.Context(), "udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- New().Serve(ctx, conn)
+	}()
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+
+	defer client.Close()
+
+	_, err = client.Write([]byte("served:1|c"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return metrictest.Find(collect(t), "served") != nil
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
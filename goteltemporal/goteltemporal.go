@@ -0,0 +1,118 @@
+// Package goteltemporal provides the tracing and metrics core for Temporal workflow and activity interceptors.
+// Workflow code must be deterministic and can't call the tracing package directly, so trace context is carried
+// in workflow headers and spans and metrics are recorded by interceptors running outside workflow code.
+//
+// The package has no dependency on the Temporal SDK; the temporalinterceptor module is the interceptor built on it.
+// A client outbound interceptor stores InjectHeaders(ctx) in the workflow header, the workflow outbound interceptor
+// copies the header to activities, and the activity inbound interceptor wraps ExecuteActivity with StartActivity.
+// The workflow inbound interceptor calls RecordWorkflow when ExecuteWorkflow returns and workflow.IsReplaying is false.
+package goteltemporal
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/goteltemporal"
+
+// WorkflowInfo identifies a workflow execution.
+type WorkflowInfo struct {
+	WorkflowType string
+	WorkflowID   string
+	RunID        string
+	TaskQueue    string
+}
+
+// ActivityInfo identifies an activity execution.
+type ActivityInfo struct {
+	WorkflowInfo
+
+	ActivityType string
+	ActivityID   string
+	Attempt      int
+}
+
+type temporalMetrics struct {
+	TemporalWorkflowDuration *metrics.Float64Histogram `unit:"s"`
+	TemporalActivityDuration *metrics.Float64Histogram `unit:"s"`
+}
+
+var getMetrics = metrics.Scoped[temporalMetrics](scopeName)
+
+func (w WorkflowInfo) attributes() []attribute.Attr {
+	return []attribute.Attr{
+		attribute.New("temporal.workflow.type", w.WorkflowType),
+		attribute.New("temporal.workflow.id", w.WorkflowID),
+		attribute.New("temporal.run.id", w.RunID),
+		attribute.New("temporal.task_queue", w.TaskQueue),
+	}
+}
+
+func (a ActivityInfo) attributes() []attribute.Attr {
+	return append(a.WorkflowInfo.attributes(),
+		attribute.New("temporal.activity.type", a.ActivityType),
+		attribute.New("temporal.activity.id", a.ActivityID),
+		attribute.New("temporal.attempt", a.Attempt),
+	)
+}
+
+func outcome(err error) attribute.Attr {
+	if err != nil {
+		return attribute.New("outcome", "error")
+	}
+
+	return attribute.New("outcome", "ok")
+}
+
+// InjectHeaders returns the trace context of ctx for storing in a workflow or activity header.
+func InjectHeaders(ctx context.Context) map[string]string {
+	return tracing.TraceHeaders(ctx)
+}
+
+// StartActivity starts an activity span as a child of the trace context propagated in the activity header.
+// The returned function ends the span, recording err and the activity duration.
+func StartActivity(ctx context.Context, headers map[string]string, info ActivityInfo) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.NewChildSpan(ctx, headers, "RunActivity:"+info.ActivityType, info.attributes()...)
+
+	end := func(err error) {
+		if err != nil {
+			span.RecordErrorAndSetStatus(err)
+		}
+
+		span.End()
+
+		getMetrics().TemporalActivityDuration.Record(ctx, time.Since(start).Seconds(),
+			attribute.New("temporal.activity.type", info.ActivityType),
+			attribute.New("temporal.task_queue", info.TaskQueue),
+			outcome(err),
+		)
+	}
+
+	return ctx, end
+}
+
+// RecordWorkflow records the duration of a completed workflow execution in seconds.
+// Call it only when the workflow isn't replaying, or replays will be counted again.
+func RecordWorkflow(ctx context.Context, info WorkflowInfo, duration time.Duration, err error) {
+	getMetrics().TemporalWorkflowDuration.Record(ctx, duration.Seconds(),
+		attribute.New("temporal.workflow.type", info.WorkflowType),
+		attribute.New("temporal.task_queue", info.TaskQueue),
+		outcome(err),
+	)
+
+	if err != nil {
+		log.Warn(ctx, "workflow failed",
+			attribute.New("temporal.workflow.type", info.WorkflowType),
+			attribute.New("temporal.workflow.id", info.WorkflowID),
+			attribute.New("temporal.run.id", info.RunID),
+			attribute.New("error", err.Error()),
+			attribute.New("duration_ms", duration.Milliseconds()),
+		)
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !gotel_disabled
+
+package goteltemporal
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	exporter = tracetest.NewInMemoryExporter()
+	reader   = sdkmetric.NewManualReader()
+)
+
+// TestMain initializes tracing and metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := tracing.InitTracing(ctx, "test-service", resourceAttrs, sdktrace.WithSyncer(exporter)); err != nil {
+		panic(err)
+	}
+
+	if _, err := metrics.InitMetrics[struct{}](ctx, "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestStartActivity(t *testing.T) {
+	exporter.Reset()
+
+	ctx, parent := tracing.NewSpan(t.Context(), "start-workflow")
+	headers := InjectHeaders(ctx)
+	parent.End()
+
+	info := ActivityInfo{
+		WorkflowInfo: WorkflowInfo{WorkflowType: "OrderWorkflow", WorkflowID: "order-1", RunID: "run-1", TaskQueue: "orders"},
+		ActivityType: "ChargeCard",
+		ActivityID:   "1",
+		Attempt:      2,
+	}
+
+	_, end := StartActivity(t.Context(), headers, info)
+	end(assert.AnError)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2, "expected 2 spans")
+
+	activity := spans[1]
+	assert.Equal(t, "RunActivity:ChargeCard", activity.Name)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), activity.SpanContext.TraceID(), "activity should continue the workflow trace")
+	assert.Equal(t, "Error", activity.Status.Code.String())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := metrictest.Find(rm, "temporal_activity_duration")
+	require.NotNil(t, metric, "activity duration metric not found")
+	assert.Equal(t, "s", metric.Unit)
+
+	hist, ok := metric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", metric.Data)
+	assert.NotEmpty(t, hist.DataPoints, "no data points recorded")
+}
+
+func TestRecordWorkflow(t *testing.T) {
+	info := WorkflowInfo{WorkflowType: "OrderWorkflow", WorkflowID: "order-1", RunID: "run-1", TaskQueue: "orders"}
+
+	RecordWorkflow(t.Context(), info, 2*time.Second, nil)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := metrictest.Find(rm, "temporal_workflow_duration")
+	require.NotNil(t, metric, "workflow duration metric not found")
+	assert.Equal(t, "s", metric.Unit)
+
+	hist, ok := metric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", metric.Data)
+	require.NotEmpty(t, hist.DataPoints, "no data points recorded")
+	assert.InDelta(t, 2.0, hist.DataPoints[0].Sum, 0.001)
+}
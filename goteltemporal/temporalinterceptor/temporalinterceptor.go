@@ -0,0 +1,195 @@
+// Package temporalinterceptor is a Temporal interceptor built on goteltemporal. It propagates trace context from
+// the client through workflow headers to activities, creates a span for each activity execution, and records
+// workflow and activity durations:
+//
+//	c, err := client.Dial(client.Options{Interceptors: []interceptor.ClientInterceptor{temporalinterceptor.New()}})
+//
+// Workers created from the client use the interceptor too. It is a separate module so that the gotel module
+// doesn't depend on the Temporal SDK.
+package temporalinterceptor
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/goteltemporal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+// HeaderKey is the workflow and activity header that carries the trace context.
+const HeaderKey = "gotel-trace"
+
+// Interceptor is a client and worker interceptor.
+type Interceptor struct {
+	interceptor.InterceptorBase
+}
+
+var _ interceptor.Interceptor = (*Interceptor)(nil)
+
+// New returns an interceptor for client.Options.Interceptors or worker.Options.Interceptors.
+func New() *Interceptor {
+	return &Interceptor{}
+}
+
+// writeHeader stores the trace context in a header.
+func writeHeader(header map[string]*commonpb.Payload, traceHeaders map[string]string) error {
+	if header == nil || len(traceHeaders) == 0 {
+		return nil
+	}
+
+	payload, err := converter.GetDefaultDataConverter().ToPayload(traceHeaders)
+	if err != nil {
+		return err
+	}
+
+	header[HeaderKey] = payload
+
+	return nil
+}
+
+// readHeader returns the trace context stored in a header, or nil if there is none.
+func readHeader(header map[string]*commonpb.Payload) map[string]string {
+	payload, ok := header[HeaderKey]
+	if !ok {
+		return nil
+	}
+
+	var traceHeaders map[string]string
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &traceHeaders); err != nil {
+		return nil
+	}
+
+	return traceHeaders
+}
+
+// InterceptClient stores the trace context of the caller in the header of the workflows it starts.
+func (i *Interceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	return &clientOutbound{ClientOutboundInterceptorBase: interceptor.ClientOutboundInterceptorBase{Next: next}}
+}
+
+type clientOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+}
+
+func (c *clientOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (client.WorkflowRun, error) {
+	if err := writeHeader(interceptor.Header(ctx), goteltemporal.InjectHeaders(ctx)); err != nil {
+		return nil, err
+	}
+
+	return c.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (c *clientOutbound) SignalWithStartWorkflow(ctx context.Context, in *interceptor.ClientSignalWithStartWorkflowInput) (client.WorkflowRun, error) {
+	if err := writeHeader(interceptor.Header(ctx), goteltemporal.InjectHeaders(ctx)); err != nil {
+		return nil, err
+	}
+
+	return c.Next.SignalWithStartWorkflow(ctx, in)
+}
+
+// InterceptActivity runs each activity in a span that continues the trace in the activity header.
+func (i *Interceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityInbound{ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next}}
+}
+
+type activityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (a *activityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (result any, err error) {
+	ctx, end := goteltemporal.StartActivity(ctx, readHeader(interceptor.Header(ctx)), activityInfo(activity.GetInfo(ctx)))
+	defer func() { end(err) }()
+
+	return a.Next.ExecuteActivity(ctx, in)
+}
+
+func activityInfo(info activity.Info) goteltemporal.ActivityInfo {
+	activityInfo := goteltemporal.ActivityInfo{
+		WorkflowInfo: goteltemporal.WorkflowInfo{
+			WorkflowID: info.WorkflowExecution.ID,
+			RunID:      info.WorkflowExecution.RunID,
+			TaskQueue:  info.TaskQueue,
+		},
+		ActivityType: info.ActivityType.Name,
+		ActivityID:   info.ActivityID,
+		Attempt:      int(info.Attempt),
+	}
+
+	if info.WorkflowType != nil {
+		activityInfo.WorkflowType = info.WorkflowType.Name
+	}
+
+	return activityInfo
+}
+
+// InterceptWorkflow records the duration of each workflow execution and copies the trace context in the workflow
+// header to the activities and child workflows it starts.
+func (i *Interceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &workflowInbound{WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next}}
+}
+
+type workflowInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+
+	traceHeaders map[string]string
+}
+
+func (w *workflowInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	return w.Next.Init(&workflowOutbound{
+		WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound},
+		inbound:                         w,
+	})
+}
+
+func (w *workflowInbound) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (any, error) {
+	w.traceHeaders = readHeader(interceptor.WorkflowHeader(ctx))
+
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+
+	// Replayed executions were recorded when they first ran
+	if !workflow.IsReplaying(ctx) {
+		info := workflow.GetInfo(ctx)
+		goteltemporal.RecordWorkflow(context.Background(), goteltemporal.WorkflowInfo{
+			WorkflowType: info.WorkflowType.Name,
+			WorkflowID:   info.WorkflowExecution.ID,
+			RunID:        info.WorkflowExecution.RunID,
+			TaskQueue:    info.TaskQueueName,
+		}, workflow.Now(ctx).Sub(info.WorkflowStartTime), err)
+	}
+
+	return result, err
+}
+
+type workflowOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+
+	inbound *workflowInbound
+}
+
+// propagate copies the trace context of the workflow to the header of an outbound call. Encoding a string map
+// can't fail with the default data converter, and workflow code has no way to handle the error.
+func (w *workflowOutbound) propagate(ctx workflow.Context) {
+	_ = writeHeader(interceptor.WorkflowHeader(ctx), w.inbound.traceHeaders)
+}
+
+func (w *workflowOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...any) workflow.Future {
+	w.propagate(ctx)
+
+	return w.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+func (w *workflowOutbound) ExecuteLocalActivity(ctx workflow.Context, activityType string, args ...any) workflow.Future {
+	w.propagate(ctx)
+
+	return w.Next.ExecuteLocalActivity(ctx, activityType, args...)
+}
+
+func (w *workflowOutbound) ExecuteChildWorkflow(ctx workflow.Context, childWorkflowType string, args ...any) workflow.ChildWorkflowFuture {
+	w.propagate(ctx)
+
+	return w.Next.ExecuteChildWorkflow(ctx, childWorkflowType, args...)
+}
@@ -0,0 +1,66 @@
+package temporalinterceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+)
+
+// setupTestTracer creates a tracer with an in-memory exporter for testing
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func TestHeader_RoundTrip(t *testing.T) {
+	header := map[string]*commonpb.Payload{}
+	traceHeaders := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+
+	require.NoError(t, writeHeader(header, traceHeaders))
+	assert.Contains(t, header, HeaderKey)
+	assert.Equal(t, traceHeaders, readHeader(header))
+}
+
+func TestHeader_Missing(t *testing.T) {
+	assert.Nil(t, readHeader(map[string]*commonpb.Payload{}))
+	require.NoError(t, writeHeader(nil, map[string]string{"traceparent": "x"}))
+}
+
+func greet(_ context.Context, name string) (string, error) {
+	return "hello " + name, nil
+}
+
+func TestInterceptActivity(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	var suite testsuite.WorkflowTestSuite
+
+	env := suite.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{New()}})
+	env.RegisterActivity(greet)
+
+	result, err := env.ExecuteActivity(greet, "gotel")
+	require.NoError(t, err)
+
+	var greeting string
+	require.NoError(t, result.Get(&greeting))
+	assert.Equal(t, "hello gotel", greeting)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "RunActivity:greet", spans[0].Name)
+}
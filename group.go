@@ -0,0 +1,104 @@
+package gotel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+// TaskGroup runs related tasks concurrently like errgroup.Group, tracing each task in a child span of the span
+// the group was created in. Create one with Group.
+type TaskGroup struct {
+	// newTask starts the span of a task in the group's context.
+	newTask func(label string) (context.Context, tracing.Span)
+	cancel  context.CancelCauseFunc
+	parent  tracing.Span
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	tasks  int
+	errs   []error
+	waited bool
+}
+
+// Group returns a TaskGroup for fan-out work in ctx, and a context derived from ctx that is canceled when a task
+// fails or Wait returns. Wait records the group.tasks and group.failed attributes and the errors of failed tasks
+// on the span of ctx, and sets its status to Error if any task failed:
+//
+//	g, ctx := gotel.Group(ctx)
+//	for _, id := range ids {
+//		g.Go("fetch "+id, func(ctx context.Context) error { return fetch(ctx, id) })
+//	}
+//	err := g.Wait()
+func Group(ctx context.Context) (*TaskGroup, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	newTask := func(label string) (context.Context, tracing.Span) {
+		return tracing.NewSpan(ctx, label)
+	}
+
+	return &TaskGroup{newTask: newTask, cancel: cancel, parent: tracing.SpanFromContext(ctx)}, ctx
+}
+
+// Go runs fn in a new goroutine, in a child span named label. An error returned by fn is recorded on the child span
+// and cancels the group's context.
+func (g *TaskGroup) Go(label string, fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	g.tasks++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		ctx, span := g.newTask(label)
+		defer span.End()
+
+		if err := fn(ctx); err != nil {
+			span.RecordErrorAndSetStatus(err)
+
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+
+			g.cancel(err)
+		}
+	}()
+}
+
+// Wait waits for every task to return and returns the first error, if any. The outcome is recorded on the parent
+// span once, with up to 10 task errors.
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var err error
+	if len(g.errs) > 0 {
+		err = g.errs[0]
+	}
+
+	g.cancel(err)
+
+	if g.waited {
+		return err
+	}
+
+	g.waited = true
+
+	g.parent.SetAttributes(attribute.New("group.tasks", g.tasks), attribute.New("group.failed", len(g.errs)))
+
+	for _, taskErr := range g.errs[:min(len(g.errs), maxBatchSampleErrors)] {
+		g.parent.RecordError(taskErr)
+	}
+
+	if err != nil {
+		g.parent.SetStatus(tracing.StatusError, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package gotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+// StartHeartbeat emits a heartbeat span and records 1 on the up gauge now and every interval, giving backends a
+// liveness signal for this instance even when it receives no traffic.
+// The returned function stops the heartbeat and records 0; it also stops when ctx is cancelled.
+func StartHeartbeat(ctx context.Context, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			beat(ctx)
+
+			select {
+			case <-ctx.Done():
+				getMetrics().Up.Record(context.WithoutCancel(ctx), 0)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func beat(ctx context.Context) {
+	_, span := tracing.NewSpan(ctx, "heartbeat")
+	span.End()
+
+	getMetrics().Up.Record(ctx, 1)
+}
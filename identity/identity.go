@@ -0,0 +1,84 @@
+// Package identity attaches user and session attributes, such as enduser.id and session.id, to spans and logs.
+// Register an extractor that reads the identity your authentication middleware stores in the context;
+// its attributes are added to every log record and to spans started from that context.
+//
+// Attributes that identify a person are personal data, so they're dropped unless AllowPII is enabled
+// or their keys are hashed with attribute.HashKeys.
+package identity
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extractor returns the identity attributes for a context, or nil if the request isn't authenticated.
+// It's called for every log record and span, so it should only read values already in the context.
+type Extractor func(ctx context.Context) []attribute.Attr
+
+// PIIKeys lists the attribute keys treated as personal data.
+var PIIKeys = []string{
+	"enduser.id",
+	"user.email",
+	"user.full_name",
+	"user.id",
+	"user.name",
+}
+
+var (
+	extractor atomic.Pointer[Extractor]
+	allowPII  atomic.Bool
+)
+
+// SetExtractor registers the extractor used to enrich spans and logs. Pass nil to remove it.
+func SetExtractor(e Extractor) {
+	if e == nil {
+		extractor.Store(nil)
+		return
+	}
+
+	extractor.Store(&e)
+}
+
+// AllowPII opts in to recording unhashed attributes listed in PIIKeys.
+func AllowPII(allow bool) {
+	allowPII.Store(allow)
+}
+
+// Attributes returns the identity attributes for ctx, without personal data unless it's allowed or hashed.
+func Attributes(ctx context.Context) []attribute.Attr {
+	e := extractor.Load()
+	if e == nil {
+		return nil
+	}
+
+	attrs := (*e)(ctx)
+	if allowPII.Load() {
+		return slices.Clip(attrs)
+	}
+
+	filtered := make([]attribute.Attr, 0, len(attrs))
+
+	for _, attr := range attrs {
+		key := string(attr.Key)
+		if !slices.Contains(PIIKeys, key) || attribute.IsHashed(key) {
+			filtered = append(filtered, attr)
+		}
+	}
+
+	return filtered
+}
+
+// Enrich adds the identity attributes to the current span. Call it from authentication middleware
+// once the identity is known, as the server span is started before authentication runs.
+func Enrich(ctx context.Context) {
+	attrs := Attributes(ctx)
+	if len(attrs) == 0 {
+		return
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.ToKeyValues(attribute.ApplyHashing(attrs))...)
+}
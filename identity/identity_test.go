@@ -0,0 +1,102 @@
+//go:build !gotel_disabled
+
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type userKey struct{}
+
+func setupExtractor(t *testing.T) {
+	t.Helper()
+
+	SetExtractor(func(ctx context.Context) []attribute.Attr {
+		user, ok := ctx.Value(userKey{}).(string)
+		if !ok {
+			return nil
+		}
+
+		return []attribute.Attr{attribute.New("enduser.id", user), attribute.New("session.id", "session-1")}
+	})
+
+	t.Cleanup(func() {
+		SetExtractor(nil)
+		AllowPII(false)
+	})
+}
+
+// findAttr returns the value of the attribute with the given key
+func findAttr(attrs []attribute.Attr, key string) (string, bool) {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value.Emit(), true
+		}
+	}
+
+	return "", false
+}
+
+func TestAttributes(t *testing.T) {
+	assert.Empty(t, Attributes(t.Context()), "no attributes without an extractor")
+
+	setupExtractor(t)
+
+	ctx := context.WithValue(t.Context(), userKey{}, "user-123")
+
+	attrs := Attributes(ctx)
+	_, ok := findAttr(attrs, "enduser.id")
+	assert.False(t, ok, "PII should be dropped by default")
+
+	session, _ := findAttr(attrs, "session.id")
+	assert.Equal(t, "session-1", session)
+
+	AllowPII(true)
+
+	user, _ := findAttr(Attributes(ctx), "enduser.id")
+	assert.Equal(t, "user-123", user)
+}
+
+func TestAttributes_Hashed(t *testing.T) {
+	setupExtractor(t)
+
+	attribute.HashKeys("salt", "enduser.id")
+	t.Cleanup(func() { attribute.HashKeys("") })
+
+	_, ok := findAttr(Attributes(context.WithValue(t.Context(), userKey{}, "user-123")), "enduser.id")
+	assert.True(t, ok, "hashed PII keys should be kept")
+}
+
+func TestEnrich(t *testing.T) {
+	setupExtractor(t)
+	AllowPII(true)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	ctx, span := provider.Tracer("test").Start(t.Context(), "request")
+	Enrich(context.WithValue(ctx, userKey{}, "user-123"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	found := false
+
+	for _, kv := range spans[0].Attributes {
+		if kv.Key == "enduser.id" {
+			found = true
+
+			assert.Equal(t, "user-123", kv.Value.AsString())
+		}
+	}
+
+	assert.True(t, found, "enduser.id should be added to the current span")
+}
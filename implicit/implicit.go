@@ -0,0 +1,116 @@
+// Package implicit is an EXPERIMENTAL goroutine-local context bridge for code that can't pass a context.Context
+// through every call, such as legacy call chains. A context bound to a goroutine with Bind is used by
+// tracing.CurrentSpan, as the parent of spans started without one, and for trace correlation of logs written
+// without one, once Enable(true) has been called.
+//
+// Go has no goroutine-local storage, so goroutines are identified by parsing runtime.Stack, which costs around a
+// microsecond per lookup. Contexts don't follow goroutines started with the go statement; start them with Go.
+// Prefer passing contexts explicitly; the API may change or be removed.
+package implicit
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	enabled  atomic.Bool
+	contexts sync.Map
+)
+
+// Enable turns the bridge on or off. While it is off, Resolve returns its context unchanged and Bind does nothing.
+func Enable(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether the bridge is on.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// goroutineID returns the ID of the calling goroutine from the header of its stack trace, "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf, _ = bytes.CutPrefix(buf, []byte("goroutine "))
+	buf, _, _ = bytes.Cut(buf, []byte(" "))
+
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+
+	return id
+}
+
+// Bind makes ctx the implicit context of the calling goroutine until the returned function is called, which
+// restores the previous one. Always call it, typically deferred, or the context outlives the call chain.
+func Bind(ctx context.Context) func() {
+	if !enabled.Load() {
+		return func() {}
+	}
+
+	id := goroutineID()
+	previous, hadPrevious := contexts.Swap(id, ctx)
+
+	return func() {
+		if hadPrevious {
+			contexts.Store(id, previous)
+		} else {
+			contexts.Delete(id)
+		}
+	}
+}
+
+// Context returns the implicit context of the calling goroutine, or context.Background if none is bound or the
+// bridge is off.
+func Context() context.Context {
+	if !enabled.Load() {
+		return context.Background()
+	}
+
+	if ctx, ok := contexts.Load(goroutineID()); ok {
+		bound, _ := ctx.(context.Context)
+
+		return bound
+	}
+
+	return context.Background()
+}
+
+// Resolve returns ctx with the span and baggage of the implicit context of the calling goroutine, if ctx carries
+// no span of its own. Otherwise, or when the bridge is off, ctx is returned unchanged.
+func Resolve(ctx context.Context) context.Context {
+	if !enabled.Load() || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	bound, ok := contexts.Load(goroutineID())
+	if !ok {
+		return ctx
+	}
+
+	boundCtx, _ := bound.(context.Context)
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(boundCtx))
+
+	if baggage.FromContext(ctx).Len() == 0 {
+		ctx = baggage.ContextWithBaggage(ctx, baggage.FromContext(boundCtx))
+	}
+
+	return ctx
+}
+
+// Go runs fn in a new goroutine with the implicit context of the calling goroutine bound.
+func Go(fn func()) {
+	ctx := Context()
+
+	go func() {
+		defer Bind(ctx)()
+
+		fn()
+	}()
+}
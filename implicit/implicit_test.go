@@ -0,0 +1,82 @@
+package implicit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanContext(id byte) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{id}, SpanID: trace.SpanID{id}}))
+}
+
+func TestBind(t *testing.T) {
+	outer := spanContext(1)
+
+	unbound := Bind(outer)
+	assert.Equal(t, context.Background(), Context(), "Bind does nothing while disabled")
+	unbound()
+
+	Enable(true)
+	t.Cleanup(func() { Enable(false) })
+
+	restore := Bind(outer)
+	assert.Equal(t, outer, Context())
+
+	inner := spanContext(2)
+	restoreInner := Bind(inner)
+	assert.Equal(t, inner, Context())
+
+	restoreInner()
+	assert.Equal(t, outer, Context(), "the previous context is restored")
+
+	restore()
+	assert.Equal(t, context.Background(), Context())
+}
+
+func TestResolve(t *testing.T) {
+	Enable(true)
+	t.Cleanup(func() { Enable(false) })
+
+	member, err := baggage.NewMember("request.id", "req-1")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	bound := baggage.ContextWithBaggage(spanContext(1), bag)
+	defer Bind(bound)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolved := Resolve(ctx)
+	assert.Equal(t, trace.TraceID{1}, trace.SpanContextFromContext(resolved).TraceID())
+	assert.Equal(t, "req-1", baggage.FromContext(resolved).Member("request.id").Value())
+
+	cancel()
+	require.ErrorIs(t, resolved.Err(), context.Canceled, "the explicit context's cancellation is kept")
+
+	explicit := spanContext(2)
+	assert.Equal(t, explicit, Resolve(explicit), "explicit spans take precedence")
+}
+
+func TestGo(t *testing.T) {
+	Enable(true)
+	t.Cleanup(func() { Enable(false) })
+
+	bound := spanContext(1)
+	defer Bind(bound)()
+
+	done := make(chan context.Context)
+
+	Go(func() { done <- Context() })
+	assert.Equal(t, bound, <-done)
+
+	go func() { done <- Context() }()
+	assert.Equal(t, context.Background(), <-done, "plain goroutines don't inherit the context")
+}
@@ -0,0 +1,102 @@
+package gotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/tracing"
+)
+
+func recordFunction(ctx context.Context, name string, start time.Time, outcome string) {
+	getMetrics().FunctionDuration.RecordDuration(ctx, time.Since(start),
+		attribute.New("function.name", name),
+		attribute.New("function.outcome", outcome),
+	)
+}
+
+// Instrument wraps fn so each call runs in a span named name, records the error fn returns on the span, and records
+// its duration in seconds in the function_duration histogram, with function.name and function.outcome (success,
+// error, or panic) attributes. A panic is recorded on the span and the histogram before being re-raised.
+// Wrap constructor outputs to instrument every method of an interface implementation:
+//
+//	getUser := gotel.Instrument("store.GetUser", store.GetUser)
+func Instrument[I any, O any](name string, fn func(context.Context, I) (O, error)) func(context.Context, I) (O, error) {
+	return func(ctx context.Context, input I) (O, error) {
+		ctx, span := tracing.NewSpan(ctx, name)
+		start := time.Now()
+		returned := false
+
+		defer func() {
+			if returned {
+				return
+			}
+
+			// runtime.Goexit also skips the return, without a panic to re-raise
+			r := recover()
+			if r != nil {
+				span.RecordErrorAndSetStatus(panicError(r))
+				recordFunction(ctx, name, start, "panic")
+			}
+
+			span.End()
+
+			if r != nil {
+				panic(r)
+			}
+		}()
+
+		output, err := fn(ctx, input)
+		returned = true
+
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+
+			span.RecordErrorAndSetStatus(err)
+		}
+
+		recordFunction(ctx, name, start, outcome)
+		span.End()
+
+		return output, err
+	}
+}
+
+// StartCall starts a span named name for a call and returns a function to defer with a pointer to the call's error
+// result, or nil for calls without one. That function ends the span, records the duration in the function_duration
+// histogram as Instrument does, and logs a non-nil error. Deferred directly, it also records a panic before
+// re-raising it. Decorators generated by gotel wrap use it for each method:
+//
+//	func (w *InstrumentedStore) GetUser(ctx context.Context, id int) (user *User, err error) {
+//		ctx, end := gotel.StartCall(ctx, "Store.GetUser")
+//		defer end(&err)
+//
+//		return w.next.GetUser(ctx, id)
+//	}
+func StartCall(ctx context.Context, name string) (context.Context, func(*error)) {
+	ctx, span := tracing.NewSpan(ctx, name)
+	start := time.Now()
+
+	return ctx, func(errp *error) {
+		if r := recover(); r != nil {
+			span.RecordErrorAndSetStatus(panicError(r))
+			recordFunction(ctx, name, start, "panic")
+			span.End()
+
+			panic(r)
+		}
+
+		outcome := "success"
+		if errp != nil && *errp != nil {
+			outcome = "error"
+
+			span.RecordErrorAndSetStatus(*errp)
+			log.Error(ctx, *errp, attribute.New("function.name", name))
+		}
+
+		recordFunction(ctx, name, start, outcome)
+		span.End()
+	}
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	handlersMu    sync.Mutex
+	addedHandlers atomic.Pointer[[]slog.Handler]
+)
+
+// AddHandler subscribes a handler to every log record after initialization, e.g. for a plugin or an admin UI
+// streaming logs. It receives records from InitLogger's pipeline, including after re-initialization.
+// A nil handler is ignored.
+func AddHandler(handler slog.Handler) {
+	if handler == nil {
+		return
+	}
+
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	handlers := append(slices.Clone(currentHandlers()), handler)
+	addedHandlers.Store(&handlers)
+}
+
+// RemoveHandler unsubscribes a handler added with AddHandler. Handlers are matched by equality,
+// so pass the same pointer that was added.
+func RemoveHandler(handler slog.Handler) {
+	if handler == nil || !reflect.TypeOf(handler).Comparable() {
+		return
+	}
+
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	handlers := slices.DeleteFunc(slices.Clone(currentHandlers()), func(h slog.Handler) bool {
+		return reflect.TypeOf(h).Comparable() && h == handler
+	})
+	addedHandlers.Store(&handlers)
+}
+
+func currentHandlers() []slog.Handler {
+	if handlers := addedHandlers.Load(); handlers != nil {
+		return *handlers
+	}
+
+	return nil
+}
+
+// dynamicHandler sends records to the handlers passed to InitLogger and those added with AddHandler.
+type dynamicHandler struct {
+	base slog.Handler
+	// wrap applies the WithAttrs and WithGroup calls made on this handler to the added handlers.
+	wrap func(slog.Handler) slog.Handler
+}
+
+func newDynamicHandler(base slog.Handler) *dynamicHandler {
+	return &dynamicHandler{base: base, wrap: func(h slog.Handler) slog.Handler { return h }}
+}
+
+func (h *dynamicHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.base.Enabled(ctx, level) {
+		return true
+	}
+
+	for _, handler := range currentHandlers() {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *dynamicHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	if h.base.Enabled(ctx, record.Level) {
+		err = h.base.Handle(ctx, record.Clone())
+	}
+
+	for _, handler := range currentHandlers() {
+		handler = h.wrap(handler)
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		if handleErr := handler.Handle(ctx, record.Clone()); handleErr != nil && err == nil {
+			err = handleErr
+		}
+	}
+
+	return err
+}
+
+func (h *dynamicHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	wrap := h.wrap
+
+	return &dynamicHandler{
+		base: h.base.WithAttrs(attrs),
+		wrap: func(handler slog.Handler) slog.Handler { return wrap(handler).WithAttrs(attrs) },
+	}
+}
+
+func (h *dynamicHandler) WithGroup(name string) slog.Handler {
+	wrap := h.wrap
+
+	return &dynamicHandler{
+		base: h.base.WithGroup(name),
+		wrap: func(handler slog.Handler) slog.Handler { return wrap(handler).WithGroup(name) },
+	}
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var errInvalidLevels = errors.New("invalid log level spec")
+
+type levelConfig struct {
+	defaultLevel slog.Level
+	packages     map[string]slog.Level
+	// cache maps caller program counters to their resolved level
+	cache sync.Map
+}
+
+var levels atomic.Pointer[levelConfig]
+
+// SetLevels sets minimum log levels per calling package, e.g. "default=INFO, mypkg/db=DEBUG".
+// Packages are matched by import path suffix and the most specific entry wins, so "mypkg" also covers "mypkg/db".
+// It can be called at any time to change levels at runtime; an empty spec removes the overrides.
+// Overrides only filter records, so handlers must accept the lowest level configured, e.g. DEBUG.
+func SetLevels(spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		levels.Store(nil)
+		return nil
+	}
+
+	config := &levelConfig{defaultLevel: slog.LevelDebug, packages: map[string]slog.Level{}}
+
+	for entry := range strings.SplitSeq(spec, ",") {
+		name, levelText, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: %q", errInvalidLevels, entry)
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(levelText))); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidLevels, err)
+		}
+
+		name = strings.Trim(strings.TrimSpace(name), "/")
+		if name == "default" {
+			config.defaultLevel = level
+		} else {
+			config.packages[name] = level
+		}
+	}
+
+	levels.Store(config)
+
+	return nil
+}
+
+// packagePath returns the import path of the package declaring a function, e.g. "github.com/x/mypkg/db".
+func packagePath(funcName string) string {
+	lastSlash := strings.LastIndex(funcName, "/")
+
+	dot := strings.Index(funcName[lastSlash+1:], ".")
+	if dot < 0 {
+		return funcName
+	}
+
+	return funcName[:lastSlash+1+dot]
+}
+
+// matches reports whether a package name from the spec covers the import path, as a path suffix or one of its parents.
+func matches(path string, name string) bool {
+	return path == name ||
+		strings.HasSuffix(path, "/"+name) ||
+		strings.HasPrefix(path, name+"/") ||
+		strings.Contains(path, "/"+name+"/")
+}
+
+func (c *levelConfig) resolve(path string) slog.Level {
+	level := c.defaultLevel
+	longest := -1
+
+	for name, packageLevel := range c.packages {
+		if len(name) > longest && matches(path, name) {
+			level = packageLevel
+			longest = len(name)
+		}
+	}
+
+	return level
+}
+
+// enabled reports whether a record at level passes the override for the caller skip frames above it.
+func enabled(level slog.Level, skip int) bool {
+	config := levels.Load()
+	if config == nil {
+		return true
+	}
+
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return level >= config.defaultLevel
+	}
+
+	if cached, ok := config.cache.Load(pc); ok {
+		minLevel, _ := cached.(slog.Level)
+		return level >= minLevel
+	}
+
+	minLevel := config.defaultLevel
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		minLevel = config.resolve(packagePath(fn.Name()))
+	}
+
+	config.cache.Store(pc, minLevel)
+
+	return level >= minLevel
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/tinybluerobots/gotel/metrics"
+)
+
+// TruncationMarker is appended to record bodies and attribute values cut short by Limits.
+const TruncationMarker = "...[truncated]"
+
+// Limits bounds the size of log records exported over OTLP. Zero fields are unlimited.
+type Limits struct {
+	// BodyBytes is the maximum length in bytes of a string body, or of each string in a slice or map body.
+	BodyBytes int
+	// Attributes is the maximum number of attributes of a record. Further attributes are dropped and counted by the
+	// log_dropped_attributes counter.
+	Attributes int
+	// AttributeValueBytes is the maximum length in bytes of a string attribute value, or of each string in a slice
+	// or map value.
+	AttributeValueBytes int
+}
+
+type logMetrics struct {
+	LogDroppedAttributes *metrics.Int64Counter
+	LogTruncatedValues   *metrics.Int64Counter
+}
+
+var (
+	limits     atomic.Pointer[Limits]
+	getMetrics = metrics.Scoped[logMetrics]("github.com/tinybluerobots/gotel/log")
+)
+
+// SetLimits sets the limits enforced on log records before they are exported over OTLP. It is safe for concurrent
+// use, and applies to loggers created by later InitLogger calls.
+// By default the attribute limits are read from OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT, which defaults to 128, and
+// OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT, and bodies are unlimited.
+func SetLimits(l Limits) {
+	limits.Store(&l)
+}
+
+func envLimit(key string, fallback int) int {
+	limit, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || limit < 0 {
+		return fallback
+	}
+
+	return limit
+}
+
+// currentLimits returns the limits set by SetLimits, or those of the environment.
+func currentLimits() Limits {
+	if l := limits.Load(); l != nil {
+		return *l
+	}
+
+	return Limits{
+		Attributes:          envLimit("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT", 128),
+		AttributeValueBytes: envLimit("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT", 0),
+	}
+}
+
+// truncate cuts s to at most limit bytes on a rune boundary and appends TruncationMarker.
+func truncate(s string, limit int) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+
+	return s[:limit] + TruncationMarker, true
+}
@@ -4,18 +4,18 @@ package log
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
-	"os"
 	"runtime/debug"
 
 	slogmulti "github.com/samber/slog-multi"
 	"github.com/tinybluerobots/gotel/attribute"
-	"go.opentelemetry.io/contrib/bridges/otelslog"
-	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
-	"go.opentelemetry.io/otel/sdk/log"
-	"go.opentelemetry.io/otel/sdk/resource"
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/identity"
+	"github.com/tinybluerobots/gotel/implicit"
+	"github.com/tinybluerobots/gotel/requestid"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -32,9 +32,46 @@ var (
 	// Warn logs a message at WARN level with optional attributes.
 	Warn logWithContext = noopLogWithContext
 	// Error logs an error at ERROR level with stack trace and optional attributes.
+	// Within a span, the error is recorded as the exception.type, exception.message, and exception.stacktrace
+	// attributes, rather than stack_trace, so log backends render it as an exception.
 	Error func(ctx context.Context, err error, attributes ...attribute.Attr) = func(ctx context.Context, err error, attributes ...attribute.Attr) {}
 )
 
+// EventKey is the attribute holding the stable event key of records logged by the Event functions.
+const EventKey = "event.name"
+
+type logEventWithContext func(ctx context.Context, event string, message string, attributes ...attribute.Attr)
+
+var noopLogEventWithContext = func(ctx context.Context, event string, message string, attributes ...attribute.Attr) {}
+
+// The Event functions log a message with a stable event key, such as "user.login.success", as the EventKey attribute,
+// so alerts and queries match the key rather than the wording of the message, which can change or be translated.
+var (
+	// DebugEvent logs a message with an event key at DEBUG level with optional attributes.
+	DebugEvent logEventWithContext = noopLogEventWithContext
+	// InfoEvent logs a message with an event key at INFO level with optional attributes.
+	InfoEvent logEventWithContext = noopLogEventWithContext
+	// WarnEvent logs a message with an event key at WARN level with optional attributes.
+	WarnEvent logEventWithContext = noopLogEventWithContext
+	// ErrorEvent logs an error with an event key at ERROR level with stack trace and optional attributes.
+	ErrorEvent func(ctx context.Context, event string, err error, attributes ...attribute.Attr) = func(ctx context.Context, event string, err error, attributes ...attribute.Attr) {}
+)
+
+type logfWithContext func(ctx context.Context, format string, args ...any)
+
+var noopLogfWithContext = func(ctx context.Context, format string, args ...any) {}
+
+var (
+	// Debugf logs a formatted message at DEBUG level. Formatting is skipped when the level is disabled.
+	Debugf logfWithContext = noopLogfWithContext
+	// Infof logs a formatted message at INFO level. Formatting is skipped when the level is disabled.
+	Infof logfWithContext = noopLogfWithContext
+	// Warnf logs a formatted message at WARN level. Formatting is skipped when the level is disabled.
+	Warnf logfWithContext = noopLogfWithContext
+	// Errorf logs a formatted message at ERROR level with stack trace. Formatting is skipped when the level is disabled.
+	Errorf logfWithContext = noopLogfWithContext
+)
+
 func toSlogAttr(attr attribute.Attr) slog.Attr {
 	key := string(attr.Key)
 	value := attr.Value.AsInterface()
@@ -60,90 +97,142 @@ func NewJSONHandler(w io.Writer, resourceAttrs []attribute.Attr, logLevel string
 	return slog.NewJSONHandler(w, handlerOptions).WithAttrs(slogResourceAttrs), nil
 }
 
-func newHttpLogger(ctx context.Context, insecure bool, resourceAttrs []attribute.Attr) (*log.LoggerProvider, error) {
-	options := []otlploghttp.Option{}
+var (
+	scopeName    string
+	scopeVersion string
+)
+
+// SetScope sets the instrumentation scope name and version of log records exported over OTLP.
+// Call it before InitLogger. By default the scope is the service name and version from the resource attributes.
+func SetScope(name string, version string) {
+	scopeName = name
+	scopeVersion = version
+}
 
-	if insecure {
-		options = append(options, otlploghttp.WithInsecure())
+// loggerScope returns the scope set by SetScope, or the service name and version.
+func loggerScope(resourceAttrs []attribute.Attr) (string, string) {
+	if scopeName != "" {
+		return scopeName, scopeVersion
 	}
 
-	exp, err := otlploghttp.New(ctx, options...)
-	if err != nil {
-		return nil, err
-	}
+	name, version := "otelslog", ""
 
-	processor := log.NewBatchProcessor(exp)
-	provider := log.NewLoggerProvider(log.WithProcessor(processor), log.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
+	for _, attr := range resourceAttrs {
+		switch attr.Key {
+		case semconv.ServiceNameKey:
+			name = attr.Value.AsString()
+		case semconv.ServiceVersionKey:
+			version = attr.Value.AsString()
+		}
+	}
 
-	return provider, nil
+	return name, version
 }
 
-func newGrpcLogger(ctx context.Context, insecure bool, resourceAttrs []attribute.Attr) (*log.LoggerProvider, error) {
-	options := []otlploggrpc.Option{}
+// otlpProvider is the logger provider of the OTLP exporter created by InitLogger.
+type otlpProvider interface {
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
 
-	if insecure {
-		options = append(options, otlploggrpc.WithInsecure())
-	}
+var exportProvider otlpProvider
 
-	exp, err := otlploggrpc.New(ctx, options...)
-	if err != nil {
-		return nil, err
+// ForceFlush exports all pending log records now, e.g. before the process exits after a crash.
+// It does nothing if InitLogger has not created an OTLP exporter.
+func ForceFlush(ctx context.Context) error {
+	if exportProvider == nil {
+		return nil
 	}
 
-	processor := log.NewBatchProcessor(exp)
-	provider := log.NewLoggerProvider(log.WithProcessor(processor), log.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
-
-	return provider, nil
+	return exportProvider.ForceFlush(ctx)
 }
 
-func grpcLogHandler(ctx context.Context, resourceAttrs []attribute.Attr) (slog.Handler, *log.LoggerProvider, error) {
-	insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
-	useHttp := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") == "http"
-
-	var (
-		provider *log.LoggerProvider
-		err      error
-	)
+// genericErrorTypes are the types of errors created by errors.New, fmt.Errorf, and errors.Join, which say nothing
+// about what failed.
+var genericErrorTypes = map[string]bool{
+	"*errors.errorString": true,
+	"*errors.joinError":   true,
+	"*fmt.wrapError":      true,
+	"*fmt.wrapErrors":     true,
+}
 
-	if useHttp {
-		provider, err = newHttpLogger(ctx, insecure, resourceAttrs)
-	} else {
-		provider, err = newGrpcLogger(ctx, insecure, resourceAttrs)
+// exceptionType returns the type of the outermost error in the chain of err that isn't generic, such as
+// *fs.PathError wrapped by fmt.Errorf, or the type of err if all of them are.
+func exceptionType(err error) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if t := fmt.Sprintf("%T", e); !genericErrorTypes[t] {
+			return t
+		}
 	}
 
-	if err != nil {
-		return nil, nil, err
-	}
+	return fmt.Sprintf("%T", err)
+}
 
-	return otelslog.NewHandler("otelslog", otelslog.WithLoggerProvider(provider)), provider, nil
+// exceptionAttributes returns the semantic convention exception attributes of err, which log backends render as an
+// exception.
+func exceptionAttributes(err error, stackTrace []byte) []attribute.Attr {
+	return []attribute.Attr{
+		attribute.New(string(semconv.ExceptionTypeKey), exceptionType(err)),
+		attribute.New(string(semconv.ExceptionMessageKey), err.Error()),
+		attribute.New(string(semconv.ExceptionStacktraceKey), string(stackTrace)),
+	}
 }
 
 // InitLogger initializes structured logging with optional OTEL export.
 // It sets up the package-level Debug, Info, Warn, and Error functions.
-// Logs automatically include trace_id when within a valid trace context.
+// Logs automatically include trace_id when within a valid trace context, or, with the experimental implicit
+// package enabled, when the goroutine has a context bound.
+// With OTEL_SDK_DISABLED=true, logs are written to the handlers only, as with InitLocalLogger.
 func InitLogger(ctx context.Context, resourceAttrs []attribute.Attr, handler ...slog.Handler) (func(context.Context) error, error) {
+	return initLogger(ctx, resourceAttrs, !export.SDKDisabled(), handler)
+}
+
+// InitLocalLogger initializes structured logging to the handlers only, without creating a logger provider or
+// exporter, e.g. when telemetry export is turned off from configuration.
+func InitLocalLogger(ctx context.Context, handler ...slog.Handler) (func(context.Context) error, error) {
+	return initLogger(ctx, nil, false, handler)
+}
+
+func initLogger(ctx context.Context, resourceAttrs []attribute.Attr, exportLogs bool, handler []slog.Handler) (func(context.Context) error, error) {
 	slogHandlers := make([]slog.Handler, 0)
 	slogHandlers = append(slogHandlers, handler...)
 
-	var provider *log.LoggerProvider
+	var provider otlpProvider
 
-	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+	if !disabled && exportLogs && (export.Endpoint() != "" || export.Protocol() == "file" || hasExporters()) {
 		otelHandler, loggerProvider, err := grpcLogHandler(ctx, resourceAttrs)
 		if err != nil {
 			return nil, err
 		}
 
+		if traceSampledExport {
+			otelHandler = traceSampledHandler{otelHandler}
+		}
+
 		slogHandlers = append(slogHandlers, otelHandler)
 		provider = loggerProvider
 	}
 
+	exportProvider = provider
+
 	fanoutHandler := slogmulti.Fanout(slogHandlers...)
-	slogger := slog.New(fanoutHandler)
+	slogger := slog.New(newDynamicHandler(fanoutHandler))
+
+	writeLog := func(ctx context.Context, level slog.Level, logF func(ctx context.Context, msg string, args ...any), message string, logAttributes ...attribute.Attr) {
+		// Skip writeLog and the level function to resolve the level of the package that logged
+		if !enabled(level, 2) {
+			return
+		}
+
+		ctx = implicit.Resolve(ctx)
 
-	writeLog := func(ctx context.Context, logF func(ctx context.Context, msg string, args ...any), message string, logAttributes ...attribute.Attr) {
 		slogAttrs := make([]any, 0)
-		for _, attribute := range logAttributes {
-			slogAttrs = append(slogAttrs, toSlogAttr(attribute))
+		for _, attr := range attribute.ApplyHashing(append(identity.Attributes(ctx), logAttributes...)) {
+			slogAttrs = append(slogAttrs, toSlogAttr(attr))
+		}
+
+		if id := requestid.FromContext(ctx); id != "" {
+			slogAttrs = append(slogAttrs, slog.String(requestid.Key, id))
 		}
 
 		spanContext := trace.SpanFromContext(ctx).SpanContext()
@@ -156,18 +245,73 @@ func InitLogger(ctx context.Context, resourceAttrs []attribute.Attr, handler ...
 	}
 
 	Debug = func(ctx context.Context, message string, attributes ...attribute.Attr) {
-		writeLog(ctx, slogger.DebugContext, message, attributes...)
+		writeLog(ctx, slog.LevelDebug, slogger.DebugContext, message, attributes...)
 	}
 	Info = func(ctx context.Context, message string, attributes ...attribute.Attr) {
-		writeLog(ctx, slogger.InfoContext, message, attributes...)
+		writeLog(ctx, slog.LevelInfo, slogger.InfoContext, message, attributes...)
 	}
 	Warn = func(ctx context.Context, message string, attributes ...attribute.Attr) {
-		writeLog(ctx, slogger.WarnContext, message, attributes...)
+		writeLog(ctx, slog.LevelWarn, slogger.WarnContext, message, attributes...)
 	}
-	Error = func(ctx context.Context, err error, attributes ...attribute.Attr) {
+	errorAttributes := func(ctx context.Context, err error, attributes []attribute.Attr) []attribute.Attr {
 		stackTrace := debug.Stack()
 		attributes = append(attributes, attribute.New("stack_trace", string(stackTrace)))
-		writeLog(ctx, slogger.ErrorContext, err.Error(), attributes...)
+
+		if trace.SpanContextFromContext(implicit.Resolve(ctx)).IsValid() {
+			attributes = append(attributes, exceptionAttributes(err, stackTrace)...)
+		}
+
+		// Skip errorAttributes and the level function
+		return append(attributes, attribute.ErrorFingerprint(err, 2))
+	}
+
+	Error = func(ctx context.Context, err error, attributes ...attribute.Attr) {
+		writeLog(ctx, slog.LevelError, slogger.ErrorContext, err.Error(), errorAttributes(ctx, err, attributes)...)
+	}
+
+	// The event key goes first, ahead of the caller's attributes
+	eventAttributes := func(event string, attributes []attribute.Attr) []attribute.Attr {
+		return append([]attribute.Attr{attribute.New(EventKey, event)}, attributes...)
+	}
+
+	DebugEvent = func(ctx context.Context, event string, message string, attributes ...attribute.Attr) {
+		writeLog(ctx, slog.LevelDebug, slogger.DebugContext, message, eventAttributes(event, attributes)...)
+	}
+	InfoEvent = func(ctx context.Context, event string, message string, attributes ...attribute.Attr) {
+		writeLog(ctx, slog.LevelInfo, slogger.InfoContext, message, eventAttributes(event, attributes)...)
+	}
+	WarnEvent = func(ctx context.Context, event string, message string, attributes ...attribute.Attr) {
+		writeLog(ctx, slog.LevelWarn, slogger.WarnContext, message, eventAttributes(event, attributes)...)
+	}
+	ErrorEvent = func(ctx context.Context, event string, err error, attributes ...attribute.Attr) {
+		writeLog(ctx, slog.LevelError, slogger.ErrorContext, err.Error(), errorAttributes(ctx, err, eventAttributes(event, attributes))...)
+	}
+
+	// Check the handlers and the caller's package level before paying for formatting
+	formattedEnabled := func(ctx context.Context, level slog.Level) bool {
+		return slogger.Enabled(ctx, level) && enabled(level, 2)
+	}
+
+	Debugf = func(ctx context.Context, format string, args ...any) {
+		if formattedEnabled(ctx, slog.LevelDebug) {
+			writeLog(ctx, slog.LevelDebug, slogger.DebugContext, fmt.Sprintf(format, args...))
+		}
+	}
+	Infof = func(ctx context.Context, format string, args ...any) {
+		if formattedEnabled(ctx, slog.LevelInfo) {
+			writeLog(ctx, slog.LevelInfo, slogger.InfoContext, fmt.Sprintf(format, args...))
+		}
+	}
+	Warnf = func(ctx context.Context, format string, args ...any) {
+		if formattedEnabled(ctx, slog.LevelWarn) {
+			writeLog(ctx, slog.LevelWarn, slogger.WarnContext, fmt.Sprintf(format, args...))
+		}
+	}
+	Errorf = func(ctx context.Context, format string, args ...any) {
+		if formattedEnabled(ctx, slog.LevelError) {
+			stackTrace := debug.Stack()
+			writeLog(ctx, slog.LevelError, slogger.ErrorContext, fmt.Sprintf(format, args...), attribute.New("stack_trace", string(stackTrace)))
+		}
 	}
 
 	shutdown := func(ctx context.Context) error {
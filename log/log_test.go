@@ -1,13 +1,31 @@
+//go:build !gotel_disabled
+
 package log
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/identity"
+	"github.com/tinybluerobots/gotel/implicit"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/requestid"
+	otellog "go.opentelemetry.io/otel/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // captureOutput captures log output during test using the public InitLogger
@@ -82,6 +100,73 @@ func TestError(t *testing.T) {
 	assert.Equal(t, assert.AnError.Error(), logEntry["msg"])
 	assert.Equal(t, "ERROR", logEntry["level"])
 	assert.Equal(t, "error-value", logEntry["error-key"])
+	assert.Len(t, logEntry["error.fingerprint"], 16)
+}
+
+func TestError_Exception(t *testing.T) {
+	buf := captureOutput(t, "ERROR")
+	ctx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}))
+
+	Error(ctx, fmt.Errorf("loading config: %w", &fs.PathError{Op: "open", Path: "config.yaml", Err: os.ErrNotExist}))
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "*fs.PathError", logEntry["exception.type"], "the outermost error that isn't generic")
+	assert.Equal(t, "loading config: open config.yaml: file does not exist", logEntry["exception.message"])
+	assert.Contains(t, logEntry["exception.stacktrace"], "TestError_Exception")
+	assert.Contains(t, logEntry, "stack_trace", "stack_trace is kept for existing queries")
+
+	buf.Reset()
+	Error(ctx, fmt.Errorf("loading config: %w", os.ErrNotExist))
+
+	logEntry = map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "*fmt.wrapError", logEntry["exception.type"], "the error itself when every error in the chain is generic")
+}
+
+func TestEvents(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := t.Context()
+
+	InfoEvent(ctx, "user.login.success", "Welcome back!", attribute.New("user.id", "42"))
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "Welcome back!", logEntry["msg"])
+	assert.Equal(t, "INFO", logEntry["level"])
+	assert.Equal(t, "user.login.success", logEntry[EventKey])
+	assert.Equal(t, "42", logEntry["user.id"])
+
+	buf.Reset()
+	ErrorEvent(ctx, "user.login.failure", assert.AnError)
+
+	logEntry = map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, assert.AnError.Error(), logEntry["msg"])
+	assert.Equal(t, "user.login.failure", logEntry[EventKey])
+	assert.Contains(t, logEntry, "stack_trace")
+	assert.Len(t, logEntry["error.fingerprint"], 16)
+}
+
+func TestImplicitContext(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+
+	implicit.Enable(true)
+	t.Cleanup(func() { implicit.Enable(false) })
+
+	ctx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}))
+	defer implicit.Bind(ctx)()
+
+	Info(context.Background(), "legacy call")
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, trace.TraceID{1}.String(), logEntry["trace_id"])
 }
 
 func TestMultipleAttributes(t *testing.T) {
@@ -127,3 +212,344 @@ func TestNoAttributes(t *testing.T) {
 
 	assert.Equal(t, "message without attributes", logEntry["msg"])
 }
+
+func TestHashedKeys(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := t.Context()
+
+	attribute.HashKeys("salt", "user.id")
+	t.Cleanup(func() { attribute.HashKeys("") })
+
+	Info(ctx, "user logged in", attribute.New("user.id", "user-123"))
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, attribute.HashedID("user.id", "user-123", "salt").Value.AsString(), logEntry["user.id"])
+}
+
+func TestSetLevels(t *testing.T) {
+	buf := captureOutput(t, "DEBUG")
+	ctx := t.Context()
+
+	t.Cleanup(func() { _ = SetLevels("") })
+
+	require.NoError(t, SetLevels("default=DEBUG, gotel/log=WARN"))
+	Info(ctx, "filtered by package override")
+	assert.Empty(t, buf.String(), "expected INFO to be filtered for this package")
+
+	require.NoError(t, SetLevels("default=ERROR, gotel=WARN, gotel/log=DEBUG"))
+	Debug(ctx, "allowed by most specific override")
+	assert.Contains(t, buf.String(), "allowed by most specific override")
+
+	require.Error(t, SetLevels("default=LOUD"))
+	require.Error(t, SetLevels("=DEBUG"))
+}
+
+func TestPackagePath(t *testing.T) {
+	tests := []struct {
+		funcName string
+		expected string
+	}{
+		{"github.com/x/mypkg/db.(*Client).Query", "github.com/x/mypkg/db"},
+		{"github.com/x/mypkg/db.Query.func1", "github.com/x/mypkg/db"},
+		{"main.main", "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.funcName, func(t *testing.T) {
+			assert.Equal(t, tt.expected, packagePath(tt.funcName))
+		})
+	}
+}
+
+// countingStringer counts how many times it is formatted
+type countingStringer struct {
+	calls int
+}
+
+func (s *countingStringer) String() string {
+	s.calls++
+	return "formatted"
+}
+
+func TestFormattedLogging(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := t.Context()
+
+	arg := &countingStringer{}
+
+	Debugf(ctx, "debug %s", arg)
+	assert.Empty(t, buf.String(), "expected DEBUG to be filtered")
+	assert.Zero(t, arg.calls, "filtered messages should not be formatted")
+
+	Infof(ctx, "user %d is %s", 42, arg)
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "user 42 is formatted", logEntry["msg"])
+	assert.Equal(t, "INFO", logEntry["level"])
+	assert.Equal(t, 1, arg.calls)
+}
+
+func TestErrorf(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := t.Context()
+
+	Errorf(ctx, "failed to load %s", "config")
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "failed to load config", logEntry["msg"])
+	assert.Equal(t, "ERROR", logEntry["level"])
+	assert.Contains(t, logEntry, "stack_trace")
+}
+
+func TestRequestID(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := requestid.NewContext(t.Context(), "req-123")
+
+	Info(ctx, "handled")
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "req-123", logEntry["request.id"])
+}
+
+func TestIdentity(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+
+	identity.SetExtractor(func(context.Context) []attribute.Attr {
+		return []attribute.Attr{attribute.New("session.id", "session-1")}
+	})
+	t.Cleanup(func() { identity.SetExtractor(nil) })
+
+	Info(t.Context(), "handled")
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+
+	assert.Equal(t, "session-1", logEntry["session.id"])
+}
+
+func TestLoggerScope(t *testing.T) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	name, version := loggerScope(resourceAttrs)
+	assert.Equal(t, "test-service", name)
+	assert.Equal(t, "1.0.0", version)
+
+	name, _ = loggerScope(nil)
+	assert.Equal(t, "otelslog", name)
+
+	SetScope("github.com/myorg/checkout", "2.3.0")
+	t.Cleanup(func() { SetScope("", "") })
+
+	name, version = loggerScope(resourceAttrs)
+	assert.Equal(t, "github.com/myorg/checkout", name)
+	assert.Equal(t, "2.3.0", version)
+}
+
+func TestAddHandler(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+	ctx := t.Context()
+
+	added := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(added, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	AddHandler(handler)
+	t.Cleanup(func() { RemoveHandler(handler) })
+
+	Debug(ctx, "debug only reaches the added handler")
+	Info(ctx, "both", attribute.New("key", "value"))
+
+	lines := bytes.Split(bytes.TrimSpace(added.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var logEntry map[string]any
+	require.NoError(t, json.Unmarshal(lines[1], &logEntry))
+	assert.Equal(t, "both", logEntry["msg"])
+	assert.Equal(t, "value", logEntry["key"])
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+	assert.Equal(t, "both", logEntry["msg"], "the initial handler still receives records")
+
+	RemoveHandler(handler)
+	added.Reset()
+
+	Info(ctx, "after removal")
+	assert.Empty(t, added.Bytes())
+}
+
+func TestAddHandler_Nil(t *testing.T) {
+	buf := captureOutput(t, "INFO")
+
+	assert.NotPanics(t, func() {
+		AddHandler(nil)
+		RemoveHandler(nil)
+	})
+
+	Info(t.Context(), "still logged")
+	assert.Contains(t, buf.String(), "still logged")
+}
+
+func TestRingHandler(t *testing.T) {
+	ring := NewRingHandler(3, slog.LevelInfo)
+	logger := slog.New(ring)
+
+	logger.Debug("dropped by level")
+
+	for i := range 4 {
+		logger.Info("message", "i", i)
+	}
+
+	logger.WithGroup("db").With("table", "users").Warn("slow query", "ms", 120)
+
+	records := ring.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, int64(2), records[0].Attributes["i"], "oldest records are evicted")
+	assert.Equal(t, "WARN", records[2].Level)
+	assert.Equal(t, "users", records[2].Attributes["db.table"])
+	assert.Equal(t, int64(120), records[2].Attributes["db.ms"])
+
+	rec := httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?level=WARN", nil))
+
+	var served []RingRecord
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	require.Len(t, served, 1)
+	assert.Equal(t, "slow query", served[0].Message)
+
+	rec = httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?limit=2", nil))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Len(t, served, 2)
+
+	rec = httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?level=LOUD", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSetTraceSampledExport(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "file")
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", dir)
+
+	SetTraceSampledExport(true)
+	t.Cleanup(func() { SetTraceSampledExport(false) })
+
+	buf := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	shutdown, err := InitLogger(t.Context(), nil, handler)
+	require.NoError(t, err)
+
+	unsampled := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}))
+	sampledCtx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{2}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled}))
+
+	Info(unsampled, "unsampled info")
+	Warn(unsampled, "unsampled warn")
+	Debug(sampledCtx, "sampled debug")
+	Info(t.Context(), "outside a trace")
+	require.NoError(t, shutdown(t.Context()))
+
+	assert.Len(t, bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")), 4, "local handlers receive every record")
+
+	exported, err := os.ReadFile(filepath.Join(dir, "logs.jsonl"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(exported), "unsampled info")
+	assert.Contains(t, string(exported), "unsampled warn")
+	assert.Contains(t, string(exported), "sampled debug")
+	assert.Contains(t, string(exported), "outside a trace")
+}
+
+func TestInitLogger_SDKDisabled(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "file")
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", dir)
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+
+	buf := &bytes.Buffer{}
+
+	shutdown, err := InitLogger(t.Context(), nil, slog.NewJSONHandler(buf, nil))
+	require.NoError(t, err)
+
+	Info(t.Context(), "local only")
+	require.NoError(t, shutdown(t.Context()))
+
+	assert.Contains(t, buf.String(), "local only", "local handlers still receive records")
+	assert.NoFileExists(t, filepath.Join(dir, "logs.jsonl"), "no exporter is created")
+}
+
+func TestSetLimits(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "file")
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", dir)
+
+	reader := sdkmetric.NewManualReader()
+	shutdownMetrics, err := metrics.InitMetrics[struct{}](t.Context(), "test-service", nil, nil, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdownMetrics(context.Background()) })
+
+	SetLimits(Limits{BodyBytes: 8, Attributes: 2, AttributeValueBytes: 3})
+	t.Cleanup(func() { limits.Store(nil) })
+
+	shutdown, err := InitLogger(t.Context(), nil)
+	require.NoError(t, err)
+
+	Info(t.Context(), "payment failed", attribute.New("order", "o-12345"), attribute.New("attempt", 3), attribute.New("region", "eu"))
+	require.NoError(t, shutdown(t.Context()))
+
+	exported, err := os.ReadFile(filepath.Join(dir, "logs.jsonl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(exported), `"stringValue":"payment `+TruncationMarker+`"`)
+	assert.Contains(t, string(exported), `"stringValue":"o-1`+TruncationMarker+`"`)
+	assert.NotContains(t, string(exported), "region")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	counts := map[string]int64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				counts[m.Name] = sum.DataPoints[0].Value
+			}
+		}
+	}
+
+	assert.Equal(t, int64(1), counts["log_dropped_attributes"])
+	assert.Equal(t, int64(2), counts["log_truncated_values"])
+}
+
+func TestTruncate(t *testing.T) {
+	s, ok := truncate("héllo", 2)
+	assert.True(t, ok)
+	assert.Equal(t, "h"+TruncationMarker, s, "truncation doesn't split runes")
+
+	s, ok = truncate("hello", 0)
+	assert.False(t, ok)
+	assert.Equal(t, "hello", s)
+	nested := otellog.MapValue(
+		otellog.String("id", "o-12345"),
+		otellog.Slice("tags", otellog.StringValue("priority"), otellog.IntValue(1)),
+	)
+
+	value, truncated := truncateValue(nested, 3)
+	assert.Equal(t, 2, truncated, "strings nested in maps and slices are truncated")
+	assert.True(t, value.Equal(otellog.MapValue(
+		otellog.String("id", "o-1"+TruncationMarker),
+		otellog.Slice("tags", otellog.StringValue("pri"+TruncationMarker), otellog.IntValue(1)),
+	)))
+
+	value, truncated = truncateValue(nested, 0)
+	assert.Zero(t, truncated)
+	assert.True(t, value.Equal(nested))
+}
@@ -0,0 +1,67 @@
+//go:build !gotel_disabled && !js && !wasip1 && !tinygo
+
+package log
+
+import (
+	"cmp"
+	"context"
+	"os"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/otlpjson"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/sdk/log"
+)
+
+// disabled is set by the gotel_disabled build tag.
+const disabled = false
+
+func newHttpLogExporter(ctx context.Context, insecure bool, endpoint string) (log.Exporter, error) {
+	options := []otlploghttp.Option{}
+
+	if insecure {
+		options = append(options, otlploghttp.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlploghttp.WithEndpointURL(export.SignalURL(endpoint, export.SignalLogs)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalLogs); ok {
+		options = append(options, otlploghttp.WithEndpointURL(endpoint))
+	}
+
+	return otlploghttp.New(ctx, options...)
+}
+
+func newGrpcLogExporter(ctx context.Context, insecure bool, endpoint string) (log.Exporter, error) {
+	options := []otlploggrpc.Option{}
+
+	if insecure {
+		options = append(options, otlploggrpc.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlploggrpc.WithEndpointURL(export.SignalURL(endpoint, export.SignalLogs)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalLogs); ok {
+		options = append(options, otlploggrpc.WithEndpointURL(endpoint))
+	}
+
+	return otlploggrpc.New(ctx, options...)
+}
+
+// newLogExporter returns the OTLP exporter of the configured protocol, exporting to endpoint, or to the configured
+// endpoint if it is empty.
+func newLogExporter(ctx context.Context, endpoint string) (log.Exporter, error) {
+	insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
+
+	switch export.Protocol() {
+	case "http":
+		return newHttpLogExporter(ctx, insecure, endpoint)
+	case "http/json":
+		return otlpjson.NewLogExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+	case "file":
+		return otlpjson.NewFileLogExporter(otlpjson.FilePath("logs"))
+	default:
+		return newGrpcLogExporter(ctx, insecure, endpoint)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build gotel_disabled
+
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+// disabled is set by the gotel_disabled build tag, which leaves the OpenTelemetry SDK and the OTLP exporters out of
+// the binary.
+const disabled = true
+
+var errDisabled = errors.New("telemetry is disabled by the gotel_disabled build tag")
+
+func grpcLogHandler(context.Context, []attribute.Attr) (slog.Handler, otlpProvider, error) {
+	return nil, nil, errDisabled
+}
+
+func hasExporters() bool {
+	return false
+}
@@ -0,0 +1,25 @@
+//go:build !gotel_disabled && (js || wasip1 || tinygo)
+
+package log
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/otlpjson"
+	"go.opentelemetry.io/otel/sdk/log"
+)
+
+// disabled is set by the gotel_disabled build tag.
+const disabled = false
+
+// newLogExporter exports OTLP/HTTP JSON to endpoint, or to the configured endpoint if it is empty, in js/wasm, WASI,
+// and TinyGo builds unless the protocol is file, as gRPC and protobuf are unavailable.
+func newLogExporter(_ context.Context, endpoint string) (log.Exporter, error) {
+	if export.Protocol() == "file" {
+		return otlpjson.NewFileLogExporter(otlpjson.FilePath("logs"))
+	}
+
+	return otlpjson.NewLogExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RingRecord is a log record retained by a RingHandler.
+type RingRecord struct {
+	Time       time.Time      `json:"time"`
+	Level      string         `json:"level"`
+	Message    string         `json:"msg"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+type ring struct {
+	mu      sync.Mutex
+	records []RingRecord
+	next    int
+	full    bool
+}
+
+func (r *ring) add(record RingRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	r.full = r.full || r.next == 0
+}
+
+// snapshot returns the retained records, oldest first.
+func (r *ring) snapshot() []RingRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]RingRecord(nil), r.records[:r.next]...)
+	}
+
+	return append(append([]RingRecord(nil), r.records[r.next:]...), r.records[:r.next]...)
+}
+
+// RingHandler is a slog.Handler that retains the last N records at or above a level in memory,
+// and serves them as JSON as a "recent logs" debug endpoint. Add it with InitLogger or AddHandler.
+type RingHandler struct {
+	ring   *ring
+	level  slog.Leveler
+	attrs  []slog.Attr
+	prefix string
+}
+
+// NewRingHandler creates a handler retaining the last size records at or above level.
+func NewRingHandler(size int, level slog.Leveler) *RingHandler {
+	return &RingHandler{ring: &ring{records: make([]RingRecord, max(size, 1))}, level: level}
+}
+
+// Enabled reports whether the level is retained.
+func (h *RingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle retains the record, evicting the oldest when the buffer is full.
+func (h *RingHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := map[string]any{}
+	for _, attr := range h.attrs {
+		addRingAttr(attrs, "", attr)
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		addRingAttr(attrs, h.prefix, attr)
+		return true
+	})
+
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+
+	h.ring.add(RingRecord{Time: record.Time, Level: record.Level.String(), Message: record.Message, Attributes: attrs})
+
+	return nil
+}
+
+// addRingAttr adds an attribute, flattening groups into dotted keys.
+func addRingAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, member := range value.Group() {
+			addRingAttr(attrs, prefix+attr.Key+".", member)
+		}
+
+		return
+	}
+
+	attrs[prefix+attr.Key] = value.Any()
+}
+
+// WithAttrs returns a handler sharing the buffer that adds attrs to each record.
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	grouped := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	grouped = append(grouped, h.attrs...)
+
+	for _, attr := range attrs {
+		grouped = append(grouped, slog.Attr{Key: h.prefix + attr.Key, Value: attr.Value})
+	}
+
+	return &RingHandler{ring: h.ring, level: h.level, attrs: grouped, prefix: h.prefix}
+}
+
+// WithGroup returns a handler sharing the buffer that prefixes attribute keys with the group name.
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &RingHandler{ring: h.ring, level: h.level, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Records returns the retained records, oldest first.
+func (h *RingHandler) Records() []RingRecord {
+	return h.ring.snapshot()
+}
+
+// ServeHTTP serves the retained records as a JSON array, oldest first.
+// The level query parameter filters by minimum level, e.g. ?level=WARN, and limit returns only the newest records.
+func (h *RingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	minLevel := h.level.Level()
+	if level := req.URL.Query().Get("level"); level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	records := []RingRecord{}
+
+	for _, record := range h.Records() {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(record.Level)); err == nil && level >= minLevel {
+			records = append(records, record)
+		}
+	}
+
+	if limit, err := strconv.Atoi(req.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(records) {
+		records = records[len(records)-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(records)
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var traceSampledExport bool
+
+// SetTraceSampledExport exports DEBUG and INFO records over OTLP only when they are logged in a sampled trace, or
+// outside any trace, so verbose log volume follows trace volume. WARN and ERROR records are always exported, and
+// local handlers receive every record. Call it before InitLogger.
+func SetTraceSampledExport(enabled bool) {
+	traceSampledExport = enabled
+}
+
+// traceSampledHandler drops records below WARN logged in unsampled traces.
+type traceSampledHandler struct {
+	slog.Handler
+}
+
+// sampled reports whether a record at level in ctx is exported.
+func sampled(ctx context.Context, level slog.Level) bool {
+	if level >= slog.LevelWarn {
+		return true
+	}
+
+	spanContext := trace.SpanContextFromContext(ctx)
+
+	return !spanContext.IsValid() || spanContext.IsSampled()
+}
+
+func (h traceSampledHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return sampled(ctx, level) && h.Handler.Enabled(ctx, level)
+}
+
+func (h traceSampledHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !sampled(ctx, record.Level) {
+		return nil
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h traceSampledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceSampledHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceSampledHandler) WithGroup(name string) slog.Handler {
+	return traceSampledHandler{h.Handler.WithGroup(name)}
+}
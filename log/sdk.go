@@ -0,0 +1,171 @@
+//go:build !gotel_disabled
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// limitProcessor enforces Limits on records before passing them to the exporting processor.
+type limitProcessor struct {
+	log.Processor
+	limits Limits
+}
+
+// truncateValue returns v with the strings it holds, including those nested in slices and maps, cut to limit bytes,
+// and the number of strings cut.
+func truncateValue(v otellog.Value, limit int) (otellog.Value, int) {
+	if limit <= 0 {
+		return v, 0
+	}
+
+	switch v.Kind() {
+	case otellog.KindString:
+		if s, ok := truncate(v.AsString(), limit); ok {
+			return otellog.StringValue(s), 1
+		}
+	case otellog.KindSlice:
+		values := v.AsSlice()
+		truncated := 0
+		cut := make([]otellog.Value, len(values))
+
+		for i, value := range values {
+			var n int
+			cut[i], n = truncateValue(value, limit)
+			truncated += n
+		}
+
+		if truncated > 0 {
+			return otellog.SliceValue(cut...), truncated
+		}
+	case otellog.KindMap:
+		kvs := v.AsMap()
+		truncated := 0
+		cut := make([]otellog.KeyValue, len(kvs))
+
+		for i, kv := range kvs {
+			var n int
+			cut[i] = kv
+			cut[i].Value, n = truncateValue(kv.Value, limit)
+			truncated += n
+		}
+
+		if truncated > 0 {
+			return otellog.MapValue(cut...), truncated
+		}
+	}
+
+	return v, 0
+}
+
+func (p limitProcessor) OnEmit(ctx context.Context, record *log.Record) error {
+	body, truncated := truncateValue(record.Body(), p.limits.BodyBytes)
+	if truncated > 0 {
+		record.SetBody(body)
+	}
+
+	attrs := make([]otellog.KeyValue, 0, record.AttributesLen())
+	dropped := 0
+
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		if p.limits.Attributes > 0 && len(attrs) >= p.limits.Attributes {
+			dropped++
+			return true
+		}
+
+		var n int
+		kv.Value, n = truncateValue(kv.Value, p.limits.AttributeValueBytes)
+		truncated += n
+
+		attrs = append(attrs, kv)
+
+		return true
+	})
+
+	if dropped > 0 || truncated > 0 {
+		record.SetAttributes(attrs...)
+	}
+
+	if dropped > 0 {
+		getMetrics().LogDroppedAttributes.Add(ctx, int64(dropped))
+	}
+
+	if truncated > 0 {
+		getMetrics().LogTruncatedValues.Add(ctx, int64(truncated))
+	}
+
+	return p.Processor.OnEmit(ctx, record)
+}
+
+var exporters atomic.Pointer[[]log.Exporter]
+
+// SetExporters exports log records to exporters, such as a vendor or test exporter, from the next InitLogger call,
+// in addition to the OTLP exporter created when OTEL_EXPORTER_OTLP_ENDPOINT is set. Each exporter has its own batch
+// processor and the same Limits. gotel.WithLogExporter sets them from gotel.Init.
+func SetExporters(exporter ...log.Exporter) {
+	exporters.Store(&exporter)
+}
+
+// hasExporters reports whether SetExporters set any exporters.
+func hasExporters() bool {
+	e := exporters.Load()
+
+	return e != nil && len(*e) > 0
+}
+
+// grpcLogHandler returns a handler exporting to the OTLP endpoint, if one is configured, routed by the routes of
+// export.SetEndpointRoutes, and to the exporters of SetExporters.
+func grpcLogHandler(ctx context.Context, resourceAttrs []attribute.Attr) (slog.Handler, otlpProvider, error) {
+	var all []log.Exporter
+	if e := exporters.Load(); e != nil {
+		all = append(all, *e...)
+	}
+
+	if export.Endpoint() != "" || export.Protocol() == "file" {
+		exporter, err := newLogExporter(ctx, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newExporter := func(endpoint string) (log.Exporter, error) { return newLogExporter(ctx, endpoint) }
+		if exporter, err = export.RouteEndpoints(ctx, exporter, newExporter, export.RouteLogs); err != nil {
+			_ = exporter.Shutdown(ctx)
+			return nil, nil, err
+		}
+
+		all = append(all, exporter)
+	}
+
+	provider := newLoggerProvider(all, resourceAttrs)
+	name, version := loggerScope(resourceAttrs)
+
+	return otelslog.NewHandler(name, otelslog.WithLoggerProvider(provider), otelslog.WithVersion(version)), provider, nil
+}
+
+// newLoggerProvider returns a provider exporting batches of records to each of exporters, with the current Limits
+// enforced in place of the SDK's attribute limits, which drop and truncate without a trace. Limits are enforced again
+// by the processor of each exporter, which finds records already within them.
+func newLoggerProvider(exporters []log.Exporter, resourceAttrs []attribute.Attr) *log.LoggerProvider {
+	options := []log.LoggerProviderOption{
+		log.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)),
+		log.WithAttributeCountLimit(-1),
+		log.WithAttributeValueLengthLimit(-1),
+	}
+
+	limits := currentLimits()
+	for _, exporter := range exporters {
+		options = append(options, log.WithProcessor(limitProcessor{Processor: export.NewBatchLogProcessor(export.WrapLogExporter(exporter)), limits: limits}))
+	}
+
+	return log.NewLoggerProvider(options...)
+}
@@ -0,0 +1,34 @@
+// Package messaging provides tracing helpers for message consumers, following the OpenTelemetry messaging
+// semantic conventions.
+//
+//	ctx, span := messaging.StartBatchConsumerSpan(ctx, headers, "process orders")
+//	defer span.End()
+package messaging
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartBatchConsumerSpan creates one consumer span for processing a batch of messages, linked to the producer
+// context propagated in each message's carrier, such as its headers, with a messaging.batch.message_count attribute.
+// The span is a child of ctx rather than of any producer, as a batch has many; messages without a valid trace
+// context are counted but not linked.
+func StartBatchConsumerSpan(ctx context.Context, carriers []map[string]string, name string, attrs ...attribute.Attr) (context.Context, tracing.Span) {
+	links := make([]trace.SpanContext, 0, len(carriers))
+
+	for _, carrier := range carriers {
+		links = append(links, trace.SpanContextFromContext(tracing.Extract(context.Background(), carrier)))
+	}
+
+	attrs = append([]attribute.Attr{
+		{KeyValue: semconv.MessagingOperationTypeProcess},
+		{KeyValue: semconv.MessagingBatchMessageCount(len(carriers))},
+	}, attrs...)
+
+	return tracing.NewSpanWithLinks(ctx, tracing.SpanKindConsumer, name, links, attrs...)
+}
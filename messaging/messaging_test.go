@@ -0,0 +1,54 @@
+//go:build !gotel_disabled
+
+package messaging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartBatchConsumerSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	_, err := tracing.InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	carriers := []map[string]string{}
+	producers := []trace.SpanContext{}
+
+	for range 2 {
+		ctx, span := tracing.NewSpanWithKind(t.Context(), tracing.SpanKindProducer, "send orders")
+		carriers = append(carriers, tracing.TraceHeaders(ctx))
+		producers = append(producers, trace.SpanContextFromContext(ctx))
+		span.End()
+	}
+
+	carriers = append(carriers, map[string]string{})
+
+	_, span := StartBatchConsumerSpan(t.Context(), carriers, "process orders", attribute.New("messaging.destination.name", "orders"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	consumer := spans[2]
+	assert.Equal(t, "process orders", consumer.Name)
+	assert.Equal(t, trace.SpanKindConsumer, consumer.SpanKind)
+	assert.Contains(t, consumer.Attributes, semconv.MessagingBatchMessageCount(3))
+	assert.Contains(t, consumer.Attributes, semconv.MessagingOperationTypeProcess)
+	assert.Contains(t, consumer.Attributes, semconv.MessagingDestinationName("orders"))
+
+	require.Len(t, consumer.Links, 2, "messages without a trace context are not linked")
+	assert.Equal(t, producers[0].SpanID(), consumer.Links[0].SpanContext.SpanID())
+	assert.Equal(t, producers[1].SpanID(), consumer.Links[1].SpanContext.SpanID())
+	assert.False(t, consumer.Parent.IsValid(), "the batch span is not a child of any producer")
+}
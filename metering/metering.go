@@ -0,0 +1,521 @@
+// Package metering records billing-grade usage that must not be lost.
+// Usage is appended to a local ledger file and synced to disk before Record returns, then exported in batches with
+// sequence numbers until the exporter acknowledges them. A crash before the acknowledgement is written causes the
+// batch to be exported again, so exporters must deduplicate by sequence number to make delivery exactly once.
+//
+//	ledger, err := metering.Open("/var/lib/myservice/usage.ledger", metering.ExporterFunc(
+//		func(ctx context.Context, records []metering.Record) error {
+//			return billing.Submit(ctx, records) // deduplicated by Record.Sequence
+//		}))
+//	go ledger.Run(ctx, 10*time.Second)
+//
+//	ledger.Record(ctx, "tenant-42", 1, map[string]string{"sku": "api-call"})
+package metering
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+)
+
+const scopeName = "github.com/tinybluerobots/gotel/metering"
+
+var errCorrupt = errors.New("corrupt metering ledger")
+
+type meteringMetrics struct {
+	MeteringPendingRecords *metrics.Int64Gauge
+	MeteringExportFailures *metrics.Int64Counter
+}
+
+var getMetrics = metrics.Scoped[meteringMetrics](scopeName)
+
+// Record is a unit of usage. Sequence numbers increase by one for each record in a ledger.
+type Record struct {
+	Sequence   uint64            `json:"seq"`
+	Key        string            `json:"key"`
+	Quantity   float64           `json:"quantity"`
+	Time       time.Time         `json:"time"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Exporter delivers records to the billing system. It must be idempotent: records may be delivered more than once
+// after a failure, and should be deduplicated by Sequence.
+type Exporter interface {
+	Export(ctx context.Context, records []Record) error
+}
+
+// ExporterFunc adapts a function to an Exporter.
+type ExporterFunc func(ctx context.Context, records []Record) error
+
+// Export calls f.
+func (f ExporterFunc) Export(ctx context.Context, records []Record) error {
+	return f(ctx, records)
+}
+
+type checkpoint struct {
+	Sequence uint64             `json:"seq"`
+	Acked    uint64             `json:"acked"`
+	Recorded map[string]float64 `json:"recorded"`
+	Exported map[string]float64 `json:"exported"`
+}
+
+// entry is a line of the ledger file.
+type entry struct {
+	Record     *Record     `json:"record,omitempty"`
+	Ack        uint64      `json:"ack,omitempty"`
+	Checkpoint *checkpoint `json:"checkpoint,omitempty"`
+}
+
+type config struct {
+	batchSize           int
+	compactionThreshold int64
+}
+
+// Option configures Open.
+type Option func(*config)
+
+// WithBatchSize sets the maximum number of records passed to each Export call. The default is 500.
+func WithBatchSize(size int) Option {
+	return func(c *config) {
+		c.batchSize = size
+	}
+}
+
+// WithCompactionThreshold sets the size in bytes the ledger file must reach before Flush rewrites it without the
+// exported records. The default is 1 MiB. Compaction rewrites and syncs the whole file, so a small threshold makes
+// every idle flush do disk work.
+func WithCompactionThreshold(bytes int64) Option {
+	return func(c *config) {
+		c.compactionThreshold = bytes
+	}
+}
+
+func newConfig(options ...Option) *config {
+	c := &config{batchSize: 500, compactionThreshold: 1 << 20}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Ledger buffers usage records on disk until they are exported.
+type Ledger struct {
+	config   *config
+	path     string
+	exporter Exporter
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	sequence uint64
+	acked    uint64
+	pending  []Record
+	recorded map[string]float64
+	exported map[string]float64
+
+	flushMu sync.Mutex
+}
+
+// Open opens the ledger at path, creating it if needed, and replays records that were not yet exported.
+func Open(path string, exporter Exporter, options ...Option) (*Ledger, error) {
+	l := &Ledger{
+		config:   newConfig(options...),
+		path:     path,
+		exporter: exporter,
+		recorded: map[string]float64{},
+		exported: map[string]float64{},
+	}
+
+	if err := l.replay(); err != nil {
+		return nil, err
+	}
+
+	if err := l.openFile(os.O_CREATE); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// replay applies the entries of the ledger file, and truncates a final line torn by a crash mid-write, so the next
+// entry is appended after the last complete one.
+func (l *Ledger) replay() error {
+	file, err := os.OpenFile(l.path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+
+	var (
+		size int64
+		torn error
+	)
+
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(data) == 0 && errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		// Only the final line may be torn by a crash mid-write
+		if torn != nil {
+			return torn
+		}
+
+		var e entry
+		if errors.Is(err, io.EOF) {
+			torn = fmt.Errorf("%w: line %d: missing newline", errCorrupt, line)
+			continue
+		}
+
+		if err := json.Unmarshal(data, &e); err != nil {
+			torn = fmt.Errorf("%w: line %d: %w", errCorrupt, line, err)
+			continue
+		}
+
+		l.apply(e)
+		size += int64(len(data))
+	}
+
+	if torn == nil {
+		return nil
+	}
+
+	if err := file.Truncate(size); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func (l *Ledger) apply(e entry) {
+	switch {
+	case e.Checkpoint != nil:
+		l.sequence = e.Checkpoint.Sequence
+		l.acked = e.Checkpoint.Acked
+		l.recorded = maps.Clone(e.Checkpoint.Recorded)
+		l.exported = maps.Clone(e.Checkpoint.Exported)
+		l.pending = nil
+
+		if l.recorded == nil {
+			l.recorded = map[string]float64{}
+		}
+
+		if l.exported == nil {
+			l.exported = map[string]float64{}
+		}
+	case e.Record != nil:
+		l.sequence = max(l.sequence, e.Record.Sequence)
+		l.recorded[e.Record.Key] += e.Record.Quantity
+		l.pending = append(l.pending, *e.Record)
+	case e.Ack != 0:
+		l.ack(e.Ack)
+	}
+}
+
+// ack marks records up to and including sequence as exported.
+func (l *Ledger) ack(sequence uint64) {
+	i := 0
+	for ; i < len(l.pending) && l.pending[i].Sequence <= sequence; i++ {
+		l.exported[l.pending[i].Key] += l.pending[i].Quantity
+	}
+
+	l.pending = l.pending[i:]
+	l.acked = max(l.acked, sequence)
+}
+
+// write appends e to the ledger file and syncs it. If either fails, the file is truncated to its previous size, so
+// a partial entry doesn't corrupt the next one.
+func (l *Ledger) write(e entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.file.Write(append(data, '\n'))
+	if err == nil {
+		err = l.file.Sync()
+	}
+
+	if err != nil {
+		_ = l.file.Truncate(l.size)
+		return err
+	}
+
+	l.size += int64(len(data)) + 1
+
+	return nil
+}
+
+// Record appends usage for key to the ledger and returns its sequence number once it is on disk.
+func (l *Ledger) Record(ctx context.Context, key string, quantity float64, attributes map[string]string) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record := Record{
+		Sequence:   l.sequence + 1,
+		Key:        key,
+		Quantity:   quantity,
+		Time:       time.Now().UTC(),
+		Attributes: attributes,
+	}
+
+	if err := l.write(entry{Record: &record}); err != nil {
+		return 0, err
+	}
+
+	l.sequence = record.Sequence
+	l.recorded[key] += quantity
+	l.pending = append(l.pending, record)
+
+	getMetrics().MeteringPendingRecords.Record(ctx, int64(len(l.pending)))
+
+	return record.Sequence, nil
+}
+
+// Flush exports pending records in batches, returning the first export error.
+// Records stay in the ledger until their batch is acknowledged. Once every record is exported, the ledger is
+// compacted if it has grown past the compaction threshold.
+func (l *Ledger) Flush(ctx context.Context) error {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+
+	for {
+		l.mu.Lock()
+		batch := append([]Record(nil), l.pending[:min(len(l.pending), l.config.batchSize)]...)
+		l.mu.Unlock()
+
+		if len(batch) == 0 {
+			return l.compact(l.config.compactionThreshold)
+		}
+
+		if err := l.exporter.Export(ctx, batch); err != nil {
+			getMetrics().MeteringExportFailures.Add(ctx, 1)
+			return fmt.Errorf("failed to export usage records %d to %d: %w", batch[0].Sequence, batch[len(batch)-1].Sequence, err)
+		}
+
+		l.mu.Lock()
+		last := batch[len(batch)-1].Sequence
+		err := l.write(entry{Ack: last})
+
+		if err == nil {
+			l.ack(last)
+		}
+
+		getMetrics().MeteringPendingRecords.Record(ctx, int64(len(l.pending)))
+		l.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compact rewrites the ledger as a checkpoint followed by the pending records, so it doesn't grow without bound.
+// It does nothing until the ledger file reaches threshold bytes.
+func (l *Ledger) compact(threshold int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size < threshold {
+		return nil
+	}
+
+	tmpPath := l.path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(tmp)
+
+	err = encoder.Encode(entry{Checkpoint: &checkpoint{Sequence: l.sequence, Acked: l.acked, Recorded: l.recorded, Exported: l.exported}})
+	for i := 0; err == nil && i < len(l.pending); i++ {
+		err = encoder.Encode(entry{Record: &l.pending[i]})
+	}
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, l.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	_ = l.file.Close()
+
+	if err := l.openFile(0); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(l.path))
+}
+
+// openFile opens the ledger file for appending, with flag added to the open flags.
+func (l *Ledger) openFile(flag int) error {
+	file, err := os.OpenFile(l.path, flag|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+// syncDir syncs the directory at path, so a file renamed into it survives a crash.
+// Windows doesn't support syncing directories, and persists renames itself.
+func syncDir(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(dir.Sync(), dir.Close())
+}
+
+// Run flushes the ledger every interval until ctx is cancelled, logging export failures.
+func (l *Ledger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Flush(ctx); err != nil {
+				log.Error(ctx, err)
+			}
+		}
+	}
+}
+
+// Close flushes the ledger and closes its file.
+func (l *Ledger) Close(ctx context.Context) error {
+	err := l.Flush(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return errors.Join(err, l.file.Close())
+}
+
+// Totals is the usage of a key recorded in the ledger and exported from it.
+type Totals struct {
+	Recorded float64
+	Exported float64
+	Pending  float64
+}
+
+// Totals returns the usage per key since the ledger was created.
+func (l *Ledger) Totals() map[string]Totals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	totals := make(map[string]Totals, len(l.recorded))
+	for key, recorded := range l.recorded {
+		totals[key] = Totals{Recorded: recorded, Exported: l.exported[key], Pending: recorded - l.exported[key]}
+	}
+
+	return totals
+}
+
+// Discrepancy is a key whose exported usage differs from the billing system's.
+type Discrepancy struct {
+	Key      string
+	Exported float64
+	Billed   float64
+}
+
+// Reconcile compares the exported usage per key with the totals the billing system holds,
+// returning the keys that differ by more than tolerance, sorted by key.
+func (l *Ledger) Reconcile(billed map[string]float64, tolerance float64) []Discrepancy {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := map[string]struct{}{}
+	for key := range l.exported {
+		keys[key] = struct{}{}
+	}
+
+	for key := range billed {
+		keys[key] = struct{}{}
+	}
+
+	discrepancies := []Discrepancy{}
+
+	for key := range keys {
+		if math.Abs(l.exported[key]-billed[key]) > tolerance {
+			discrepancies = append(discrepancies, Discrepancy{Key: key, Exported: l.exported[key], Billed: billed[key]})
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool { return discrepancies[i].Key < discrepancies[j].Key })
+
+	for _, d := range discrepancies {
+		log.Warn(context.Background(), "usage discrepancy",
+			attribute.New("metering.key", d.Key),
+			attribute.New("metering.exported", d.Exported),
+			attribute.New("metering.billed", d.Billed),
+		)
+	}
+
+	return discrepancies
+}
+
+// Sequence returns the last sequence number recorded and the last acknowledged by the exporter.
+func (l *Ledger) Sequence() (uint64, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.sequence, l.acked
+}
@@ -0,0 +1,209 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = errors.New("billing unavailable")
+
+type fakeExporter struct {
+	fail    bool
+	batches [][]Record
+}
+
+func (e *fakeExporter) Export(_ context.Context, records []Record) error {
+	if e.fail {
+		return errUnavailable
+	}
+
+	e.batches = append(e.batches, records)
+
+	return nil
+}
+
+func TestLedger_RecordAndFlush(t *testing.T) {
+	exporter := &fakeExporter{}
+	path := filepath.Join(t.TempDir(), "usage.ledger")
+
+	ledger, err := Open(path, exporter, WithBatchSize(2))
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err := ledger.Record(t.Context(), "tenant-a", 1.5, map[string]string{"sku": "api-call"})
+		require.NoError(t, err)
+	}
+
+	seq, err := ledger.Record(t.Context(), "tenant-b", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), seq)
+
+	require.NoError(t, ledger.Flush(t.Context()))
+
+	require.Len(t, exporter.batches, 2)
+	assert.Equal(t, uint64(1), exporter.batches[0][0].Sequence)
+	assert.Equal(t, "api-call", exporter.batches[0][0].Attributes["sku"])
+	assert.Equal(t, uint64(4), exporter.batches[1][1].Sequence)
+
+	totals := ledger.Totals()
+	assert.Equal(t, Totals{Recorded: 4.5, Exported: 4.5, Pending: 0}, totals["tenant-a"])
+	assert.Equal(t, Totals{Recorded: 2, Exported: 2, Pending: 0}, totals["tenant-b"])
+
+	require.NoError(t, ledger.Close(t.Context()))
+}
+
+func TestLedger_SurvivesRestart(t *testing.T) {
+	exporter := &fakeExporter{fail: true}
+	path := filepath.Join(t.TempDir(), "usage.ledger")
+
+	ledger, err := Open(path, exporter)
+	require.NoError(t, err)
+
+	_, err = ledger.Record(t.Context(), "tenant-a", 1, nil)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, ledger.Flush(t.Context()), errUnavailable)
+	require.ErrorIs(t, ledger.Close(t.Context()), errUnavailable)
+
+	exporter.fail = false
+
+	reopened, err := Open(path, exporter, WithCompactionThreshold(1))
+	require.NoError(t, err)
+
+	seq, err := reopened.Record(t.Context(), "tenant-a", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), seq, "sequence numbers continue after a restart")
+
+	require.NoError(t, reopened.Flush(t.Context()))
+	require.Len(t, exporter.batches, 1)
+	assert.Len(t, exporter.batches[0], 2, "unexported records are replayed")
+
+	require.NoError(t, reopened.Close(t.Context()))
+
+	// The compacted ledger keeps the totals and sequence without the exported records
+	compacted, err := Open(path, exporter)
+	require.NoError(t, err)
+
+	last, acked := compacted.Sequence()
+	assert.Equal(t, uint64(2), last)
+	assert.Equal(t, uint64(2), acked)
+	assert.Equal(t, Totals{Recorded: 3, Exported: 3, Pending: 0}, compacted.Totals()["tenant-a"])
+
+	require.NoError(t, compacted.Flush(t.Context()))
+	assert.Len(t, exporter.batches, 1, "acknowledged records are not exported again")
+}
+
+func TestLedger_CompactionThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.ledger")
+
+	ledger, err := Open(path, &fakeExporter{}, WithCompactionThreshold(4096))
+	require.NoError(t, err)
+
+	size := func() int64 {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+
+		return info.Size()
+	}
+
+	_, err = ledger.Record(t.Context(), "tenant-a", 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, ledger.Flush(t.Context()))
+
+	small := size()
+	require.NoError(t, ledger.Flush(t.Context()))
+	assert.Equal(t, small, size(), "a ledger below the threshold should not be rewritten")
+
+	for range 50 {
+		_, err = ledger.Record(t.Context(), "tenant-a", 1, nil)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, ledger.Flush(t.Context()))
+	assert.Less(t, size(), int64(4096), "a ledger past the threshold should be compacted")
+	assert.Equal(t, Totals{Recorded: 51, Exported: 51, Pending: 0}, ledger.Totals()["tenant-a"])
+
+	require.NoError(t, ledger.Close(t.Context()))
+}
+
+func TestLedger_TornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.ledger")
+	content := `{"record":{"seq":1,"key":"tenant-a","quantity":1,"time":"2025-01-01T00:00:00Z"}}` + "\n" + `{"record":{"seq":2,"ke`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	ledger, err := Open(path, &fakeExporter{})
+	require.NoError(t, err)
+
+	last, _ := ledger.Sequence()
+	assert.Equal(t, uint64(1), last, "a torn final line is ignored")
+
+	corrupt := filepath.Join(t.TempDir(), "corrupt.ledger")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not json\n"+`{"ack":1}`+"\n"), 0o600))
+
+	_, err = Open(corrupt, &fakeExporter{})
+	require.ErrorIs(t, err, errCorrupt)
+}
+
+func TestLedger_CrashRecovery(t *testing.T) {
+	exporter := &fakeExporter{fail: true}
+	path := filepath.Join(t.TempDir(), "usage.ledger")
+
+	ledger, err := Open(path, exporter)
+	require.NoError(t, err)
+
+	_, err = ledger.Record(t.Context(), "tenant-a", 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, ledger.file.Close())
+
+	// A crash mid-write leaves part of the next record at the end of the file
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"record":{"seq":2,"ke`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	recovered, err := Open(path, exporter)
+	require.NoError(t, err)
+
+	seq, err := recovered.Record(t.Context(), "tenant-a", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), seq, "the torn record was never acknowledged")
+	require.NoError(t, recovered.file.Close())
+
+	reopened, err := Open(path, exporter)
+	require.NoError(t, err, "records are appended after the last complete line")
+
+	last, _ := reopened.Sequence()
+	assert.Equal(t, uint64(2), last)
+	assert.Equal(t, Totals{Recorded: 3, Exported: 0, Pending: 3}, reopened.Totals()["tenant-a"])
+
+	exporter.fail = false
+
+	require.NoError(t, reopened.Close(t.Context()))
+	require.Len(t, exporter.batches, 1)
+	assert.Len(t, exporter.batches[0], 2)
+}
+
+func TestLedger_Reconcile(t *testing.T) {
+	ledger, err := Open(filepath.Join(t.TempDir(), "usage.ledger"), &fakeExporter{})
+	require.NoError(t, err)
+
+	_, err = ledger.Record(t.Context(), "tenant-a", 10, nil)
+	require.NoError(t, err)
+	_, err = ledger.Record(t.Context(), "tenant-b", 5, nil)
+	require.NoError(t, err)
+	require.NoError(t, ledger.Flush(t.Context()))
+
+	discrepancies := ledger.Reconcile(map[string]float64{"tenant-a": 10, "tenant-b": 4, "tenant-c": 1}, 0.001)
+
+	assert.Equal(t, []Discrepancy{
+		{Key: "tenant-b", Exported: 5, Billed: 4},
+		{Key: "tenant-c", Exported: 0, Billed: 1},
+	}, discrepancies)
+}
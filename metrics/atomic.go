@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// AtomicFloat64 is a float64 that can be updated atomically, for values observed by Float64ObservableGauge.BindAtomic.
+// The zero value is 0.
+type AtomicFloat64 struct {
+	bits atomic.Uint64
+}
+
+// Load returns the value.
+func (f *AtomicFloat64) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// Store sets the value.
+func (f *AtomicFloat64) Store(value float64) {
+	f.bits.Store(math.Float64bits(value))
+}
+
+// Add adds delta to the value and returns the new value.
+func (f *AtomicFloat64) Add(delta float64) float64 {
+	for {
+		old := f.bits.Load()
+		updated := math.Float64frombits(old) + delta
+
+		if f.bits.CompareAndSwap(old, math.Float64bits(updated)) {
+			return updated
+		}
+	}
+}
+
+// BindAtomic observes the value of a shared variable at each collection, with attrs, so a variable updated by the
+// application is exported without writing a callback. Unregister the returned registration to stop observing.
+// A nil instrument returns a registration that does nothing.
+func (g *Int64ObservableGauge) BindAtomic(value *atomic.Int64, attrs ...attribute.Attr) (metric.Registration, error) {
+	if g == nil {
+		return noop.Registration{}, nil
+	}
+
+	option := metric.WithAttributeSet(newAttributeSet(attrs...))
+
+	return g.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(g.int64ObservableGauge, value.Load(), option)
+
+		return nil
+	}, g.int64ObservableGauge)
+}
+
+// BindAtomic observes the value of a shared variable at each collection, with attrs, so a variable updated by the
+// application is exported without writing a callback. Unregister the returned registration to stop observing.
+// A nil instrument returns a registration that does nothing.
+func (g *Float64ObservableGauge) BindAtomic(value *AtomicFloat64, attrs ...attribute.Attr) (metric.Registration, error) {
+	if g == nil {
+		return noop.Registration{}, nil
+	}
+
+	option := metric.WithAttributeSet(newAttributeSet(attrs...))
+
+	return g.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveFloat64(g.float64ObservableGauge, value.Load(), option)
+
+		return nil
+	}, g.float64ObservableGauge)
+}
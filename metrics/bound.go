@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// boundAttributes holds an attribute set computed once for a bound instrument.
+type boundAttributes struct {
+	attrs  []attribute.Attr
+	limit  *attributeLimit
+	option metric.MeasurementOption
+}
+
+func newBoundAttributes(attrs []attribute.Attr, limit *attributeLimit) boundAttributes {
+	attrs = append([]attribute.Attr(nil), attrs...)
+
+	return boundAttributes{attrs: attrs, limit: limit, option: metric.WithAttributeSet(newAttributeSet(limit.apply(attrs)...))}
+}
+
+// measurementOption returns the precomputed attribute set, unless SetSpanAttributes requires merging span attributes.
+func (b boundAttributes) measurementOption(ctx context.Context) metric.MeasurementOption {
+	if spanAttributeKeys.Load() == nil {
+		return b.option
+	}
+
+	return metric.WithAttributeSet(newAttributeSet(b.limit.apply(withSpanAttributes(ctx, b.attrs))...))
+}
+
+// BoundInt64Counter is a Int64Counter with attributes bound by With.
+type BoundInt64Counter struct {
+	instrument *Int64Counter
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (c *Int64Counter) With(attrs ...attribute.Attr) *BoundInt64Counter {
+	if c == nil {
+		return nil
+	}
+
+	return &BoundInt64Counter{instrument: c, attributes: newBoundAttributes(attrs, c.limit)}
+}
+
+// Add increments the counter by the given value.
+func (b *BoundInt64Counter) Add(ctx context.Context, value int64) {
+	if b != nil {
+		b.instrument.int64Counter.Add(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundFloat64Counter is a Float64Counter with attributes bound by With.
+type BoundFloat64Counter struct {
+	instrument *Float64Counter
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (c *Float64Counter) With(attrs ...attribute.Attr) *BoundFloat64Counter {
+	if c == nil {
+		return nil
+	}
+
+	return &BoundFloat64Counter{instrument: c, attributes: newBoundAttributes(attrs, c.limit)}
+}
+
+// Add increments the counter by the given value.
+func (b *BoundFloat64Counter) Add(ctx context.Context, value float64) {
+	if b != nil {
+		b.instrument.float64Counter.Add(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundInt64UpDownCounter is a Int64UpDownCounter with attributes bound by With.
+type BoundInt64UpDownCounter struct {
+	instrument *Int64UpDownCounter
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (c *Int64UpDownCounter) With(attrs ...attribute.Attr) *BoundInt64UpDownCounter {
+	if c == nil {
+		return nil
+	}
+
+	return &BoundInt64UpDownCounter{instrument: c, attributes: newBoundAttributes(attrs, c.limit)}
+}
+
+// Add adds the given value to the counter (can be negative).
+func (b *BoundInt64UpDownCounter) Add(ctx context.Context, value int64) {
+	if b != nil {
+		b.instrument.int64UpDownCounter.Add(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundFloat64UpDownCounter is a Float64UpDownCounter with attributes bound by With.
+type BoundFloat64UpDownCounter struct {
+	instrument *Float64UpDownCounter
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (c *Float64UpDownCounter) With(attrs ...attribute.Attr) *BoundFloat64UpDownCounter {
+	if c == nil {
+		return nil
+	}
+
+	return &BoundFloat64UpDownCounter{instrument: c, attributes: newBoundAttributes(attrs, c.limit)}
+}
+
+// Add adds the given value to the counter (can be negative).
+func (b *BoundFloat64UpDownCounter) Add(ctx context.Context, value float64) {
+	if b != nil {
+		b.instrument.float64UpDownCounter.Add(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundInt64Gauge is a Int64Gauge with attributes bound by With.
+type BoundInt64Gauge struct {
+	instrument *Int64Gauge
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (g *Int64Gauge) With(attrs ...attribute.Attr) *BoundInt64Gauge {
+	if g == nil {
+		return nil
+	}
+
+	return &BoundInt64Gauge{instrument: g, attributes: newBoundAttributes(attrs, g.limit)}
+}
+
+// Record records a measurement.
+func (b *BoundInt64Gauge) Record(ctx context.Context, value int64) {
+	if b != nil {
+		b.instrument.int64Gauge.Record(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundFloat64Gauge is a Float64Gauge with attributes bound by With.
+type BoundFloat64Gauge struct {
+	instrument *Float64Gauge
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (g *Float64Gauge) With(attrs ...attribute.Attr) *BoundFloat64Gauge {
+	if g == nil {
+		return nil
+	}
+
+	return &BoundFloat64Gauge{instrument: g, attributes: newBoundAttributes(attrs, g.limit)}
+}
+
+// Record records a measurement.
+func (b *BoundFloat64Gauge) Record(ctx context.Context, value float64) {
+	if b != nil {
+		b.instrument.float64Gauge.Record(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundInt64Histogram is a Int64Histogram with attributes bound by With.
+type BoundInt64Histogram struct {
+	instrument *Int64Histogram
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (h *Int64Histogram) With(attrs ...attribute.Attr) *BoundInt64Histogram {
+	if h == nil {
+		return nil
+	}
+
+	return &BoundInt64Histogram{instrument: h, attributes: newBoundAttributes(attrs, h.limit)}
+}
+
+// Record records a value in the histogram distribution.
+func (b *BoundInt64Histogram) Record(ctx context.Context, value int64) {
+	if b != nil {
+		b.instrument.int64Histogram.Record(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
+
+// BoundFloat64Histogram is a Float64Histogram with attributes bound by With.
+type BoundFloat64Histogram struct {
+	instrument *Float64Histogram
+	attributes boundAttributes
+}
+
+// With returns the instrument with attrs bound, computing their attribute set once rather than on every measurement,
+// for hot paths that record with the same attributes.
+func (h *Float64Histogram) With(attrs ...attribute.Attr) *BoundFloat64Histogram {
+	if h == nil {
+		return nil
+	}
+
+	return &BoundFloat64Histogram{instrument: h, attributes: newBoundAttributes(attrs, h.limit)}
+}
+
+// Record records a value in the histogram distribution.
+func (b *BoundFloat64Histogram) Record(ctx context.Context, value float64) {
+	if b != nil {
+		b.instrument.float64Histogram.Record(ctx, value, b.attributes.measurementOption(ctx))
+	}
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// generatedStruct is implemented by metrics structs with an InitInstruments method generated by the gotel metrics
+// command, which InitMetrics, Reinit, NewInstance, and InitScoped call instead of walking the struct by reflection.
+type generatedStruct interface {
+	InitInstruments(b *Builder) error
+}
+
+// Builder creates the instruments of a metrics struct in the InitInstruments method generated by the gotel metrics
+// command, on the meter and with the options of the InitMetrics, Reinit, NewInstance, or InitScoped call.
+type Builder struct {
+	factory factory
+	report  *FieldReport
+}
+
+// Meter returns the meter to create the instruments on.
+func (b *Builder) Meter() metric.Meter {
+	return b.factory.meter
+}
+
+// Name returns the instrument name of a field without a metric tag, in the style set by WithNameStyle.
+func (b *Builder) Name(fieldName string) string {
+	return InstrumentName(fieldName, b.factory.nameStyle)
+}
+
+// Wrap returns the instrument of the metrics struct field at path, wrapping inst, the OpenTelemetry instrument
+// created on the builder's meter under name with the options of the field's tag. The attributes and max_cardinality
+// tags apply as they do in InitMetrics. PreAggregatedHistogram fields have no OpenTelemetry instrument, so inst is nil.
+func Wrap[T Instrument](b *Builder, path string, name string, inst any, tag reflect.StructTag) (*T, error) {
+	if _, ok := any((*T)(nil)).(*PreAggregatedHistogram); ok {
+		b.report.Instruments[path] = name
+		instrument, _ := any(b.factory.histograms.newHistogram(name, tag.Get("unit"), tag.Get("description"))).(*T)
+
+		return instrument, nil
+	}
+
+	limit, err := newAttributeLimit(name, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, ok := wrapInstrument(b.factory, reflect.TypeFor[*T](), inst, tag.Get("unit"), limit)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T for metric field %s of type %s", errNotInstrument, inst, path, reflect.TypeFor[T]())
+	}
+
+	b.report.Instruments[path] = name
+	instrument, _ := wrapped.(*T)
+
+	return instrument, nil
+}
+
+// WrapFamily returns the Family of the metrics struct field at path, named name and configured by the field's tag,
+// as InitMetrics creates it. Its instruments are created on the builder's meter on the first Get of each key.
+func WrapFamily[T Instrument](b *Builder, path string, name string, tag reflect.StructTag) (*Family[T], error) {
+	options, err := fieldOptions(path, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Family[T]{}
+	if err := f.init(b.factory, name, tag, options); err != nil {
+		return nil, err
+	}
+
+	b.report.Instruments[path] = name
+
+	return f, nil
+}
+
+// WrapMap returns the map field at path holding an instrument for each key of its keys tag and of current, named name
+// and configured by the field's tag, as InitMetrics creates it. Its instruments are created on the builder's meter.
+func WrapMap[T Instrument](b *Builder, path string, name string, tag reflect.StructTag, current map[string]*T) (map[string]*T, error) {
+	options, err := fieldOptions(path, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newInstrumentMap(b.factory, reflect.TypeFor[map[string]*T](), name, tag, options, reflect.ValueOf(current))
+	if err != nil {
+		return nil, err
+	}
+
+	b.report.Instruments[path] = name
+	instruments, _ := m.Interface().(map[string]*T)
+
+	return instruments, nil
+}
+
+// wrapInstrument returns the instrument of type t wrapping inst, or false if inst isn't the OpenTelemetry instrument
+// of t.
+func wrapInstrument(f factory, t reflect.Type, inst any, unit string, limit *attributeLimit) (any, bool) {
+	var wrapped any
+
+	switch t {
+	case reflect.TypeFor[*Int64Counter]():
+		if inst, ok := inst.(metric.Int64Counter); ok {
+			wrapped = &Int64Counter{int64Counter: inst, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Float64Counter]():
+		if inst, ok := inst.(metric.Float64Counter); ok {
+			wrapped = &Float64Counter{float64Counter: inst, unit: unit, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Int64UpDownCounter]():
+		if inst, ok := inst.(metric.Int64UpDownCounter); ok {
+			wrapped = &Int64UpDownCounter{int64UpDownCounter: inst, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Float64UpDownCounter]():
+		if inst, ok := inst.(metric.Float64UpDownCounter); ok {
+			wrapped = &Float64UpDownCounter{float64UpDownCounter: inst, unit: unit, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Int64ObservableCounter]():
+		if inst, ok := inst.(metric.Int64ObservableCounter); ok {
+			wrapped = &Int64ObservableCounter{inst, f.meter}
+		}
+	case reflect.TypeFor[*Float64ObservableCounter]():
+		if inst, ok := inst.(metric.Float64ObservableCounter); ok {
+			wrapped = &Float64ObservableCounter{inst, f.meter}
+		}
+	case reflect.TypeFor[*Int64ObservableUpDownCounter]():
+		if inst, ok := inst.(metric.Int64ObservableUpDownCounter); ok {
+			wrapped = &Int64ObservableUpDownCounter{inst, f.meter}
+		}
+	case reflect.TypeFor[*Float64ObservableUpDownCounter]():
+		if inst, ok := inst.(metric.Float64ObservableUpDownCounter); ok {
+			wrapped = &Float64ObservableUpDownCounter{inst, f.meter}
+		}
+	case reflect.TypeFor[*Int64Gauge]():
+		if inst, ok := inst.(metric.Int64Gauge); ok {
+			wrapped = &Int64Gauge{int64Gauge: inst, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Float64Gauge]():
+		if inst, ok := inst.(metric.Float64Gauge); ok {
+			wrapped = &Float64Gauge{float64Gauge: inst, unit: unit, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Int64ObservableGauge]():
+		if inst, ok := inst.(metric.Int64ObservableGauge); ok {
+			wrapped = &Int64ObservableGauge{inst, f.meter}
+		}
+	case reflect.TypeFor[*Float64ObservableGauge]():
+		if inst, ok := inst.(metric.Float64ObservableGauge); ok {
+			wrapped = &Float64ObservableGauge{inst, f.meter}
+		}
+	case reflect.TypeFor[*Int64Histogram]():
+		if inst, ok := inst.(metric.Int64Histogram); ok {
+			wrapped = &Int64Histogram{int64Histogram: inst, limit: limit, owner: f.owner}
+		}
+	case reflect.TypeFor[*Float64Histogram]():
+		if inst, ok := inst.(metric.Float64Histogram); ok {
+			wrapped = &Float64Histogram{float64Histogram: inst, unit: unit, limit: limit, owner: f.owner}
+		}
+	}
+
+	return wrapped, wrapped != nil
+}
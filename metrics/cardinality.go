@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+)
+
+// OverflowValue replaces every attribute value of a measurement that would exceed the max_cardinality tag of its
+// instrument, so overflowing series collapse into one, and the keys of a Family beyond its max_keys tag.
+const OverflowValue = "other"
+
+var errInvalidCardinality = errors.New("invalid max_cardinality")
+
+// attributeLimit applies the attributes and max_cardinality tags of a synchronous instrument: attributes with keys
+// outside the allow-list are dropped, and attribute sets beyond the limit are recorded as OverflowValue.
+// A limit with fixed attributes adds them to every measurement after applying next, for the members of a Family.
+type attributeLimit struct {
+	name    string
+	allowed []string
+	max     int
+	fixed   []attribute.Attr
+	next    *attributeLimit
+
+	mu     sync.Mutex
+	series map[otelattribute.Distinct]struct{}
+	warned bool
+}
+
+// newAttributeLimit returns the limit set by the tags of the instrument named name, or nil if it has none.
+func newAttributeLimit(name string, tag reflect.StructTag) (*attributeLimit, error) {
+	allowed, hasAllowed := tag.Lookup("attributes")
+	maxCardinality := tag.Get("max_cardinality")
+
+	if !hasAllowed && maxCardinality == "" {
+		return nil, nil
+	}
+
+	l := &attributeLimit{name: name, series: map[otelattribute.Distinct]struct{}{}}
+
+	if hasAllowed {
+		l.allowed = []string{}
+
+		for key := range strings.SplitSeq(allowed, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				l.allowed = append(l.allowed, key)
+			}
+		}
+	}
+
+	if maxCardinality != "" {
+		limit, err := strconv.Atoi(maxCardinality)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("%w for metric %s: %q", errInvalidCardinality, name, maxCardinality)
+		}
+
+		l.max = limit
+	}
+
+	return l, nil
+}
+
+// apply returns attrs without the keys outside the allow-list, with their values replaced by OverflowValue if they
+// are a new attribute set beyond the limit. The first overflow logs a warning.
+func (l *attributeLimit) apply(attrs []attribute.Attr) []attribute.Attr {
+	if l == nil {
+		return attrs
+	}
+
+	if l.fixed != nil {
+		return slices.Concat(l.next.apply(attrs), l.fixed)
+	}
+
+	if l.allowed != nil {
+		attrs = slices.DeleteFunc(slices.Clone(attrs), func(attr attribute.Attr) bool {
+			return !slices.Contains(l.allowed, string(attr.Key))
+		})
+	}
+
+	if l.max == 0 {
+		return attrs
+	}
+
+	set := newAttributeSet(attrs...)
+	distinct := set.Equivalent()
+
+	l.mu.Lock()
+
+	if _, ok := l.series[distinct]; ok || len(l.series) < l.max {
+		l.series[distinct] = struct{}{}
+		l.mu.Unlock()
+
+		return attrs
+	}
+
+	warn := !l.warned
+	l.warned = true
+	l.mu.Unlock()
+
+	if warn {
+		slog.Warn("metric attribute cardinality limit reached, recording new series as "+OverflowValue, "metric", l.name, "max_cardinality", l.max)
+	}
+
+	overflow := make([]attribute.Attr, len(attrs))
+	for i, attr := range attrs {
+		overflow[i] = attribute.New(string(attr.Key), OverflowValue)
+	}
+
+	return overflow
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"github.com/tinybluerobots/gotel/export"
+)
+
+// config is the configuration of InitMetrics, Reinit, and NewInstance set by the options of this package that
+// configure more than the meter provider.
+type config struct {
+	providerConfig
+
+	nameStyle    NameStyle
+	strictFields bool
+	disabled     bool
+}
+
+// newConfig applies the options of this package and returns the others, for the meter provider.
+func newConfig(options []Option) (config, []Option) {
+	c := config{disabled: export.SDKDisabled()}
+	providerOptions := make([]Option, 0, len(options))
+
+	for _, o := range options {
+		if opt, ok := o.(option); ok {
+			opt.apply(&c)
+			continue
+		}
+
+		providerOptions = append(providerOptions, o)
+	}
+
+	return c, providerOptions
+}
@@ -0,0 +1,485 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// scope is an instrumentation scope, whose meter is obtained from the provider InitMetrics created.
+type scope struct {
+	name    string
+	options []metric.MeterOption
+}
+
+// meter returns the meter of the scope on provider.
+func (sc scope) meter(provider metric.MeterProvider) metric.Meter {
+	return provider.Meter(sc.name, sc.options...)
+}
+
+// scopedMeter returns the meter of the scopeName instrumentation scope for library instruments, such as those of
+// InitScoped, New, and Meter. Its instruments follow the provider: they record nothing before InitMetrics, and are
+// created again on the provider of each later InitMetrics or Reinit call.
+func scopedMeter(scopeName string, options ...metric.MeterOption) metric.Meter {
+	return &delegatingMeter{scope: scope{name: scopeName, options: options}}
+}
+
+type binding[T any] struct {
+	provider   metric.MeterProvider
+	instrument T
+}
+
+// delegate is an instrument created again on each provider.
+type delegate[T any] struct {
+	scope  scope
+	create func(metric.Meter) (T, error)
+	bound  atomic.Pointer[binding[T]]
+	mu     sync.Mutex
+}
+
+// init creates the instrument on the current provider, returning errors such as invalid names to the caller.
+func (d *delegate[T]) init(sc scope, create func(metric.Meter) (T, error)) error {
+	d.scope, d.create = sc, create
+	provider := meterProvider()
+
+	instrument, err := create(sc.meter(provider))
+	if err != nil {
+		return err
+	}
+
+	d.bound.Store(&binding[T]{provider: provider, instrument: instrument})
+
+	return nil
+}
+
+// instrumentFor returns the instrument created on provider, creating it if it was created on another.
+func (d *delegate[T]) instrumentFor(provider metric.MeterProvider) T {
+	if b := d.bound.Load(); b != nil && b.provider == provider {
+		return b.instrument
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if b := d.bound.Load(); b != nil && b.provider == provider {
+		return b.instrument
+	}
+
+	instrument, err := d.create(d.scope.meter(provider))
+	if err != nil {
+		otel.Handle(err)
+
+		instrument, _ = d.create(noop.Meter{})
+	}
+
+	d.bound.Store(&binding[T]{provider: provider, instrument: instrument})
+
+	return instrument
+}
+
+// get returns the instrument of the current provider.
+func (d *delegate[T]) get() T {
+	return d.instrumentFor(meterProvider())
+}
+
+// binder is an observable instrument or callback registration, which must exist on the current provider for its
+// callbacks to run.
+type binder interface {
+	bind(provider metric.MeterProvider)
+}
+
+var (
+	// bindMu serializes binding, so callbacks are registered once on each provider
+	bindMu  sync.Mutex
+	binders = map[binder]struct{}{}
+)
+
+// addBinder binds b to the current provider and to every later provider.
+func addBinder(b binder) {
+	bindMu.Lock()
+	defer bindMu.Unlock()
+
+	binders[b] = struct{}{}
+	b.bind(meterProvider())
+}
+
+// bindProvider creates the observable instruments and callback registrations of library instruments on provider.
+func bindProvider(provider metric.MeterProvider) {
+	bindMu.Lock()
+	defer bindMu.Unlock()
+
+	for b := range binders {
+		b.bind(provider)
+	}
+}
+
+// observableDelegate is an observable instrument created again on each provider.
+type observableDelegate[T metric.Observable] struct {
+	delegate[T]
+}
+
+func (d *observableDelegate[T]) bind(provider metric.MeterProvider) {
+	d.instrumentFor(provider)
+}
+
+// observableFor returns the instrument created on provider, for callbacks registered on it.
+func (d *observableDelegate[T]) observableFor(provider metric.MeterProvider) metric.Observable {
+	return d.instrumentFor(provider)
+}
+
+// initObservable creates the instrument on the current provider and on every later provider.
+func (d *observableDelegate[T]) initObservable(sc scope, create func(metric.Meter) (T, error)) error {
+	if err := d.init(sc, create); err != nil {
+		return err
+	}
+
+	addBinder(d)
+
+	return nil
+}
+
+type delegatedObservable interface {
+	observableFor(provider metric.MeterProvider) metric.Observable
+}
+
+type delegatingMeter struct {
+	embedded.Meter
+
+	scope scope
+}
+
+type int64Counter struct {
+	embedded.Int64Counter
+	delegate[metric.Int64Counter]
+}
+
+func (c *int64Counter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	c.get().Add(ctx, incr, options...)
+}
+
+func (m *delegatingMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	c := &int64Counter{}
+
+	return c, c.init(m.scope, func(meter metric.Meter) (metric.Int64Counter, error) {
+		return meter.Int64Counter(name, options...)
+	})
+}
+
+type int64UpDownCounter struct {
+	embedded.Int64UpDownCounter
+	delegate[metric.Int64UpDownCounter]
+}
+
+func (c *int64UpDownCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	c.get().Add(ctx, incr, options...)
+}
+
+func (m *delegatingMeter) Int64UpDownCounter(name string, options ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	c := &int64UpDownCounter{}
+
+	return c, c.init(m.scope, func(meter metric.Meter) (metric.Int64UpDownCounter, error) {
+		return meter.Int64UpDownCounter(name, options...)
+	})
+}
+
+type int64Histogram struct {
+	embedded.Int64Histogram
+	delegate[metric.Int64Histogram]
+}
+
+func (h *int64Histogram) Record(ctx context.Context, value int64, options ...metric.RecordOption) {
+	h.get().Record(ctx, value, options...)
+}
+
+func (m *delegatingMeter) Int64Histogram(name string, options ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	h := &int64Histogram{}
+
+	return h, h.init(m.scope, func(meter metric.Meter) (metric.Int64Histogram, error) {
+		return meter.Int64Histogram(name, options...)
+	})
+}
+
+type int64Gauge struct {
+	embedded.Int64Gauge
+	delegate[metric.Int64Gauge]
+}
+
+func (g *int64Gauge) Record(ctx context.Context, value int64, options ...metric.RecordOption) {
+	g.get().Record(ctx, value, options...)
+}
+
+func (m *delegatingMeter) Int64Gauge(name string, options ...metric.Int64GaugeOption) (metric.Int64Gauge, error) {
+	g := &int64Gauge{}
+
+	return g, g.init(m.scope, func(meter metric.Meter) (metric.Int64Gauge, error) {
+		return meter.Int64Gauge(name, options...)
+	})
+}
+
+type float64Counter struct {
+	embedded.Float64Counter
+	delegate[metric.Float64Counter]
+}
+
+func (c *float64Counter) Add(ctx context.Context, incr float64, options ...metric.AddOption) {
+	c.get().Add(ctx, incr, options...)
+}
+
+func (m *delegatingMeter) Float64Counter(name string, options ...metric.Float64CounterOption) (metric.Float64Counter, error) {
+	c := &float64Counter{}
+
+	return c, c.init(m.scope, func(meter metric.Meter) (metric.Float64Counter, error) {
+		return meter.Float64Counter(name, options...)
+	})
+}
+
+type float64UpDownCounter struct {
+	embedded.Float64UpDownCounter
+	delegate[metric.Float64UpDownCounter]
+}
+
+func (c *float64UpDownCounter) Add(ctx context.Context, incr float64, options ...metric.AddOption) {
+	c.get().Add(ctx, incr, options...)
+}
+
+func (m *delegatingMeter) Float64UpDownCounter(name string, options ...metric.Float64UpDownCounterOption) (metric.Float64UpDownCounter, error) {
+	c := &float64UpDownCounter{}
+
+	return c, c.init(m.scope, func(meter metric.Meter) (metric.Float64UpDownCounter, error) {
+		return meter.Float64UpDownCounter(name, options...)
+	})
+}
+
+type float64Histogram struct {
+	embedded.Float64Histogram
+	delegate[metric.Float64Histogram]
+}
+
+func (h *float64Histogram) Record(ctx context.Context, value float64, options ...metric.RecordOption) {
+	h.get().Record(ctx, value, options...)
+}
+
+func (m *delegatingMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	h := &float64Histogram{}
+
+	return h, h.init(m.scope, func(meter metric.Meter) (metric.Float64Histogram, error) {
+		return meter.Float64Histogram(name, options...)
+	})
+}
+
+type float64Gauge struct {
+	embedded.Float64Gauge
+	delegate[metric.Float64Gauge]
+}
+
+func (g *float64Gauge) Record(ctx context.Context, value float64, options ...metric.RecordOption) {
+	g.get().Record(ctx, value, options...)
+}
+
+func (m *delegatingMeter) Float64Gauge(name string, options ...metric.Float64GaugeOption) (metric.Float64Gauge, error) {
+	g := &float64Gauge{}
+
+	return g, g.init(m.scope, func(meter metric.Meter) (metric.Float64Gauge, error) {
+		return meter.Float64Gauge(name, options...)
+	})
+}
+
+// Observable instruments embed the API interface they implement for its unexported methods.
+
+type int64ObservableCounter struct {
+	metric.Int64Observable
+	embedded.Int64ObservableCounter
+	observableDelegate[metric.Int64ObservableCounter]
+}
+
+func (m *delegatingMeter) Int64ObservableCounter(name string, options ...metric.Int64ObservableCounterOption) (metric.Int64ObservableCounter, error) {
+	c := &int64ObservableCounter{}
+
+	return c, c.initObservable(m.scope, func(meter metric.Meter) (metric.Int64ObservableCounter, error) {
+		return meter.Int64ObservableCounter(name, options...)
+	})
+}
+
+type int64ObservableUpDownCounter struct {
+	metric.Int64Observable
+	embedded.Int64ObservableUpDownCounter
+	observableDelegate[metric.Int64ObservableUpDownCounter]
+}
+
+func (m *delegatingMeter) Int64ObservableUpDownCounter(name string, options ...metric.Int64ObservableUpDownCounterOption) (metric.Int64ObservableUpDownCounter, error) {
+	c := &int64ObservableUpDownCounter{}
+
+	return c, c.initObservable(m.scope, func(meter metric.Meter) (metric.Int64ObservableUpDownCounter, error) {
+		return meter.Int64ObservableUpDownCounter(name, options...)
+	})
+}
+
+type int64ObservableGauge struct {
+	metric.Int64Observable
+	embedded.Int64ObservableGauge
+	observableDelegate[metric.Int64ObservableGauge]
+}
+
+func (m *delegatingMeter) Int64ObservableGauge(name string, options ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	g := &int64ObservableGauge{}
+
+	return g, g.initObservable(m.scope, func(meter metric.Meter) (metric.Int64ObservableGauge, error) {
+		return meter.Int64ObservableGauge(name, options...)
+	})
+}
+
+type float64ObservableCounter struct {
+	metric.Float64Observable
+	embedded.Float64ObservableCounter
+	observableDelegate[metric.Float64ObservableCounter]
+}
+
+func (m *delegatingMeter) Float64ObservableCounter(name string, options ...metric.Float64ObservableCounterOption) (metric.Float64ObservableCounter, error) {
+	c := &float64ObservableCounter{}
+
+	return c, c.initObservable(m.scope, func(meter metric.Meter) (metric.Float64ObservableCounter, error) {
+		return meter.Float64ObservableCounter(name, options...)
+	})
+}
+
+type float64ObservableUpDownCounter struct {
+	metric.Float64Observable
+	embedded.Float64ObservableUpDownCounter
+	observableDelegate[metric.Float64ObservableUpDownCounter]
+}
+
+func (m *delegatingMeter) Float64ObservableUpDownCounter(name string, options ...metric.Float64ObservableUpDownCounterOption) (metric.Float64ObservableUpDownCounter, error) {
+	c := &float64ObservableUpDownCounter{}
+
+	return c, c.initObservable(m.scope, func(meter metric.Meter) (metric.Float64ObservableUpDownCounter, error) {
+		return meter.Float64ObservableUpDownCounter(name, options...)
+	})
+}
+
+type float64ObservableGauge struct {
+	metric.Float64Observable
+	embedded.Float64ObservableGauge
+	observableDelegate[metric.Float64ObservableGauge]
+}
+
+func (m *delegatingMeter) Float64ObservableGauge(name string, options ...metric.Float64ObservableGaugeOption) (metric.Float64ObservableGauge, error) {
+	g := &float64ObservableGauge{}
+
+	return g, g.initObservable(m.scope, func(meter metric.Meter) (metric.Float64ObservableGauge, error) {
+		return meter.Float64ObservableGauge(name, options...)
+	})
+}
+
+// registration is a callback registered again on each provider.
+type registration struct {
+	embedded.Registration
+
+	scope       scope
+	callback    metric.Callback
+	instruments []metric.Observable
+	// bound is the registration on the provider the callback was last registered on, guarded by bindMu
+	bound *binding[metric.Registration]
+}
+
+func (m *delegatingMeter) RegisterCallback(callback metric.Callback, instruments ...metric.Observable) (metric.Registration, error) {
+	r := &registration{scope: m.scope, callback: callback, instruments: instruments}
+	addBinder(r)
+
+	return r, nil
+}
+
+func (r *registration) bind(provider metric.MeterProvider) {
+	if r.bound != nil {
+		if r.bound.provider == provider {
+			return
+		}
+
+		_ = r.bound.instrument.Unregister()
+	}
+
+	// The callback observes the instruments created on provider, which the provider's observer accepts
+	observer := &delegatingObserver{
+		delegated:  r.instruments,
+		observable: make([]metric.Observable, len(r.instruments)),
+	}
+
+	for i, instrument := range r.instruments {
+		observer.observable[i] = instrument
+		if delegated, ok := instrument.(delegatedObservable); ok {
+			observer.observable[i] = delegated.observableFor(provider)
+		}
+	}
+
+	callback := func(ctx context.Context, o metric.Observer) error {
+		return r.callback(ctx, observer.with(o))
+	}
+
+	registered, err := r.scope.meter(provider).RegisterCallback(callback, observer.observable...)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	if registered == nil {
+		registered = noop.Registration{}
+	}
+
+	r.bound = &binding[metric.Registration]{provider: provider, instrument: registered}
+}
+
+func (r *registration) Unregister() error {
+	bindMu.Lock()
+	defer bindMu.Unlock()
+
+	delete(binders, r)
+
+	if r.bound == nil {
+		return nil
+	}
+
+	return r.bound.instrument.Unregister()
+}
+
+// delegatingObserver observes the instruments of a provider in place of the library instruments delegating to them.
+type delegatingObserver struct {
+	embedded.Observer
+
+	delegated  []metric.Observable
+	observable []metric.Observable
+	observer   metric.Observer
+}
+
+func (o *delegatingObserver) with(observer metric.Observer) *delegatingObserver {
+	return &delegatingObserver{delegated: o.delegated, observable: o.observable, observer: observer}
+}
+
+// instrument returns the provider's instrument for a library instrument, or the instrument itself.
+func (o *delegatingObserver) instrument(instrument metric.Observable) metric.Observable {
+	for i, delegated := range o.delegated {
+		if delegated == instrument {
+			return o.observable[i]
+		}
+	}
+
+	return instrument
+}
+
+func (o *delegatingObserver) ObserveInt64(instrument metric.Int64Observable, value int64, options ...metric.ObserveOption) {
+	if observable, ok := o.instrument(instrument).(metric.Int64Observable); ok {
+		instrument = observable
+	}
+
+	o.observer.ObserveInt64(instrument, value, options...)
+}
+
+func (o *delegatingObserver) ObserveFloat64(instrument metric.Float64Observable, value float64, options ...metric.ObserveOption) {
+	if observable, ok := o.instrument(instrument).(metric.Float64Observable); ok {
+		instrument = observable
+	}
+
+	o.observer.ObserveFloat64(instrument, value, options...)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldOption configures a metrics struct field registered with Describe, like the struct tag of the same name.
+type FieldOption struct {
+	tag   string
+	value string
+}
+
+// Name sets the instrument name, like the metric tag.
+func Name(name string) FieldOption {
+	return FieldOption{tag: "metric", value: name}
+}
+
+// Unit sets the instrument unit, like the unit tag.
+func Unit(unit string) FieldOption {
+	return FieldOption{tag: "unit", value: unit}
+}
+
+// Description sets the instrument description, like the description tag.
+func Description(description string) FieldOption {
+	return FieldOption{tag: "description", value: description}
+}
+
+// Buckets sets the explicit bucket boundaries of a histogram, like the buckets tag.
+func Buckets(bounds ...float64) FieldOption {
+	values := make([]string, len(bounds))
+	for i, bound := range bounds {
+		values[i] = strconv.FormatFloat(bound, 'g', -1, 64)
+	}
+
+	return FieldOption{tag: "buckets", value: strings.Join(values, ",")}
+}
+
+// describedField identifies a field of a metrics struct type.
+type describedField struct {
+	owner reflect.Type
+	name  string
+}
+
+var (
+	descriptionsMu sync.Mutex
+	// pending holds the options of fields passed to Describe, by address, until their struct is initialized
+	pending = map[any]reflect.StructTag{}
+	// descriptions holds the options of initialized fields, by struct type and field name
+	descriptions = map[describedField]reflect.StructTag{}
+)
+
+// Describe configures the instrument of a metrics struct field without struct tags, e.g.
+// metrics.Describe(&m.Latency, metrics.Unit("ms"), metrics.Description("Request latency")).
+// Call it before InitMetrics, InitScoped, or Reinit initialize the struct; options take precedence over the field's
+// tags. Once the struct is initialized, they apply to the field of every struct of its type initialized afterwards,
+// as tags do. Code generated by the gotel metrics command reads only struct tags.
+func Describe[T Instrument](field **T, options ...FieldOption) {
+	tags := make([]string, len(options))
+	for i, option := range options {
+		tags[i] = option.tag + ":" + strconv.Quote(option.value)
+	}
+
+	descriptionsMu.Lock()
+	defer descriptionsMu.Unlock()
+
+	// Later calls take precedence, as StructTag.Get returns the first value of a key
+	pending[field] = joinTags(reflect.StructTag(strings.Join(tags, " ")), pending[field])
+}
+
+// describedTag returns the tag of the field named name of a struct of type owner, preceded by the options registered
+// for it with Describe. Options registered for the field's address are moved to its struct type, so the addresses of
+// initialized structs aren't retained.
+func describedTag(field reflect.Value, owner reflect.Type, name string, tag reflect.StructTag) reflect.StructTag {
+	key := describedField{owner: owner, name: name}
+
+	descriptionsMu.Lock()
+	defer descriptionsMu.Unlock()
+
+	if field.CanAddr() {
+		addr := field.Addr().Interface()
+		if options, ok := pending[addr]; ok {
+			delete(pending, addr)
+			descriptions[key] = joinTags(options, descriptions[key])
+		}
+	}
+
+	return joinTags(descriptions[key], tag)
+}
+
+// joinTags returns the tags of first followed by those of second, which StructTag.Get finds only if first lacks them.
+func joinTags(first reflect.StructTag, second reflect.StructTag) reflect.StructTag {
+	return reflect.StructTag(strings.TrimSpace(string(first) + " " + string(second)))
+}
@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+var (
+	errFamilyKey  = errors.New("family_key requires a synchronous instrument")
+	errFamilyType = errors.New("unsupported Family instrument type")
+	errMaxKeys    = errors.New("invalid max_keys")
+	errMapKeys    = errors.New("more keys than max_keys")
+)
+
+// defaultMaxKeys is the number of keys a Family or map field creates instruments for without a max_keys tag.
+const defaultMaxKeys = 100
+
+// synchronousTypes are the instrument types that record attributes through an attributeLimit.
+var synchronousTypes = map[reflect.Type]bool{
+	reflect.TypeFor[*Int64Counter]():         true,
+	reflect.TypeFor[*Float64Counter]():       true,
+	reflect.TypeFor[*Int64UpDownCounter]():   true,
+	reflect.TypeFor[*Float64UpDownCounter](): true,
+	reflect.TypeFor[*Int64Gauge]():           true,
+	reflect.TypeFor[*Float64Gauge]():         true,
+	reflect.TypeFor[*Int64Histogram]():       true,
+	reflect.TypeFor[*Float64Histogram]():     true,
+}
+
+// keyedInstruments names and configures the instruments of the keys of a Family or map field.
+type keyedInstruments struct {
+	factory   factory
+	name      string
+	separator string
+	options   []any
+	key       string
+	limit     *attributeLimit
+	maxKeys   int
+}
+
+// newKeyedInstruments returns the configuration of the instruments of type t, a pointer to an instrument type, of the
+// Family or map field named name.
+func newKeyedInstruments(fac factory, t reflect.Type, name string, tag reflect.StructTag, options []any) (keyedInstruments, error) {
+	limit, err := newAttributeLimit(name, tag)
+	if err != nil {
+		return keyedInstruments{}, err
+	}
+
+	if t == reflect.TypeFor[*PreAggregatedHistogram]() {
+		return keyedInstruments{}, fmt.Errorf("%w: metric %s is a %s", errFamilyType, name, t.Elem())
+	}
+
+	key := tag.Get("family_key")
+	if key != "" && !synchronousTypes[t] {
+		return keyedInstruments{}, fmt.Errorf("%w: metric %s is a %s", errFamilyKey, name, t.Elem())
+	}
+
+	maxKeys := defaultMaxKeys
+	if value := tag.Get("max_keys"); value != "" {
+		if maxKeys, err = strconv.Atoi(value); err != nil || maxKeys < 1 {
+			return keyedInstruments{}, fmt.Errorf("%w for metric %s: %q", errMaxKeys, name, value)
+		}
+	}
+
+	separator := "_"
+	if fac.nameStyle == DotCase {
+		separator = "."
+	}
+
+	return keyedInstruments{factory: fac, name: name, separator: separator, options: options, key: key, limit: limit, maxKeys: maxKeys}, nil
+}
+
+// newMember creates the instrument of type t for key on the meter of fac.
+func (k keyedInstruments) newMember(fac factory, t reflect.Type, key string) (reflect.Value, error) {
+	name, limit := k.name+k.separator+key, k.limit
+	if k.key != "" {
+		name, limit = k.name, &attributeLimit{fixed: []attribute.Attr{attribute.New(k.key, key)}, next: k.limit}
+	}
+
+	return newLimitedInstrumentValue(fac, t, name, limit, k.options)
+}
+
+// Family is a metrics struct field holding one instrument of type T per key, such as per topic or shard, for sets of
+// instruments that are bounded but only known at runtime. Each instrument is created on the first Get of its key.
+// PreAggregatedHistogram families are not supported.
+//
+// Keys are appended to the field's instrument name, joined by an underscore, or by a dot with WithNameStyle(DotCase).
+// With the family_key tag, every key shares the field's instrument instead, and measurements through the instrument
+// returned by Get carry the key as that attribute. The max_keys tag bounds the keys, 100 by default; further keys
+// share the instrument of the key OverflowValue:
+//
+//	type Metrics struct {
+//		TopicMessages *metrics.Family[metrics.Int64Counter]                        // topic_messages_orders, ...
+//		ShardLag      *metrics.Family[metrics.Int64Gauge] `family_key:"shard.id"` // shard_lag{shard.id="3"}, ...
+//	}
+type Family[T Instrument] struct {
+	keyedInstruments
+
+	members sync.Map
+	mu      sync.Mutex
+	keys    int
+	warned  bool
+}
+
+// familyField is implemented by the Family types, so InitMetrics can initialize Family fields of any instrument type.
+type familyField interface {
+	init(f factory, name string, tag reflect.StructTag, options []any) error
+}
+
+var familyFieldType = reflect.TypeFor[familyField]()
+
+func (f *Family[T]) init(fac factory, name string, tag reflect.StructTag, options []any) error {
+	keyed, err := newKeyedInstruments(fac, reflect.TypeFor[*T](), name, tag, options)
+	if err != nil {
+		return err
+	}
+
+	f.keyedInstruments = keyed
+
+	return nil
+}
+
+// Get returns the instrument for key, creating it on first use. Keys beyond max_keys return the instrument of the key
+// OverflowValue, and the first of them logs a warning. Instruments that can't be created, e.g. because the key makes
+// an invalid instrument name, are reported to the OpenTelemetry error handler and record nothing.
+func (f *Family[T]) Get(key string) *T {
+	if f == nil {
+		return nil
+	}
+
+	if member, ok := f.members.Load(key); ok {
+		instrument, _ := member.(*T)
+		return instrument
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Concurrent callers may have created the instrument while waiting for the lock
+	if member, ok := f.members.Load(key); ok {
+		instrument, _ := member.(*T)
+		return instrument
+	}
+
+	if f.keys >= f.maxKeys {
+		if !f.warned {
+			f.warned = true
+			slog.Warn("metric family key limit reached, recording new keys as "+OverflowValue, "metric", f.name, "max_keys", f.maxKeys)
+		}
+
+		if member, ok := f.members.Load(OverflowValue); ok {
+			instrument, _ := member.(*T)
+			return instrument
+		}
+
+		key = OverflowValue
+	}
+
+	inst, err := f.newMember(f.factory, reflect.TypeFor[*T](), key)
+	if err != nil {
+		otel.Handle(err)
+
+		// Cache a noop instrument so a bad key is reported once
+		noopFactory := f.factory
+		noopFactory.meter = noop.NewMeterProvider().Meter("")
+		inst, _ = f.newMember(noopFactory, reflect.TypeFor[*T](), key)
+	}
+
+	instrument, _ := inst.Interface().(*T)
+	f.members.Store(key, instrument)
+	f.keys++
+
+	return instrument
+}
+
+// Keys returns the number of keys the family has created instruments for, including OverflowValue.
+func (f *Family[T]) Keys() int {
+	if f == nil {
+		return 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.keys
+}
+
+// isInstrumentMap reports whether t is the type of a map field holding an instrument per key: a map from a string
+// type to a pointer to an instrument type.
+func isInstrumentMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && instrumentTypes[t.Elem()]
+}
+
+// newInstrumentMap returns a map of type t holding an instrument for each key listed by the keys tag or already in
+// current, named and configured as the members of a Family. A Go map can't create instruments on access, so keys must
+// be known when the struct is initialized, and there may be no more than max_keys of them.
+func newInstrumentMap(fac factory, t reflect.Type, name string, tag reflect.StructTag, options []any, current reflect.Value) (reflect.Value, error) {
+	keyed, err := newKeyedInstruments(fac, t.Elem(), name, tag, options)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	keys := []string{}
+
+	for key := range strings.SplitSeq(tag.Get("keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	if current.IsValid() {
+		for _, key := range current.MapKeys() {
+			keys = append(keys, key.String())
+		}
+	}
+
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	if len(keys) > keyed.maxKeys {
+		return reflect.Value{}, fmt.Errorf("%w: metric %s has %d keys, max_keys is %d", errMapKeys, name, len(keys), keyed.maxKeys)
+	}
+
+	m := reflect.MakeMapWithSize(t, len(keys))
+
+	for _, key := range keys {
+		inst, err := keyed.newMember(fac, t.Elem(), key)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), inst)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var errUnsupportedFields = errors.New("unsupported metric struct fields")
+
+// WithStrictFields makes InitMetrics, Reinit, NewInstance, and InitScoped return an error listing every exported
+// field of the metrics struct that was left unset, such as a field of an unrecognized type or an instrument held by
+// value, instead of silently leaving it nil.
+func WithStrictFields() Option {
+	return newOption(func(c *config) {
+		c.strictFields = true
+	})
+}
+
+// FieldReport describes how the fields of a metrics struct were initialized.
+// Fields are identified by their path in the struct, e.g. "HTTP.Requests".
+type FieldReport struct {
+	// Instruments maps each initialized field to the name of its instrument.
+	Instruments map[string]string
+	// Skipped maps each exported field left unset to the reason it was skipped.
+	Skipped map[string]string
+}
+
+func newFieldReport() *FieldReport {
+	return &FieldReport{Instruments: map[string]string{}, Skipped: map[string]string{}}
+}
+
+func (r *FieldReport) err() error {
+	if len(r.Skipped) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(r.Skipped))
+	for _, field := range slices.Sorted(maps.Keys(r.Skipped)) {
+		fields = append(fields, field+" ("+r.Skipped[field]+")")
+	}
+
+	return fmt.Errorf("%w: %s", errUnsupportedFields, strings.Join(fields, ", "))
+}
+
+var (
+	reportMu   sync.Mutex
+	lastReport = FieldReport{}
+)
+
+// Report returns the fields initialized and skipped by the last call to InitMetrics, to check at startup that
+// every instrument was created.
+func Report() FieldReport {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+
+	return lastReport
+}
+
+func setReport(report *FieldReport) {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+
+	lastReport = *report
+}
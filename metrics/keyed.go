@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type keyedCount struct {
+	value    int64
+	lastSeen time.Time
+}
+
+// KeyedCounter counts usage per key, such as an API key or tenant, and exports only keys active within its TTL,
+// bounding the cardinality of the key attribute. The count is cumulative per key, so backends derive rates from it;
+// a key that expires and becomes active again restarts from zero, which backends treat as a counter reset.
+type KeyedCounter struct {
+	attributeKey string
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	counts    map[string]*keyedCount
+	lastPrune time.Time
+}
+
+// NewKeyedCounter creates a counter named name under its own instrumentation scope, exporting each active key as
+// the attributeKey attribute. Keys not incremented within ttl are dropped.
+// The counter records nothing until InitMetrics is called, and follows later InitMetrics and Reinit calls.
+func NewKeyedCounter(scopeName string, name string, attributeKey string, ttl time.Duration, options ...metric.InstrumentOption) (*KeyedCounter, error) {
+	c := &KeyedCounter{
+		attributeKey: attributeKey,
+		ttl:          ttl,
+		now:          time.Now,
+		counts:       map[string]*keyedCount{},
+	}
+
+	instrumentOptions := make([]any, len(options))
+	for i, option := range options {
+		instrumentOptions[i] = option
+	}
+
+	meter := scopedMeter(scopeName)
+
+	counter, err := newInstrument(name, meter.Int64ObservableCounter, instrumentOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for key, value := range c.active() {
+			o.ObserveInt64(counter, value, metric.WithAttributeSet(newAttributeSet(attribute.New(c.attributeKey, key))))
+		}
+
+		return nil
+	}, counter)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Add increments the count for key.
+func (c *KeyedCounter) Add(key string, value int64) {
+	if c == nil {
+		return
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count, ok := c.counts[key]
+	if !ok {
+		count = &keyedCount{}
+		c.counts[key] = count
+	}
+
+	count.value += value
+	count.lastSeen = now
+
+	// Prune here too, so idle keys don't accumulate when nothing collects
+	if now.Sub(c.lastPrune) > c.ttl {
+		c.prune(now)
+	}
+}
+
+// Len returns the number of active keys.
+func (c *KeyedCounter) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	return len(c.active())
+}
+
+func (c *KeyedCounter) active() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(c.now())
+
+	active := make(map[string]int64, len(c.counts))
+	for key, count := range c.counts {
+		active[key] = count.value
+	}
+
+	return active
+}
+
+func (c *KeyedCounter) prune(now time.Time) {
+	for key, count := range c.counts {
+		if now.Sub(count.lastSeen) > c.ttl {
+			delete(c.counts, key)
+		}
+	}
+
+	c.lastPrune = now
+}
@@ -4,32 +4,124 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"go.opentelemetry.io/otel"
 	otelattribute "go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/metric"
-	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
-	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/metric/noop"
 	"unicode"
 )
 
-var metricsInstance any
+var errNotInstrument = errors.New("not a metric instrument type")
+
+// ErrAlreadyInitialized is returned by InitMetrics when metrics are already initialized and the shutdown function
+// of the earlier call hasn't been called. Use Reinit to replace them.
+var ErrAlreadyInitialized = errors.New("metrics are already initialized")
+
+// session is the provider and metrics struct of an InitMetrics or Reinit call, replaced as a whole by Reinit.
+type session struct {
+	provider   metric.MeterProvider
+	meter      metric.Meter
+	histograms *histogramSet
+	config     config
+	instance   any
+	registry   sync.Map
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+var current atomic.Pointer[session]
+
+// sdkProvider is a meter provider of the SDK, which records measurements, unlike the no-op provider of a session
+// created without one.
+type sdkProvider interface {
+	metric.MeterProvider
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// shutdown shuts the provider down once, and clears the session if it is still current.
+func (s *session) shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		current.CompareAndSwap(s, nil)
+
+		if provider, ok := s.provider.(sdkProvider); ok {
+			s.shutdownErr = provider.Shutdown(ctx)
+		}
+	})
+
+	return s.shutdownErr
+}
+
+// recording reports whether the session is current and its provider records measurements.
+func (s *session) recording() bool {
+	_, ok := s.provider.(sdkProvider)
+	return ok && current.Load() == s
+}
+
+// factory returns the factory of the instruments of the session's metrics struct.
+func (s *session) factory() factory {
+	return factory{meter: s.meter, histograms: s.histograms, owner: s, nameStyle: s.config.nameStyle, strictFields: s.config.strictFields}
+}
+
+// owner is the provider instruments were created for, which Strict checks records measurements.
+type owner interface {
+	recording() bool
+}
+
+// libraryOwner owns the library instruments, which record on the provider of the current session.
+type libraryOwner struct{}
+
+func (libraryOwner) recording() bool {
+	_, ok := meterProvider().(sdkProvider)
+	return ok
+}
+
+// factory creates the instruments of a metrics struct on a meter, adding its PreAggregatedHistograms to histograms
+// and naming fields without a metric tag in nameStyle. With strictFields, skipped fields are an error.
+type factory struct {
+	meter        metric.Meter
+	histograms   *histogramSet
+	owner        owner
+	nameStyle    NameStyle
+	strictFields bool
+}
+
+// libraryFactory returns the factory of library instruments created on meter.
+func libraryFactory(meter metric.Meter) factory {
+	return factory{meter: meter, histograms: libraryHistograms, owner: libraryOwner{}}
+}
+
+// meterProvider returns the provider of the current session, or a no-op provider.
+func meterProvider() metric.MeterProvider {
+	if s := current.Load(); s != nil {
+		return s.provider
+	}
+
+	return noop.NewMeterProvider()
+}
 
 // Metrics retrieves the initialized metrics struct.
 // Returns nil if metrics have not been initialized or if the type doesn't match.
 func Metrics[T any]() *T {
-	if metricsInstance == nil {
+	s := current.Load()
+	if s == nil {
 		return nil
 	}
 
-	m, ok := metricsInstance.(*T)
+	m, ok := s.instance.(*T)
 	if !ok {
 		return nil
 	}
@@ -40,21 +132,31 @@ func Metrics[T any]() *T {
 // Int64Counter is a monotonically increasing counter for int64 values.
 type Int64Counter struct {
 	int64Counter metric.Int64Counter
+	limit        *attributeLimit
+	owner        owner
 }
 
 // Float64Counter is a monotonically increasing counter for float64 values.
 type Float64Counter struct {
 	float64Counter metric.Float64Counter
+	unit           string
+	limit          *attributeLimit
+	owner          owner
 }
 
 // Int64UpDownCounter is a counter that can increase or decrease for int64 values.
 type Int64UpDownCounter struct {
 	int64UpDownCounter metric.Int64UpDownCounter
+	limit              *attributeLimit
+	owner              owner
 }
 
 // Float64UpDownCounter is a counter that can increase or decrease for float64 values.
 type Float64UpDownCounter struct {
 	float64UpDownCounter metric.Float64UpDownCounter
+	unit                 string
+	limit                *attributeLimit
+	owner                owner
 }
 
 // Int64ObservableCounter is a callback-based monotonically increasing counter for int64 values.
@@ -84,11 +186,16 @@ type Float64ObservableUpDownCounter struct {
 // Int64Gauge records instantaneous int64 measurements.
 type Int64Gauge struct {
 	int64Gauge metric.Int64Gauge
+	limit      *attributeLimit
+	owner      owner
 }
 
 // Float64Gauge records instantaneous float64 measurements.
 type Float64Gauge struct {
 	float64Gauge metric.Float64Gauge
+	unit         string
+	limit        *attributeLimit
+	owner        owner
 }
 
 // Int64ObservableGauge is a callback-based gauge for int64 values.
@@ -106,11 +213,16 @@ type Float64ObservableGauge struct {
 // Int64Histogram records a distribution of int64 values.
 type Int64Histogram struct {
 	int64Histogram metric.Int64Histogram
+	limit          *attributeLimit
+	owner          owner
 }
 
 // Float64Histogram records a distribution of float64 values.
 type Float64Histogram struct {
 	float64Histogram metric.Float64Histogram
+	unit             string
+	limit            *attributeLimit
+	owner            owner
 }
 
 func newAttributeSet(attrs ...attribute.Attr) otelattribute.Set {
@@ -126,39 +238,79 @@ func newAttributeSet(attrs ...attribute.Attr) otelattribute.Set {
 // Add increments the counter by the given value.
 func (c *Int64Counter) Add(ctx context.Context, Value int64, attrs ...attribute.Attr) {
 	if c != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(c.limit.apply(withSpanAttributes(ctx, attrs))...)
 		c.int64Counter.Add(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// AddUint64 increments the counter by an unsigned value, such as a byte count from OS statistics.
+// Values above math.MaxInt64 are clamped to math.MaxInt64.
+func (c *Int64Counter) AddUint64(ctx context.Context, value uint64, attrs ...attribute.Attr) {
+	if value > math.MaxInt64 {
+		value = math.MaxInt64
+	}
+
+	c.Add(ctx, int64(value), attrs...)
+}
+
+// Inc increments the counter by one.
+func (c *Int64Counter) Inc(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, 1, attrs...)
+}
+
 // Add increments the counter by the given value.
 func (c *Float64Counter) Add(ctx context.Context, Value float64, attrs ...attribute.Attr) {
 	if c != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(c.limit.apply(withSpanAttributes(ctx, attrs))...)
 		c.float64Counter.Add(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// Inc increments the counter by one.
+func (c *Float64Counter) Inc(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, 1, attrs...)
+}
+
 // Add adds the given value to the counter (can be negative).
 func (c *Int64UpDownCounter) Add(ctx context.Context, Value int64, attrs ...attribute.Attr) {
 	if c != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(c.limit.apply(withSpanAttributes(ctx, attrs))...)
 		c.int64UpDownCounter.Add(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// Inc increments the counter by one.
+func (c *Int64UpDownCounter) Inc(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, 1, attrs...)
+}
+
+// Dec decrements the counter by one.
+func (c *Int64UpDownCounter) Dec(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, -1, attrs...)
+}
+
 // Add adds the given value to the counter (can be negative).
 func (c *Float64UpDownCounter) Add(ctx context.Context, Value float64, attrs ...attribute.Attr) {
 	if c != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(c.limit.apply(withSpanAttributes(ctx, attrs))...)
 		c.float64UpDownCounter.Add(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// Inc increments the counter by one.
+func (c *Float64UpDownCounter) Inc(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, 1, attrs...)
+}
+
+// Dec decrements the counter by one.
+func (c *Float64UpDownCounter) Dec(ctx context.Context, attrs ...attribute.Attr) {
+	c.Add(ctx, -1, attrs...)
+}
+
 // Record records a measurement.
 func (g *Int64Gauge) Record(ctx context.Context, Value int64, attrs ...attribute.Attr) {
 	if g != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(g.limit.apply(withSpanAttributes(ctx, attrs))...)
 		g.int64Gauge.Record(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
@@ -166,7 +318,7 @@ func (g *Int64Gauge) Record(ctx context.Context, Value int64, attrs ...attribute
 // Record records a measurement.
 func (g *Float64Gauge) Record(ctx context.Context, Value float64, attrs ...attribute.Attr) {
 	if g != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(g.limit.apply(withSpanAttributes(ctx, attrs))...)
 		g.float64Gauge.Record(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
@@ -174,19 +326,57 @@ func (g *Float64Gauge) Record(ctx context.Context, Value float64, attrs ...attri
 // Record records a value in the histogram distribution.
 func (h *Int64Histogram) Record(ctx context.Context, Value int64, attrs ...attribute.Attr) {
 	if h != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(h.limit.apply(withSpanAttributes(ctx, attrs))...)
 		h.int64Histogram.Record(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// RecordDuration records a duration in milliseconds.
+func (h *Int64Histogram) RecordDuration(ctx context.Context, d time.Duration, attrs ...attribute.Attr) {
+	h.Record(ctx, d.Milliseconds(), attrs...)
+}
+
+// Start starts timing an operation and returns a function that records the elapsed time in milliseconds:
+//
+//	stop := m.Latency.Start(ctx, attrs...)
+//	defer stop()
+func (h *Int64Histogram) Start(ctx context.Context, attrs ...attribute.Attr) func() {
+	start := time.Now()
+
+	return func() {
+		h.RecordDuration(ctx, time.Since(start), attrs...)
+	}
+}
+
 // Record records a value in the histogram distribution.
 func (h *Float64Histogram) Record(ctx context.Context, Value float64, attrs ...attribute.Attr) {
 	if h != nil {
-		attributeSet := newAttributeSet(attrs...)
+		attributeSet := newAttributeSet(h.limit.apply(withSpanAttributes(ctx, attrs))...)
 		h.float64Histogram.Record(ctx, Value, metric.WithAttributeSet(attributeSet))
 	}
 }
 
+// RecordDuration records a duration in seconds, the unit the semantic conventions use for durations.
+func (h *Float64Histogram) RecordDuration(ctx context.Context, d time.Duration, attrs ...attribute.Attr) {
+	h.Record(ctx, d.Seconds(), attrs...)
+}
+
+// Start starts timing an operation and returns a function that records the elapsed time,
+// in the histogram's unit if it is a unit of time and otherwise in seconds:
+//
+//	stop := m.Latency.Start(ctx, attrs...)
+//	defer stop()
+func (h *Float64Histogram) Start(ctx context.Context, attrs ...attribute.Attr) func() {
+	start := time.Now()
+
+	return func() {
+		elapsed := time.Since(start)
+		if err := h.RecordQuantity(ctx, Seconds(elapsed), attrs...); err != nil {
+			h.RecordDuration(ctx, elapsed, attrs...)
+		}
+	}
+}
+
 // Observe records a value from within a callback.
 func (c *Int64ObservableCounter) Observe(observer metric.Int64Observer, value int64, attrs ...attribute.Attr) {
 	if c != nil {
@@ -289,8 +479,16 @@ func (g *Float64ObservableGauge) RegisterCallback(callback func(ctx context.Cont
 	return g.meter.RegisterCallback(callback, g.float64ObservableGauge)
 }
 
-func newInstrument[T any, U any](name string, newInstrument func(string, ...U) (T, error)) (T, error) {
-	c, err := newInstrument(name)
+func newInstrument[T any, U any](name string, newInstrument func(string, ...U) (T, error), options []any) (T, error) {
+	instrumentOptions := make([]U, 0, len(options))
+
+	for _, option := range options {
+		if instrumentOption, ok := option.(U); ok {
+			instrumentOptions = append(instrumentOptions, instrumentOption)
+		}
+	}
+
+	c, err := newInstrument(name, instrumentOptions...)
 	if err != nil {
 		var zero T
 		return zero, fmt.Errorf("failed to create metric instrument %s: %w", name, err)
@@ -319,178 +517,623 @@ func toSnakeCase(str string) string {
 	return sb.String()
 }
 
-func initMetricFields(meter metric.Meter, m any) error {
+// NameStyle is how instrument names are derived from the names of metrics struct fields without a metric tag.
+type NameStyle int32
+
+const (
+	// SnakeCase names instruments like request_duration for a RequestDuration field. It is the default.
+	SnakeCase NameStyle = iota
+	// DotCase names instruments like request.duration for a RequestDuration field, as OpenTelemetry conventions do.
+	DotCase
+)
+
+// WithNameStyle sets how InitMetrics, Reinit, NewInstance, and InitScoped derive instrument names from field names.
+// The metric tag overrides the derived name of a field, and prefix tags are prepended unchanged.
+func WithNameStyle(style NameStyle) Option {
+	return newOption(func(c *config) {
+		c.nameStyle = style
+	})
+}
+
+// InstrumentName returns the instrument name InitMetrics derives from the name of a metrics struct field without a
+// metric tag, in the given style.
+func InstrumentName(fieldName string, style NameStyle) string {
+	name := toSnakeCase(fieldName)
+	if style == DotCase {
+		return strings.ReplaceAll(name, "_", ".")
+	}
+
+	return name
+}
+
+// initMetricFields initializes the instruments of the metrics struct m, which may be nil, and reports its fields.
+func initMetricFields(f factory, m any) (*FieldReport, error) {
 	if m == nil || reflect.ValueOf(m).IsNil() {
-		return nil
+		return newFieldReport(), nil
 	}
 
-	metricsInstance = m
+	return initReportedInstruments(f, m)
+}
 
-	v := reflect.ValueOf(m).Elem()
-	for i := range v.NumField() {
-		field := v.Field(i)
+// fieldOptions returns the instrument options set by the unit, description, and buckets tags of the named field.
+func fieldOptions(fieldName string, tag reflect.StructTag) ([]any, error) {
+	options := []any{}
 
-		fieldName := v.Type().Field(i).Name
-		fieldName = toSnakeCase(fieldName)
+	if unit := tag.Get("unit"); unit != "" {
+		options = append(options, metric.WithUnit(unit))
+	}
 
-		switch field.Type() {
-		case reflect.TypeOf(&Int64Counter{}):
-			inst, err := newInstrument(fieldName, meter.Int64Counter)
-			if err != nil {
-				return err
-			}
+	if description := tag.Get("description"); description != "" {
+		options = append(options, metric.WithDescription(description))
+	}
 
-			field.Set(reflect.ValueOf(&Int64Counter{inst}))
-		case reflect.TypeOf(&Float64Counter{}):
-			inst, err := newInstrument(fieldName, meter.Float64Counter)
-			if err != nil {
-				return err
-			}
+	if buckets := tag.Get("buckets"); buckets != "" {
+		bounds := []float64{}
 
-			field.Set(reflect.ValueOf(&Float64Counter{inst}))
-		case reflect.TypeOf(&Int64UpDownCounter{}):
-			inst, err := newInstrument(fieldName, meter.Int64UpDownCounter)
+		for bucket := range strings.SplitSeq(buckets, ",") {
+			bound, err := strconv.ParseFloat(strings.TrimSpace(bucket), 64)
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("invalid bucket boundary for metric field %s: %w", fieldName, err)
 			}
 
-			field.Set(reflect.ValueOf(&Int64UpDownCounter{inst}))
-		case reflect.TypeOf(&Float64UpDownCounter{}):
-			inst, err := newInstrument(fieldName, meter.Float64UpDownCounter)
-			if err != nil {
-				return err
-			}
+			bounds = append(bounds, bound)
+		}
 
-			field.Set(reflect.ValueOf(&Float64UpDownCounter{inst}))
-		case reflect.TypeOf(&Int64ObservableCounter{}):
-			inst, err := newInstrument(fieldName, meter.Int64ObservableCounter)
-			if err != nil {
-				return err
-			}
+		options = append(options, metric.WithExplicitBucketBoundaries(bounds...))
+	}
 
-			field.Set(reflect.ValueOf(&Int64ObservableCounter{inst, meter}))
-		case reflect.TypeOf(&Float64ObservableCounter{}):
-			inst, err := newInstrument(fieldName, meter.Float64ObservableCounter)
-			if err != nil {
-				return err
-			}
+	return options, nil
+}
 
-			field.Set(reflect.ValueOf(&Float64ObservableCounter{inst, meter}))
-		case reflect.TypeOf(&Int64ObservableUpDownCounter{}):
-			inst, err := newInstrument(fieldName, meter.Int64ObservableUpDownCounter)
-			if err != nil {
-				return err
-			}
+func initInstruments(f factory, m any) error {
+	_, err := initReportedInstruments(f, m)
 
-			field.Set(reflect.ValueOf(&Int64ObservableUpDownCounter{inst, meter}))
-		case reflect.TypeOf(&Float64ObservableUpDownCounter{}):
-			inst, err := newInstrument(fieldName, meter.Float64ObservableUpDownCounter)
-			if err != nil {
-				return err
-			}
+	return err
+}
 
-			field.Set(reflect.ValueOf(&Float64ObservableUpDownCounter{inst, meter}))
-		case reflect.TypeOf(&Int64Gauge{}):
-			inst, err := newInstrument(fieldName, meter.Int64Gauge)
-			if err != nil {
-				return err
-			}
+// initReportedInstruments initializes the instruments of the metrics struct m and reports its fields, with its
+// generated InitInstruments method if it has one. With WithStrictFields, skipped fields are returned as an error.
+func initReportedInstruments(f factory, m any) (*FieldReport, error) {
+	report := newFieldReport()
 
-			field.Set(reflect.ValueOf(&Int64Gauge{inst}))
-		case reflect.TypeOf(&Float64Gauge{}):
-			inst, err := newInstrument(fieldName, meter.Float64Gauge)
-			if err != nil {
-				return err
-			}
+	if generated, ok := m.(generatedStruct); ok {
+		return report, generated.InitInstruments(&Builder{factory: f, report: report})
+	}
 
-			field.Set(reflect.ValueOf(&Float64Gauge{inst}))
-		case reflect.TypeOf(&Int64ObservableGauge{}):
-			inst, err := newInstrument(fieldName, meter.Int64ObservableGauge)
-			if err != nil {
-				return err
+	if err := initStruct(f, reflect.ValueOf(m).Elem(), "", "", map[reflect.Type]bool{}, report); err != nil {
+		return report, err
+	}
+
+	if f.strictFields {
+		return report, report.err()
+	}
+
+	return report, nil
+}
+
+var instrumentTypes = map[reflect.Type]bool{
+	reflect.TypeFor[*Int64Counter]():                   true,
+	reflect.TypeFor[*Float64Counter]():                 true,
+	reflect.TypeFor[*Int64UpDownCounter]():             true,
+	reflect.TypeFor[*Float64UpDownCounter]():           true,
+	reflect.TypeFor[*Int64ObservableCounter]():         true,
+	reflect.TypeFor[*Float64ObservableCounter]():       true,
+	reflect.TypeFor[*Int64ObservableUpDownCounter]():   true,
+	reflect.TypeFor[*Float64ObservableUpDownCounter](): true,
+	reflect.TypeFor[*Int64Gauge]():                     true,
+	reflect.TypeFor[*Float64Gauge]():                   true,
+	reflect.TypeFor[*Int64ObservableGauge]():           true,
+	reflect.TypeFor[*Float64ObservableGauge]():         true,
+	reflect.TypeFor[*Int64Histogram]():                 true,
+	reflect.TypeFor[*Float64Histogram]():               true,
+	reflect.TypeFor[*PreAggregatedHistogram]():         true,
+}
+
+// isMetricGroup reports whether the struct type t has instrument fields, directly or in nested groups.
+func isMetricGroup(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+
+	seen[t] = true
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case instrumentTypes[field.Type], field.Type.Implements(familyFieldType), isInstrumentMap(field.Type):
+			return true
+		case field.Type.Kind() == reflect.Struct && isMetricGroup(field.Type, seen):
+			return true
+		case field.Type.Kind() == reflect.Pointer && isMetricGroup(field.Type.Elem(), seen):
+			return true
+		}
+	}
+
+	return false
+}
+
+// initStruct creates an instrument for each instrument field of the struct, descending into nested structs,
+// pointers to structs, and embedded structs such as the Semconv groups.
+// Fields are named by their metric tag, or by their name in snake case, after the prefix tags of their parents.
+// The instruments created and the fields skipped are added to report under their path, which starts with path.
+func initStruct(f factory, v reflect.Value, prefix string, path string, visiting map[reflect.Type]bool, report *FieldReport) error {
+	visiting[v.Type()] = true
+	defer delete(visiting, v.Type())
+
+	for i := range v.NumField() {
+		field := v.Field(i)
+		structField := v.Type().Field(i)
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		fieldPath := path + structField.Name
+
+		if field.Kind() == reflect.Struct {
+			if instrumentTypes[reflect.PointerTo(field.Type())] {
+				report.Skipped[fieldPath] = "instrument is not a pointer"
+				continue
 			}
 
-			field.Set(reflect.ValueOf(&Int64ObservableGauge{inst, meter}))
-		case reflect.TypeOf(&Float64ObservableGauge{}):
-			inst, err := newInstrument(fieldName, meter.Float64ObservableGauge)
-			if err != nil {
+			if err := initStruct(f, field, prefix+structField.Tag.Get("prefix"), fieldPath+".", visiting, report); err != nil {
 				return err
 			}
 
-			field.Set(reflect.ValueOf(&Float64ObservableGauge{inst, meter}))
-		case reflect.TypeOf(&Int64Histogram{}):
-			inst, err := newInstrument(fieldName, meter.Int64Histogram)
-			if err != nil {
+			continue
+		}
+
+		tag := describedTag(field, v.Type(), structField.Name, structField.Tag)
+
+		fieldName := tag.Get("metric")
+		if fieldName == "" {
+			fieldName = InstrumentName(structField.Name, f.nameStyle)
+		}
+
+		options, err := fieldOptions(structField.Name, tag)
+		if err != nil {
+			return err
+		}
+
+		if field.Type().Implements(familyFieldType) {
+			family := reflect.New(field.Type().Elem())
+
+			initializer, _ := family.Interface().(familyField)
+			if err := initializer.init(f, prefix+fieldName, tag, options); err != nil {
 				return err
 			}
 
-			field.Set(reflect.ValueOf(&Int64Histogram{inst}))
-		case reflect.TypeOf(&Float64Histogram{}):
-			inst, err := newInstrument(fieldName, meter.Float64Histogram)
+			field.Set(family)
+			report.Instruments[fieldPath] = prefix + fieldName
+
+			continue
+		}
+
+		if isInstrumentMap(field.Type()) {
+			m, err := newInstrumentMap(f, field.Type(), prefix+fieldName, tag, options, field)
 			if err != nil {
 				return err
 			}
 
-			field.Set(reflect.ValueOf(&Float64Histogram{inst}))
+			field.Set(m)
+			report.Instruments[fieldPath] = prefix + fieldName
+
+			continue
+		}
+
+		inst, err := newInstrumentValue(f, field.Type(), prefix+fieldName, tag, options)
+		if err != nil {
+			return err
+		}
+
+		if inst.IsValid() {
+			field.Set(inst)
+			report.Instruments[fieldPath] = prefix + fieldName
+
+			continue
+		}
+
+		// Pointers to metric groups are allocated and initialized like nested structs, skipping self references
+		groupType := field.Type()
+		if groupType.Kind() != reflect.Pointer || !isMetricGroup(groupType.Elem(), map[reflect.Type]bool{}) {
+			report.Skipped[fieldPath] = "unsupported type " + groupType.String()
+			continue
+		}
+
+		if visiting[groupType.Elem()] {
+			continue
+		}
+
+		if field.IsNil() {
+			field.Set(reflect.New(groupType.Elem()))
+		}
+
+		if err := initStruct(f, field.Elem(), prefix+structField.Tag.Get("prefix"), fieldPath+".", visiting, report); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func newGrpcMetricExporter(ctx context.Context, insecure bool) (sdkmetric.Exporter, error) {
-	options := []otlpmetricgrpc.Option{}
+// newInstrumentValue creates the instrument for a field of type t, or returns an invalid Value if t isn't an instrument type.
+func newInstrumentValue(f factory, t reflect.Type, name string, tag reflect.StructTag, options []any) (reflect.Value, error) {
+	if t == reflect.TypeOf(&PreAggregatedHistogram{}) {
+		return reflect.ValueOf(f.histograms.newHistogram(name, tag.Get("unit"), tag.Get("description"))), nil
+	}
 
-	if insecure {
-		options = append(options, otlpmetricgrpc.WithInsecure())
+	limit, err := newAttributeLimit(name, tag)
+	if err != nil {
+		return reflect.Value{}, err
 	}
 
-	return otlpmetricgrpc.New(ctx, options...)
+	return newLimitedInstrumentValue(f, t, name, limit, options)
 }
 
-func newHttpMetricExporter(ctx context.Context, insecure bool) (sdkmetric.Exporter, error) {
-	options := []otlpmetrichttp.Option{}
+// newLimitedInstrumentValue creates the instrument for a field of type t with limit applied to the attributes of its
+// measurements, or returns an invalid Value if t isn't an instrument type other than PreAggregatedHistogram.
+func newLimitedInstrumentValue(f factory, t reflect.Type, name string, limit *attributeLimit, options []any) (reflect.Value, error) {
+	meter := f.meter
+
+	switch t {
+	case reflect.TypeOf(&Int64Counter{}):
+		inst, err := newInstrument(name, meter.Int64Counter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64Counter{int64Counter: inst, limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Float64Counter{}):
+		inst, err := newInstrument(name, meter.Float64Counter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64Counter{float64Counter: inst, unit: instrumentUnit(options), limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Int64UpDownCounter{}):
+		inst, err := newInstrument(name, meter.Int64UpDownCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64UpDownCounter{int64UpDownCounter: inst, limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Float64UpDownCounter{}):
+		inst, err := newInstrument(name, meter.Float64UpDownCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64UpDownCounter{float64UpDownCounter: inst, unit: instrumentUnit(options), limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Int64ObservableCounter{}):
+		inst, err := newInstrument(name, meter.Int64ObservableCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64ObservableCounter{inst, meter}), nil
+	case reflect.TypeOf(&Float64ObservableCounter{}):
+		inst, err := newInstrument(name, meter.Float64ObservableCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64ObservableCounter{inst, meter}), nil
+	case reflect.TypeOf(&Int64ObservableUpDownCounter{}):
+		inst, err := newInstrument(name, meter.Int64ObservableUpDownCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64ObservableUpDownCounter{inst, meter}), nil
+	case reflect.TypeOf(&Float64ObservableUpDownCounter{}):
+		inst, err := newInstrument(name, meter.Float64ObservableUpDownCounter, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64ObservableUpDownCounter{inst, meter}), nil
+	case reflect.TypeOf(&Int64Gauge{}):
+		inst, err := newInstrument(name, meter.Int64Gauge, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64Gauge{int64Gauge: inst, limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Float64Gauge{}):
+		inst, err := newInstrument(name, meter.Float64Gauge, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64Gauge{float64Gauge: inst, unit: instrumentUnit(options), limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Int64ObservableGauge{}):
+		inst, err := newInstrument(name, meter.Int64ObservableGauge, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64ObservableGauge{inst, meter}), nil
+	case reflect.TypeOf(&Float64ObservableGauge{}):
+		inst, err := newInstrument(name, meter.Float64ObservableGauge, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Float64ObservableGauge{inst, meter}), nil
+	case reflect.TypeOf(&Int64Histogram{}):
+		inst, err := newInstrument(name, meter.Int64Histogram, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&Int64Histogram{int64Histogram: inst, limit: limit, owner: f.owner}), nil
+	case reflect.TypeOf(&Float64Histogram{}):
+		inst, err := newInstrument(name, meter.Float64Histogram, options)
+		if err != nil {
+			return reflect.Value{}, err
+		}
 
-	if insecure {
-		options = append(options, otlpmetrichttp.WithInsecure())
+		return reflect.ValueOf(&Float64Histogram{float64Histogram: inst, unit: instrumentUnit(options), limit: limit, owner: f.owner}), nil
 	}
 
-	return otlpmetrichttp.New(ctx, options...)
+	return reflect.Value{}, nil
+}
+
+// ShutdownFunc flushes and closes a meter provider.
+type ShutdownFunc func(context.Context) error
+
+// WithDisabled turns the metrics of InitMetrics, Reinit, or NewInstance off, e.g. in one environment from
+// configuration, as OTEL_SDK_DISABLED=true does: the instruments are created on a no-op meter, so the metrics struct
+// is initialized and usable, but no provider, reader, or exporter is created and nothing is recorded or exported,
+// including PreAggregatedHistogram fields.
+func WithDisabled() Option {
+	return newOption(func(c *config) {
+		c.disabled = true
+	})
 }
 
 // InitMetrics initializes metrics with OTLP exporters.
 // Metric instruments are automatically created from the struct fields using reflection.
-// Returns a shutdown function to flush and close the meter provider.
-func InitMetrics[T any](ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct *T, options ...sdkmetric.Option) (func(context.Context) error, error) {
-	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
-		insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
-		useHttp := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") == "http"
+// With OTEL_SDK_DISABLED=true or WithDisabled, the instruments are created on a no-op meter instead: Metrics still
+// returns the struct and every instrument can be used, but nothing is recorded or exported.
+// Returns a shutdown function to flush and close the meter provider. It returns ErrAlreadyInitialized if metrics
+// are already initialized, until the shutdown function of the earlier call is called.
+func InitMetrics[T any](ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct *T, options ...Option) (func(context.Context) error, error) {
+	if current.Load() != nil {
+		return nil, ErrAlreadyInitialized
+	}
 
-		var (
-			exporter sdkmetric.Exporter
-			err      error
-		)
+	return initSession(ctx, serviceName, resourceAttrs, metricsStruct, false, options)
+}
 
-		if useHttp {
-			exporter, err = newHttpMetricExporter(ctx, insecure)
-		} else {
-			exporter, err = newGrpcMetricExporter(ctx, insecure)
-		}
+// Reinit replaces the metrics initialized by InitMetrics or an earlier Reinit, e.g. to apply new configuration.
+// It creates a provider and initializes metricsStruct as InitMetrics does, makes them current, and then shuts the
+// previous provider down, flushing its pending measurements. If initialization fails, the previous metrics stay
+// current. An error shutting the previous provider down is returned with the new shutdown function.
+// Library instruments, such as those of InitScoped, New, and Meter, record on the new provider from then on,
+// and instruments cached by Named are created again on the new provider.
+func Reinit[T any](ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct *T, options ...Option) (func(context.Context) error, error) {
+	return initSession(ctx, serviceName, resourceAttrs, metricsStruct, true, options)
+}
 
+func initSession(ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, metricsStruct any, replace bool, options []Option) (func(context.Context) error, error) {
+	c, options := newConfig(options)
+	s := &session{provider: noop.NewMeterProvider(), histograms: &histogramSet{}, config: c, instance: metricsStruct}
+
+	if !disabled && !c.disabled {
+		provider, err := newSessionProvider(ctx, c, resourceAttrs, options, producerFor(libraryHistograms, s.histograms))
 		if err != nil {
 			return nil, err
 		}
 
-		options = append(options, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+		s.provider = provider
 	}
 
-	options = append(options, sdkmetric.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
-	provider := sdkmetric.NewMeterProvider(options...)
-	meter := provider.Meter(serviceName)
+	s.meter = s.provider.Meter(serviceName)
 
-	if err := initMetricFields(meter, metricsStruct); err != nil {
+	report, err := initMetricFields(s.factory(), metricsStruct)
+	if err != nil {
+		_ = s.shutdown(ctx)
 		return nil, err
 	}
 
-	return provider.Shutdown, nil
+	// Only a fully initialized session is made current, so a failed call leaves the current metrics untouched
+	var previous *session
+
+	if replace {
+		previous = current.Swap(s)
+	} else if !current.CompareAndSwap(nil, s) {
+		_ = s.shutdown(ctx)
+		return nil, ErrAlreadyInitialized
+	}
+
+	setReport(report)
+	bindProvider(s.provider)
+
+	if err := export.RecordMetrics(s.provider); err != nil {
+		otel.Handle(err)
+	}
+
+	if previous != nil {
+		return s.shutdown, previous.shutdown(ctx)
+	}
+
+	return s.shutdown, nil
+}
+
+// instance is the provider of a metrics struct created by NewInstance.
+type instance struct {
+	provider sdkProvider
+	closed   atomic.Bool
+}
+
+// instances holds the instances not yet shut down, flushed by ForceFlush.
+var instances sync.Map
+
+func (i *instance) recording() bool {
+	return i.provider != nil && !i.closed.Load()
+}
+
+func (i *instance) shutdown(ctx context.Context) error {
+	if i.closed.Swap(true) || i.provider == nil {
+		return nil
+	}
+
+	instances.Delete(i)
+
+	return i.provider.Shutdown(ctx)
+}
+
+// NewInstance creates a metrics struct on its own meter provider, independent of InitMetrics and of other instances,
+// so a library or subsystem can own its metrics and export them on its own schedule. The instruments are created
+// from the struct fields as in InitMetrics, under the scopeName instrumentation scope, and export to the OTLP
+// endpoint if one is configured. Pass sdkmetric.WithResource to set the resource, which otherwise comes from the
+// environment. Metrics and InitScoped are unaffected, and ForceFlush flushes the instance until it is shut down.
+func NewInstance[T any](ctx context.Context, scopeName string, options ...Option) (*T, ShutdownFunc, error) {
+	c, options := newConfig(options)
+	i := &instance{}
+	histograms := &histogramSet{}
+
+	if !c.disabled {
+		provider, err := newMeterProvider(ctx, c, options, producerFor(histograms))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		i.provider = provider
+	}
+
+	var provider metric.MeterProvider = noop.NewMeterProvider()
+	if i.provider != nil {
+		provider = i.provider
+	}
+
+	metricsStruct := new(T)
+
+	if err := initInstruments(factory{meter: provider.Meter(scopeName), histograms: histograms, owner: i, nameStyle: c.nameStyle, strictFields: c.strictFields}, metricsStruct); err != nil {
+		_ = i.shutdown(ctx)
+		return nil, nil, err
+	}
+
+	if i.provider != nil {
+		instances.Store(i, struct{}{})
+	}
+
+	return metricsStruct, i.shutdown, nil
+}
+
+// New creates a single instrument of type T, such as Int64Counter, under its own instrumentation scope,
+// for instruments whose names are only known at runtime.
+// Instruments record nothing until InitMetrics is called, and follow later InitMetrics and Reinit calls.
+func New[T any](scopeName string, name string, options ...metric.InstrumentOption) (*T, error) {
+	instrumentOptions := make([]any, len(options))
+	for i, option := range options {
+		instrumentOptions[i] = option
+	}
+
+	inst, err := newInstrumentValue(libraryFactory(scopedMeter(scopeName)), reflect.TypeFor[*T](), name, "", instrumentOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if !inst.IsValid() {
+		return nil, fmt.Errorf("%w: %s", errNotInstrument, reflect.TypeFor[T]())
+	}
+
+	instrument, _ := inst.Interface().(*T)
+
+	return instrument, nil
+}
+
+// Instrument is the set of instrument types a metrics struct field can have.
+type Instrument interface {
+	Int64Counter | Float64Counter | Int64UpDownCounter | Float64UpDownCounter |
+		Int64ObservableCounter | Float64ObservableCounter | Int64ObservableUpDownCounter | Float64ObservableUpDownCounter |
+		Int64Gauge | Float64Gauge | Int64ObservableGauge | Float64ObservableGauge |
+		Int64Histogram | Float64Histogram | PreAggregatedHistogram
+}
+
+// NewField creates the instrument of a metrics struct field on meter, with the options of the field's unit,
+// description, and buckets tags, as InitMetrics does for each field it finds by reflection, for instruments
+// declared by tag strings at runtime.
+func NewField[T Instrument](meter metric.Meter, name string, tag reflect.StructTag) (*T, error) {
+	options, err := fieldOptions(name, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := newInstrumentValue(libraryFactory(meter), reflect.TypeFor[*T](), name, tag, options)
+	if err != nil {
+		return nil, err
+	}
+
+	instrument, _ := inst.Interface().(*T)
+
+	return instrument, nil
+}
+
+// Meter returns the meter of the provider created by InitMetrics for the scopeName instrumentation scope,
+// for instruments created by NewField. Instruments record nothing until InitMetrics is called, and follow later
+// InitMetrics and Reinit calls.
+func Meter(scopeName string) metric.Meter {
+	return scopedMeter(scopeName)
+}
+
+// ForceFlush collects and exports all pending measurements now rather than at the next periodic export,
+// e.g. at the end of a long streaming response. It flushes the provider of InitMetrics, if it has been called,
+// and those of the NewInstance structs not yet shut down.
+func ForceFlush(ctx context.Context) error {
+	errs := []error{}
+
+	if provider, ok := meterProvider().(sdkProvider); ok {
+		errs = append(errs, provider.ForceFlush(ctx))
+	}
+
+	instances.Range(func(key, _ any) bool {
+		if i, ok := key.(*instance); ok {
+			errs = append(errs, i.provider.ForceFlush(ctx))
+		}
+
+		return true
+	})
+
+	return errors.Join(errs...)
+}
+
+// InitScoped initializes the instruments of a library-owned metrics struct on the provider created by InitMetrics.
+// Instruments are created under their own instrumentation scope and the struct returned by Metrics is unaffected.
+// Instruments record nothing until InitMetrics is called, and follow later InitMetrics and Reinit calls, so a library
+// can initialize them once, e.g. with Scoped, whenever it first needs them. Of the options, only those configuring
+// the struct's fields, WithNameStyle and WithStrictFields, apply.
+func InitScoped[T any](scopeName string, metricsStruct *T, options ...Option) error {
+	if metricsStruct == nil {
+		return nil
+	}
+
+	c, _ := newConfig(options)
+	f := libraryFactory(scopedMeter(scopeName))
+	f.nameStyle, f.strictFields = c.nameStyle, c.strictFields
+
+	return initInstruments(f, metricsStruct)
+}
+
+// Scoped returns a function that initializes a library-owned metrics struct with InitScoped on its first call
+// and returns the same struct on every call, so a package can declare its instruments once:
+//
+//	var getMetrics = metrics.Scoped[clientMetrics]("github.com/acme/client")
+//
+// Errors creating instruments are passed to the OpenTelemetry error handler, and the failed fields stay nil.
+func Scoped[T any](scopeName string) func() *T {
+	return sync.OnceValue(func() *T {
+		metricsStruct := new(T)
+		if err := InitScoped(scopeName, metricsStruct); err != nil {
+			otel.Handle(err)
+		}
+
+		return metricsStruct
+	})
 }
@@ -1,15 +1,27 @@
+//go:build !gotel_disabled
+
 package metrics
 
 import (
 	"context"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // TestMetrics is a sample metrics struct for testing
@@ -35,7 +47,7 @@ func initTestMetrics(t *testing.T) (*TestMetrics, *sdkmetric.ManualReader) {
 	reader := sdkmetric.NewManualReader()
 	m := &TestMetrics{}
 	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
-	_, err := InitMetrics(
+	_, err := Reinit(
 		t.Context(),
 		"test-service",
 		resourceAttrs,
@@ -86,6 +98,56 @@ func TestInt64Counter_Add(t *testing.T) {
 	assert.NotEmpty(t, sum.DataPoints, "no data points recorded")
 }
 
+func TestInt64Counter_AddUint64(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	m.Counter.AddUint64(ctx, math.MaxUint64)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	metric := findMetric(rm, "counter")
+	require.NotNil(t, metric, "Counter metric not found")
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", metric.Data)
+	require.NotEmpty(t, sum.DataPoints, "no data points recorded")
+	assert.Equal(t, int64(math.MaxInt64), sum.DataPoints[0].Value, "value should be clamped")
+}
+
+func TestIncDec(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	m.Counter.Inc(ctx)
+	m.Counter.Inc(ctx)
+	m.FloatCounter.Inc(ctx, attribute.New("key", "value"))
+	m.UpDown.Inc(ctx)
+	m.UpDown.Inc(ctx)
+	m.UpDown.Dec(ctx)
+	m.FloatUpDown.Dec(ctx)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	counter, _ := findMetric(rm, "counter").Data.(metricdata.Sum[int64])
+	require.Len(t, counter.DataPoints, 1)
+	assert.Equal(t, int64(2), counter.DataPoints[0].Value)
+
+	floatCounter, _ := findMetric(rm, "float_counter").Data.(metricdata.Sum[float64])
+	require.Len(t, floatCounter.DataPoints, 1)
+	assert.InDelta(t, 1.0, floatCounter.DataPoints[0].Value, 0)
+
+	upDown, _ := findMetric(rm, "up_down").Data.(metricdata.Sum[int64])
+	require.Len(t, upDown.DataPoints, 1)
+	assert.Equal(t, int64(1), upDown.DataPoints[0].Value)
+
+	floatUpDown, _ := findMetric(rm, "float_up_down").Data.(metricdata.Sum[float64])
+	require.Len(t, floatUpDown.DataPoints, 1)
+	assert.InDelta(t, -1.0, floatUpDown.DataPoints[0].Value, 0)
+}
+
 func TestFloat64Counter_Add(t *testing.T) {
 	m, reader := initTestMetrics(t)
 	ctx := t.Context()
@@ -195,6 +257,79 @@ func TestFloat64Histogram_Record(t *testing.T) {
 	assert.NotEmpty(t, hist.DataPoints, "no data points recorded")
 }
 
+func TestHistogram_RecordDuration(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	m.Histogram.RecordDuration(ctx, 1500*time.Millisecond)
+	m.FloatHistogram.RecordDuration(ctx, 1500*time.Millisecond)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	metric := findMetric(rm, "histogram")
+	require.NotNil(t, metric, "Histogram metric not found")
+
+	hist, ok := metric.Data.(metricdata.Histogram[int64])
+	require.True(t, ok, "expected Histogram[int64], got %T", metric.Data)
+	require.NotEmpty(t, hist.DataPoints, "no data points recorded")
+	assert.Equal(t, int64(1500), hist.DataPoints[0].Sum, "int histograms record milliseconds")
+
+	metric = findMetric(rm, "float_histogram")
+	require.NotNil(t, metric, "FloatHistogram metric not found")
+
+	floatHist, ok := metric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", metric.Data)
+	require.NotEmpty(t, floatHist.DataPoints, "no data points recorded")
+	assert.InDelta(t, 1.5, floatHist.DataPoints[0].Sum, 0.001, "float histograms record seconds")
+}
+
+func TestHistogram_Start(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	seconds, err := New[Float64Histogram]("test-scope", "seconds_histogram")
+	require.NoError(t, err)
+
+	millis, err := New[Float64Histogram]("test-scope", "millis_histogram", metric.WithUnit("ms"))
+	require.NoError(t, err)
+
+	ints, err := New[Int64Histogram]("test-scope", "int_histogram")
+	require.NoError(t, err)
+
+	stops := []func(){seconds.Start(ctx), millis.Start(ctx), ints.Start(ctx)}
+
+	time.Sleep(20 * time.Millisecond)
+
+	for _, stop := range stops {
+		stop()
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	floatSum := func(name string) float64 {
+		metric := findMetric(rm, name)
+		require.NotNil(t, metric)
+
+		hist, ok := metric.Data.(metricdata.Histogram[float64])
+		require.True(t, ok)
+
+		return hist.DataPoints[0].Sum
+	}
+
+	assert.GreaterOrEqual(t, floatSum("seconds_histogram"), 0.02)
+	assert.Less(t, floatSum("seconds_histogram"), 1.0, "histograms without a time unit record seconds")
+	assert.GreaterOrEqual(t, floatSum("millis_histogram"), 20.0, "histograms with a time unit record in it")
+
+	metric := findMetric(rm, "int_histogram")
+	require.NotNil(t, metric)
+
+	hist, ok := metric.Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, hist.DataPoints[0].Sum, int64(20), "int histograms record milliseconds")
+}
+
 // Test nil receiver safety - methods should not panic on nil
 func TestNilReceiverSafety(t *testing.T) {
 	ctx := t.Context()
@@ -203,24 +338,29 @@ func TestNilReceiverSafety(t *testing.T) {
 		var c *Int64Counter
 
 		assert.NotPanics(t, func() { c.Add(ctx, 1) })
+		assert.NotPanics(t, func() { c.AddUint64(ctx, 1) })
+		assert.NotPanics(t, func() { c.Inc(ctx) })
 	})
 
 	t.Run("Float64Counter", func(t *testing.T) {
 		var c *Float64Counter
 
 		assert.NotPanics(t, func() { c.Add(ctx, 1.0) })
+		assert.NotPanics(t, func() { c.Inc(ctx) })
 	})
 
 	t.Run("Int64UpDownCounter", func(t *testing.T) {
 		var c *Int64UpDownCounter
 
 		assert.NotPanics(t, func() { c.Add(ctx, 1) })
+		assert.NotPanics(t, func() { c.Dec(ctx) })
 	})
 
 	t.Run("Float64UpDownCounter", func(t *testing.T) {
 		var c *Float64UpDownCounter
 
 		assert.NotPanics(t, func() { c.Add(ctx, 1.0) })
+		assert.NotPanics(t, func() { c.Dec(ctx) })
 	})
 
 	t.Run("Int64Gauge", func(t *testing.T) {
@@ -239,12 +379,22 @@ func TestNilReceiverSafety(t *testing.T) {
 		var h *Int64Histogram
 
 		assert.NotPanics(t, func() { h.Record(ctx, 1) })
+		assert.NotPanics(t, func() { h.RecordDuration(ctx, time.Second) })
+		assert.NotPanics(t, func() { h.Start(ctx)() })
 	})
 
 	t.Run("Float64Histogram", func(t *testing.T) {
 		var h *Float64Histogram
 
 		assert.NotPanics(t, func() { h.Record(ctx, 1.0) })
+		assert.NotPanics(t, func() { h.RecordDuration(ctx, time.Second) })
+		assert.NotPanics(t, func() { h.Start(ctx)() })
+	})
+
+	t.Run("PreAggregatedHistogram", func(t *testing.T) {
+		var h *PreAggregatedHistogram
+
+		assert.NotPanics(t, func() { _ = h.Record(ctx, HistogramSummary{}) })
 	})
 }
 
@@ -295,6 +445,120 @@ func TestInt64ObservableCounter_RegisterCallback(t *testing.T) {
 	assert.Equal(t, int64(42), sum.DataPoints[0].Value)
 }
 
+func TestObserveFunc(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	registration, err := m.ObservableGauge.ObserveFunc(func(ctx context.Context) (int64, []attribute.Attr) {
+		return 7, []attribute.Attr{attribute.New("queue", "orders")}
+	})
+	require.NoError(t, err)
+
+	_, err = m.ObservableFloatCounter.ObserveFunc(func(ctx context.Context) (float64, []attribute.Attr) {
+		return 1.5, nil
+	})
+	require.NoError(t, err)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "observable_gauge")
+	require.NotNil(t, foundMetric, "ObservableGauge metric not found")
+
+	gauge, ok := foundMetric.Data.(metricdata.Gauge[int64])
+	require.True(t, ok, "expected Gauge[int64], got %T", foundMetric.Data)
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(7), gauge.DataPoints[0].Value)
+
+	queue, _ := gauge.DataPoints[0].Attributes.Value("queue")
+	assert.Equal(t, "orders", queue.AsString())
+
+	foundMetric = findMetric(rm, "observable_float_counter")
+	require.NotNil(t, foundMetric, "ObservableFloatCounter metric not found")
+
+	sum, ok := foundMetric.Data.(metricdata.Sum[float64])
+	require.True(t, ok, "expected Sum[float64], got %T", foundMetric.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.InDelta(t, 1.5, sum.DataPoints[0].Value, 0)
+
+	require.NoError(t, registration.Unregister())
+
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	if foundMetric := findMetric(rm, "observable_gauge"); foundMetric != nil {
+		gauge, _ := foundMetric.Data.(metricdata.Gauge[int64])
+		assert.Empty(t, gauge.DataPoints, "unregistered functions are not observed")
+	}
+
+	var nilGauge *Int64ObservableGauge
+
+	registration, err = nilGauge.ObserveFunc(func(ctx context.Context) (int64, []attribute.Attr) { return 0, nil })
+	require.NoError(t, err)
+	require.NotNil(t, registration)
+	assert.NoError(t, registration.Unregister(), "a nil instrument's registration can be unregistered")
+}
+
+func TestBindAtomic(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	var connections atomic.Int64
+
+	registration, err := m.ObservableGauge.BindAtomic(&connections, attribute.New("pool", "primary"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = registration.Unregister() })
+
+	var load AtomicFloat64
+
+	floatRegistration, err := m.ObservableFloatGauge.BindAtomic(&load)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = floatRegistration.Unregister() })
+
+	connections.Add(3)
+	load.Store(0.25)
+	assert.InDelta(t, 0.75, load.Add(0.5), 0)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "observable_gauge")
+	require.NotNil(t, foundMetric, "ObservableGauge metric not found")
+
+	gauge, ok := foundMetric.Data.(metricdata.Gauge[int64])
+	require.True(t, ok, "expected Gauge[int64], got %T", foundMetric.Data)
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(3), gauge.DataPoints[0].Value)
+
+	pool, _ := gauge.DataPoints[0].Attributes.Value("pool")
+	assert.Equal(t, "primary", pool.AsString())
+
+	foundMetric = findMetric(rm, "observable_float_gauge")
+	require.NotNil(t, foundMetric, "ObservableFloatGauge metric not found")
+
+	floatGauge, ok := foundMetric.Data.(metricdata.Gauge[float64])
+	require.True(t, ok, "expected Gauge[float64], got %T", foundMetric.Data)
+	require.Len(t, floatGauge.DataPoints, 1)
+	assert.InDelta(t, 0.75, floatGauge.DataPoints[0].Value, 0)
+
+	var nilGauge *Int64ObservableGauge
+
+	nilRegistration, err := nilGauge.BindAtomic(&connections)
+	require.NoError(t, err)
+	require.NotNil(t, nilRegistration)
+	assert.NoError(t, nilRegistration.Unregister())
+}
+
+func TestRegisterCallback_NilInstruments(t *testing.T) {
+	registration, err := RegisterCallback(func(context.Context, Observer) error {
+		t.Error("callback of nil instruments called")
+		return nil
+	}, (*Int64ObservableGauge)(nil), (*Float64ObservableCounter)(nil))
+	require.NoError(t, err)
+	require.NotNil(t, registration)
+	assert.NoError(t, registration.Unregister())
+}
+
 func TestFloat64ObservableCounter_RegisterCallback(t *testing.T) {
 	m, reader := initTestMetrics(t)
 	ctx := t.Context()
@@ -404,3 +668,1521 @@ func TestFloat64ObservableUpDownCounter_RegisterCallback(t *testing.T) {
 	require.NotEmpty(t, sum.DataPoints, "no data points recorded")
 	assert.InDelta(t, -3.5, sum.DataPoints[0].Value, 0.001)
 }
+
+func TestInitScoped_FollowsReinit(t *testing.T) {
+	ctx := t.Context()
+
+	type LibraryMetrics struct {
+		FollowCounter *Int64Counter
+		FollowGauge   *Int64ObservableGauge
+	}
+
+	if s := current.Load(); s != nil {
+		require.NoError(t, s.shutdown(ctx))
+	}
+
+	lib := &LibraryMetrics{}
+	require.NoError(t, InitScoped("library", lib), "library instruments can be created before InitMetrics")
+
+	_, err := lib.FollowGauge.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(lib.FollowGauge.int64ObservableGauge, 7)
+		return nil
+	})
+	require.NoError(t, err)
+
+	lib.FollowCounter.Add(ctx, 1)
+
+	for _, replace := range []bool{false, true, true} {
+		reader := sdkmetric.NewManualReader()
+
+		var shutdown func(context.Context) error
+		if replace {
+			shutdown, err = Reinit[struct{}](ctx, "test-service", nil, nil, sdkmetric.WithReader(reader))
+		} else {
+			shutdown, err = InitMetrics[struct{}](ctx, "test-service", nil, nil, sdkmetric.WithReader(reader))
+		}
+
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = shutdown(ctx) })
+
+		lib.FollowCounter.Add(ctx, 2)
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &rm))
+
+		counter := findMetric(rm, "follow_counter")
+		require.NotNil(t, counter, "the counter records on the current provider")
+
+		sum, ok := counter.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+		require.Len(t, sum.DataPoints, 1)
+		assert.Equal(t, int64(2), sum.DataPoints[0].Value, "measurements before InitMetrics or on the previous provider are not carried over")
+
+		gauge := findMetric(rm, "follow_gauge")
+		require.NotNil(t, gauge, "callbacks are registered on the current provider")
+
+		observed, ok := gauge.Data.(metricdata.Gauge[int64])
+		require.True(t, ok)
+		require.Len(t, observed.DataPoints, 1, "callbacks are registered once")
+		assert.Equal(t, int64(7), observed.DataPoints[0].Value)
+	}
+}
+
+func TestInitScoped(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type LibraryMetrics struct {
+		LibraryCounter *Int64Counter
+	}
+
+	lib := &LibraryMetrics{}
+	require.NoError(t, InitScoped("library", lib))
+	require.NotNil(t, lib.LibraryCounter, "LibraryCounter not initialized")
+
+	lib.LibraryCounter.Add(ctx, 1)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "library_counter")
+	require.NotNil(t, foundMetric, "LibraryCounter metric not found")
+	assert.Equal(t, m, Metrics[TestMetrics](), "InitScoped should not replace the global metrics struct")
+}
+
+func TestScoped(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type LibraryMetrics struct {
+		ScopedCounter *Int64Counter
+	}
+
+	getMetrics := Scoped[LibraryMetrics]("scoped-library")
+	require.Same(t, getMetrics(), getMetrics(), "Scoped should initialize the struct once")
+
+	getMetrics().ScopedCounter.Inc(ctx)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "scoped_counter")
+	require.NotNil(t, foundMetric, "ScopedCounter metric not found")
+}
+
+func TestDescribe(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type DescribedMetrics struct {
+		Latency *Float64Histogram `unit:"s" description:"From the tag."`
+		Jobs    *Int64Counter
+	}
+
+	m := &DescribedMetrics{}
+	Describe(&m.Latency, Unit("ms"), Description(`Request "latency"`), Buckets(5, 50, 500))
+	Describe(&m.Jobs, Name("jobs_total"))
+
+	require.NoError(t, InitScoped("described", m))
+
+	m.Latency.Record(ctx, 20)
+	m.Jobs.Inc(ctx)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "latency")
+	require.NotNil(t, foundMetric, "latency metric not found")
+	assert.Equal(t, "ms", foundMetric.Unit, "Describe takes precedence over tags")
+	assert.Equal(t, `Request "latency"`, foundMetric.Description)
+
+	hist, ok := foundMetric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", foundMetric.Data)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, []float64{5, 50, 500}, hist.DataPoints[0].Bounds)
+
+	assert.NotNil(t, findMetric(rm, "jobs_total"), "jobs_total metric not found")
+	assert.Empty(t, pending, "the addresses of initialized structs aren't retained")
+
+	reinitReader := sdkmetric.NewManualReader()
+	_, err := Reinit(ctx, "test-service", nil, &DescribedMetrics{}, sdkmetric.WithReader(reinitReader))
+	require.NoError(t, err)
+
+	again := Metrics[DescribedMetrics]()
+	again.Jobs.Inc(ctx)
+
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reinitReader.Collect(ctx, &rm))
+	assert.NotNil(t, findMetric(rm, "jobs_total"), "options apply to later structs of the same type")
+}
+
+func TestFamily(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type FamilyMetrics struct {
+		TopicMessages *Family[Int64Counter]
+		ShardLag      *Family[Int64Gauge] `family_key:"shard.id" unit:"{message}"`
+		Queues        struct {
+			Depth *Family[Int64UpDownCounter]
+		} `prefix:"queue_"`
+	}
+
+	m := &FamilyMetrics{}
+	require.NoError(t, InitScoped("family", m))
+
+	m.TopicMessages.Get("orders").Add(ctx, 2)
+	m.TopicMessages.Get("orders").Inc(ctx)
+	m.TopicMessages.Get("refunds").Inc(ctx)
+	m.ShardLag.Get("3").Record(ctx, 40, attribute.New("region", "eu"))
+	m.Queues.Depth.Get("emails").Inc(ctx)
+
+	assert.Same(t, m.TopicMessages.Get("orders"), m.TopicMessages.Get("orders"), "instruments are created once per key")
+	assert.Equal(t, 2, m.TopicMessages.Keys())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	orders := findMetric(rm, "topic_messages_orders")
+	require.NotNil(t, orders, "topic_messages_orders metric not found")
+
+	sum, ok := orders.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", orders.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(3), sum.DataPoints[0].Value)
+
+	assert.NotNil(t, findMetric(rm, "topic_messages_refunds"), "topic_messages_refunds metric not found")
+	assert.NotNil(t, findMetric(rm, "queue_depth_emails"), "queue_depth_emails metric not found")
+
+	lag := findMetric(rm, "shard_lag")
+	require.NotNil(t, lag, "shard_lag metric not found")
+	assert.Equal(t, "{message}", lag.Unit)
+
+	gauge, ok := lag.Data.(metricdata.Gauge[int64])
+	require.True(t, ok, "expected Gauge[int64], got %T", lag.Data)
+	require.Len(t, gauge.DataPoints, 1)
+
+	shard, _ := gauge.DataPoints[0].Attributes.Value("shard.id")
+	assert.Equal(t, "3", shard.AsString())
+	assert.True(t, gauge.DataPoints[0].Attributes.HasValue("region"))
+
+	var nilFamily *Family[Int64Counter]
+	assert.NotPanics(t, func() { nilFamily.Get("orders").Inc(ctx) })
+
+	type ObservableFamily struct {
+		Usage *Family[Int64ObservableGauge] `family_key:"disk"`
+	}
+
+	require.ErrorIs(t, InitScoped("family", &ObservableFamily{}), errFamilyKey)
+}
+
+func TestFamily_MaxKeys(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type LimitedFamily struct {
+		TenantRequests *Family[Int64Counter] `max_keys:"2"`
+	}
+
+	m := &LimitedFamily{}
+	require.NoError(t, InitScoped("family", m))
+
+	for _, tenant := range []string{"a", "b", "c", "d"} {
+		m.TenantRequests.Get(tenant).Inc(ctx)
+	}
+
+	assert.Same(t, m.TenantRequests.Get("c"), m.TenantRequests.Get(OverflowValue), "keys beyond max_keys share one instrument")
+	assert.Equal(t, 3, m.TenantRequests.Keys())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	assert.NotNil(t, findMetric(rm, "tenant_requests_a"), "tenant_requests_a metric not found")
+	assert.Nil(t, findMetric(rm, "tenant_requests_c"))
+
+	other := findMetric(rm, "tenant_requests_"+OverflowValue)
+	require.NotNil(t, other, "tenant_requests_other metric not found")
+
+	sum, ok := other.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", other.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+
+	type InvalidFamily struct {
+		Requests *Family[Int64Counter] `max_keys:"0"`
+	}
+
+	require.ErrorIs(t, InitScoped("family", &InvalidFamily{}), errMaxKeys)
+}
+
+func TestMapField(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type MapMetrics struct {
+		TopicMessages map[string]*Int64Counter `keys:"orders, refunds"`
+		ShardLag      map[string]*Int64Gauge   `family_key:"shard.id"`
+	}
+
+	m := &MapMetrics{ShardLag: map[string]*Int64Gauge{"1": nil, "2": nil}}
+	require.NoError(t, InitScoped("maps", m))
+
+	assert.Len(t, m.TopicMessages, 2)
+	assert.Len(t, m.ShardLag, 2, "keys already in the map are created")
+	assert.Nil(t, m.TopicMessages["unknown"])
+
+	m.TopicMessages["orders"].Inc(ctx)
+	m.TopicMessages["unknown"].Inc(ctx)
+	m.ShardLag["2"].Record(ctx, 7)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	assert.NotNil(t, findMetric(rm, "topic_messages_orders"), "topic_messages_orders metric not found")
+
+	lag := findMetric(rm, "shard_lag")
+	require.NotNil(t, lag, "shard_lag metric not found")
+
+	gauge, ok := lag.Data.(metricdata.Gauge[int64])
+	require.True(t, ok, "expected Gauge[int64], got %T", lag.Data)
+	require.Len(t, gauge.DataPoints, 1)
+
+	shard, _ := gauge.DataPoints[0].Attributes.Value("shard.id")
+	assert.Equal(t, "2", shard.AsString())
+
+	type TooManyKeys struct {
+		Requests map[string]*Int64Counter `keys:"a,b,c" max_keys:"2"`
+	}
+
+	require.ErrorIs(t, InitScoped("maps", &TooManyKeys{}), errMapKeys)
+}
+
+func TestSemconvMetrics(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	type ServiceMetrics struct {
+		SemconvHTTPServer
+		SemconvDBClient
+
+		OrdersPlaced *Int64Counter `unit:"{order}"`
+	}
+
+	m := &ServiceMetrics{}
+	require.NoError(t, InitScoped("service", m))
+	require.NotNil(t, m.HTTPServerRequestDuration, "embedded HTTPServerRequestDuration not initialized")
+	require.NotNil(t, m.DBClientOperationDuration, "embedded DBClientOperationDuration not initialized")
+
+	m.HTTPServerRequestDuration.Record(ctx, 0.03, attribute.New("http.request.method", "GET"))
+	m.OrdersPlaced.Add(ctx, 1)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "http.server.request.duration")
+	require.NotNil(t, foundMetric, "http.server.request.duration metric not found")
+	assert.Equal(t, "s", foundMetric.Unit)
+
+	hist, ok := foundMetric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", foundMetric.Data)
+	require.NotEmpty(t, hist.DataPoints, "no data points recorded")
+	assert.InDelta(t, 0.005, hist.DataPoints[0].Bounds[0], 0.0001)
+
+	foundMetric = findMetric(rm, "orders_placed")
+	require.NotNil(t, foundMetric, "OrdersPlaced metric not found")
+	assert.Equal(t, "{order}", foundMetric.Unit)
+}
+
+func TestInvalidBuckets(t *testing.T) {
+	initTestMetrics(t)
+
+	type BadMetrics struct {
+		Latency *Float64Histogram `buckets:"1,two"`
+	}
+
+	require.Error(t, InitScoped("bad", &BadMetrics{}))
+}
+
+// countingExporter counts the exports made by a periodic reader
+type countingExporter struct {
+	exports atomic.Int32
+}
+
+func (e *countingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *countingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *countingExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	e.exports.Add(1)
+
+	return nil
+}
+
+func (e *countingExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (e *countingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestForceFlush(t *testing.T) {
+	exporter := &countingExporter{}
+	m := &TestMetrics{}
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	shutdown, err := Reinit(
+		t.Context(),
+		"test-service",
+		resourceAttrs,
+		m,
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(time.Hour))),
+	)
+	require.NoError(t, err)
+
+	m.Counter.Add(t.Context(), 1)
+
+	require.NoError(t, ForceFlush(t.Context()))
+	assert.Equal(t, int32(1), exporter.exports.Load(), "ForceFlush should export without waiting for the interval")
+	require.NoError(t, shutdown(t.Context()))
+}
+
+func TestPreAggregatedHistogram_Record(t *testing.T) {
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(PreAggregatedProducer()))
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := Reinit[struct{}](t.Context(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+
+	type BridgeMetrics struct {
+		LegacyLatency *PreAggregatedHistogram `unit:"ms"`
+	}
+
+	m := &BridgeMetrics{}
+	require.NoError(t, InitScoped("bridge", m))
+
+	ctx := t.Context()
+	summary := HistogramSummary{Count: 3, Sum: 60, Min: 10, Max: 30, Bounds: []float64{15, 25}, BucketCounts: []uint64{1, 1, 1}}
+	require.NoError(t, m.LegacyLatency.Record(ctx, summary, attribute.New("source", "statsd")))
+	require.NoError(t, m.LegacyLatency.Record(ctx, HistogramSummary{Count: 1, Sum: 5, Min: 5, Max: 5, Bounds: []float64{15, 25}, BucketCounts: []uint64{1, 0, 0}}, attribute.New("source", "statsd")))
+
+	require.ErrorIs(t, m.LegacyLatency.Record(ctx, HistogramSummary{Bounds: []float64{1}}), errBucketCounts)
+	require.ErrorIs(t, m.LegacyLatency.Record(ctx, HistogramSummary{Bounds: []float64{1}, BucketCounts: []uint64{0, 1}}, attribute.New("source", "statsd")), errBoundsMismatch)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "legacy_latency")
+	require.NotNil(t, foundMetric, "LegacyLatency metric not found")
+	assert.Equal(t, "ms", foundMetric.Unit)
+
+	hist, ok := foundMetric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", foundMetric.Data)
+	require.Len(t, hist.DataPoints, 1)
+
+	point := hist.DataPoints[0]
+	assert.Equal(t, uint64(4), point.Count)
+	assert.InDelta(t, 65.0, point.Sum, 0.001)
+	assert.Equal(t, []uint64{2, 1, 1}, point.BucketCounts)
+
+	minValue, _ := point.Min.Value()
+	assert.InDelta(t, 5.0, minValue, 0.001)
+}
+
+func TestPreAggregatedHistogram_Set(t *testing.T) {
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(PreAggregatedProducer()))
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := Reinit[struct{}](t.Context(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+
+	h, err := New[PreAggregatedHistogram]("bridge", "scraped_latency")
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	require.NoError(t, h.Set(ctx, HistogramSummary{Count: 2, Sum: 3, Min: math.NaN(), Max: math.NaN(), Bounds: []float64{1}, BucketCounts: []uint64{1, 1}}))
+	require.NoError(t, h.Set(ctx, HistogramSummary{Count: 5, Sum: 9, Min: math.NaN(), Max: math.NaN(), Bounds: []float64{1}, BucketCounts: []uint64{2, 3}}))
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "scraped_latency")
+	require.NotNil(t, foundMetric, "scraped_latency metric not found")
+
+	hist, ok := foundMetric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", foundMetric.Data)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, uint64(5), hist.DataPoints[0].Count, "Set should replace rather than merge")
+
+	_, defined := hist.DataPoints[0].Min.Value()
+	assert.False(t, defined, "NaN extrema should be omitted")
+}
+
+func TestNew(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	counter, err := New[Float64Counter]("bridge", "api.requests", metric.WithUnit("{request}"))
+	require.NoError(t, err)
+
+	counter.Add(ctx, 2)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "api.requests")
+	require.NotNil(t, foundMetric, "api.requests metric not found")
+	assert.Equal(t, "{request}", foundMetric.Unit)
+
+	_, err = New[string]("bridge", "invalid")
+	require.ErrorIs(t, err, errNotInstrument)
+}
+
+func TestNamed(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	counter, err := NewCounter("queue.orders.consumed", metric.WithUnit("{message}"))
+	require.NoError(t, err)
+
+	again, err := NewCounter("queue.orders.consumed")
+	require.NoError(t, err)
+	assert.Same(t, counter, again)
+
+	counter.Add(ctx, 2)
+	again.Inc(ctx)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "queue.orders.consumed")
+	require.NotNil(t, foundMetric, "queue.orders.consumed metric not found")
+	assert.Equal(t, "{message}", foundMetric.Unit)
+
+	sum, ok := foundMetric.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", foundMetric.Data)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(3), sum.DataPoints[0].Value)
+
+	_, err = NewHistogram("queue.orders.consumed")
+	require.ErrorIs(t, err, errInstrumentConflict)
+}
+
+func TestNewField(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	histogram, err := NewField[Float64Histogram](Meter("generated"), "job_duration", `unit:"s" buckets:"1,10"`)
+	require.NoError(t, err)
+
+	histogram.Record(ctx, 5)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "job_duration")
+	require.NotNil(t, foundMetric, "job_duration metric not found")
+	assert.Equal(t, "s", foundMetric.Unit)
+
+	hist, ok := foundMetric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", foundMetric.Data)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, []float64{1, 10}, hist.DataPoints[0].Bounds)
+
+	_, err = NewField[Float64Histogram](Meter("generated"), "invalid", `buckets:"fast"`)
+	require.Error(t, err)
+}
+
+// generatedMetrics has an InitInstruments method like those generated by the gotel metrics command.
+type generatedMetrics struct {
+	Requests *Int64Counter
+	Latency  *Float64Histogram `unit:"s" buckets:"1,10" max_cardinality:"1"`
+	Batches  *PreAggregatedHistogram
+	Topics   *Family[Int64Counter]
+	Shards   map[string]*Int64Gauge `keys:"1"`
+}
+
+func (m *generatedMetrics) InitInstruments(b *Builder) error {
+	meter := b.Meter()
+
+	var err error
+
+	{
+		name := b.Name("Requests")
+
+		var inst metric.Int64Counter
+		if inst, err = meter.Int64Counter(name); err != nil {
+			return err
+		}
+
+		if m.Requests, err = Wrap[Int64Counter](b, "Requests", name, inst, ""); err != nil {
+			return err
+		}
+	}
+
+	{
+		name := "job." + b.Name("Latency")
+
+		var inst metric.Float64Histogram
+		if inst, err = meter.Float64Histogram(name, metric.WithUnit("s"), metric.WithExplicitBucketBoundaries(1, 10)); err != nil {
+			return err
+		}
+
+		if m.Latency, err = Wrap[Float64Histogram](b, "Latency", name, inst, `unit:"s" buckets:"1,10" max_cardinality:"1"`); err != nil {
+			return err
+		}
+	}
+
+	if m.Batches, err = Wrap[PreAggregatedHistogram](b, "Batches", b.Name("Batches"), nil, ""); err != nil {
+		return err
+	}
+
+	if m.Topics, err = WrapFamily[Int64Counter](b, "Topics", b.Name("Topics"), ""); err != nil {
+		return err
+	}
+
+	if m.Shards, err = WrapMap[Int64Gauge](b, "Shards", b.Name("Shards"), `keys:"1"`, m.Shards); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func TestInitInstruments(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := &generatedMetrics{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m, sdkmetric.WithReader(reader), WithNameStyle(DotCase))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	assert.Same(t, m, Metrics[generatedMetrics](), "InitMetrics calls the generated method")
+	assert.Equal(t, map[string]string{"Requests": "requests", "Latency": "job.latency", "Batches": "batches", "Topics": "topics", "Shards": "shards"}, Report().Instruments)
+	require.NotNil(t, m.Batches)
+
+	m.Requests.Inc(ctx)
+	for _, job := range []string{"a", "b", "c"} {
+		m.Latency.Record(ctx, 5, attribute.New("job", job))
+	}
+	m.Topics.Get("orders").Inc(ctx)
+	m.Shards["1"].Record(ctx, 3)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	require.NotNil(t, findMetric(rm, "requests"))
+	require.NotNil(t, findMetric(rm, "topics.orders"), "family keys are joined in the name style")
+	require.NotNil(t, findMetric(rm, "shards.1"), "map keys are joined in the name style")
+
+	latency := findMetric(rm, "job.latency")
+	require.NotNil(t, latency)
+	assert.Equal(t, "s", latency.Unit)
+
+	hist, ok := latency.Data.(metricdata.Histogram[float64])
+	require.True(t, ok, "expected Histogram[float64], got %T", latency.Data)
+	require.Len(t, hist.DataPoints, 2, "the max_cardinality tag applies")
+	assert.Equal(t, []float64{1, 10}, hist.DataPoints[0].Bounds)
+
+	_, err = Wrap[Int64Gauge](&Builder{factory: libraryFactory(Meter("generated")), report: newFieldReport()}, "Requests", "requests", m.Requests, "")
+	require.ErrorIs(t, err, errNotInstrument)
+}
+
+func TestSetErrorHandler_Restore(t *testing.T) {
+	original := otel.GetErrorHandler()
+	t.Cleanup(func() { otel.SetErrorHandler(original) })
+
+	previous := []error{}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { previous = append(previous, err) }))
+
+	handled := []error{}
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+
+	otel.Handle(errTemporality)
+	assert.Len(t, handled, 1)
+
+	SetErrorHandler(nil)
+
+	otel.Handle(errTemporality)
+	assert.Len(t, handled, 1, "the handler should be removed")
+	assert.Len(t, previous, 1, "the earlier OpenTelemetry error handler should be restored")
+}
+
+func TestStrict(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	handled := []error{}
+
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+	t.Cleanup(func() { SetErrorHandler(nil) })
+
+	counter := NewStrict(m.Counter)
+	require.NoError(t, counter.Add(ctx, 5))
+	require.ErrorIs(t, counter.Add(ctx, -1), errNegativeValue)
+
+	histogram := NewStrict(m.FloatHistogram)
+	require.NoError(t, histogram.Record(ctx, 1.5))
+	require.ErrorIs(t, histogram.Record(ctx, math.NaN()), errInvalidValue)
+	require.ErrorIs(t, NewStrict(m.FloatCounter).Add(ctx, math.Inf(1)), errInvalidValue)
+
+	require.NoError(t, NewStrict(m.UpDown).Add(ctx, -1), "up-down counters accept negative values")
+
+	var unbound *Int64Gauge
+	require.ErrorIs(t, NewStrict(unbound).Record(ctx, 1), errNotInitialized)
+
+	assert.Len(t, handled, 4)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	metric := findMetric(rm, "counter")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(5), sum.DataPoints[0].Value, "rejected measurements should not be recorded")
+}
+
+func TestQuantity_In(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity Quantity
+		unit     string
+		expected float64
+		wantErr  bool
+	}{
+		{"Seconds to milliseconds", Seconds(1500 * time.Millisecond), "ms", 1500, false},
+		{"Milliseconds to seconds", Milliseconds(250 * time.Millisecond), "s", 0.25, false},
+		{"Same unit", Seconds(2 * time.Second), "s", 2, false},
+		{"Megabytes to bytes", Megabytes(3_000_000), "By", 3_000_000, false},
+		{"Bytes to mebibytes", Bytes(1 << 20), "MiBy", 1, false},
+		{"Time to bytes", Seconds(time.Second), "By", 0, true},
+		{"No unit", Seconds(time.Second), "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.quantity.In(tt.unit)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errUnitMismatch)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, value, 1e-9)
+		})
+	}
+}
+
+func TestRecordQuantity(t *testing.T) {
+	_, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	scoped := struct {
+		Latency *Float64Histogram `unit:"ms"`
+		Memory  *Float64Gauge     `unit:"By"`
+	}{}
+	require.NoError(t, InitScoped("units", &scoped))
+
+	require.NoError(t, scoped.Latency.RecordQuantity(ctx, Seconds(2*time.Second)))
+	require.NoError(t, scoped.Memory.RecordQuantity(ctx, Megabytes(5_000_000)))
+	require.ErrorIs(t, scoped.Latency.RecordQuantity(ctx, Megabytes(1)), errUnitMismatch)
+
+	counter, err := New[Float64Counter]("units", "transferred", metric.WithUnit("KBy"))
+	require.NoError(t, err)
+	require.NoError(t, counter.AddQuantity(ctx, Bytes(2048)))
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	latency := findMetric(rm, "latency")
+	require.NotNil(t, latency)
+
+	histogram, ok := latency.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+	assert.InDelta(t, 2000, histogram.DataPoints[0].Sum, 1e-9)
+
+	transferred := findMetric(rm, "transferred")
+	require.NotNil(t, transferred)
+
+	sum, ok := transferred.Data.(metricdata.Sum[float64])
+	require.True(t, ok)
+	assert.InDelta(t, 2.048, sum.DataPoints[0].Value, 1e-9)
+}
+
+func TestSetSpanAttributes(t *testing.T) {
+	m, reader := initTestMetrics(t)
+
+	SetSpanAttributes("http.route", "http.response.status_code")
+	t.Cleanup(func() { SetSpanAttributes() })
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(t.Context(), "GET /users/{id}")
+	span.SetAttributes(
+		attribute.New("http.route", "/users/{id}").KeyValue,
+		attribute.New("http.response.status_code", 200).KeyValue,
+		attribute.New("url.full", "https://example.com/users/42").KeyValue,
+	)
+
+	m.Counter.Add(ctx, 1, attribute.New("http.response.status_code", 404))
+	span.End()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := findMetric(rm, "counter")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+
+	attrs := sum.DataPoints[0].Attributes
+
+	route, ok := attrs.Value("http.route")
+	require.True(t, ok, "allowlisted span attribute should be copied")
+	assert.Equal(t, "/users/{id}", route.AsString())
+
+	status, _ := attrs.Value("http.response.status_code")
+	assert.Equal(t, int64(404), status.AsInt64(), "explicit attributes take precedence")
+
+	assert.False(t, attrs.HasValue("url.full"), "only allowlisted attributes are copied")
+}
+
+func TestKeyedCounter(t *testing.T) {
+	_, reader := initTestMetrics(t)
+
+	counter, err := NewKeyedCounter("keyed", "api_requests", "api.key", time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	counter.now = func() time.Time { return now }
+
+	counter.Add("tenant-a", 2)
+	counter.Add("tenant-b", 1)
+
+	now = now.Add(45 * time.Second)
+
+	counter.Add("tenant-a", 3)
+
+	now = now.Add(30 * time.Second)
+
+	assert.Equal(t, 1, counter.Len(), "tenant-b should have expired")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := findMetric(rm, "api_requests")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+
+	key, _ := sum.DataPoints[0].Attributes.Value("api.key")
+	assert.Equal(t, "tenant-a", key.AsString())
+	assert.Equal(t, int64(5), sum.DataPoints[0].Value)
+
+	var nilCounter *KeyedCounter
+
+	assert.NotPanics(t, func() { nilCounter.Add("tenant-a", 1) })
+}
+
+type httpGroup struct {
+	Requests *Int64Counter
+}
+
+type dbGroup struct {
+	Queries *Int64Counter `metric:"queries_total"`
+}
+
+type linkedGroup struct {
+	Hits *Int64Counter
+	Next *linkedGroup
+}
+
+func TestNestedGroups(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := &struct {
+		HTTP   httpGroup  `prefix:"http_"`
+		DB     *dbGroup   `prefix:"db_"`
+		Cache  *httpGroup `prefix:"cache_"`
+		Linked *linkedGroup
+		Client *http.Client
+	}{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+
+	require.NotNil(t, m.HTTP.Requests)
+	require.NotNil(t, m.DB, "pointer groups are allocated")
+	require.NotNil(t, m.Cache)
+	require.NotNil(t, m.Linked.Hits)
+	assert.Nil(t, m.Linked.Next, "self references are not followed")
+	assert.Nil(t, m.Client, "structs without instruments are not allocated")
+
+	m.HTTP.Requests.Add(t.Context(), 1)
+	m.DB.Queries.Add(t.Context(), 1)
+	m.Cache.Requests.Add(t.Context(), 1)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	assert.NotNil(t, findMetric(rm, "http_requests"))
+	assert.NotNil(t, findMetric(rm, "db_queries_total"))
+	assert.NotNil(t, findMetric(rm, "cache_requests"))
+}
+
+func TestTemporalitySelector(t *testing.T) {
+	tests := []struct {
+		preference string
+		kind       sdkmetric.InstrumentKind
+		expected   metricdata.Temporality
+	}{
+		{"", sdkmetric.InstrumentKindCounter, metricdata.CumulativeTemporality},
+		{"cumulative", sdkmetric.InstrumentKindHistogram, metricdata.CumulativeTemporality},
+		{"delta", sdkmetric.InstrumentKindCounter, metricdata.DeltaTemporality},
+		{"Delta", sdkmetric.InstrumentKindObservableCounter, metricdata.DeltaTemporality},
+		{"delta", sdkmetric.InstrumentKindUpDownCounter, metricdata.CumulativeTemporality},
+		{"lowmemory", sdkmetric.InstrumentKindHistogram, metricdata.DeltaTemporality},
+		{"lowmemory", sdkmetric.InstrumentKindObservableCounter, metricdata.CumulativeTemporality},
+	}
+
+	for _, tt := range tests {
+		selector, err := temporalitySelector(tt.preference)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, selector(tt.kind), "%s %s", tt.preference, tt.kind)
+	}
+
+	_, err := temporalitySelector("sometimes")
+	require.ErrorIs(t, err, errTemporality)
+}
+
+func TestExemplars(t *testing.T) {
+	m, reader := initTestMetrics(t)
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(t.Context(), "operation")
+	m.FloatHistogram.Record(ctx, 1.5)
+	span.End()
+
+	m.FloatHistogram.Record(t.Context(), 2.5)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := findMetric(rm, "float_histogram")
+	require.NotNil(t, metric)
+
+	histogram, ok := metric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+
+	exemplars := histogram.DataPoints[0].Exemplars
+	require.Len(t, exemplars, 1, "only measurements in a sampled span are exemplars")
+
+	traceID := span.SpanContext().TraceID()
+	spanID := span.SpanContext().SpanID()
+	assert.Equal(t, traceID[:], exemplars[0].TraceID)
+	assert.Equal(t, spanID[:], exemplars[0].SpanID)
+	assert.InDelta(t, 1.5, exemplars[0].Value, 1e-9)
+}
+
+func TestExemplars_Counter(t *testing.T) {
+	m, reader := initTestMetrics(t)
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(t.Context(), "operation")
+	m.Counter.Add(ctx, 3)
+	span.End()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	metric := findMetric(rm, "counter")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+
+	exemplars := sum.DataPoints[0].Exemplars
+	require.Len(t, exemplars, 1, "counters keep exemplars of measurements in sampled spans")
+
+	traceID := span.SpanContext().TraceID()
+	assert.Equal(t, traceID[:], exemplars[0].TraceID)
+	assert.Equal(t, int64(3), exemplars[0].Value)
+}
+
+func TestExemplarReservoirView(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := &TestMetrics{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m,
+		sdkmetric.WithReader(reader),
+		WithViews(
+			ExemplarReservoirView("counter", exemplar.FixedSizeReservoirProvider(2)),
+			DropExemplarsView("float_histogram"),
+		),
+	)
+	require.NoError(t, err)
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+
+	for range 5 {
+		ctx, span := tracer.Start(t.Context(), "operation")
+		m.Counter.Add(ctx, 1)
+		m.FloatHistogram.Record(ctx, 1.5)
+		span.End()
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	counter := findMetric(rm, "counter")
+	require.NotNil(t, counter)
+
+	sum, ok := counter.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Len(t, sum.DataPoints[0].Exemplars, 2, "the reservoir keeps at most 2 exemplars")
+
+	floatHistogram := findMetric(rm, "float_histogram")
+	require.NotNil(t, floatHistogram)
+
+	histogram, ok := floatHistogram.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+	assert.Empty(t, histogram.DataPoints[0].Exemplars)
+	assert.Equal(t, uint64(5), histogram.DataPoints[0].Count)
+}
+
+func TestWithViews(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := &TestMetrics{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m,
+		sdkmetric.WithReader(reader),
+		WithViews(
+			RenameView("counter", "requests_total"),
+			DropAttributesView("float_counter", "user.id"),
+			AggregationView("gauge", sdkmetric.AggregationDrop{}),
+		),
+	)
+	require.NoError(t, err)
+
+	m.Counter.Add(t.Context(), 1)
+	m.FloatCounter.Add(t.Context(), 1.5, attribute.New("user.id", "42"), attribute.New("region", "eu"))
+	m.Gauge.Record(t.Context(), 7)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	assert.Nil(t, findMetric(rm, "counter"))
+	assert.NotNil(t, findMetric(rm, "requests_total"))
+	assert.Nil(t, findMetric(rm, "gauge"), "dropped instruments are not exported")
+
+	metric := findMetric(rm, "float_counter")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[float64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+
+	_, hasUser := sum.DataPoints[0].Attributes.Value("user.id")
+	_, hasRegion := sum.DataPoints[0].Attributes.Value("region")
+	assert.False(t, hasUser)
+	assert.True(t, hasRegion)
+}
+
+func TestInitRuntimeMetrics(t *testing.T) {
+	_, reader := initTestMetrics(t)
+
+	require.NoError(t, InitRuntimeMetrics())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	goroutines := findMetric(rm, "go.goroutine.count")
+	require.NotNil(t, goroutines)
+
+	sum, ok := goroutines.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Positive(t, sum.DataPoints[0].Value)
+	assert.False(t, sum.IsMonotonic)
+
+	allocated := findMetric(rm, "go.memory.allocated")
+	require.NotNil(t, allocated)
+
+	sum, ok = allocated.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	assert.True(t, sum.IsMonotonic)
+
+	assert.NotNil(t, findMetric(rm, "go.gc.pause.cpu_time"))
+	assert.NotNil(t, findMetric(rm, "go.cgo.calls"))
+}
+
+func TestInitRuntimeMetrics_ConcurrentCollect(t *testing.T) {
+	first, second := sdkmetric.NewManualReader(), sdkmetric.NewManualReader()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+	_, err := Reinit[struct{}](t.Context(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(first), sdkmetric.WithReader(second))
+	require.NoError(t, err)
+
+	require.NoError(t, InitRuntimeMetrics())
+
+	var wg sync.WaitGroup
+
+	for _, reader := range []*sdkmetric.ManualReader{first, second} {
+		wg.Go(func() {
+			for range 20 {
+				rm := metricdata.ResourceMetrics{}
+				assert.NoError(t, reader.Collect(t.Context(), &rm))
+				assert.NotNil(t, findMetric(rm, "go.goroutine.count"))
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestInitProcessMetrics(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("thread counts are only read on Linux")
+	}
+
+	_, reader := initTestMetrics(t)
+
+	require.NoError(t, InitProcessMetrics())
+
+	value := func(name string) int64 {
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(t.Context(), &rm))
+
+		found := findMetric(rm, name)
+		require.NotNil(t, found, "%s metric not found", name)
+
+		sum, ok := found.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+		require.Len(t, sum.DataPoints, 1)
+		assert.False(t, sum.IsMonotonic)
+
+		return sum.DataPoints[0].Value
+	}
+
+	before := value("process.open_file_descriptor.count")
+
+	f, err := os.Open(os.Args[0])
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Equal(t, before+1, value("process.open_file_descriptor.count"))
+	assert.Greater(t, value("process.open_file_descriptor.limit"), before)
+	assert.Positive(t, value("process.thread.count"))
+}
+
+func TestSDKDisabled(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+
+	m, reader := initTestMetrics(t)
+
+	require.Same(t, m, Metrics[TestMetrics](), "the struct is still current")
+	require.NotNil(t, m.Counter, "instruments are created on a no-op meter")
+
+	assert.NotPanics(t, func() {
+		m.Counter.Add(t.Context(), 1)
+		m.Histogram.Record(t.Context(), 5)
+	})
+
+	rm := metricdata.ResourceMetrics{}
+	require.ErrorIs(t, reader.Collect(t.Context(), &rm), sdkmetric.ErrReaderNotRegistered, "no provider is created")
+
+	instance, shutdown, err := NewInstance[TestMetrics](t.Context(), "library", sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+	require.NotNil(t, instance.Counter)
+	instance.Counter.Inc(t.Context())
+	require.NoError(t, shutdown(t.Context()))
+}
+
+func TestWithDisabled(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	exporter := &memoryExporter{}
+
+	m, shutdown, err := NewInstance[bridgeMetrics](t.Context(), "library", sdkmetric.WithReader(reader), WithExporter(exporter), WithDisabled())
+	require.NoError(t, err)
+
+	require.NotNil(t, m.Requests)
+	m.Requests.Inc(t.Context())
+	require.NoError(t, m.Latency.Record(t.Context(), HistogramSummary{Count: 1, Sum: 1, Bounds: []float64{1}, BucketCounts: []uint64{1, 0}}))
+	require.ErrorIs(t, NewStrict(m.Requests).Add(t.Context(), 1), errNotInitialized)
+
+	require.NoError(t, ForceFlush(t.Context()))
+	require.NoError(t, shutdown(t.Context()))
+
+	rm := metricdata.ResourceMetrics{}
+	require.ErrorIs(t, reader.Collect(t.Context(), &rm), sdkmetric.ErrReaderNotRegistered, "no provider is created")
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	assert.Empty(t, exporter.exports, "nothing is exported, including pre-aggregated histograms")
+}
+
+func TestNewInstance(t *testing.T) {
+	global, globalReader := initTestMetrics(t)
+
+	readerA := sdkmetric.NewManualReader()
+	a, shutdownA, err := NewInstance[TestMetrics](t.Context(), "library-a", sdkmetric.WithReader(readerA))
+	require.NoError(t, err)
+
+	readerB := sdkmetric.NewManualReader()
+	b, shutdownB, err := NewInstance[TestMetrics](t.Context(), "library-b", sdkmetric.WithReader(readerB))
+	require.NoError(t, err)
+
+	a.Counter.Add(t.Context(), 1)
+	b.Counter.Add(t.Context(), 2)
+
+	counterValue := func(reader *sdkmetric.ManualReader) int64 {
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(t.Context(), &rm))
+
+		metric := findMetric(rm, "counter")
+		if metric == nil {
+			return 0
+		}
+
+		sum, ok := metric.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+
+		return sum.DataPoints[0].Value
+	}
+
+	assert.Equal(t, int64(1), counterValue(readerA))
+	assert.Equal(t, int64(2), counterValue(readerB))
+	assert.Equal(t, int64(0), counterValue(globalReader), "instances don't record on the global provider")
+	assert.Same(t, global, Metrics[TestMetrics](), "the global metrics struct is unaffected")
+
+	require.NoError(t, shutdownA(t.Context()))
+	require.NoError(t, shutdownB(t.Context()))
+}
+
+type bridgeMetrics struct {
+	Latency  *PreAggregatedHistogram `metric:"bridge_latency"`
+	Requests *Int64Counter
+}
+
+func TestNewInstance_OwnsInstruments(t *testing.T) {
+	ctx := t.Context()
+	globalReader := sdkmetric.NewManualReader(sdkmetric.WithProducer(PreAggregatedProducer()))
+	_, err := Reinit[struct{}](ctx, "test-service", nil, nil, sdkmetric.WithReader(globalReader))
+	require.NoError(t, err)
+
+	exporter := &memoryExporter{}
+	m, shutdown, err := NewInstance[bridgeMetrics](ctx, "bridge", WithExporter(exporter))
+	require.NoError(t, err)
+
+	require.NoError(t, m.Latency.Record(ctx, HistogramSummary{Count: 1, Sum: 1, Bounds: []float64{1}, BucketCounts: []uint64{1, 0}}))
+	require.NoError(t, NewStrict(m.Requests).Add(ctx, 1))
+	require.NoError(t, ForceFlush(ctx), "ForceFlush flushes instances")
+
+	exporter.mu.Lock()
+	require.NotEmpty(t, exporter.exports)
+	assert.NotNil(t, findMetric(exporter.exports[len(exporter.exports)-1], "bridge_latency"))
+	assert.NotNil(t, findMetric(exporter.exports[len(exporter.exports)-1], "requests"))
+	exporter.mu.Unlock()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, globalReader.Collect(ctx, &rm))
+	assert.Nil(t, findMetric(rm, "bridge_latency"), "instance histograms aren't exported by the global producer")
+
+	require.NoError(t, shutdown(ctx))
+	require.ErrorIs(t, NewStrict(m.Requests).Add(ctx, 1), errNotInitialized, "the instance is shut down")
+}
+
+func TestWith(t *testing.T) {
+	m, reader := initTestMetrics(t)
+	ctx := t.Context()
+
+	attrs := []attribute.Attr{attribute.New("method", "GET"), attribute.New("route", "/users")}
+
+	m.Counter.With(attrs...).Add(ctx, 2)
+	m.Counter.Add(ctx, 3, attrs[1], attrs[0])
+	m.FloatHistogram.With(attrs...).Record(ctx, 1.5)
+	m.Gauge.With().Record(ctx, 7)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	metric := findMetric(rm, "counter")
+	require.NotNil(t, metric)
+
+	sum, ok := metric.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1, "bound and unbound measurements share a series")
+	assert.Equal(t, int64(5), sum.DataPoints[0].Value)
+
+	metric = findMetric(rm, "float_histogram")
+	require.NotNil(t, metric)
+
+	hist, ok := metric.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, 2, hist.DataPoints[0].Attributes.Len())
+
+	var nilCounter *Int64Counter
+
+	assert.NotPanics(t, func() { nilCounter.With(attrs...).Add(ctx, 1) })
+}
+
+func BenchmarkInt64Counter_Add(b *testing.B) {
+	reader := sdkmetric.NewManualReader()
+	m := &TestMetrics{}
+
+	_, err := Reinit(b.Context(), "test-service", nil, m, sdkmetric.WithReader(reader))
+	require.NoError(b, err)
+
+	attrs := []attribute.Attr{attribute.New("method", "GET"), attribute.New("route", "/users"), attribute.New("status", 200)}
+
+	b.Run("Unbound", func(b *testing.B) {
+		for b.Loop() {
+			m.Counter.Add(b.Context(), 1, attrs...)
+		}
+	})
+
+	b.Run("Bound", func(b *testing.B) {
+		bound := m.Counter.With(attrs...)
+
+		for b.Loop() {
+			bound.Add(b.Context(), 1)
+		}
+	})
+}
+
+func TestWithNameStyle(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := &struct {
+		HTTPRequestDuration *Float64Histogram
+		QueueDepth          *Int64Gauge `metric:"queue_depth"`
+		DB                  struct {
+			Queries *Int64Counter
+		} `prefix:"db."`
+	}{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m, sdkmetric.WithReader(reader), WithNameStyle(DotCase))
+	require.NoError(t, err)
+
+	m.HTTPRequestDuration.Record(t.Context(), 0.1)
+	m.QueueDepth.Record(t.Context(), 3)
+	m.DB.Queries.Add(t.Context(), 1)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	assert.NotNil(t, findMetric(rm, "http.request.duration"))
+	assert.NotNil(t, findMetric(rm, "queue_depth"), "the metric tag overrides the derived name")
+	assert.NotNil(t, findMetric(rm, "db.queries"))
+
+	lib := &struct{ CacheHits *Int64Counter }{}
+	require.NoError(t, InitScoped("library", lib))
+	lib.CacheHits.Inc(t.Context())
+
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	assert.NotNil(t, findMetric(rm, "cache_hits"), "the name style of InitMetrics doesn't apply to library structs")
+}
+
+func TestWithStrictFields(t *testing.T) {
+	type testMetrics struct {
+		Requests *Int64Counter
+		Errors   Int64Counter
+		Name     string
+		DB       struct {
+			Queries *Int64Counter
+		} `prefix:"db_"`
+		unexported *Int64Counter
+	}
+
+	m := &testMetrics{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, m, sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	require.NoError(t, err, "skipped fields are not an error by default")
+
+	report := Report()
+	assert.Equal(t, map[string]string{"Requests": "requests", "DB.Queries": "db_queries"}, report.Instruments)
+	assert.Equal(t, map[string]string{"Errors": "instrument is not a pointer", "Name": "unsupported type string"}, report.Skipped)
+	assert.Nil(t, m.unexported)
+
+	_, err = Reinit(t.Context(), "test-service", nil, &testMetrics{}, sdkmetric.WithReader(sdkmetric.NewManualReader()), WithStrictFields())
+	require.ErrorIs(t, err, errUnsupportedFields)
+	assert.EqualError(t, err, "unsupported metric struct fields: Errors (instrument is not a pointer), Name (unsupported type string)")
+
+	require.ErrorIs(t, InitScoped("github.com/myorg/mylib", &testMetrics{}, WithStrictFields()), errUnsupportedFields)
+
+	_, _, err = NewInstance[testMetrics](t.Context(), "github.com/myorg/mylib", WithStrictFields())
+	require.ErrorIs(t, err, errUnsupportedFields)
+
+	_, err = Reinit(t.Context(), "test-service", nil, &struct{ Requests *Int64Counter }{}, sdkmetric.WithReader(sdkmetric.NewManualReader()), WithStrictFields())
+	require.NoError(t, err)
+}
+
+// memoryExporter keeps the metrics it exports
+type memoryExporter struct {
+	mu      sync.Mutex
+	exports []metricdata.ResourceMetrics
+}
+
+func (e *memoryExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *memoryExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *memoryExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.exports = append(e.exports, *rm)
+
+	return nil
+}
+
+func (e *memoryExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestWithExporter(t *testing.T) {
+	exporter := &memoryExporter{}
+	m := &TestMetrics{}
+
+	shutdown, err := Reinit(t.Context(), "test-service", nil, m, WithExporter(exporter))
+	require.NoError(t, err)
+
+	m.Counter.Add(t.Context(), 1)
+
+	require.NoError(t, shutdown(t.Context()))
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	require.NotEmpty(t, exporter.exports, "shutting down exports to the custom exporter")
+	assert.NotNil(t, findMetric(exporter.exports[len(exporter.exports)-1], "counter"))
+}
+
+func TestReinit(t *testing.T) {
+	readerA := sdkmetric.NewManualReader()
+	a := &TestMetrics{}
+
+	_, err := Reinit(t.Context(), "test-service", nil, a, sdkmetric.WithReader(readerA))
+	require.NoError(t, err)
+
+	_, err = InitMetrics(t.Context(), "test-service", nil, &TestMetrics{}, sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	require.ErrorIs(t, err, ErrAlreadyInitialized)
+	assert.Same(t, a, Metrics[TestMetrics](), "a failed InitMetrics leaves the current metrics")
+
+	readerB := sdkmetric.NewManualReader()
+	b := &TestMetrics{}
+
+	shutdown, err := Reinit(t.Context(), "test-service", nil, b, sdkmetric.WithReader(readerB))
+	require.NoError(t, err)
+	assert.Same(t, b, Metrics[TestMetrics]())
+
+	rm := metricdata.ResourceMetrics{}
+	require.ErrorIs(t, readerA.Collect(t.Context(), &rm), sdkmetric.ErrReaderShutdown, "Reinit shuts the previous provider down")
+
+	b.Counter.Add(t.Context(), 1)
+	require.NoError(t, readerB.Collect(t.Context(), &rm))
+	assert.NotNil(t, findMetric(rm, "counter"))
+
+	require.NoError(t, shutdown(t.Context()))
+	require.NoError(t, shutdown(t.Context()), "shutdown is idempotent")
+	assert.Nil(t, Metrics[TestMetrics]())
+
+	shutdown, err = InitMetrics(t.Context(), "test-service", nil, &TestMetrics{}, sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	require.NoError(t, err, "InitMetrics succeeds once the previous provider is shut down")
+	require.NoError(t, shutdown(t.Context()))
+}
+
+func TestReinit_Failure(t *testing.T) {
+	ctx := t.Context()
+	a, _ := initTestMetrics(t)
+	report := Report()
+
+	h, err := New[PreAggregatedHistogram]("bridge", "reinit_latency")
+	require.NoError(t, err)
+	require.NoError(t, h.Record(ctx, HistogramSummary{Count: 1, Sum: 1, Bounds: []float64{1}, BucketCounts: []uint64{1, 0}}))
+
+	type BadMetrics struct {
+		Latency *Float64Histogram `buckets:"1,x"`
+	}
+
+	_, err = Reinit(ctx, "test-service", nil, &BadMetrics{}, sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	require.Error(t, err)
+	assert.Same(t, a, Metrics[TestMetrics](), "a failed Reinit leaves the current metrics")
+	assert.Equal(t, report, Report(), "a failed Reinit leaves the report")
+
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(PreAggregatedProducer()))
+	_, err = Reinit[struct{}](ctx, "test-service", nil, nil, sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+	assert.NotNil(t, findMetric(rm, "reinit_latency"), "library histograms are exported by the new provider")
+}
+
+type limitedMetrics struct {
+	Requests *Int64Counter `attributes:"method,tenant" max_cardinality:"2"`
+}
+
+type invalidCardinalityMetrics struct {
+	Requests *Int64Counter `max_cardinality:"none"`
+}
+
+func TestAttributeLimit(t *testing.T) {
+	ctx := t.Context()
+	reader := sdkmetric.NewManualReader()
+
+	m, shutdown, err := NewInstance[limitedMetrics](ctx, "limited", sdkmetric.WithReader(reader))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdown(ctx) })
+
+	for _, tenant := range []string{"a", "b", "c", "d", "a"} {
+		m.Requests.Inc(ctx, attribute.New("method", "GET"), attribute.New("tenant", tenant), attribute.New("user_id", tenant))
+	}
+
+	m.Requests.With(attribute.New("method", "GET"), attribute.New("tenant", "e")).Add(ctx, 1)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	foundMetric := findMetric(rm, "requests")
+	require.NotNil(t, foundMetric, "requests metric not found")
+
+	sum, ok := foundMetric.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", foundMetric.Data)
+
+	values := map[string]int64{}
+
+	for _, dp := range sum.DataPoints {
+		_, hasUserID := dp.Attributes.Value("user_id")
+		assert.False(t, hasUserID, "attributes outside the allow-list are dropped")
+
+		method, _ := dp.Attributes.Value("method")
+		tenant, _ := dp.Attributes.Value("tenant")
+		values[method.AsString()+"/"+tenant.AsString()] = dp.Value
+	}
+
+	assert.Equal(t, map[string]int64{"GET/a": 2, "GET/b": 1, "other/other": 3}, values)
+
+	_, _, err = NewInstance[invalidCardinalityMetrics](ctx, "invalid")
+	require.ErrorIs(t, err, errInvalidCardinality)
+}
@@ -0,0 +1,268 @@
+//go:build !gotel_disabled
+
+// Package metrictest records the metrics of a metrics struct in tests, without an OTLP endpoint:
+//
+//	func TestCheckout(t *testing.T) {
+//		m := metrictest.Init[AppMetrics](t)
+//
+//		checkout(t.Context(), m)
+//
+//		metrictest.AssertCounterValue(t, "orders", 1, attribute.New("status", "paid"))
+//	}
+//
+// Metrics are global, so tests using the package must not run in parallel.
+package metrictest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var reader atomic.Pointer[sdkmetric.ManualReader]
+
+// Init initializes a metrics struct of type T on a provider read by a manual reader, replacing any metrics
+// initialized before, and shuts it down when the test ends. Options are passed to the provider, e.g. views.
+func Init[T any](t testing.TB, options ...sdkmetric.Option) *T {
+	t.Helper()
+
+	manualReader := sdkmetric.NewManualReader()
+	m := new(T)
+
+	shutdown, err := metrics.Reinit(t.Context(), t.Name(), nil, m, append(options, sdkmetric.WithReader(manualReader))...)
+	if err != nil {
+		t.Fatalf("initializing metrics: %v", err)
+	}
+
+	reader.Store(manualReader)
+
+	t.Cleanup(func() {
+		reader.CompareAndSwap(manualReader, nil)
+		_ = shutdown(t.Context())
+	})
+
+	return m
+}
+
+// Collect returns the metrics recorded since Init.
+func Collect(t testing.TB) metricdata.ResourceMetrics {
+	t.Helper()
+
+	manualReader := reader.Load()
+	if manualReader == nil {
+		t.Fatal("metrictest.Init has not been called")
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	if err := manualReader.Collect(t.Context(), &rm); err != nil {
+		t.Fatalf("collecting metrics: %v", err)
+	}
+
+	return rm
+}
+
+// Find returns the metric named name, or nil.
+func Find(rm metricdata.ResourceMetrics, name string) *metricdata.Metrics {
+	for _, sm := range rm.ScopeMetrics {
+		for i := range sm.Metrics {
+			if sm.Metrics[i].Name == name {
+				return &sm.Metrics[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+func attributeSet(attrs []attribute.Attr) otelattribute.Set {
+	return otelattribute.NewSet(attribute.ToKeyValues(attrs)...)
+}
+
+// value returns the value of the sum or gauge data point of the metric named name with exactly attrs, reporting
+// an error if there is none.
+func value(t testing.TB, name string, attrs []attribute.Attr) (float64, bool) {
+	t.Helper()
+
+	metric := Find(Collect(t), name)
+	if metric == nil {
+		t.Errorf("metric %s not found", name)
+		return 0, false
+	}
+
+	set := attributeSet(attrs)
+
+	var (
+		actual float64
+		found  bool
+	)
+
+	switch data := metric.Data.(type) {
+	case metricdata.Sum[int64]:
+		actual, found = dataPointValue(data.DataPoints, set)
+	case metricdata.Sum[float64]:
+		actual, found = dataPointValue(data.DataPoints, set)
+	case metricdata.Gauge[int64]:
+		actual, found = dataPointValue(data.DataPoints, set)
+	case metricdata.Gauge[float64]:
+		actual, found = dataPointValue(data.DataPoints, set)
+	default:
+		t.Errorf("metric %s is a %T, not a counter or gauge", name, metric.Data)
+		return 0, false
+	}
+
+	if !found {
+		t.Errorf("metric %s has no data point with attributes %s", name, describe(attrs))
+	}
+
+	return actual, found
+}
+
+func dataPointValue[N int64 | float64](dataPoints []metricdata.DataPoint[N], set otelattribute.Set) (float64, bool) {
+	for _, dp := range dataPoints {
+		if dp.Attributes.Equals(&set) {
+			return float64(dp.Value), true
+		}
+	}
+
+	return 0, false
+}
+
+// AssertCounterValue asserts that the counter named name has the value expected for exactly attrs.
+func AssertCounterValue[N int64 | float64](t testing.TB, name string, expected N, attrs ...attribute.Attr) bool {
+	t.Helper()
+
+	return assertValue(t, name, float64(expected), attrs)
+}
+
+// AssertGaugeValue asserts that the gauge named name last recorded expected for exactly attrs.
+func AssertGaugeValue[N int64 | float64](t testing.TB, name string, expected N, attrs ...attribute.Attr) bool {
+	t.Helper()
+
+	return assertValue(t, name, float64(expected), attrs)
+}
+
+func assertValue(t testing.TB, name string, expected float64, attrs []attribute.Attr) bool {
+	t.Helper()
+
+	actual, ok := value(t, name, attrs)
+	if !ok {
+		return false
+	}
+
+	if actual != expected {
+		t.Errorf("metric %s with attributes %s: expected %v, got %v", name, describe(attrs), expected, actual)
+		return false
+	}
+
+	return true
+}
+
+// AssertHistogramCount asserts that the histogram named name recorded expected measurements for exactly attrs.
+func AssertHistogramCount(t testing.TB, name string, expected uint64, attrs ...attribute.Attr) bool {
+	t.Helper()
+
+	metric := Find(Collect(t), name)
+	if metric == nil {
+		t.Errorf("metric %s not found", name)
+		return false
+	}
+
+	set := attributeSet(attrs)
+
+	var actual uint64
+
+	found := false
+
+	switch data := metric.Data.(type) {
+	case metricdata.Histogram[int64]:
+		actual, found = histogramCount(data.DataPoints, set)
+	case metricdata.Histogram[float64]:
+		actual, found = histogramCount(data.DataPoints, set)
+	default:
+		t.Errorf("metric %s is a %T, not a histogram", name, metric.Data)
+		return false
+	}
+
+	if !found {
+		t.Errorf("metric %s has no data point with attributes %s", name, describe(attrs))
+		return false
+	}
+
+	if actual != expected {
+		t.Errorf("metric %s with attributes %s: expected %d measurements, got %d", name, describe(attrs), expected, actual)
+		return false
+	}
+
+	return true
+}
+
+func histogramCount[N int64 | float64](dataPoints []metricdata.HistogramDataPoint[N], set otelattribute.Set) (uint64, bool) {
+	for _, dp := range dataPoints {
+		if dp.Attributes.Equals(&set) {
+			return dp.Count, true
+		}
+	}
+
+	return 0, false
+}
+
+func describe(attrs []attribute.Attr) string {
+	set := attributeSet(attrs)
+
+	return "{" + set.Encoded(otelattribute.DefaultEncoder()) + "}"
+}
+
+// Snapshot renders the recorded sums, gauges, and histogram counts as sorted lines such as
+// `orders{status=paid} 1`, to compare the whole state of a metrics struct in one assertion.
+func Snapshot(t testing.TB) string {
+	t.Helper()
+
+	lines := []string{}
+
+	add := func(name string, set otelattribute.Set, value any) {
+		lines = append(lines, fmt.Sprintf("%s{%s} %v", name, set.Encoded(otelattribute.DefaultEncoder()), value))
+	}
+
+	for _, sm := range Collect(t).ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Sum[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name+"_count", dp.Attributes, dp.Count)
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name+"_count", dp.Attributes, dp.Count)
+				}
+			}
+		}
+	}
+
+	slices.Sort(lines)
+
+	return strings.Join(lines, "\n")
+}
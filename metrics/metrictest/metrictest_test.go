@@ -0,0 +1,56 @@
+//go:build !gotel_disabled
+
+package metrictest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+)
+
+type appMetrics struct {
+	Orders   *metrics.Int64Counter
+	Load     *metrics.Float64Gauge
+	Duration *metrics.Float64Histogram
+}
+
+// recorder captures failures instead of failing the test, to check the assertions fail when they should.
+type recorder struct {
+	testing.TB
+
+	failures int
+}
+
+func (r *recorder) Errorf(string, ...any) {
+	r.failures++
+}
+
+func TestAssertions(t *testing.T) {
+	m := Init[appMetrics](t)
+	ctx := t.Context()
+
+	paid := attribute.New("status", "paid")
+
+	m.Orders.Add(ctx, 2, paid)
+	m.Orders.Inc(ctx, attribute.New("status", "refunded"))
+	m.Load.Record(ctx, 0.5)
+	m.Duration.Record(ctx, 1.2, paid)
+
+	AssertCounterValue(t, "orders", int64(2), paid)
+	AssertGaugeValue(t, "load", 0.5)
+	AssertHistogramCount(t, "duration", 1, paid)
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertCounterValue(r, "orders", int64(3), paid))
+	assert.False(t, AssertCounterValue(r, "orders", int64(2)), "attributes must match exactly")
+	assert.False(t, AssertCounterValue(r, "missing", int64(1)))
+	assert.False(t, AssertHistogramCount(r, "orders", 1))
+	assert.Equal(t, 4, r.failures)
+
+	assert.Equal(t, `duration_count{status=paid} 1
+load{} 0.5
+orders{status=paid} 2
+orders{status=refunded} 1`, Snapshot(t))
+}
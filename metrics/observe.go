@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+// Unregister the returned registration to stop observing. A nil instrument returns a registration that does nothing.
+func (c *Int64ObservableCounter) ObserveFunc(fn func(ctx context.Context) (int64, []attribute.Attr)) (metric.Registration, error) {
+	if c == nil {
+		return noop.Registration{}, nil
+	}
+
+	return c.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveInt64(c.int64ObservableCounter, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, c.int64ObservableCounter)
+}
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+func (c *Float64ObservableCounter) ObserveFunc(fn func(ctx context.Context) (float64, []attribute.Attr)) (metric.Registration, error) {
+	if c == nil {
+		return noop.Registration{}, nil
+	}
+
+	return c.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveFloat64(c.float64ObservableCounter, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, c.float64ObservableCounter)
+}
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+func (c *Int64ObservableUpDownCounter) ObserveFunc(fn func(ctx context.Context) (int64, []attribute.Attr)) (metric.Registration, error) {
+	if c == nil {
+		return noop.Registration{}, nil
+	}
+
+	return c.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveInt64(c.int64ObservableUpDownCounter, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, c.int64ObservableUpDownCounter)
+}
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+func (c *Float64ObservableUpDownCounter) ObserveFunc(fn func(ctx context.Context) (float64, []attribute.Attr)) (metric.Registration, error) {
+	if c == nil {
+		return noop.Registration{}, nil
+	}
+
+	return c.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveFloat64(c.float64ObservableUpDownCounter, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, c.float64ObservableUpDownCounter)
+}
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+func (g *Int64ObservableGauge) ObserveFunc(fn func(ctx context.Context) (int64, []attribute.Attr)) (metric.Registration, error) {
+	if g == nil {
+		return noop.Registration{}, nil
+	}
+
+	return g.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveInt64(g.int64ObservableGauge, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, g.int64ObservableGauge)
+}
+
+// ObserveFunc registers fn to be called at each collection, recording the value and attributes it returns.
+func (g *Float64ObservableGauge) ObserveFunc(fn func(ctx context.Context) (float64, []attribute.Attr)) (metric.Registration, error) {
+	if g == nil {
+		return noop.Registration{}, nil
+	}
+
+	return g.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		value, attrs := fn(ctx)
+		o.ObserveFloat64(g.float64ObservableGauge, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+
+		return nil
+	}, g.float64ObservableGauge)
+}
+
+// Observable is an observable instrument, which RegisterCallback observes together with others.
+type Observable interface {
+	observable() (metric.Observable, metric.Meter)
+}
+
+func (c *Int64ObservableCounter) observable() (metric.Observable, metric.Meter) {
+	if c == nil {
+		return nil, nil
+	}
+
+	return c.int64ObservableCounter, c.meter
+}
+
+func (c *Float64ObservableCounter) observable() (metric.Observable, metric.Meter) {
+	if c == nil {
+		return nil, nil
+	}
+
+	return c.float64ObservableCounter, c.meter
+}
+
+func (c *Int64ObservableUpDownCounter) observable() (metric.Observable, metric.Meter) {
+	if c == nil {
+		return nil, nil
+	}
+
+	return c.int64ObservableUpDownCounter, c.meter
+}
+
+func (c *Float64ObservableUpDownCounter) observable() (metric.Observable, metric.Meter) {
+	if c == nil {
+		return nil, nil
+	}
+
+	return c.float64ObservableUpDownCounter, c.meter
+}
+
+func (g *Int64ObservableGauge) observable() (metric.Observable, metric.Meter) {
+	if g == nil {
+		return nil, nil
+	}
+
+	return g.int64ObservableGauge, g.meter
+}
+
+func (g *Float64ObservableGauge) observable() (metric.Observable, metric.Meter) {
+	if g == nil {
+		return nil, nil
+	}
+
+	return g.float64ObservableGauge, g.meter
+}
+
+// Observer records the values of the instruments of a RegisterCallback callback.
+type Observer struct {
+	observer metric.Observer
+}
+
+// ObserveInt64 records value for instrument, an int64 instrument passed to RegisterCallback.
+func (o Observer) ObserveInt64(instrument Observable, value int64, attrs ...attribute.Attr) {
+	if observable, ok := observableOf(instrument).(metric.Int64Observable); ok {
+		o.observer.ObserveInt64(observable, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+	}
+}
+
+// ObserveFloat64 records value for instrument, a float64 instrument passed to RegisterCallback.
+func (o Observer) ObserveFloat64(instrument Observable, value float64, attrs ...attribute.Attr) {
+	if observable, ok := observableOf(instrument).(metric.Float64Observable); ok {
+		o.observer.ObserveFloat64(observable, value, metric.WithAttributeSet(newAttributeSet(attrs...)))
+	}
+}
+
+func observableOf(instrument Observable) metric.Observable {
+	observable, _ := instrument.observable()
+	return observable
+}
+
+// RegisterCallback registers callback to be called once at each collection, observing all of instruments, which are
+// fields of the same metrics struct, e.g. to record several values read from a single stats snapshot. Unregister the
+// returned registration to stop observing. If every instrument is nil, the registration does nothing.
+func RegisterCallback(callback func(ctx context.Context, o Observer) error, instruments ...Observable) (metric.Registration, error) {
+	var (
+		meter       metric.Meter
+		observables []metric.Observable
+	)
+
+	for _, instrument := range instruments {
+		if observable, m := instrument.observable(); observable != nil {
+			meter = m
+			observables = append(observables, observable)
+		}
+	}
+
+	if meter == nil {
+		return noop.Registration{}, nil
+	}
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		return callback(ctx, Observer{observer: o})
+	}, observables...)
+}
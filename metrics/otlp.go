@@ -0,0 +1,57 @@
+//go:build !gotel_disabled && !js && !wasip1 && !tinygo
+
+package metrics
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/otlpjson"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// disabled is set by the gotel_disabled build tag.
+const disabled = false
+
+func newGrpcMetricExporter(ctx context.Context, insecure bool, endpoint string, temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	options := []otlpmetricgrpc.Option{otlpmetricgrpc.WithTemporalitySelector(temporality)}
+
+	if insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlpmetricgrpc.WithEndpointURL(export.SignalURL(endpoint, export.SignalMetrics)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalMetrics); ok {
+		options = append(options, otlpmetricgrpc.WithEndpointURL(endpoint))
+	}
+
+	return otlpmetricgrpc.New(ctx, options...)
+}
+
+func newHttpMetricExporter(ctx context.Context, insecure bool, endpoint string, temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	options := []otlpmetrichttp.Option{otlpmetrichttp.WithTemporalitySelector(temporality)}
+
+	if insecure {
+		options = append(options, otlpmetrichttp.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlpmetrichttp.WithEndpointURL(export.SignalURL(endpoint, export.SignalMetrics)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalMetrics); ok {
+		options = append(options, otlpmetrichttp.WithEndpointURL(endpoint))
+	}
+
+	return otlpmetrichttp.New(ctx, options...)
+}
+
+func newJSONMetricExporter(endpoint string, temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return otlpjson.NewMetricExporter(cmp.Or(endpoint, otlpjson.Endpoint()), temporality), nil
+}
+
+func newFileMetricExporter(temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return otlpjson.NewFileMetricExporter(otlpjson.FilePath("metrics"), temporality)
+}
@@ -0,0 +1,31 @@
+//go:build !gotel_disabled && (js || wasip1 || tinygo)
+
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// disabled is set in js/wasm, WASI, and TinyGo builds, where only traces and logs are exported.
+const disabled = true
+
+var errDisabled = errors.New("metrics export is disabled in this build")
+
+func newGrpcMetricExporter(context.Context, bool, string, sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return nil, errDisabled
+}
+
+func newHttpMetricExporter(context.Context, bool, string, sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return nil, errDisabled
+}
+
+func newJSONMetricExporter(string, sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return nil, errDisabled
+}
+
+func newFileMetricExporter(sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	return nil, errDisabled
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+)
+
+const preAggregatedScope = "github.com/tinybluerobots/gotel/metrics"
+
+var (
+	errBucketCounts   = errors.New("histogram summary must have one more bucket count than bounds")
+	errBoundsMismatch = errors.New("histogram summary bounds differ from previously recorded bounds")
+)
+
+// HistogramSummary is histogram data aggregated outside the OpenTelemetry SDK,
+// such as a statsd timer flush or a Prometheus histogram sample.
+type HistogramSummary struct {
+	Count uint64
+	Sum   float64
+	// Min and Max are omitted from the export when NaN, for sources that don't track them.
+	Min float64
+	Max float64
+	// Bounds are the bucket upper boundaries. BucketCounts has one more entry, for values above the last bound.
+	Bounds       []float64
+	BucketCounts []uint64
+}
+
+func (s HistogramSummary) validate() error {
+	if len(s.BucketCounts) != len(s.Bounds)+1 {
+		return errBucketCounts
+	}
+
+	return nil
+}
+
+type summaryPoint struct {
+	attrs   otelattribute.Set
+	start   time.Time
+	summary HistogramSummary
+}
+
+func (p *summaryPoint) merge(summary HistogramSummary) error {
+	if !slices.Equal(p.summary.Bounds, summary.Bounds) {
+		return errBoundsMismatch
+	}
+
+	p.summary.Count += summary.Count
+	p.summary.Sum += summary.Sum
+	p.summary.Min = min(p.summary.Min, summary.Min)
+	p.summary.Max = max(p.summary.Max, summary.Max)
+
+	for i, count := range summary.BucketCounts {
+		p.summary.BucketCounts[i] += count
+	}
+
+	return nil
+}
+
+// PreAggregatedHistogram ingests pre-aggregated histogram data, for adapters bridging metrics aggregated elsewhere.
+// Summaries recorded with the same attributes are merged and exported as a cumulative histogram
+// by readers created with PreAggregatedProducer.
+type PreAggregatedHistogram struct {
+	name        string
+	unit        string
+	description string
+
+	mu     sync.Mutex
+	points map[otelattribute.Distinct]*summaryPoint
+}
+
+// Record merges a summary into the histogram.
+// Returns an error if the bucket counts don't match the bounds, or the bounds differ from earlier summaries.
+func (h *PreAggregatedHistogram) Record(_ context.Context, summary HistogramSummary, attrs ...attribute.Attr) error {
+	if h == nil {
+		return nil
+	}
+
+	if err := summary.validate(); err != nil {
+		return err
+	}
+
+	attributeSet := newAttributeSet(attrs...)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if point, ok := h.points[attributeSet.Equivalent()]; ok {
+		return point.merge(summary)
+	}
+
+	summary.Bounds = slices.Clone(summary.Bounds)
+	summary.BucketCounts = slices.Clone(summary.BucketCounts)
+	h.points[attributeSet.Equivalent()] = &summaryPoint{attrs: attributeSet, start: time.Now(), summary: summary}
+
+	return nil
+}
+
+// Set replaces the histogram data for the attributes, for sources that already aggregate cumulatively such as Prometheus.
+// Returns an error if the bucket counts don't match the bounds.
+func (h *PreAggregatedHistogram) Set(_ context.Context, summary HistogramSummary, attrs ...attribute.Attr) error {
+	if h == nil {
+		return nil
+	}
+
+	if err := summary.validate(); err != nil {
+		return err
+	}
+
+	attributeSet := newAttributeSet(attrs...)
+	summary.Bounds = slices.Clone(summary.Bounds)
+	summary.BucketCounts = slices.Clone(summary.BucketCounts)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	start := time.Now()
+	if point, ok := h.points[attributeSet.Equivalent()]; ok {
+		start = point.start
+	}
+
+	h.points[attributeSet.Equivalent()] = &summaryPoint{attrs: attributeSet, start: start, summary: summary}
+
+	return nil
+}
+
+// histogramSet holds the PreAggregatedHistograms created for a meter provider.
+type histogramSet struct {
+	mu         sync.Mutex
+	histograms []*PreAggregatedHistogram
+}
+
+// libraryHistograms holds the histograms of InitScoped, New, and NewField, exported by the provider of each
+// InitMetrics or Reinit call in turn, as the other library instruments are.
+var libraryHistograms = &histogramSet{}
+
+func (s *histogramSet) newHistogram(name string, unit string, description string) *PreAggregatedHistogram {
+	h := &PreAggregatedHistogram{
+		name:        name,
+		unit:        unit,
+		description: description,
+		points:      map[otelattribute.Distinct]*summaryPoint{},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.histograms = append(s.histograms, h)
+
+	return h
+}
+
+func (s *histogramSet) all() []*PreAggregatedHistogram {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.histograms)
+}
+
+// preAggregatedProducer exports the data of the histograms in the sets it returns.
+type preAggregatedProducer func() []*histogramSet
+
+// producerFor returns the producer of a fixed list of histogram sets.
+func producerFor(sets ...*histogramSet) preAggregatedProducer {
+	return func() []*histogramSet {
+		return sets
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const processScopeName = "github.com/tinybluerobots/gotel/metrics/process"
+
+var (
+	initProcess    sync.Once
+	initProcessErr error
+)
+
+// InitProcessMetrics registers process metrics on the provider created by InitMetrics, to catch file descriptor and
+// thread leaks that memory metrics miss: process.open_file_descriptor.count, process.open_file_descriptor.limit, the
+// soft RLIMIT_NOFILE limit, and process.thread.count, read from the operating system at each collection.
+// Metrics the platform doesn't provide are not recorded: threads are only counted on Linux, and nothing is recorded
+// outside Unix. The metrics are recorded on the provider of each InitMetrics and Reinit call, and calling it again
+// does nothing.
+func InitProcessMetrics() error {
+	initProcess.Do(func() {
+		initProcessErr = registerProcessMetrics()
+	})
+
+	return initProcessErr
+}
+
+func registerProcessMetrics() error {
+	meter := scopedMeter(processScopeName)
+
+	openFileDescriptors, err := meter.Int64ObservableUpDownCounter("process.open_file_descriptor.count",
+		metric.WithUnit("{file_descriptor}"), metric.WithDescription("Number of file descriptors in use by the process."))
+	if err != nil {
+		return err
+	}
+
+	fileDescriptorLimit, err := meter.Int64ObservableUpDownCounter("process.open_file_descriptor.limit",
+		metric.WithUnit("{file_descriptor}"), metric.WithDescription("Maximum number of file descriptors the process can open."))
+	if err != nil {
+		return err
+	}
+
+	threads, err := meter.Int64ObservableUpDownCounter("process.thread.count",
+		metric.WithUnit("{thread}"), metric.WithDescription("Process threads count."))
+	if err != nil {
+		return err
+	}
+
+	readers := map[metric.Int64Observable]func() (int64, bool){
+		openFileDescriptors: countOpenFileDescriptors,
+		fileDescriptorLimit: readFileDescriptorLimit,
+		threads:             countThreads,
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for observable, read := range readers {
+			if value, ok := read(); ok {
+				o.ObserveInt64(observable, value)
+			}
+		}
+
+		return nil
+	}, openFileDescriptors, fileDescriptorLimit, threads)
+
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build unix && !linux
+
+package metrics
+
+func countOpenFileDescriptors() (int64, bool) {
+	return countDirectory("/dev/fd")
+}
+
+// countThreads is unsupported outside Linux, which is the only Unix exposing the thread count without cgo.
+func countThreads() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func countOpenFileDescriptors() (int64, bool) {
+	return countDirectory("/proc/self/fd")
+}
+
+// countThreads reads the thread count from the Threads line of /proc/self/status.
+func countThreads() (int64, bool) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Threads:"); ok {
+			threads, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			return threads, err == nil
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package metrics
+
+// Process metrics are only read on Unix.
+
+func countOpenFileDescriptors() (int64, bool) {
+	return 0, false
+}
+
+func readFileDescriptorLimit() (int64, bool) {
+	return 0, false
+}
+
+func countThreads() (int64, bool) {
+	return 0, false
+}
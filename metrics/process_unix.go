@@ -0,0 +1,36 @@
+//go:build unix
+
+package metrics
+
+import (
+	"math"
+	"os"
+	"syscall"
+)
+
+// readFileDescriptorLimit returns the soft limit on the number of open file descriptors.
+func readFileDescriptorLimit() (int64, bool) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, false
+	}
+
+	return int64(min(limit.Cur, math.MaxInt64)), true
+}
+
+// countDirectory returns the number of entries of a directory listing the open file descriptors, excluding the
+// descriptor opened to read it.
+func countDirectory(dir string) (int64, bool) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return 0, false
+	}
+
+	return int64(len(names) - 1), true
+}
@@ -0,0 +1,94 @@
+//go:build !gotel_disabled
+
+package metrics
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newExtrema(value float64) metricdata.Extrema[float64] {
+	if math.IsNaN(value) {
+		return metricdata.Extrema[float64]{}
+	}
+
+	return metricdata.NewExtrema(value)
+}
+
+func (h *PreAggregatedHistogram) export(now time.Time) (metricdata.Metrics, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.points) == 0 {
+		return metricdata.Metrics{}, false
+	}
+
+	dataPoints := make([]metricdata.HistogramDataPoint[float64], 0, len(h.points))
+	for _, point := range h.points {
+		dataPoints = append(dataPoints, metricdata.HistogramDataPoint[float64]{
+			Attributes:   point.attrs,
+			StartTime:    point.start,
+			Time:         now,
+			Count:        point.summary.Count,
+			Bounds:       slices.Clone(point.summary.Bounds),
+			BucketCounts: slices.Clone(point.summary.BucketCounts),
+			Min:          newExtrema(point.summary.Min),
+			Max:          newExtrema(point.summary.Max),
+			Sum:          point.summary.Sum,
+		})
+	}
+
+	return metricdata.Metrics{
+		Name:        h.name,
+		Description: h.description,
+		Unit:        h.unit,
+		Data: metricdata.Histogram[float64]{
+			Temporality: metricdata.CumulativeTemporality,
+			DataPoints:  dataPoints,
+		},
+	}, true
+}
+
+// Produce returns the data of the pre-aggregated histograms.
+func (p preAggregatedProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	now := time.Now()
+	scopeMetrics := metricdata.ScopeMetrics{Scope: instrumentation.Scope{Name: preAggregatedScope}}
+
+	for _, set := range p() {
+		for _, h := range set.all() {
+			if m, ok := h.export(now); ok {
+				scopeMetrics.Metrics = append(scopeMetrics.Metrics, m)
+			}
+		}
+	}
+
+	if len(scopeMetrics.Metrics) == 0 {
+		return nil, nil
+	}
+
+	return []metricdata.ScopeMetrics{scopeMetrics}, nil
+}
+
+// PreAggregatedProducer returns the producer that exports PreAggregatedHistogram data of the library instruments
+// and of the metrics struct passed to InitMetrics or Reinit. InitMetrics adds it to the OTLP reader and to readers
+// created by WithExporter; pass it to any other reader supplied as an option:
+//
+//	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(metrics.PreAggregatedProducer()))
+//
+// Histograms of a NewInstance struct are exported only by the OTLP reader of that instance and by WithExporter.
+func PreAggregatedProducer() sdkmetric.Producer {
+	return preAggregatedProducer(func() []*histogramSet {
+		sets := []*histogramSet{libraryHistograms}
+		if s := current.Load(); s != nil {
+			sets = append(sets, s.histograms)
+		}
+
+		return sets
+	})
+}
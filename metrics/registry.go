@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+var errInstrumentConflict = errors.New("instrument already registered with a different type")
+
+// Named returns the instrument of type T named name, created on the meter of the metrics struct passed to
+// InitMetrics, for instruments whose names are only known at runtime, such as one per plugin or queue.
+// Instruments are cached by name, so later calls return the same instrument and ignore options; asking for a name
+// already registered with a different type is an error. The cache is discarded by Reinit.
+// Instruments record nothing if InitMetrics has not been called, and are not cached until it is.
+func Named[T Instrument](name string, options ...metric.InstrumentOption) (*T, error) {
+	s := current.Load()
+	if s == nil {
+		return newNamed[T](factory{meter: noop.NewMeterProvider().Meter(""), histograms: &histogramSet{}, owner: libraryOwner{}}, name, options)
+	}
+
+	if cached, ok := s.registry.Load(name); ok {
+		return registeredAs[T](name, cached)
+	}
+
+	instrument, err := newNamed[T](s.factory(), name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Concurrent callers may create the same instrument, the first one stored wins
+	cached, _ := s.registry.LoadOrStore(name, instrument)
+
+	return registeredAs[T](name, cached)
+}
+
+func newNamed[T Instrument](f factory, name string, options []metric.InstrumentOption) (*T, error) {
+	instrumentOptions := make([]any, len(options))
+	for i, option := range options {
+		instrumentOptions[i] = option
+	}
+
+	inst, err := newInstrumentValue(f, reflect.TypeFor[*T](), name, "", instrumentOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	instrument, _ := inst.Interface().(*T)
+
+	return instrument, nil
+}
+
+func registeredAs[T Instrument](name string, cached any) (*T, error) {
+	instrument, ok := cached.(*T)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is a %T, not a %s", errInstrumentConflict, name, cached, reflect.TypeFor[*T]())
+	}
+
+	return instrument, nil
+}
+
+// NewCounter returns the Int64Counter named name, as Named does.
+func NewCounter(name string, options ...metric.InstrumentOption) (*Int64Counter, error) {
+	return Named[Int64Counter](name, options...)
+}
+
+// NewUpDownCounter returns the Int64UpDownCounter named name, as Named does.
+func NewUpDownCounter(name string, options ...metric.InstrumentOption) (*Int64UpDownCounter, error) {
+	return Named[Int64UpDownCounter](name, options...)
+}
+
+// NewGauge returns the Float64Gauge named name, as Named does.
+func NewGauge(name string, options ...metric.InstrumentOption) (*Float64Gauge, error) {
+	return Named[Float64Gauge](name, options...)
+}
+
+// NewHistogram returns the Float64Histogram named name, as Named does.
+func NewHistogram(name string, options ...metric.InstrumentOption) (*Float64Histogram, error) {
+	return Named[Float64Histogram](name, options...)
+}
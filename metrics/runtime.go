@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const runtimeScopeName = "github.com/tinybluerobots/gotel/metrics/runtime"
+
+// runtimeMetric maps a runtime/metrics sample to an instrument.
+type runtimeMetric struct {
+	sample      string
+	name        string
+	unit        string
+	description string
+	counter     bool
+}
+
+var runtimeMetrics = []runtimeMetric{
+	{"/gc/heap/allocs:bytes", "go.memory.allocated", "By", "Memory allocated to the heap by the application.", true},
+	{"/gc/heap/allocs:objects", "go.memory.allocations", "{allocation}", "Count of allocations to the heap by the application.", true},
+	{"/memory/classes/heap/objects:bytes", "go.memory.heap", "By", "Memory occupied by live and unswept heap objects.", false},
+	{"/gc/heap/goal:bytes", "go.memory.gc.goal", "By", "Heap size target for the end of the GC cycle.", false},
+	{"/gc/cycles/total:gc-cycles", "go.gc.count", "{gc_cycle}", "Count of completed GC cycles.", true},
+	{"/sched/goroutines:goroutines", "go.goroutine.count", "{goroutine}", "Count of live goroutines.", false},
+	{"/sched/gomaxprocs:threads", "go.processor.limit", "{thread}", "Number of OS threads that can execute user-level Go code simultaneously.", false},
+	{"/cgo/go-to-c-calls:calls", "go.cgo.calls", "{call}", "Count of calls made from Go to C.", true},
+}
+
+const gcPauseSample = "/cpu/classes/gc/pause:cpu-seconds"
+
+var (
+	initRuntime    sync.Once
+	initRuntimeErr error
+)
+
+// InitRuntimeMetrics registers Go runtime metrics on the provider created by InitMetrics: heap allocations and size,
+// GC cycles and pause CPU time, goroutines, GOMAXPROCS, and cgo calls, read from runtime/metrics at each collection.
+// The metrics are recorded on the provider of each InitMetrics and Reinit call, and calling it again does nothing.
+func InitRuntimeMetrics() error {
+	initRuntime.Do(func() {
+		initRuntimeErr = registerRuntimeMetrics()
+	})
+
+	return initRuntimeErr
+}
+
+func registerRuntimeMetrics() error {
+	meter := scopedMeter(runtimeScopeName)
+	sampleNames := make([]string, len(runtimeMetrics), len(runtimeMetrics)+1)
+	observables := make([]metric.Int64Observable, len(runtimeMetrics))
+	instruments := make([]metric.Observable, 0, len(runtimeMetrics)+1)
+
+	for i, rm := range runtimeMetrics {
+		var (
+			observable metric.Int64Observable
+			err        error
+		)
+
+		if rm.counter {
+			observable, err = meter.Int64ObservableCounter(rm.name, metric.WithUnit(rm.unit), metric.WithDescription(rm.description))
+		} else {
+			observable, err = meter.Int64ObservableUpDownCounter(rm.name, metric.WithUnit(rm.unit), metric.WithDescription(rm.description))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		sampleNames[i] = rm.sample
+		observables[i] = observable
+		instruments = append(instruments, observable)
+	}
+
+	gcPause, err := meter.Float64ObservableCounter("go.gc.pause.cpu_time", metric.WithUnit("s"),
+		metric.WithDescription("Estimated CPU time spent with the world stopped for garbage collection."))
+	if err != nil {
+		return err
+	}
+
+	sampleNames = append(sampleNames, gcPauseSample)
+	instruments = append(instruments, gcPause)
+
+	// Collections by different readers and providers can run concurrently, so each reads into its own samples
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		samples := make([]metrics.Sample, len(sampleNames))
+		for i, name := range sampleNames {
+			samples[i].Name = name
+		}
+
+		metrics.Read(samples)
+
+		for i, observable := range observables {
+			if samples[i].Value.Kind() == metrics.KindUint64 {
+				o.ObserveInt64(observable, int64(min(samples[i].Value.Uint64(), math.MaxInt64)))
+			}
+		}
+
+		if pause := samples[len(observables)]; pause.Value.Kind() == metrics.KindFloat64 {
+			o.ObserveFloat64(gcPause, pause.Value.Float64())
+		}
+
+		return nil
+	}, instruments...)
+
+	return err
+}
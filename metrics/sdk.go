@@ -0,0 +1,137 @@
+//go:build !gotel_disabled
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// Option configures InitMetrics, Reinit, and NewInstance: an sdkmetric.Option for the meter provider, or an option
+// of this package such as WithExporter or WithNameStyle.
+type Option = sdkmetric.Option
+
+// option is an Option applied by InitMetrics, Reinit, and NewInstance rather than by the meter provider.
+// It embeds an option registering no views, so a provider created with it directly is unaffected.
+type option struct {
+	sdkmetric.Option
+
+	apply func(*config)
+}
+
+func newOption(apply func(*config)) Option {
+	return option{Option: sdkmetric.WithView(), apply: apply}
+}
+
+// providerConfig is the part of config that only applies to the meter provider.
+type providerConfig struct {
+	exporters []sdkmetric.Exporter
+}
+
+var errTemporality = errors.New("invalid OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE")
+
+// temporalitySelector returns the selector for a temporality preference, as defined by the OTLP exporter specification.
+// Delta suits backends such as Datadog and Dynatrace; lowmemory uses delta only where it saves memory.
+func temporalitySelector(preference string) (sdkmetric.TemporalitySelector, error) {
+	switch strings.ToLower(preference) {
+	case "", "cumulative":
+		return sdkmetric.DefaultTemporalitySelector, nil
+	case "delta":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+				return metricdata.CumulativeTemporality
+			default:
+				return metricdata.DeltaTemporality
+			}
+		}, nil
+	case "lowmemory":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			default:
+				return metricdata.CumulativeTemporality
+			}
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", errTemporality, preference)
+}
+
+// newMetricExporter returns the OTLP exporter of the configured protocol, exporting to endpoint, or to the configured
+// endpoint if it is empty.
+func newMetricExporter(ctx context.Context, endpoint string, temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
+
+	switch export.Protocol() {
+	case "http":
+		return newHttpMetricExporter(ctx, insecure, endpoint, temporality)
+	case "http/json":
+		return newJSONMetricExporter(endpoint, temporality)
+	case "file":
+		return newFileMetricExporter(temporality)
+	default:
+		return newGrpcMetricExporter(ctx, insecure, endpoint, temporality)
+	}
+}
+
+// newMeterProvider creates a meter provider that exports to the OTLP endpoint, if one is configured, routed by the
+// routes of export.SetEndpointRoutes, and to the exporters of WithExporter. Its periodic readers export the pre-aggregated histograms of producer.
+func newMeterProvider(ctx context.Context, c config, options []Option, producer preAggregatedProducer) (*sdkmetric.MeterProvider, error) {
+	for _, exporter := range c.exporters {
+		options = append(options, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(export.WrapMetricExporter(exporter), sdkmetric.WithProducer(producer))))
+	}
+
+	if !disabled && (export.Endpoint() != "" || export.Protocol() == "file") {
+		temporality, err := temporalitySelector(os.Getenv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE"))
+		if err != nil {
+			return nil, err
+		}
+
+		exporter, err := newMetricExporter(ctx, "", temporality)
+		if err != nil {
+			return nil, err
+		}
+
+		newExporter := func(endpoint string) (sdkmetric.Exporter, error) {
+			return newMetricExporter(ctx, endpoint, temporality)
+		}
+
+		if exporter, err = export.RouteEndpoints(ctx, exporter, newExporter, export.RouteMetrics); err != nil {
+			_ = exporter.Shutdown(ctx)
+			return nil, err
+		}
+
+		options = append(options, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(export.WrapMetricExporter(exporter), sdkmetric.WithProducer(producer))))
+	}
+
+	return sdkmetric.NewMeterProvider(options...), nil
+}
+
+// newSessionProvider creates the meter provider of an InitMetrics or Reinit call, with resourceAttrs as its resource.
+func newSessionProvider(ctx context.Context, c config, resourceAttrs []attribute.Attr, options []Option, producer preAggregatedProducer) (*sdkmetric.MeterProvider, error) {
+	options = append(options, sdkmetric.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
+
+	return newMeterProvider(ctx, c, options, producer)
+}
+
+// WithExporter exports metrics to exporter, such as a vendor or test exporter, on the OTEL_METRIC_EXPORT_INTERVAL
+// schedule, in addition to the OTLP exporter created when OTEL_EXPORTER_OTLP_ENDPOINT is set.
+// Exports are counted by the export package and include the pre-aggregated histograms of the provider.
+// To collect on demand instead, pass sdkmetric.WithReader. It only applies to InitMetrics, Reinit, and NewInstance.
+func WithExporter(exporter sdkmetric.Exporter) Option {
+	return newOption(func(c *config) {
+		c.exporters = append(c.exporters, exporter)
+	})
+}
@@ -0,0 +1,43 @@
+//go:build gotel_disabled
+
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+// disabled is set by the gotel_disabled build tag, which leaves the OpenTelemetry SDK out of the binary.
+const disabled = true
+
+var errDisabled = errors.New("telemetry is disabled by the gotel_disabled build tag")
+
+// Option configures InitMetrics, Reinit, and NewInstance. The gotel_disabled build leaves the OpenTelemetry SDK and
+// its options out, so only the options of this package, such as WithNameStyle, exist.
+type Option interface {
+	metricsOption()
+}
+
+// option is an Option applied by InitMetrics, Reinit, and NewInstance.
+type option struct {
+	apply func(*config)
+}
+
+func (option) metricsOption() {}
+
+func newOption(apply func(*config)) Option {
+	return option{apply: apply}
+}
+
+// providerConfig is the part of config that only applies to the meter provider, of which there is none.
+type providerConfig struct{}
+
+func newMeterProvider(context.Context, config, []Option, preAggregatedProducer) (sdkProvider, error) {
+	return nil, errDisabled
+}
+
+func newSessionProvider(context.Context, config, []attribute.Attr, []Option, preAggregatedProducer) (sdkProvider, error) {
+	return nil, errDisabled
+}
@@ -0,0 +1,55 @@
+package metrics
+
+// The Semconv structs hold instruments named, united, and bucketed as the OpenTelemetry semantic conventions define them.
+// Embed them in a metrics struct to get standard metrics that dashboards and backends recognise:
+//
+//	type AppMetrics struct {
+//		metrics.SemconvHTTPServer
+//		OrdersPlaced *metrics.Int64Counter
+//	}
+//
+// Record them with the attributes the conventions require, such as http.request.method and http.response.status_code.
+
+// SemconvHTTPServer holds the HTTP server metrics.
+type SemconvHTTPServer struct {
+	HTTPServerRequestDuration  *Float64Histogram   `metric:"http.server.request.duration" unit:"s" description:"Duration of HTTP server requests." buckets:"0.005,0.01,0.025,0.05,0.075,0.1,0.25,0.5,0.75,1,2.5,5,7.5,10"`
+	HTTPServerActiveRequests   *Int64UpDownCounter `metric:"http.server.active_requests" unit:"{request}" description:"Number of active HTTP server requests."`
+	HTTPServerRequestBodySize  *Int64Histogram     `metric:"http.server.request.body.size" unit:"By" description:"Size of HTTP server request bodies."`
+	HTTPServerResponseBodySize *Int64Histogram     `metric:"http.server.response.body.size" unit:"By" description:"Size of HTTP server response bodies."`
+}
+
+// SemconvHTTPClient holds the HTTP client metrics.
+type SemconvHTTPClient struct {
+	HTTPClientRequestDuration  *Float64Histogram   `metric:"http.client.request.duration" unit:"s" description:"Duration of HTTP client requests." buckets:"0.005,0.01,0.025,0.05,0.075,0.1,0.25,0.5,0.75,1,2.5,5,7.5,10"`
+	HTTPClientActiveRequests   *Int64UpDownCounter `metric:"http.client.active_requests" unit:"{request}" description:"Number of active HTTP client requests."`
+	HTTPClientRequestBodySize  *Int64Histogram     `metric:"http.client.request.body.size" unit:"By" description:"Size of HTTP client request bodies."`
+	HTTPClientResponseBodySize *Int64Histogram     `metric:"http.client.response.body.size" unit:"By" description:"Size of HTTP client response bodies."`
+}
+
+// SemconvRPCServer holds the RPC server metrics.
+type SemconvRPCServer struct {
+	RPCServerDuration     *Float64Histogram `metric:"rpc.server.duration" unit:"ms" description:"Duration of inbound RPCs."`
+	RPCServerRequestSize  *Int64Histogram   `metric:"rpc.server.request.size" unit:"By" description:"Size of RPC request messages."`
+	RPCServerResponseSize *Int64Histogram   `metric:"rpc.server.response.size" unit:"By" description:"Size of RPC response messages."`
+}
+
+// SemconvRPCClient holds the RPC client metrics.
+type SemconvRPCClient struct {
+	RPCClientDuration     *Float64Histogram `metric:"rpc.client.duration" unit:"ms" description:"Duration of outbound RPCs."`
+	RPCClientRequestSize  *Int64Histogram   `metric:"rpc.client.request.size" unit:"By" description:"Size of RPC request messages."`
+	RPCClientResponseSize *Int64Histogram   `metric:"rpc.client.response.size" unit:"By" description:"Size of RPC response messages."`
+}
+
+// SemconvDBClient holds the database client metrics.
+type SemconvDBClient struct {
+	DBClientOperationDuration *Float64Histogram   `metric:"db.client.operation.duration" unit:"s" description:"Duration of database client operations." buckets:"0.001,0.005,0.01,0.05,0.1,0.5,1,5,10"`
+	DBClientConnectionCount   *Int64UpDownCounter `metric:"db.client.connection.count" unit:"{connection}" description:"Number of connections in the state described by db.client.connection.state."`
+}
+
+// SemconvMessaging holds the messaging client and consumer metrics.
+type SemconvMessaging struct {
+	MessagingClientOperationDuration *Float64Histogram `metric:"messaging.client.operation.duration" unit:"s" description:"Duration of messaging operations initiated by a producer or consumer client." buckets:"0.005,0.01,0.025,0.05,0.075,0.1,0.25,0.5,0.75,1,2.5,5,7.5,10"`
+	MessagingClientSentMessages      *Int64Counter     `metric:"messaging.client.sent.messages" unit:"{message}" description:"Number of messages producer attempted to send to the broker."`
+	MessagingClientConsumedMessages  *Int64Counter     `metric:"messaging.client.consumed.messages" unit:"{message}" description:"Number of messages delivered to the application."`
+	MessagingProcessDuration         *Float64Histogram `metric:"messaging.process.duration" unit:"s" description:"Duration of processing operations." buckets:"0.005,0.01,0.025,0.05,0.075,0.1,0.25,0.5,0.75,1,2.5,5,7.5,10"`
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var spanAttributeKeys atomic.Pointer[[]string]
+
+// SetSpanAttributes copies the listed attributes of the current span, such as http.route, onto every measurement
+// recorded by a synchronous instrument, so metric series align with trace attributes without repeating them at
+// each call site. Attributes passed to Add or Record take precedence. Only sampled spans carry attributes.
+// Keep the list to low-cardinality attributes; calling it with no keys disables copying.
+func SetSpanAttributes(keys ...string) {
+	if len(keys) == 0 {
+		spanAttributeKeys.Store(nil)
+		return
+	}
+
+	copied := slices.Clone(keys)
+	spanAttributeKeys.Store(&copied)
+}
+
+// attributesReader is implemented by SDK spans.
+type attributesReader interface {
+	Attributes() []otelattribute.KeyValue
+}
+
+func withSpanAttributes(ctx context.Context, attrs []attribute.Attr) []attribute.Attr {
+	keys := spanAttributeKeys.Load()
+	if keys == nil {
+		return attrs
+	}
+
+	span, ok := trace.SpanFromContext(ctx).(attributesReader)
+	if !ok {
+		return attrs
+	}
+
+	// Clip so appending never writes into the caller's backing array
+	merged := slices.Clip(attrs)
+
+	for _, kv := range span.Attributes() {
+		if !slices.Contains(*keys, string(kv.Key)) {
+			continue
+		}
+
+		if slices.ContainsFunc(attrs, func(attr attribute.Attr) bool { return attr.Key == kv.Key }) {
+			continue
+		}
+
+		merged = append(merged, attribute.Attr{KeyValue: kv})
+	}
+
+	return merged
+}
@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	errNotInitialized = errors.New("metrics not initialized")
+	errNegativeValue  = errors.New("negative value added to monotonic counter")
+	errInvalidValue   = errors.New("invalid measurement value")
+)
+
+var (
+	errorHandler atomic.Pointer[func(error)]
+
+	// otelErrorHandler is the OpenTelemetry error handler replaced by SetErrorHandler, restored by SetErrorHandler(nil)
+	otelErrorHandler   otel.ErrorHandler
+	otelErrorHandlerMu sync.Mutex
+)
+
+// SetErrorHandler registers a function called with every error detected by a Strict instrument.
+// It is also installed as the OpenTelemetry error handler, so failures inside the SDK, such as export errors,
+// reach it too. Passing nil stops calling the handler from Strict instruments and restores the OpenTelemetry error
+// handler that was installed before the first call.
+func SetErrorHandler(handler func(error)) {
+	otelErrorHandlerMu.Lock()
+	defer otelErrorHandlerMu.Unlock()
+
+	if handler == nil {
+		errorHandler.Store(nil)
+
+		if otelErrorHandler != nil {
+			otel.SetErrorHandler(otelErrorHandler)
+			otelErrorHandler = nil
+		}
+
+		return
+	}
+
+	if otelErrorHandler == nil {
+		otelErrorHandler = otel.GetErrorHandler()
+	}
+
+	errorHandler.Store(&handler)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(handler))
+}
+
+func handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if handler := errorHandler.Load(); handler != nil {
+		(*handler)(err)
+	}
+
+	return err
+}
+
+// StrictInstrument is implemented by the synchronous instruments that can be wrapped by Strict.
+type StrictInstrument[V int64 | float64] interface {
+	measure(ctx context.Context, value V, attrs []attribute.Attr) error
+}
+
+// Strict wraps an instrument so that measurements which would be silently dropped return an error instead,
+// and are passed to the SetErrorHandler handler, for teams that must know when telemetry fails.
+// Errors are returned when the instrument or metrics aren't initialized, when a monotonic counter is given a
+// negative value, and for NaN and infinite values.
+type Strict[T StrictInstrument[V], V int64 | float64] struct {
+	instrument T
+}
+
+// NewStrict wraps an instrument, e.g. metrics.NewStrict(m.RequestCount).
+func NewStrict[T StrictInstrument[V], V int64 | float64](instrument T) Strict[T, V] {
+	return Strict[T, V]{instrument: instrument}
+}
+
+// Add increments a counter, returning an error if the measurement can't be recorded.
+func (s Strict[T, V]) Add(ctx context.Context, value V, attrs ...attribute.Attr) error {
+	return handleError(s.instrument.measure(ctx, value, attrs))
+}
+
+// Record records a gauge or histogram measurement, returning an error if it can't be recorded.
+func (s Strict[T, V]) Record(ctx context.Context, value V, attrs ...attribute.Attr) error {
+	return handleError(s.instrument.measure(ctx, value, attrs))
+}
+
+// checkInitialized returns an error if the instrument is nil, or the provider it was created for doesn't record:
+// InitMetrics has not been called for library instruments, or the provider was replaced or shut down.
+func checkInitialized(name string, isNil bool, owner func() owner) error {
+	if isNil {
+		return fmt.Errorf("%w: %s is nil", errNotInitialized, name)
+	}
+
+	if o := owner(); o == nil || !o.recording() {
+		return fmt.Errorf("%w: the provider of %s is not recording", errNotInitialized, name)
+	}
+
+	return nil
+}
+
+func checkValue[V int64 | float64](value V, monotonic bool) error {
+	f := float64(value)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%w: %v", errInvalidValue, value)
+	}
+
+	if monotonic && value < 0 {
+		return fmt.Errorf("%w: %v", errNegativeValue, value)
+	}
+
+	return nil
+}
+
+func (c *Int64Counter) measure(ctx context.Context, value int64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Int64Counter", c == nil, func() owner { return c.owner }), checkValue(value, true)); err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+func (c *Float64Counter) measure(ctx context.Context, value float64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Float64Counter", c == nil, func() owner { return c.owner }), checkValue(value, true)); err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+func (c *Int64UpDownCounter) measure(ctx context.Context, value int64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Int64UpDownCounter", c == nil, func() owner { return c.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+func (c *Float64UpDownCounter) measure(ctx context.Context, value float64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Float64UpDownCounter", c == nil, func() owner { return c.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+func (g *Int64Gauge) measure(ctx context.Context, value int64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Int64Gauge", g == nil, func() owner { return g.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	g.Record(ctx, value, attrs...)
+
+	return nil
+}
+
+func (g *Float64Gauge) measure(ctx context.Context, value float64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Float64Gauge", g == nil, func() owner { return g.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	g.Record(ctx, value, attrs...)
+
+	return nil
+}
+
+func (h *Int64Histogram) measure(ctx context.Context, value int64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Int64Histogram", h == nil, func() owner { return h.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	h.Record(ctx, value, attrs...)
+
+	return nil
+}
+
+func (h *Float64Histogram) measure(ctx context.Context, value float64, attrs []attribute.Attr) error {
+	if err := errors.Join(checkInitialized("Float64Histogram", h == nil, func() owner { return h.owner }), checkValue(value, false)); err != nil {
+		return err
+	}
+
+	h.Record(ctx, value, attrs...)
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var errUnitMismatch = errors.New("quantity unit does not match instrument unit")
+
+// Quantity is a measurement with its unit, created by helpers such as Seconds and Megabytes.
+// Recording a Quantity converts it to the unit of the instrument, so every team records a histogram in the same unit.
+type Quantity struct {
+	value float64
+	unit  string
+}
+
+type unitScale struct {
+	dimension string
+	scale     float64
+}
+
+// units maps the UCUM units the helpers produce to their dimension and size in the dimension's base unit.
+var units = map[string]unitScale{
+	"ns":   {"time", 1e-9},
+	"us":   {"time", 1e-6},
+	"ms":   {"time", 1e-3},
+	"s":    {"time", 1},
+	"min":  {"time", 60},
+	"h":    {"time", 3600},
+	"By":   {"bytes", 1},
+	"KBy":  {"bytes", 1e3},
+	"MBy":  {"bytes", 1e6},
+	"GBy":  {"bytes", 1e9},
+	"KiBy": {"bytes", 1 << 10},
+	"MiBy": {"bytes", 1 << 20},
+	"GiBy": {"bytes", 1 << 30},
+}
+
+// Seconds returns d in seconds, the unit the semantic conventions use for durations.
+func Seconds(d time.Duration) Quantity {
+	return Quantity{value: d.Seconds(), unit: "s"}
+}
+
+// Milliseconds returns d in milliseconds.
+func Milliseconds(d time.Duration) Quantity {
+	return Quantity{value: float64(d) / float64(time.Millisecond), unit: "ms"}
+}
+
+// Bytes returns n bytes.
+func Bytes(n int64) Quantity {
+	return Quantity{value: float64(n), unit: "By"}
+}
+
+// Megabytes returns n bytes in megabytes of 10^6 bytes.
+func Megabytes(n int64) Quantity {
+	return Quantity{value: float64(n) / 1e6, unit: "MBy"}
+}
+
+// Value returns the quantity in its own unit.
+func (q Quantity) Value() float64 {
+	return q.value
+}
+
+// Unit returns the UCUM unit of the quantity, e.g. "s" or "MBy".
+func (q Quantity) Unit() string {
+	return q.unit
+}
+
+// In converts the quantity to unit, returning an error if the units measure different things.
+func (q Quantity) In(unit string) (float64, error) {
+	if unit == q.unit {
+		return q.value, nil
+	}
+
+	from, fromOk := units[q.unit]
+	to, toOk := units[unit]
+
+	if !fromOk || !toOk || from.dimension != to.dimension {
+		return 0, fmt.Errorf("%w: %s to %q", errUnitMismatch, q.unit, unit)
+	}
+
+	return q.value * from.scale / to.scale, nil
+}
+
+// instrumentUnit returns the unit set by the WithUnit option, or the unit struct tag.
+func instrumentUnit(options []any) string {
+	unitOptions := []metric.Float64CounterOption{}
+
+	for _, option := range options {
+		if unitOption, ok := option.(metric.Float64CounterOption); ok {
+			unitOptions = append(unitOptions, unitOption)
+		}
+	}
+
+	config := metric.NewFloat64CounterConfig(unitOptions...)
+
+	return config.Unit()
+}
+
+// AddQuantity converts q to the unit of the counter and adds it.
+func (c *Float64Counter) AddQuantity(ctx context.Context, q Quantity, attrs ...attribute.Attr) error {
+	if c == nil {
+		return nil
+	}
+
+	value, err := q.In(c.unit)
+	if err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+// AddQuantity converts q to the unit of the counter and adds it.
+func (c *Float64UpDownCounter) AddQuantity(ctx context.Context, q Quantity, attrs ...attribute.Attr) error {
+	if c == nil {
+		return nil
+	}
+
+	value, err := q.In(c.unit)
+	if err != nil {
+		return err
+	}
+
+	c.Add(ctx, value, attrs...)
+
+	return nil
+}
+
+// RecordQuantity converts q to the unit of the gauge and records it.
+func (g *Float64Gauge) RecordQuantity(ctx context.Context, q Quantity, attrs ...attribute.Attr) error {
+	if g == nil {
+		return nil
+	}
+
+	value, err := q.In(g.unit)
+	if err != nil {
+		return err
+	}
+
+	g.Record(ctx, value, attrs...)
+
+	return nil
+}
+
+// RecordQuantity converts q to the unit of the histogram and records it.
+func (h *Float64Histogram) RecordQuantity(ctx context.Context, q Quantity, attrs ...attribute.Attr) error {
+	if h == nil {
+		return nil
+	}
+
+	value, err := q.In(h.unit)
+	if err != nil {
+		return err
+	}
+
+	h.Record(ctx, value, attrs...)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build !gotel_disabled
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+)
+
+// WithViews registers views with the meter provider created by InitMetrics, to rename instruments,
+// drop attributes, or change aggregations without touching the metrics struct.
+// Views match instruments by the name derived from the struct field, e.g. "request_duration", and names may use * and ? wildcards.
+func WithViews(views ...sdkmetric.View) Option {
+	return sdkmetric.WithView(views...)
+}
+
+// RenameView exports the instrument named name as newName. The name must not contain wildcards.
+func RenameView(name string, newName string) sdkmetric.View {
+	return sdkmetric.NewView(sdkmetric.Instrument{Name: name}, sdkmetric.Stream{Name: newName})
+}
+
+// DropAttributesView removes the listed attribute keys from measurements of matching instruments,
+// to reduce cardinality of attributes recorded at call sites.
+func DropAttributesView(name string, keys ...string) sdkmetric.View {
+	otelKeys := make([]otelattribute.Key, len(keys))
+	for i, key := range keys {
+		otelKeys[i] = otelattribute.Key(key)
+	}
+
+	return sdkmetric.NewView(sdkmetric.Instrument{Name: name}, sdkmetric.Stream{AttributeFilter: otelattribute.NewDenyKeysFilter(otelKeys...)})
+}
+
+// AggregationView sets the aggregation of matching instruments, such as sdkmetric.AggregationDrop{} to disable them
+// or sdkmetric.AggregationBase2ExponentialHistogram{} for exponential histograms.
+func AggregationView(name string, aggregation sdkmetric.Aggregation) sdkmetric.View {
+	return sdkmetric.NewView(sdkmetric.Instrument{Name: name}, sdkmetric.Stream{Aggregation: aggregation})
+}
+
+// ExemplarReservoirView sets how exemplars are sampled for matching instruments, which otherwise keep one exemplar per
+// bucket for histograms and one per CPU for counters and gauges. For example, exemplar.FixedSizeReservoirProvider(10)
+// keeps up to ten exemplars per series, sampled uniformly from the measurements recorded in sampled spans.
+func ExemplarReservoirView(name string, provider exemplar.ReservoirProvider) sdkmetric.View {
+	selector := func(sdkmetric.Aggregation) exemplar.ReservoirProvider { return provider }
+
+	return sdkmetric.NewView(sdkmetric.Instrument{Name: name}, sdkmetric.Stream{ExemplarReservoirProviderSelector: selector})
+}
+
+// DropExemplarsView disables exemplars for matching instruments, e.g. high-frequency counters whose exemplars
+// aren't worth their export size.
+func DropExemplarsView(name string) sdkmetric.View {
+	return ExemplarReservoirView(name, func(otelattribute.Set) exemplar.Reservoir { return dropReservoir{} })
+}
+
+// dropReservoir is an exemplar reservoir that keeps nothing.
+type dropReservoir struct{}
+
+func (dropReservoir) Offer(context.Context, time.Time, exemplar.Value, []otelattribute.KeyValue) {}
+
+func (dropReservoir) Collect(dest *[]exemplar.Exemplar) {
+	*dest = (*dest)[:0]
+}
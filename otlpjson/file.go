@@ -0,0 +1,93 @@
+//go:build !gotel_disabled
+
+package otlpjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/export"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// FilePath returns the file a signal, "traces", "metrics", or "logs", is exported to when export.Protocol is file:
+// <signal>.jsonl in the export.FileDirectory directory, or in the working directory if it's unset.
+func FilePath(signal string) string {
+	return filepath.Join(export.FileDirectory(), signal+".jsonl")
+}
+
+// file appends each OTLP JSON request to a file as a line, the format read by the collector's otlpjsonfile receiver.
+type file struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func openFile(path string) (*file, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &file{file: f}, nil
+}
+
+func (f *file) send(_ context.Context, request any) error {
+	line, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, err = f.file.Write(append(line, '\n'))
+
+	return err
+}
+
+// close syncs the file before closing it, so the lines exported before shutdown survive a crash of the host.
+func (f *file) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return errors.Join(f.file.Sync(), f.file.Close())
+}
+
+// NewFileSpanExporter returns an exporter appending spans to the file at path as JSON lines, for environments
+// where a sidecar ships telemetry by tailing files.
+func NewFileSpanExporter(path string) (*SpanExporter, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SpanExporter{sender: f}, nil
+}
+
+// NewFileMetricExporter returns an exporter appending metrics to the file at path as JSON lines, with the
+// temporality chosen by temporality, or cumulative temporality if it is nil.
+func NewFileMetricExporter(path string, temporality sdkmetric.TemporalitySelector) (*MetricExporter, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter := NewMetricExporter("", temporality)
+	exporter.sender = f
+
+	return exporter, nil
+}
+
+// NewFileLogExporter returns an exporter appending log records to the file at path as JSON lines.
+func NewFileLogExporter(path string) (*LogExporter, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogExporter{sender: f}, nil
+}
@@ -0,0 +1,165 @@
+//go:build !gotel_disabled
+
+package otlpjson
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+type logsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  otlpResource `json:"resource"`
+	ScopeLogs []scopeLogs  `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano           string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano   string     `json:"observedTimeUnixNano"`
+	SeverityNumber         int        `json:"severityNumber,omitempty"`
+	SeverityText           string     `json:"severityText,omitempty"`
+	EventName              string     `json:"eventName,omitempty"`
+	Body                   *anyValue  `json:"body,omitempty"`
+	Attributes             []keyValue `json:"attributes,omitempty"`
+	DroppedAttributesCount int        `json:"droppedAttributesCount,omitempty"`
+	Flags                  int        `json:"flags,omitempty"`
+	TraceID                string     `json:"traceId,omitempty"`
+	SpanID                 string     `json:"spanId,omitempty"`
+}
+
+// LogExporter exports log records to the /v1/logs path of an OTLP/HTTP collector as JSON, or to a file as JSON lines.
+type LogExporter struct {
+	sender sender
+}
+
+var _ sdklog.Exporter = (*LogExporter)(nil)
+
+// NewLogExporter returns an exporter to the collector at endpoint, e.g. "http://localhost:4318".
+func NewLogExporter(endpoint string) *LogExporter {
+	return &LogExporter{sender: newClient(endpoint, "/v1/logs")}
+}
+
+// Export sends records to the collector in a single request.
+func (e *LogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return e.sender.send(ctx, newLogsRequest(records))
+}
+
+// Shutdown closes the file of a file exporter. Requests are not buffered.
+func (e *LogExporter) Shutdown(context.Context) error {
+	return e.sender.close()
+}
+
+// ForceFlush does nothing, as requests are not buffered.
+func (e *LogExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func newLogsRequest(records []sdklog.Record) logsRequest {
+	request := logsRequest{}
+	resourceIndex := map[*resource.Resource]int{}
+	scopeIndex := map[*resource.Resource]map[instrumentation.Scope]int{}
+
+	for _, r := range records {
+		res := r.Resource()
+
+		i, ok := resourceIndex[res]
+		if !ok {
+			i = len(request.ResourceLogs)
+			resourceIndex[res] = i
+			scopeIndex[res] = map[instrumentation.Scope]int{}
+			request.ResourceLogs = append(request.ResourceLogs, resourceLogs{Resource: newResource(res)})
+		}
+
+		rl := &request.ResourceLogs[i]
+
+		j, ok := scopeIndex[res][r.InstrumentationScope()]
+		if !ok {
+			j = len(rl.ScopeLogs)
+			scopeIndex[res][r.InstrumentationScope()] = j
+			rl.ScopeLogs = append(rl.ScopeLogs, scopeLogs{Scope: newScope(r.InstrumentationScope())})
+		}
+
+		rl.ScopeLogs[j].LogRecords = append(rl.ScopeLogs[j].LogRecords, newLogRecord(r))
+	}
+
+	return request
+}
+
+func newLogRecord(r sdklog.Record) logRecord {
+	traceID, spanID := r.TraceID(), r.SpanID()
+
+	result := logRecord{
+		TimeUnixNano:           unixNano(r.Timestamp()),
+		ObservedTimeUnixNano:   unixNano(r.ObservedTimestamp()),
+		SeverityNumber:         int(r.Severity()),
+		SeverityText:           r.SeverityText(),
+		EventName:              r.EventName(),
+		DroppedAttributesCount: r.DroppedAttributes(),
+		Flags:                  int(r.TraceFlags()),
+		TraceID:                hexID(traceID[:], traceID.IsValid()),
+		SpanID:                 hexID(spanID[:], spanID.IsValid()),
+	}
+
+	if body := r.Body(); !body.Empty() {
+		value := logValue(body)
+		result.Body = &value
+	}
+
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		result.Attributes = append(result.Attributes, keyValue{Key: kv.Key, Value: logValue(kv.Value)})
+		return true
+	})
+
+	return result
+}
+
+func logValue(v log.Value) anyValue {
+	switch v.Kind() {
+	case log.KindBool:
+		b := v.AsBool()
+
+		return anyValue{BoolValue: &b}
+	case log.KindFloat64:
+		f := v.AsFloat64()
+
+		return anyValue{DoubleValue: &f}
+	case log.KindInt64:
+		return intValue(v.AsInt64())
+	case log.KindBytes:
+		return anyValue{BytesValue: v.AsBytes()}
+	case log.KindSlice:
+		values := []anyValue{}
+		for _, item := range v.AsSlice() {
+			values = append(values, logValue(item))
+		}
+
+		return anyValue{ArrayValue: &arrayValue{Values: values}}
+	case log.KindMap:
+		values := []keyValue{}
+		for _, kv := range v.AsMap() {
+			values = append(values, keyValue{Key: kv.Key, Value: logValue(kv.Value)})
+		}
+
+		return anyValue{KvlistValue: &kvList{Values: values}}
+	case log.KindString, log.KindEmpty:
+		return stringValue(v.AsString())
+	default:
+		return stringValue(v.String())
+	}
+}
@@ -0,0 +1,379 @@
+//go:build !gotel_disabled
+
+package otlpjson
+
+import (
+	"context"
+	"strconv"
+
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type metricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     otlpResource   `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name                 string                `json:"name"`
+	Description          string                `json:"description,omitempty"`
+	Unit                 string                `json:"unit,omitempty"`
+	Gauge                *gauge                `json:"gauge,omitempty"`
+	Sum                  *sum                  `json:"sum,omitempty"`
+	Histogram            *histogram            `json:"histogram,omitempty"`
+	ExponentialHistogram *exponentialHistogram `json:"exponentialHistogram,omitempty"`
+	Summary              *summary              `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type exponentialHistogram struct {
+	DataPoints             []exponentialHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                             `json:"aggregationTemporality"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsInt             *string    `json:"asInt,omitempty"`
+	AsDouble          *float64   `json:"asDouble,omitempty"`
+	Exemplars         []exemplar `json:"exemplars,omitempty"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	BucketCounts      []string   `json:"bucketCounts"`
+	ExplicitBounds    []float64  `json:"explicitBounds"`
+	Min               *float64   `json:"min,omitempty"`
+	Max               *float64   `json:"max,omitempty"`
+	Exemplars         []exemplar `json:"exemplars,omitempty"`
+}
+
+type exponentialHistogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	Scale             int32      `json:"scale"`
+	ZeroCount         string     `json:"zeroCount"`
+	ZeroThreshold     float64    `json:"zeroThreshold"`
+	Positive          buckets    `json:"positive"`
+	Negative          buckets    `json:"negative"`
+	Min               *float64   `json:"min,omitempty"`
+	Max               *float64   `json:"max,omitempty"`
+	Exemplars         []exemplar `json:"exemplars,omitempty"`
+}
+
+type buckets struct {
+	Offset       int32    `json:"offset"`
+	BucketCounts []string `json:"bucketCounts"`
+}
+
+type summaryDataPoint struct {
+	Attributes        []keyValue      `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	QuantileValues    []quantileValue `json:"quantileValues"`
+}
+
+type quantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type exemplar struct {
+	FilteredAttributes []keyValue `json:"filteredAttributes,omitempty"`
+	TimeUnixNano       string     `json:"timeUnixNano"`
+	AsInt              *string    `json:"asInt,omitempty"`
+	AsDouble           *float64   `json:"asDouble,omitempty"`
+	SpanID             string     `json:"spanId,omitempty"`
+	TraceID            string     `json:"traceId,omitempty"`
+}
+
+// OTLP aggregation temporalities, which are ordered differently from metricdata.Temporality.
+const (
+	temporalityDelta      = 1
+	temporalityCumulative = 2
+)
+
+// MetricExporter exports metrics to the /v1/metrics path of an OTLP/HTTP collector as JSON, or to a file as JSON lines.
+type MetricExporter struct {
+	sender      sender
+	temporality sdkmetric.TemporalitySelector
+}
+
+var _ sdkmetric.Exporter = (*MetricExporter)(nil)
+
+// NewMetricExporter returns an exporter to the collector at endpoint, e.g. "http://localhost:4318", with the
+// temporality chosen by temporality, or cumulative temporality if it is nil.
+func NewMetricExporter(endpoint string, temporality sdkmetric.TemporalitySelector) *MetricExporter {
+	if temporality == nil {
+		temporality = sdkmetric.DefaultTemporalitySelector
+	}
+
+	return &MetricExporter{sender: newClient(endpoint, "/v1/metrics"), temporality: temporality}
+}
+
+// Temporality returns the temporality of the instrument kind.
+func (e *MetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.temporality(kind)
+}
+
+// Aggregation returns the default aggregation of the instrument kind.
+func (e *MetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export sends metrics to the collector in a single request.
+func (e *MetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	request := newMetricsRequest(rm)
+	if len(request.ResourceMetrics[0].ScopeMetrics) == 0 {
+		return nil
+	}
+
+	return e.sender.send(ctx, request)
+}
+
+// ForceFlush does nothing, as requests are not buffered.
+func (e *MetricExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Shutdown closes the file of a file exporter. Requests are not buffered.
+func (e *MetricExporter) Shutdown(context.Context) error {
+	return e.sender.close()
+}
+
+func newMetricsRequest(rm *metricdata.ResourceMetrics) metricsRequest {
+	rs := resourceMetrics{Resource: newResource(rm.Resource), ScopeMetrics: []scopeMetrics{}}
+
+	for _, sm := range rm.ScopeMetrics {
+		scoped := scopeMetrics{Scope: newScope(sm.Scope)}
+
+		for _, m := range sm.Metrics {
+			if converted, ok := newMetric(m); ok {
+				scoped.Metrics = append(scoped.Metrics, converted)
+			}
+		}
+
+		if len(scoped.Metrics) > 0 {
+			rs.ScopeMetrics = append(rs.ScopeMetrics, scoped)
+		}
+	}
+
+	return metricsRequest{ResourceMetrics: []resourceMetrics{rs}}
+}
+
+// newMetric converts a metric, reporting false for an aggregation it doesn't know.
+func newMetric(m metricdata.Metrics) (metric, bool) {
+	result := metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+
+	switch data := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		result.Gauge = &gauge{DataPoints: numberDataPoints(data.DataPoints)}
+	case metricdata.Gauge[float64]:
+		result.Gauge = &gauge{DataPoints: numberDataPoints(data.DataPoints)}
+	case metricdata.Sum[int64]:
+		result.Sum = &sum{DataPoints: numberDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality), IsMonotonic: data.IsMonotonic}
+	case metricdata.Sum[float64]:
+		result.Sum = &sum{DataPoints: numberDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality), IsMonotonic: data.IsMonotonic}
+	case metricdata.Histogram[int64]:
+		result.Histogram = &histogram{DataPoints: histogramDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality)}
+	case metricdata.Histogram[float64]:
+		result.Histogram = &histogram{DataPoints: histogramDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality)}
+	case metricdata.ExponentialHistogram[int64]:
+		result.ExponentialHistogram = &exponentialHistogram{DataPoints: exponentialHistogramDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality)}
+	case metricdata.ExponentialHistogram[float64]:
+		result.ExponentialHistogram = &exponentialHistogram{DataPoints: exponentialHistogramDataPoints(data.DataPoints), AggregationTemporality: temporality(data.Temporality)}
+	case metricdata.Summary:
+		result.Summary = &summary{DataPoints: summaryDataPoints(data.DataPoints)}
+	default:
+		return metric{}, false
+	}
+
+	return result, true
+}
+
+func temporality(t metricdata.Temporality) int {
+	if t == metricdata.DeltaTemporality {
+		return temporalityDelta
+	}
+
+	return temporalityCumulative
+}
+
+// number returns the OTLP asInt or asDouble value of v.
+func number[N int64 | float64](v N) (*string, *float64) {
+	if i, ok := any(v).(int64); ok {
+		s := strconv.FormatInt(i, 10)
+
+		return &s, nil
+	}
+
+	f := float64(v)
+
+	return nil, &f
+}
+
+func extremum[N int64 | float64](e metricdata.Extrema[N]) *float64 {
+	v, ok := e.Value()
+	if !ok {
+		return nil
+	}
+
+	f := float64(v)
+
+	return &f
+}
+
+func counts(values []uint64) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strconv.FormatUint(v, 10)
+	}
+
+	return result
+}
+
+func setAttributes(set otelattribute.Set) []keyValue {
+	return attributes(set.ToSlice())
+}
+
+func exemplars[N int64 | float64](values []metricdata.Exemplar[N]) []exemplar {
+	result := make([]exemplar, 0, len(values))
+
+	for _, e := range values {
+		asInt, asDouble := number(e.Value)
+		result = append(result, exemplar{
+			FilteredAttributes: attributes(e.FilteredAttributes),
+			TimeUnixNano:       unixNano(e.Time),
+			AsInt:              asInt,
+			AsDouble:           asDouble,
+			SpanID:             hexID(e.SpanID, len(e.SpanID) > 0),
+			TraceID:            hexID(e.TraceID, len(e.TraceID) > 0),
+		})
+	}
+
+	return result
+}
+
+func numberDataPoints[N int64 | float64](points []metricdata.DataPoint[N]) []numberDataPoint {
+	result := make([]numberDataPoint, 0, len(points))
+
+	for _, p := range points {
+		asInt, asDouble := number(p.Value)
+		result = append(result, numberDataPoint{
+			Attributes:        setAttributes(p.Attributes),
+			StartTimeUnixNano: unixNano(p.StartTime),
+			TimeUnixNano:      unixNano(p.Time),
+			AsInt:             asInt,
+			AsDouble:          asDouble,
+			Exemplars:         exemplars(p.Exemplars),
+		})
+	}
+
+	return result
+}
+
+func histogramDataPoints[N int64 | float64](points []metricdata.HistogramDataPoint[N]) []histogramDataPoint {
+	result := make([]histogramDataPoint, 0, len(points))
+
+	for _, p := range points {
+		result = append(result, histogramDataPoint{
+			Attributes:        setAttributes(p.Attributes),
+			StartTimeUnixNano: unixNano(p.StartTime),
+			TimeUnixNano:      unixNano(p.Time),
+			Count:             strconv.FormatUint(p.Count, 10),
+			Sum:               float64(p.Sum),
+			BucketCounts:      counts(p.BucketCounts),
+			ExplicitBounds:    p.Bounds,
+			Min:               extremum(p.Min),
+			Max:               extremum(p.Max),
+			Exemplars:         exemplars(p.Exemplars),
+		})
+	}
+
+	return result
+}
+
+func exponentialHistogramDataPoints[N int64 | float64](points []metricdata.ExponentialHistogramDataPoint[N]) []exponentialHistogramDataPoint {
+	result := make([]exponentialHistogramDataPoint, 0, len(points))
+
+	for _, p := range points {
+		result = append(result, exponentialHistogramDataPoint{
+			Attributes:        setAttributes(p.Attributes),
+			StartTimeUnixNano: unixNano(p.StartTime),
+			TimeUnixNano:      unixNano(p.Time),
+			Count:             strconv.FormatUint(p.Count, 10),
+			Sum:               float64(p.Sum),
+			Scale:             p.Scale,
+			ZeroCount:         strconv.FormatUint(p.ZeroCount, 10),
+			ZeroThreshold:     p.ZeroThreshold,
+			Positive:          buckets{Offset: p.PositiveBucket.Offset, BucketCounts: counts(p.PositiveBucket.Counts)},
+			Negative:          buckets{Offset: p.NegativeBucket.Offset, BucketCounts: counts(p.NegativeBucket.Counts)},
+			Min:               extremum(p.Min),
+			Max:               extremum(p.Max),
+			Exemplars:         exemplars(p.Exemplars),
+		})
+	}
+
+	return result
+}
+
+func summaryDataPoints(points []metricdata.SummaryDataPoint) []summaryDataPoint {
+	result := make([]summaryDataPoint, 0, len(points))
+
+	for _, p := range points {
+		quantiles := make([]quantileValue, len(p.QuantileValues))
+		for i, q := range p.QuantileValues {
+			quantiles[i] = quantileValue{Quantile: q.Quantile, Value: q.Value}
+		}
+
+		result = append(result, summaryDataPoint{
+			Attributes:        setAttributes(p.Attributes),
+			StartTimeUnixNano: unixNano(p.StartTime),
+			TimeUnixNano:      unixNano(p.Time),
+			Count:             strconv.FormatUint(p.Count, 10),
+			Sum:               p.Sum,
+			QuantileValues:    quantiles,
+		})
+	}
+
+	return result
+}
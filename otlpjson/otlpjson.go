@@ -0,0 +1,218 @@
+//go:build !gotel_disabled
+
+// Package otlpjson exports spans, metrics, and log records as OTLP/HTTP JSON using only net/http and
+// encoding/json, without gRPC or protobuf, for gateways and functions that can't accept protobuf content types
+// and for js/wasm and TinyGo builds.
+//
+// The file exporters append the same requests to files as JSON lines, for air-gapped environments where a sidecar
+// ships telemetry by tailing files.
+//
+// The tracing, metrics, and log packages use these exporters when OTEL_EXPORTER_OTLP_PROTOCOL is http/json or file, and
+// tracing and log always use them when built for js, wasip1, or TinyGo. They can also be passed to any SDK provider:
+//
+//	exporter := otlpjson.NewSpanExporter("https://collector.example.com:4318")
+//	shutdown, err := tracing.InitTracing(ctx, "edge", resourceAttrs, sdktrace.WithBatcher(exporter))
+package otlpjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/gotel/export"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+var errExport = errors.New("OTLP export failed")
+
+// Endpoint returns the base URL of the collector from export.Endpoint, set by export.SetEndpoint or
+// OTEL_EXPORTER_OTLP_ENDPOINT. An endpoint without a scheme uses https, or http when OTEL_EXPORTER_OTLP_INSECURE is
+// true.
+func Endpoint() string {
+	endpoint := export.Endpoint()
+	if endpoint == "" || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true" {
+		return "http://" + endpoint
+	}
+
+	return "https://" + endpoint
+}
+
+// sender delivers OTLP JSON requests to a collector or a file.
+type sender interface {
+	send(ctx context.Context, request any) error
+	close() error
+}
+
+// client posts OTLP JSON requests to a signal path of the collector.
+type client struct {
+	url        string
+	httpClient *http.Client
+}
+
+func newClient(endpoint string, path string) client {
+	return client{url: strings.TrimSuffix(endpoint, "/") + path, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c client) send(ctx context.Context, request any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s returned %s", errExport, c.url, response.Status)
+	}
+
+	return nil
+}
+
+func (client) close() error {
+	return nil
+}
+
+// The types below are the OTLP JSON encoding of the protobuf messages, in which 64-bit integers are strings
+// and trace and span IDs are hex.
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	BytesValue  []byte      `json:"bytesValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *kvList     `json:"kvlistValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+type kvList struct {
+	Values []keyValue `json:"values"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+func stringValue(s string) anyValue {
+	return anyValue{StringValue: &s}
+}
+
+func intValue(i int64) anyValue {
+	s := strconv.FormatInt(i, 10)
+
+	return anyValue{IntValue: &s}
+}
+
+func attributeValue(v otelattribute.Value) anyValue {
+	switch v.Type() {
+	case otelattribute.BOOL:
+		b := v.AsBool()
+
+		return anyValue{BoolValue: &b}
+	case otelattribute.INT64:
+		return intValue(v.AsInt64())
+	case otelattribute.FLOAT64:
+		f := v.AsFloat64()
+
+		return anyValue{DoubleValue: &f}
+	case otelattribute.BOOLSLICE, otelattribute.INT64SLICE, otelattribute.FLOAT64SLICE, otelattribute.STRINGSLICE:
+		values := []anyValue{}
+
+		switch v.Type() {
+		case otelattribute.BOOLSLICE:
+			for _, b := range v.AsBoolSlice() {
+				values = append(values, attributeValue(otelattribute.BoolValue(b)))
+			}
+		case otelattribute.INT64SLICE:
+			for _, i := range v.AsInt64Slice() {
+				values = append(values, intValue(i))
+			}
+		case otelattribute.FLOAT64SLICE:
+			for _, f := range v.AsFloat64Slice() {
+				values = append(values, attributeValue(otelattribute.Float64Value(f)))
+			}
+		default:
+			for _, s := range v.AsStringSlice() {
+				values = append(values, stringValue(s))
+			}
+		}
+
+		return anyValue{ArrayValue: &arrayValue{Values: values}}
+	default:
+		return stringValue(v.Emit())
+	}
+}
+
+func attributes(kvs []otelattribute.KeyValue) []keyValue {
+	result := make([]keyValue, len(kvs))
+	for i, kv := range kvs {
+		result[i] = keyValue{Key: string(kv.Key), Value: attributeValue(kv.Value)}
+	}
+
+	return result
+}
+
+func newResource(r *resource.Resource) otlpResource {
+	return otlpResource{Attributes: attributes(r.Attributes())}
+}
+
+func newScope(s instrumentation.Scope) scope {
+	return scope{Name: s.Name, Version: s.Version}
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// hexID returns the hex encoding of a trace or span ID, or an empty string for an invalid ID.
+func hexID(id []byte, valid bool) string {
+	if !valid {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
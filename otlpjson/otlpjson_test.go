@@ -0,0 +1,318 @@
+//go:build !gotel_disabled
+
+package otlpjson
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/export"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// collector records the path and decoded body of each request
+type collector struct {
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]any
+}
+
+func newCollector(t *testing.T, statusCode int) (*collector, string) {
+	t.Helper()
+
+	c := &collector{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		c.mu.Lock()
+		c.paths = append(c.paths, r.URL.Path)
+		c.requests = append(c.requests, body)
+		c.mu.Unlock()
+
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(server.Close)
+
+	return c, server.URL
+}
+
+// lookup returns the value at the path of map keys and slice indexes
+func lookup(v any, path ...any) any {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[key]
+		case int:
+			s, _ := v.([]any)
+			if key >= len(s) {
+				return nil
+			}
+
+			v = s[key]
+		}
+	}
+
+	return v
+}
+
+func TestSpanExporter(t *testing.T) {
+	c, endpoint := newCollector(t, http.StatusOK)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(NewSpanExporter(endpoint)),
+		sdktrace.WithResource(resource.NewSchemaless(otelattribute.String("service.name", "edge"))),
+	)
+	tracer := provider.Tracer("github.com/myorg/edge", trace.WithInstrumentationVersion("1.0.0"))
+
+	ctx, parent := tracer.Start(t.Context(), "parent")
+	_, span := tracer.Start(ctx, "child", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		otelattribute.String("http.request.method", "GET"),
+		otelattribute.Int64("http.response.status_code", 503),
+		otelattribute.StringSlice("tags", []string{"a", "b"}),
+	))
+	span.AddEvent("retry", trace.WithAttributes(otelattribute.Bool("final", true)))
+	span.SetStatus(codes.Error, "unavailable")
+	span.End()
+	parent.End()
+
+	require.Len(t, c.requests, 2)
+	assert.Equal(t, []string{"/v1/traces", "/v1/traces"}, c.paths)
+
+	request := c.requests[0]
+	assert.Equal(t, "service.name", lookup(request, "resourceSpans", 0, "resource", "attributes", 0, "key"))
+	assert.Equal(t, "github.com/myorg/edge", lookup(request, "resourceSpans", 0, "scopeSpans", 0, "scope", "name"))
+	assert.Equal(t, "1.0.0", lookup(request, "resourceSpans", 0, "scopeSpans", 0, "scope", "version"))
+
+	child := lookup(request, "resourceSpans", 0, "scopeSpans", 0, "spans", 0)
+	assert.Equal(t, "child", lookup(child, "name"))
+	assert.InDelta(t, 3, lookup(child, "kind"), 0, "client spans are kind 3")
+	assert.Equal(t, span.SpanContext().TraceID().String(), lookup(child, "traceId"))
+	assert.Equal(t, span.SpanContext().SpanID().String(), lookup(child, "spanId"))
+	assert.Equal(t, parent.SpanContext().SpanID().String(), lookup(child, "parentSpanId"))
+	assert.Equal(t, "GET", lookup(child, "attributes", 0, "value", "stringValue"))
+	assert.Equal(t, "503", lookup(child, "attributes", 1, "value", "intValue"), "64-bit integers are strings")
+	assert.Equal(t, "b", lookup(child, "attributes", 2, "value", "arrayValue", "values", 1, "stringValue"))
+	assert.Equal(t, "retry", lookup(child, "events", 0, "name"))
+	assert.Equal(t, true, lookup(child, "events", 0, "attributes", 0, "value", "boolValue"))
+	assert.InDelta(t, 2, lookup(child, "status", "code"), 0, "error status is code 2")
+	assert.Equal(t, "unavailable", lookup(child, "status", "message"))
+	assert.IsType(t, "", lookup(child, "startTimeUnixNano"))
+
+	assert.Nil(t, lookup(c.requests[1], "resourceSpans", 0, "scopeSpans", 0, "spans", 0, "parentSpanId"), "root spans have no parent")
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+}
+
+func TestSpanExporter_Error(t *testing.T) {
+	_, endpoint := newCollector(t, http.StatusServiceUnavailable)
+
+	provider := sdktrace.NewTracerProvider()
+	_, span := provider.Tracer("test").Start(t.Context(), "span")
+	span.End()
+
+	readOnly, _ := span.(sdktrace.ReadOnlySpan)
+	err := NewSpanExporter(endpoint).ExportSpans(t.Context(), []sdktrace.ReadOnlySpan{readOnly})
+	require.ErrorIs(t, err, errExport)
+	assert.Contains(t, err.Error(), "503")
+
+	assert.NoError(t, NewSpanExporter(endpoint).ExportSpans(t.Context(), nil), "empty exports send nothing")
+}
+
+func TestLogExporter(t *testing.T) {
+	c, endpoint := newCollector(t, http.StatusOK)
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewSimpleProcessor(NewLogExporter(endpoint))),
+		sdklog.WithResource(resource.NewSchemaless(otelattribute.String("service.name", "edge"))),
+	)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	record := log.Record{}
+	record.SetTimestamp(time.Unix(1, 0))
+	record.SetSeverity(log.SeverityError)
+	record.SetSeverityText("ERROR")
+	record.SetBody(log.StringValue("payment failed"))
+	record.AddAttributes(
+		log.Int64("attempt", 3),
+		log.Map("order", log.String("id", "o-1")),
+	)
+	provider.Logger("github.com/myorg/edge").Emit(ctx, record)
+
+	require.Len(t, c.requests, 1)
+	assert.Equal(t, []string{"/v1/logs"}, c.paths)
+
+	logRecord := lookup(c.requests[0], "resourceLogs", 0, "scopeLogs", 0, "logRecords", 0)
+	assert.Equal(t, "github.com/myorg/edge", lookup(c.requests[0], "resourceLogs", 0, "scopeLogs", 0, "scope", "name"))
+	assert.Equal(t, "1000000000", lookup(logRecord, "timeUnixNano"))
+	assert.InDelta(t, 17, lookup(logRecord, "severityNumber"), 0)
+	assert.Equal(t, "ERROR", lookup(logRecord, "severityText"))
+	assert.Equal(t, "payment failed", lookup(logRecord, "body", "stringValue"))
+	assert.Equal(t, "3", lookup(logRecord, "attributes", 0, "value", "intValue"))
+	assert.Equal(t, "o-1", lookup(logRecord, "attributes", 1, "value", "kvlistValue", "values", 0, "value", "stringValue"))
+	assert.Equal(t, spanContext.TraceID().String(), lookup(logRecord, "traceId"))
+	assert.Equal(t, spanContext.SpanID().String(), lookup(logRecord, "spanId"))
+	assert.InDelta(t, 1, lookup(logRecord, "flags"), 0)
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+}
+
+func TestMetricExporter(t *testing.T) {
+	c, endpoint := newCollector(t, http.StatusOK)
+
+	reader := sdkmetric.NewPeriodicReader(NewMetricExporter(endpoint, nil))
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	meter := provider.Meter("github.com/myorg/edge")
+
+	counter, err := meter.Int64Counter("requests", otelmetric.WithUnit("{request}"))
+	require.NoError(t, err)
+
+	histogram, err := meter.Float64Histogram("duration", otelmetric.WithExplicitBucketBoundaries(1, 10))
+	require.NoError(t, err)
+
+	counter.Add(t.Context(), 2, otelmetric.WithAttributes(otelattribute.String("route", "/")))
+	histogram.Record(t.Context(), 5)
+
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	require.Len(t, c.requests, 1)
+	assert.Equal(t, []string{"/v1/metrics"}, c.paths)
+
+	metrics := lookup(c.requests[0], "resourceMetrics", 0, "scopeMetrics", 0, "metrics")
+	assert.Equal(t, "github.com/myorg/edge", lookup(c.requests[0], "resourceMetrics", 0, "scopeMetrics", 0, "scope", "name"))
+
+	assert.Equal(t, "requests", lookup(metrics, 0, "name"))
+	assert.Equal(t, "{request}", lookup(metrics, 0, "unit"))
+	assert.Equal(t, "2", lookup(metrics, 0, "sum", "dataPoints", 0, "asInt"))
+	assert.Equal(t, "/", lookup(metrics, 0, "sum", "dataPoints", 0, "attributes", 0, "value", "stringValue"))
+	assert.Equal(t, true, lookup(metrics, 0, "sum", "isMonotonic"))
+	assert.InDelta(t, 2, lookup(metrics, 0, "sum", "aggregationTemporality"), 0, "cumulative is 2")
+
+	assert.Equal(t, "duration", lookup(metrics, 1, "name"))
+	assert.Equal(t, "1", lookup(metrics, 1, "histogram", "dataPoints", 0, "count"))
+	assert.Equal(t, []any{"0", "1", "0"}, lookup(metrics, 1, "histogram", "dataPoints", 0, "bucketCounts"))
+	assert.Equal(t, []any{1.0, 10.0}, lookup(metrics, 1, "histogram", "dataPoints", 0, "explicitBounds"))
+	assert.InDelta(t, 5, lookup(metrics, 1, "histogram", "dataPoints", 0, "min"), 0)
+
+	exporter := NewMetricExporter(endpoint, func(sdkmetric.InstrumentKind) metricdata.Temporality { return metricdata.DeltaTemporality })
+	assert.Equal(t, metricdata.DeltaTemporality, exporter.Temporality(sdkmetric.InstrumentKindCounter))
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+}
+
+func TestEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4318")
+	assert.Equal(t, "https://collector:4318", Endpoint())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	assert.Equal(t, "http://collector:4318", Endpoint())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://collector.example.com")
+	assert.Equal(t, "https://collector.example.com", Endpoint())
+
+	assert.Equal(t, "https://collector.example.com/v1/traces", newClient("https://collector.example.com/", "/v1/traces").url)
+}
+
+// readLines decodes each line of a JSON lines file
+func readLines(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := []map[string]any{}
+
+	for line := range strings.Lines(string(data)) {
+		var request map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &request))
+
+		lines = append(lines, request)
+	}
+
+	return lines
+}
+
+func TestFileExporters(t *testing.T) {
+	dir := t.TempDir()
+
+	spanExporter, err := NewFileSpanExporter(filepath.Join(dir, "traces.jsonl"))
+	require.NoError(t, err)
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter))
+	for _, name := range []string{"first", "second"} {
+		_, span := tracerProvider.Tracer("test").Start(t.Context(), name)
+		span.End()
+	}
+
+	require.NoError(t, tracerProvider.Shutdown(t.Context()))
+
+	spans := readLines(t, filepath.Join(dir, "traces.jsonl"))
+	require.Len(t, spans, 2, "each request is a line")
+	assert.Equal(t, "second", lookup(spans[1], "resourceSpans", 0, "scopeSpans", 0, "spans", 0, "name"))
+
+	logExporter, err := NewFileLogExporter(filepath.Join(dir, "logs.jsonl"))
+	require.NoError(t, err)
+
+	loggerProvider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(logExporter)))
+	record := log.Record{}
+	record.SetBody(log.StringValue("started"))
+	loggerProvider.Logger("test").Emit(t.Context(), record)
+	require.NoError(t, loggerProvider.Shutdown(t.Context()))
+
+	logs := readLines(t, filepath.Join(dir, "logs.jsonl"))
+	require.Len(t, logs, 1)
+	assert.Equal(t, "started", lookup(logs[0], "resourceLogs", 0, "scopeLogs", 0, "logRecords", 0, "body", "stringValue"))
+
+	metricExporter, err := NewFileMetricExporter(filepath.Join(dir, "metrics.jsonl"), nil)
+	require.NoError(t, err)
+
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	counter, err := meterProvider.Meter("test").Int64Counter("jobs")
+	require.NoError(t, err)
+	counter.Add(t.Context(), 1)
+	require.NoError(t, meterProvider.Shutdown(t.Context()))
+
+	metrics := readLines(t, filepath.Join(dir, "metrics.jsonl"))
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "jobs", lookup(metrics[0], "resourceMetrics", 0, "scopeMetrics", 0, "metrics", 0, "name"))
+
+	assert.Error(t, metricExporter.Export(t.Context(), &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{Metrics: []metricdata.Metrics{{Name: "late", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}}}}}}},
+	}), "Shutdown closes the file")
+
+	_, err = NewFileSpanExporter(filepath.Join(dir, "missing", "traces.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestFilePath(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_FILE_PATH", "/var/log/otel")
+	assert.Equal(t, "/var/log/otel/logs.jsonl", FilePath("logs"))
+
+	export.SetFileExport("/data/otel")
+	t.Cleanup(func() { export.SetFileExport("") })
+
+	assert.Equal(t, "/data/otel/logs.jsonl", FilePath("logs"), "SetFileExport overrides the environment")
+}
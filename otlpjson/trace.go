@@ -0,0 +1,168 @@
+//go:build !gotel_disabled
+
+package otlpjson
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type tracesRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   otlpResource `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type span struct {
+	TraceID                string     `json:"traceId"`
+	SpanID                 string     `json:"spanId"`
+	TraceState             string     `json:"traceState,omitempty"`
+	ParentSpanID           string     `json:"parentSpanId,omitempty"`
+	Name                   string     `json:"name"`
+	Kind                   int        `json:"kind"`
+	StartTimeUnixNano      string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano        string     `json:"endTimeUnixNano"`
+	Attributes             []keyValue `json:"attributes,omitempty"`
+	DroppedAttributesCount int        `json:"droppedAttributesCount,omitempty"`
+	Events                 []event    `json:"events,omitempty"`
+	Links                  []link     `json:"links,omitempty"`
+	Status                 status     `json:"status"`
+}
+
+type event struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+}
+
+type link struct {
+	TraceID    string     `json:"traceId"`
+	SpanID     string     `json:"spanId"`
+	TraceState string     `json:"traceState,omitempty"`
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type status struct {
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code"`
+}
+
+// OTLP status codes, which are ordered differently from codes.Code.
+const (
+	statusCodeOk    = 1
+	statusCodeError = 2
+)
+
+// SpanExporter exports spans to the /v1/traces path of an OTLP/HTTP collector as JSON, or to a file as JSON lines.
+type SpanExporter struct {
+	sender sender
+}
+
+var _ sdktrace.SpanExporter = (*SpanExporter)(nil)
+
+// NewSpanExporter returns an exporter to the collector at endpoint, e.g. "http://localhost:4318".
+func NewSpanExporter(endpoint string) *SpanExporter {
+	return &SpanExporter{sender: newClient(endpoint, "/v1/traces")}
+}
+
+// ExportSpans sends spans to the collector in a single request.
+func (e *SpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return e.sender.send(ctx, newTracesRequest(spans))
+}
+
+// Shutdown closes the file of a file exporter. Requests are not buffered.
+func (e *SpanExporter) Shutdown(context.Context) error {
+	return e.sender.close()
+}
+
+func newTracesRequest(spans []sdktrace.ReadOnlySpan) tracesRequest {
+	request := tracesRequest{}
+	resourceIndex := map[*resource.Resource]int{}
+	scopeIndex := map[*resource.Resource]map[instrumentation.Scope]int{}
+
+	for _, s := range spans {
+		res := s.Resource()
+
+		i, ok := resourceIndex[res]
+		if !ok {
+			i = len(request.ResourceSpans)
+			resourceIndex[res] = i
+			scopeIndex[res] = map[instrumentation.Scope]int{}
+			request.ResourceSpans = append(request.ResourceSpans, resourceSpans{Resource: newResource(res)})
+		}
+
+		rs := &request.ResourceSpans[i]
+
+		j, ok := scopeIndex[res][s.InstrumentationScope()]
+		if !ok {
+			j = len(rs.ScopeSpans)
+			scopeIndex[res][s.InstrumentationScope()] = j
+			rs.ScopeSpans = append(rs.ScopeSpans, scopeSpans{Scope: newScope(s.InstrumentationScope())})
+		}
+
+		rs.ScopeSpans[j].Spans = append(rs.ScopeSpans[j].Spans, newSpan(s))
+	}
+
+	return request
+}
+
+func newSpan(s sdktrace.ReadOnlySpan) span {
+	sc := s.SpanContext()
+	traceID, spanID := sc.TraceID(), sc.SpanID()
+	parentSpanID := s.Parent().SpanID()
+
+	result := span{
+		TraceID:                hexID(traceID[:], sc.HasTraceID()),
+		SpanID:                 hexID(spanID[:], sc.HasSpanID()),
+		TraceState:             sc.TraceState().String(),
+		ParentSpanID:           hexID(parentSpanID[:], s.Parent().HasSpanID()),
+		Name:                   s.Name(),
+		Kind:                   int(s.SpanKind()),
+		StartTimeUnixNano:      unixNano(s.StartTime()),
+		EndTimeUnixNano:        unixNano(s.EndTime()),
+		Attributes:             attributes(s.Attributes()),
+		DroppedAttributesCount: s.DroppedAttributes(),
+		Status:                 status{Message: s.Status().Description},
+	}
+
+	switch s.Status().Code {
+	case codes.Ok:
+		result.Status.Code = statusCodeOk
+	case codes.Error:
+		result.Status.Code = statusCodeError
+	case codes.Unset:
+	}
+
+	for _, e := range s.Events() {
+		result.Events = append(result.Events, event{TimeUnixNano: unixNano(e.Time), Name: e.Name, Attributes: attributes(e.Attributes)})
+	}
+
+	for _, l := range s.Links() {
+		linkTraceID, linkSpanID := l.SpanContext.TraceID(), l.SpanContext.SpanID()
+
+		result.Links = append(result.Links, link{
+			TraceID:    hexID(linkTraceID[:], l.SpanContext.HasTraceID()),
+			SpanID:     hexID(linkSpanID[:], l.SpanContext.HasSpanID()),
+			TraceState: l.SpanContext.TraceState().String(),
+			Attributes: attributes(l.Attributes),
+		})
+	}
+
+	return result
+}
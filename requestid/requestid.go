@@ -0,0 +1,64 @@
+// Package requestid stores a request ID in the context so it can be included in every log record and span.
+// The ID is kept in OpenTelemetry baggage, where other baggage-aware components can also read it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const (
+	// Key is the baggage member, log attribute, and span attribute that hold the request ID.
+	Key = "request.id"
+	// Header is the HTTP header that carries the request ID.
+	Header = "X-Request-Id"
+
+	maxLength = 128
+)
+
+// New generates a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether an incoming request ID is safe to accept: non-empty, at most 128 characters,
+// and made of printable ASCII.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	member, err := baggage.NewMemberRaw(Key, id)
+	if err != nil {
+		return ctx
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// FromContext returns the request ID carried by ctx, or an empty string if there isn't one.
+func FromContext(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(Key).Value()
+}
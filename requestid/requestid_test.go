@@ -0,0 +1,43 @@
+package requestid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContext(t *testing.T) {
+	ctx := NewContext(t.Context(), "req-123")
+
+	assert.Equal(t, "req-123", FromContext(ctx))
+	assert.Empty(t, FromContext(t.Context()))
+}
+
+func TestNew(t *testing.T) {
+	first, second := New(), New()
+
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+	assert.True(t, Valid(first))
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected bool
+	}{
+		{"UUID", "3f1c9a52-7d1e-4b8e-9b7a-2f6c1d0e8a44", true},
+		{"Empty", "", false},
+		{"Too long", strings.Repeat("a", 129), false},
+		{"Control characters", "abc\ndef", false},
+		{"Spaces", "abc def", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Valid(tt.id))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package gotel
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+)
+
+// DataResidencyKey is the resource attribute WithDataResidency sets on all exported telemetry.
+const DataResidencyKey = "data.residency"
+
+var errDataResidency = errors.New("invalid data residency configuration")
+
+type residency struct {
+	region    string
+	endpoints map[string]string
+}
+
+// WithDataResidency exports all telemetry to the OTLP endpoint of region, e.g. keeping EU traffic on an EU collector,
+// and sets the data.residency resource attribute to region on every span, metric, and log record. The endpoint is
+// passed to the OTLP exporters with export.SetEndpoint; the environment is left unchanged. Init fails if region has
+// no endpoint, if any endpoint isn't an absolute URL, if OTEL_EXPORTER_OTLP_ENDPOINT, a per-signal endpoint, or the
+// endpoint of an export route is set to another scheme or host, or if WithMetricExporter, WithSpanExporter, or
+// WithLogExporter adds an exporter, whose destination gotel can't check, so a misconfigured deployment can't export
+// outside its region.
+//
+//	gotel.WithDataResidency(os.Getenv("REGION"), map[string]string{
+//		"eu": "https://otel.eu.example.com:4318",
+//		"us": "https://otel.us.example.com:4318",
+//	})
+func WithDataResidency(region string, endpoints map[string]string) Option {
+	return func(c *config) {
+		c.residency = &residency{region: region, endpoints: endpoints}
+	}
+}
+
+// sameOrigin reports whether endpoint has the scheme and host of u, ignoring the path, e.g. /v1/traces of a
+// per-signal endpoint.
+func sameOrigin(endpoint string, u *url.URL) bool {
+	other, err := url.Parse(endpoint)
+
+	return err == nil && strings.EqualFold(other.Scheme, u.Scheme) && strings.EqualFold(other.Host, u.Host)
+}
+
+// apply validates the configuration and points OTLP export at the region's endpoint, returning resourceAttrs with
+// the region added. exporters is the number of exporters added by WithMetricExporter, WithSpanExporter, and
+// WithLogExporter, and routes are the endpoints of the export routes.
+func (r *residency) apply(resourceAttrs []attribute.Attr, exporters int, routes []string) ([]attribute.Attr, error) {
+	for region, endpoint := range r.endpoints {
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w: endpoint %q of region %q is not an absolute URL", errDataResidency, endpoint, region)
+		}
+	}
+
+	endpoint, ok := r.endpoints[r.region]
+	if !ok {
+		return nil, fmt.Errorf("%w: no endpoint for region %q", errDataResidency, r.region)
+	}
+
+	if exporters > 0 {
+		return nil, fmt.Errorf("%w: exporters added with WithMetricExporter, WithSpanExporter, or WithLogExporter may export outside region %q", errDataResidency, r.region)
+	}
+
+	u, _ := url.Parse(endpoint)
+
+	for _, key := range []string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT",
+	} {
+		if value := os.Getenv(key); value != "" && !sameOrigin(value, u) {
+			return nil, fmt.Errorf("%w: %s is %q, not the endpoint of region %q", errDataResidency, key, value, r.region)
+		}
+	}
+
+	for _, route := range routes {
+		if !sameOrigin(route, u) {
+			return nil, fmt.Errorf("%w: export route endpoint %q is not the endpoint of region %q", errDataResidency, route, r.region)
+		}
+	}
+
+	export.SetEndpoint(endpoint)
+
+	return append(slices.Clip(resourceAttrs), attribute.New(DataResidencyKey, r.region)), nil
+}
@@ -0,0 +1,143 @@
+//go:build !gotel_disabled
+
+package gotel
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/tracing"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// WithExemplarFilter sets which measurements are sampled as exemplars, linking histogram and counter data points
+// to the trace and span they were recorded in. The default, exemplar.TraceBasedFilter, samples measurements
+// recorded in the context of a sampled span; OTEL_METRICS_EXEMPLAR_FILTER sets it from the environment.
+func WithExemplarFilter(filter exemplar.Filter) Option {
+	return func(c *config) {
+		c.metricOptions = append(c.metricOptions, sdkmetric.WithExemplarFilter(filter))
+	}
+}
+
+// WithViews registers metric views, such as those built by metrics.RenameView, metrics.DropAttributesView,
+// and metrics.AggregationView, with the meter provider.
+func WithViews(views ...sdkmetric.View) Option {
+	return func(c *config) {
+		c.metricOptions = append(c.metricOptions, metrics.WithViews(views...))
+	}
+}
+
+// exporterConfig is the part of config that only applies to the exporters of the SDK providers.
+type exporterConfig struct {
+	spanExporters []sdktrace.SpanExporter
+	logExporters  []sdklog.Exporter
+	routes        []export.EndpointRoute
+	routesSet     bool
+}
+
+// load sets the routes from GOTEL_EXPORT_ROUTES, unless WithExportRoutes set them.
+func (c *exporterConfig) load() error {
+	if c.routesSet {
+		return nil
+	}
+
+	routes, err := export.ParseEndpointRoutes(os.Getenv("GOTEL_EXPORT_ROUTES"))
+	if err != nil {
+		return fmt.Errorf("GOTEL_EXPORT_ROUTES: %w", err)
+	}
+
+	c.routes = routes
+
+	return nil
+}
+
+// custom returns the number of exporters added by WithSpanExporter and WithLogExporter.
+func (c *exporterConfig) custom() int {
+	return len(c.spanExporters) + len(c.logExporters)
+}
+
+// endpoints returns the endpoints of the routes.
+func (c *exporterConfig) endpoints() []string {
+	endpoints := make([]string, 0, len(c.routes))
+	for _, route := range c.routes {
+		endpoints = append(endpoints, route.Endpoint)
+	}
+
+	return endpoints
+}
+
+// install passes the exporters and routes to the tracing, log, and export packages before the providers are created.
+func (c *exporterConfig) install() {
+	tracing.SetExporters(c.spanExporters...)
+	log.SetExporters(c.logExporters...)
+
+	if len(c.routes) > 0 {
+		export.SetEndpointRoutes(c.routes...)
+	}
+}
+
+// WithMetricExporter exports metrics to exporter as well as to the OTLP endpoint, if one is configured.
+// See metrics.WithExporter. WithDataResidency rejects it, as gotel can't check where exporter sends metrics.
+func WithMetricExporter(exporter sdkmetric.Exporter) Option {
+	return func(c *config) {
+		c.metricExporters++
+		c.metricOptions = append(c.metricOptions, metrics.WithExporter(exporter))
+	}
+}
+
+// WithSpanExporter exports spans to exporter, such as a vendor exporter or one built by export.RouteSpans, as well
+// as to the OTLP endpoint, if one is configured. See tracing.SetExporters. WithDataResidency rejects it, as gotel
+// can't check where exporter sends spans.
+func WithSpanExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *config) {
+		c.exporters.spanExporters = append(c.exporters.spanExporters, exporter)
+	}
+}
+
+// WithLogExporter exports log records to exporter, such as a vendor exporter or one built by export.RouteLogs, as
+// well as to the OTLP endpoint, if one is configured. See log.SetExporters. WithDataResidency rejects it, as gotel
+// can't check where exporter sends log records.
+func WithLogExporter(exporter sdklog.Exporter) Option {
+	return func(c *config) {
+		c.exporters.logExporters = append(c.exporters.logExporters, exporter)
+	}
+}
+
+// WithExportRoutes sends the spans, metric data points, and log records selected by each route to its OTLP endpoint
+// rather than the configured one, e.g. to keep the telemetry of internal tenants in a separate backend, in place of
+// GOTEL_EXPORT_ROUTES. See export.SetEndpointRoutes and export.ParseEndpointRoutes.
+//
+//	gotel.WithExportRoutes(export.EndpointRoute{
+//		Match:    export.Match{Key: "tenant", Values: []string{"internal"}},
+//		Endpoint: "https://otel.internal.example.com:4318",
+//	})
+func WithExportRoutes(routes ...export.EndpointRoute) Option {
+	return func(c *config) {
+		c.exporters.routes = routes
+		c.exporters.routesSet = true
+	}
+}
+
+// WithAdaptiveSampling samples root spans of each name at the rate that keeps the name within spansPerMinute,
+// while still exporting spans that end with an error or are slow. See tracing.AdaptiveSampler and
+// tracing.SetKeepErrorsAndSlow.
+func WithAdaptiveSampling(spansPerMinute float64) Option {
+	return func(c *config) {
+		c.keepErrorsSlow = true
+		c.tracerOptions = append(c.tracerOptions, sdktrace.WithSampler(tracing.OverrideSampler(tracing.AdaptiveSampler(spansPerMinute))))
+	}
+}
+
+// WithSpanMetrics derives request counts and latency histograms by span name, kind, and status from ended spans,
+// recorded on the same meter provider as the application's metrics. See tracing.SpanMetricsProcessor.
+func WithSpanMetrics() Option {
+	return func(c *config) {
+		c.tracerOptions = append(c.tracerOptions, sdktrace.WithSpanProcessor(tracing.SpanMetricsProcessor()))
+	}
+}
@@ -0,0 +1,21 @@
+//go:build gotel_disabled
+
+package gotel
+
+// exporterConfig is the part of config that only applies to the exporters of the SDK providers, of which there are
+// none.
+type exporterConfig struct{}
+
+func (*exporterConfig) load() error {
+	return nil
+}
+
+func (*exporterConfig) custom() int {
+	return 0
+}
+
+func (*exporterConfig) endpoints() []string {
+	return nil
+}
+
+func (*exporterConfig) install() {}
@@ -0,0 +1,45 @@
+package gotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/log"
+)
+
+// logShutdownReport summarizes the export totals of the session, so operators reviewing the logs of an instance can
+// see whether its telemetry was healthy.
+func logShutdownReport(ctx context.Context, session time.Duration, flush time.Duration, shutdownErr error) {
+	totals := export.Totals()
+
+	attrs := []attribute.Attr{
+		attribute.New("session_duration_ms", session.Milliseconds()),
+		attribute.New("flush_duration_ms", flush.Milliseconds()),
+	}
+
+	failures := int64(0)
+
+	for _, signal := range []export.Signal{export.SignalTraces, export.SignalMetrics, export.SignalLogs} {
+		stats := totals[signal]
+		failures += stats.Errors
+
+		attrs = append(attrs,
+			attribute.New(string(signal)+".exported", stats.Exported),
+			attribute.New(string(signal)+".dropped", stats.Failed+stats.QueueDropped),
+			attribute.New(string(signal)+".export_errors", stats.Errors),
+		)
+	}
+
+	if shutdownErr != nil {
+		attrs = append(attrs, attribute.New("shutdown_error", shutdownErr.Error()))
+	}
+
+	if failures > 0 || shutdownErr != nil {
+		log.Warn(ctx, "telemetry session summary", attrs...)
+		return
+	}
+
+	log.Info(ctx, "telemetry session summary", attrs...)
+}
@@ -0,0 +1,105 @@
+package syncx
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+type queued[T any] struct {
+	value    T
+	enqueued time.Time
+}
+
+// Queue is a buffered channel for internal pipelines that records the queue_depth up/down counter, the
+// queue_enqueued and queue_dequeued counters, and the queue_time histogram, the time items wait in the queue,
+// with a queue.name attribute.
+type Queue[T any] struct {
+	name  attribute.Attr
+	items chan queued[T]
+}
+
+// NewQueue returns a queue named name that buffers up to size items.
+func NewQueue[T any](name string, size int) *Queue[T] {
+	return &Queue[T]{name: attribute.New("queue.name", name), items: make(chan queued[T], size)}
+}
+
+func (q *Queue[T]) enqueued(ctx context.Context) {
+	m := getMetrics()
+	m.QueueDepth.Inc(ctx, q.name)
+	m.QueueEnqueued.Inc(ctx, q.name)
+}
+
+func (q *Queue[T]) dequeued(ctx context.Context, item queued[T]) T {
+	m := getMetrics()
+	m.QueueDepth.Dec(ctx, q.name)
+	m.QueueDequeued.Inc(ctx, q.name)
+	m.QueueTime.Record(ctx, time.Since(item.enqueued).Seconds(), q.name)
+
+	return item.value
+}
+
+// Send adds v to the queue, waiting for space until ctx is done.
+// Like sending on a channel, it panics if the queue is closed.
+func (q *Queue[T]) Send(ctx context.Context, v T) error {
+	select {
+	case q.items <- queued[T]{value: v, enqueued: time.Now()}:
+		q.enqueued(ctx)
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend adds v to the queue without waiting and reports whether there was space.
+func (q *Queue[T]) TrySend(v T) bool {
+	select {
+	case q.items <- queued[T]{value: v, enqueued: time.Now()}:
+		q.enqueued(context.Background())
+
+		return true
+	default:
+		return false
+	}
+}
+
+// Receive removes the oldest item from the queue, waiting for one until ctx is done. It returns false once the
+// queue is closed and empty.
+func (q *Queue[T]) Receive(ctx context.Context) (T, bool, error) {
+	select {
+	case item, ok := <-q.items:
+		if !ok {
+			return item.value, false, nil
+		}
+
+		return q.dequeued(ctx, item), true, nil
+	case <-ctx.Done():
+		var zero T
+
+		return zero, false, ctx.Err()
+	}
+}
+
+// All returns an iterator over the items of the queue, which waits for items until the queue is closed.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for item := range q.items {
+			if !yield(q.dequeued(context.Background(), item)) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue[T]) Len() int {
+	return len(q.items)
+}
+
+// Close closes the queue, so receivers stop once it's empty.
+func (q *Queue[T]) Close() {
+	close(q.items)
+}
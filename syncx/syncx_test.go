@@ -0,0 +1,192 @@
+//go:build !gotel_disabled
+
+package syncx
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/metrics"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var reader = sdkmetric.NewManualReader()
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// lockHistogram returns the data point of a lock's histogram
+func lockHistogram(t *testing.T, name string, lockName string) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+
+	return histogram(t, name, "lock.name", lockName)
+}
+
+// queueTime returns the data point of a queue's queue_time histogram
+func queueTime(t *testing.T, queueName string) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+
+	return histogram(t, "queue_time", "queue.name", queueName)
+}
+
+// histogram returns the data point of the histogram with the given attribute value
+func histogram(t *testing.T, name string, key string, value string) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || m.Name != name {
+				continue
+			}
+
+			for _, dp := range hist.DataPoints {
+				if attr, _ := dp.Attributes.Value(otelattribute.Key(key)); attr.AsString() == value {
+					return dp
+				}
+			}
+		}
+	}
+
+	require.Failf(t, "histogram not found", "%s for %s", name, value)
+
+	return metricdata.HistogramDataPoint[float64]{}
+}
+
+func TestMutex(t *testing.T) {
+	mu := Mutex{Name: "cache"}
+	counter := 0
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Go(func() {
+			mu.Lock()
+			defer mu.Unlock()
+
+			counter++
+
+			time.Sleep(time.Millisecond)
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 10, counter)
+
+	wait := lockHistogram(t, "sync_lock_wait_duration", "cache")
+	assert.Equal(t, uint64(10), wait.Count)
+	assert.Positive(t, wait.Sum, "contended locks wait")
+
+	hold := lockHistogram(t, "sync_lock_hold_duration", "cache")
+	assert.Equal(t, uint64(10), hold.Count)
+	assert.GreaterOrEqual(t, hold.Sum, 0.01)
+
+	lockType, _ := hold.Attributes.Value("lock.type")
+	assert.Equal(t, "mutex", lockType.AsString())
+
+	require.True(t, mu.TryLock())
+	assert.False(t, mu.TryLock())
+	mu.Unlock()
+}
+
+func TestSemaphore(t *testing.T) {
+	sem := NewSemaphore("uploads", 2)
+
+	release1, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+
+	release2, ok := sem.TryAcquire()
+	require.True(t, ok)
+
+	_, ok = sem.TryAcquire()
+	assert.False(t, ok, "no permits left")
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond)
+	defer cancel()
+
+	_, err = sem.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	release1()
+	release2()
+
+	release, err := sem.Acquire(t.Context())
+	require.NoError(t, err)
+	release()
+
+	assert.Equal(t, uint64(2), lockHistogram(t, "sync_lock_wait_duration", "uploads").Count)
+	assert.Equal(t, uint64(3), lockHistogram(t, "sync_lock_hold_duration", "uploads").Count, "releasing twice records once")
+}
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[int]("jobs", 2)
+
+	require.NoError(t, q.Send(t.Context(), 1))
+	assert.True(t, q.TrySend(2))
+	assert.False(t, q.TrySend(3), "the queue is full")
+	assert.Equal(t, 2, q.Len())
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, q.Send(ctx, 3), context.DeadlineExceeded)
+
+	v, ok, err := q.Receive(t.Context())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	q.Close()
+
+	values := []int{}
+	for v := range q.All() {
+		values = append(values, v)
+	}
+
+	assert.Equal(t, []int{2}, values)
+
+	_, ok, err = q.Receive(t.Context())
+	require.NoError(t, err)
+	assert.False(t, ok, "closed queues are empty")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	sums := map[string]int64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range sum.DataPoints {
+					if name, _ := dp.Attributes.Value("queue.name"); name.AsString() == "jobs" {
+						sums[m.Name] += dp.Value
+					}
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"queue_depth": 0, "queue_enqueued": 2, "queue_dequeued": 2}, sums)
+	assert.Equal(t, uint64(2), queueTime(t, "jobs").Count)
+}
@@ -0,0 +1,211 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AdaptiveRateKey is the attribute holding the rate at which the adaptive sampler sampled a root span, so backends
+// can extrapolate span counts.
+const AdaptiveRateKey = "sampler.adaptive.rate"
+
+// adaptiveKeptKey is the attribute recording why KeepErrorsAndSlow exported a span that was sampled out.
+const adaptiveKeptKey = "sampler.adaptive.kept"
+
+// maxAdaptiveNames bounds the span names AdaptiveSampler keeps rates and sampler_adaptive_rate series for.
+const maxAdaptiveNames = 1000
+
+type adaptiveRate struct {
+	rate        float64
+	count       int64
+	windowStart time.Time
+	lastUsed    time.Time
+
+	// registration is the rate's sampler_adaptive_rate callback, unregistered when the rate is evicted
+	registration metric.Registration
+	evicted      bool
+}
+
+type adaptiveSampler struct {
+	spansPerMinute float64
+	now            func() time.Time
+
+	mu    sync.Mutex
+	rates map[string]*adaptiveRate
+}
+
+// AdaptiveSampler returns a sampler that samples root spans of each name at the rate that keeps the name within
+// spansPerMinute, recomputed every minute from the number of spans started in the previous minute. Child spans
+// follow their parent. Spans that are sampled out are still recorded, so KeepErrorsAndSlow can export those that
+// end with an error status or exceed their SetSlowThresholds threshold; SetKeepErrorsAndSlow applies it to the OTLP
+// exporter of InitTracing. Rates are kept for the maxAdaptiveNames most recently started span names; a name forgotten
+// beyond that starts again at rate 1. The current rate of each span name is exported as the sampler_adaptive_rate
+// gauge with a span.name attribute:
+//
+//	tracing.SetKeepErrorsAndSlow(true)
+//	tracing.InitTracing(ctx, "myservice", resourceAttrs, sdktrace.WithSampler(tracing.AdaptiveSampler(600)))
+func AdaptiveSampler(spansPerMinute float64) sdktrace.Sampler {
+	return &adaptiveSampler{spansPerMinute: spansPerMinute, now: time.Now, rates: map[string]*adaptiveRate{}}
+}
+
+// evictOldest removes the least recently used rate, returning its callback registration if it has one yet.
+// s.mu must be held.
+func (s *adaptiveSampler) evictOldest() metric.Registration {
+	oldest := ""
+
+	for name, r := range s.rates {
+		if oldest == "" || r.lastUsed.Before(s.rates[oldest].lastUsed) {
+			oldest = name
+		}
+	}
+
+	r := s.rates[oldest]
+	r.evicted = true
+	delete(s.rates, oldest)
+
+	return r.registration
+}
+
+// rate counts a root span with the name and returns the sample rate of its name.
+func (s *adaptiveSampler) rate(name string) float64 {
+	s.mu.Lock()
+
+	now := s.now()
+
+	var evicted metric.Registration
+
+	r, ok := s.rates[name]
+	if !ok {
+		if len(s.rates) >= maxAdaptiveNames {
+			evicted = s.evictOldest()
+		}
+
+		r = &adaptiveRate{rate: 1, windowStart: now}
+		s.rates[name] = r
+	}
+
+	r.lastUsed = now
+
+	if elapsed := now.Sub(r.windowStart); elapsed >= time.Minute {
+		perMinute := float64(r.count) / elapsed.Minutes()
+		r.rate = min(1, s.spansPerMinute/perMinute)
+		r.count = 0
+		r.windowStart = now
+	}
+
+	r.count++
+	rate := r.rate
+
+	s.mu.Unlock()
+
+	// Register and unregister outside the lock, as the meter provider holds its own lock while calling the function
+	if evicted != nil {
+		_ = evicted.Unregister()
+	}
+
+	if !ok {
+		registration, err := getMetrics().SamplerAdaptiveRate.ObserveFunc(func(context.Context) (float64, []attribute.Attr) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			return r.rate, []attribute.Attr{attribute.New("span.name", name)}
+		})
+		if err == nil && registration != nil {
+			s.mu.Lock()
+			r.registration = registration
+			evictedMeanwhile := r.evicted
+			s.mu.Unlock()
+
+			if evictedMeanwhile {
+				_ = registration.Unregister()
+			}
+		}
+	}
+
+	return rate
+}
+
+func (s *adaptiveSampler) ShouldSample(parameters sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(parameters.ParentContext)
+	result := sdktrace.SamplingResult{Decision: sdktrace.RecordOnly, Tracestate: parent.TraceState()}
+
+	if parent.IsValid() {
+		if parent.IsSampled() {
+			result.Decision = sdktrace.RecordAndSample
+		}
+
+		return result
+	}
+
+	rate := s.rate(parameters.Name)
+	result.Attributes = append(result.Attributes, attribute.New(AdaptiveRateKey, rate).KeyValue)
+
+	// Compare the trace ID with the rate as TraceIDRatioBased does, so every service sampling the trace agrees
+	if binary.BigEndian.Uint64(parameters.TraceID[8:16])>>1 < uint64(rate*(1<<63)) {
+		result.Decision = sdktrace.RecordAndSample
+	}
+
+	return result
+}
+
+func (s *adaptiveSampler) Description() string {
+	return fmt.Sprintf("AdaptiveSampler{%g/min}", s.spansPerMinute)
+}
+
+type keepProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+// KeepErrorsAndSlow wraps a span processor, such as a batch processor, to also pass it spans that were recorded
+// but sampled out when they end with an error status or take longer than their SetSlowThresholds threshold.
+// The spans are passed on as sampled, with a sampler.adaptive.kept attribute of "error" or "slow".
+// Use it with AdaptiveSampler for processors created outside InitTracing, and SetKeepErrorsAndSlow for its own.
+func KeepErrorsAndSlow(next sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	return keepProcessor{SpanProcessor: next}
+}
+
+func (p keepProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.SpanProcessor.OnEnd(s)
+		return
+	}
+
+	if s.Status().Code == codes.Error {
+		p.SpanProcessor.OnEnd(keptSpan{ReadOnlySpan: s, reason: "error"})
+		return
+	}
+
+	if threshold, ok := slowThreshold(s.Name()); ok && s.EndTime().Sub(s.StartTime()) > threshold {
+		p.SpanProcessor.OnEnd(keptSpan{ReadOnlySpan: s, reason: "slow"})
+	}
+}
+
+// keptSpan is a sampled-out span exported by KeepErrorsAndSlow.
+type keptSpan struct {
+	sdktrace.ReadOnlySpan
+
+	reason string
+}
+
+func (s keptSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+func (s keptSpan) Attributes() []otelattribute.KeyValue {
+	return append(slices.Clip(s.ReadOnlySpan.Attributes()), attribute.New(adaptiveKeptKey, s.reason).KeyValue)
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"slices"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContinuationCountKey is the attribute counting how many times a resumable job has been resumed.
+const ContinuationCountKey = "job.continuation.count"
+
+// Continuation is the trace state to persist with the checkpoint of a resumable job, so a run resumed after a
+// restart can be linked to the trace the job started in.
+type Continuation struct {
+	// SpanContext is the MarshalSpanContext token of the job's original root span.
+	SpanContext string `json:"span_context"`
+	// Count is the number of times the job has been resumed.
+	Count int `json:"count"`
+}
+
+type continuationKey struct{}
+
+// NewContinuation returns the continuation to persist with a checkpoint taken in ctx. In a run started by
+// ResumeSpan it refers to the original root span and the resume count; otherwise it refers to the current span.
+func NewContinuation(ctx context.Context) Continuation {
+	if c, ok := ctx.Value(continuationKey{}).(Continuation); ok {
+		return c
+	}
+
+	return Continuation{SpanContext: MarshalSpanContext(ctx)}
+}
+
+// ResumeSpan starts the root span of a job resumed from a checkpoint, linked to the job's original root span and
+// with a job.continuation.count attribute, so every run of a job restarted many times is connected to its first.
+// A checkpoint without a valid span context returns an error along with an unlinked span, which later
+// continuations link to instead.
+func ResumeSpan(ctx context.Context, c Continuation, name string, attrs ...attribute.Attr) (context.Context, Span, error) {
+	resumed := Continuation{SpanContext: c.SpanContext, Count: c.Count + 1}
+	attrs = append(slices.Clip(attrs), attribute.New(ContinuationCountKey, resumed.Count))
+	options := []trace.SpanStartOption{trace.WithNewRoot()}
+
+	link, err := ParseSpanContext(c.SpanContext)
+	if err == nil {
+		options = append(options, trace.WithLinks(trace.Link{SpanContext: link}))
+	}
+
+	ctx, span := newSpanWithOptions(ctx, SpanKindInternal, name, attrs, options)
+	if err != nil {
+		resumed.SpanContext = MarshalSpanContext(ctx)
+	}
+
+	return context.WithValue(ctx, continuationKey{}, resumed), span, err
+}
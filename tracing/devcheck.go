@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+)
+
+var devChecks atomic.Bool
+
+// SetDevChecks enables checks for common instrumentation mistakes during development, each logged as a WARN with the
+// call sites involved: child spans ending after their parent, spans still open when the tracer provider shuts down,
+// and Span methods called after End, which OpenTelemetry silently ignores.
+// Only spans started while the checks are enabled are tracked. Don't enable them in production.
+func SetDevChecks(enabled bool) {
+	devChecks.Store(enabled)
+}
+
+// checkEnded logs a warning if SetDevChecks is enabled and the span has already ended, naming the method called.
+func (s *Span) checkEnded(method string) {
+	if !devChecks.Load() {
+		return
+	}
+
+	// Sampled out spans are not recording either, but only recorded spans have an end time
+	readOnly, ok := s.traceSpan.(interface {
+		Name() string
+		EndTime() time.Time
+	})
+	if !ok || readOnly.EndTime().IsZero() {
+		return
+	}
+
+	log.Warn(context.Background(), "span modified after End",
+		attribute.New("span.name", readOnly.Name()),
+		attribute.New("span.method", method),
+		attribute.New("span.called_at", callSite()),
+	)
+}
+
+// callSite returns the file and line of the first caller outside this package and the OpenTelemetry SDK.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		internal := strings.HasPrefix(frame.Function, "go.opentelemetry.io/") ||
+			(strings.HasPrefix(frame.Function, "github.com/tinybluerobots/gotel/tracing.") && !strings.HasSuffix(frame.File, "_test.go"))
+		if !internal {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// devSpan is a span tracked by devCheckProcessor.
+type devSpan struct {
+	name      string
+	parent    trace.SpanID
+	startSite string
+	endSite   string
+	ended     bool
+	children  int
+}
+
+// devCheckProcessor tracks the spans of the process while SetDevChecks is enabled. InitTracing registers it.
+type devCheckProcessor struct {
+	mu    sync.Mutex
+	spans map[trace.SpanID]*devSpan
+}
+
+func newDevCheckProcessor() *devCheckProcessor {
+	return &devCheckProcessor{spans: map[trace.SpanID]*devSpan{}}
+}
+
+func (p *devCheckProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if !devChecks.Load() {
+		return
+	}
+
+	span := &devSpan{name: s.Name(), startSite: callSite()}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Remote parents end in another process, so only parents started here are checked
+	if parentSpan, ok := p.spans[s.Parent().SpanID()]; ok && !s.Parent().IsRemote() {
+		span.parent = s.Parent().SpanID()
+		parentSpan.children++
+	}
+
+	p.spans[s.SpanContext().SpanID()] = span
+}
+
+func (p *devCheckProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mu.Lock()
+
+	span, ok := p.spans[s.SpanContext().SpanID()]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	span.ended = true
+	span.endSite = callSite()
+	p.release(s.SpanContext().SpanID(), span)
+
+	var endedParent *devSpan
+
+	if parent, ok := p.spans[span.parent]; ok && span.parent.IsValid() {
+		if parent.ended {
+			endedParent = parent
+		}
+
+		parent.children--
+		p.release(span.parent, parent)
+	}
+
+	p.mu.Unlock()
+
+	if endedParent != nil {
+		log.Warn(context.Background(), "span ended after its parent",
+			attribute.New("span.name", s.Name()),
+			attribute.New("span.started_at", span.startSite),
+			attribute.New("span.ended_at", span.endSite),
+			attribute.New("parent.name", endedParent.name),
+			attribute.New("parent.ended_at", endedParent.endSite),
+		)
+	}
+}
+
+// release stops tracking an ended span once none of its children are open.
+func (p *devCheckProcessor) release(id trace.SpanID, span *devSpan) {
+	if span.ended && span.children == 0 {
+		delete(p.spans, id)
+	}
+}
+
+// Shutdown reports the spans that were never ended.
+func (p *devCheckProcessor) Shutdown(context.Context) error {
+	p.mu.Lock()
+
+	open := []*devSpan{}
+
+	for id, span := range p.spans {
+		if !span.ended {
+			open = append(open, span)
+		}
+
+		delete(p.spans, id)
+	}
+
+	p.mu.Unlock()
+
+	for _, span := range open {
+		log.Warn(context.Background(), "span never ended",
+			attribute.New("span.name", span.name),
+			attribute.New("span.started_at", span.startSite),
+		)
+	}
+
+	return nil
+}
+
+func (p *devCheckProcessor) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build !gotel_disabled && !js && !wasip1 && !tinygo
+
+package tracing
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/tinybluerobots/gotel/export"
+	"github.com/tinybluerobots/gotel/otlpjson"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func newGrpcTraceExporter(ctx context.Context, insecure bool, endpoint string) (sdktrace.SpanExporter, error) {
+	options := []otlptracegrpc.Option{}
+
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlptracegrpc.WithEndpointURL(export.SignalURL(endpoint, export.SignalTraces)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalTraces); ok {
+		options = append(options, otlptracegrpc.WithEndpointURL(endpoint))
+	}
+
+	return otlptracegrpc.New(ctx, options...)
+}
+
+func newHttpTraceExporter(ctx context.Context, insecure bool, endpoint string) (sdktrace.SpanExporter, error) {
+	options := []otlptracehttp.Option{}
+
+	if insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	if endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(export.SignalURL(endpoint, export.SignalTraces)))
+	} else if endpoint, ok := export.SignalEndpoint(export.SignalTraces); ok {
+		options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+	}
+
+	return otlptracehttp.New(ctx, options...)
+}
+
+func newJSONTraceExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	return otlpjson.NewSpanExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+}
+
+func newFileTraceExporter() (sdktrace.SpanExporter, error) {
+	return otlpjson.NewFileSpanExporter(otlpjson.FilePath("traces"))
+}
@@ -0,0 +1,29 @@
+//go:build !gotel_disabled && (js || wasip1 || tinygo)
+
+package tracing
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/tinybluerobots/gotel/otlpjson"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// js/wasm, WASI, and TinyGo builds export OTLP/HTTP JSON whatever the protocol, as gRPC and protobuf are unavailable.
+
+func newGrpcTraceExporter(_ context.Context, _ bool, endpoint string) (sdktrace.SpanExporter, error) {
+	return otlpjson.NewSpanExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+}
+
+func newHttpTraceExporter(_ context.Context, _ bool, endpoint string) (sdktrace.SpanExporter, error) {
+	return otlpjson.NewSpanExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+}
+
+func newJSONTraceExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	return otlpjson.NewSpanExporter(cmp.Or(endpoint, otlpjson.Endpoint())), nil
+}
+
+func newFileTraceExporter() (sdktrace.SpanExporter, error) {
+	return otlpjson.NewFileSpanExporter(otlpjson.FilePath("traces"))
+}
@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// samplingOverrideKey holds whether spans created from the context are sampled, true, or dropped, false.
+type samplingOverrideKey struct{}
+
+// ForceSample returns a context in which spans are sampled regardless of the sampler's decision, e.g. to capture the
+// full trace of a request sent with a debug header. The sampled flag propagates to downstream services, so
+// parent-based samplers there keep the trace too. It only takes effect with an OverrideSampler, which InitTracing
+// installs by default unless OTEL_TRACES_SAMPLER is set.
+func ForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, samplingOverrideKey{}, true)
+}
+
+// ForceDrop returns a context in which spans are dropped regardless of the sampler's decision, e.g. for health checks.
+// Like ForceSample, it only takes effect with an OverrideSampler.
+func ForceDrop(ctx context.Context) context.Context {
+	return context.WithValue(ctx, samplingOverrideKey{}, false)
+}
+
+// SamplingCounts are the decisions made by OverrideSampler since the process started.
+type SamplingCounts struct {
+	// Sampled and Dropped count the decisions of the wrapped sampler; Dropped includes spans recorded but not sampled.
+	Sampled int64
+	Dropped int64
+	// ForcedSampled and ForcedDropped count spans created from ForceSample and ForceDrop contexts.
+	ForcedSampled int64
+	ForcedDropped int64
+}
+
+var sampled, dropped, forcedSampled, forcedDropped atomic.Int64
+
+// SamplingDecisions returns the decisions made by OverrideSampler, so operators can check the effective sampling rate.
+func SamplingDecisions() SamplingCounts {
+	return SamplingCounts{
+		Sampled:       sampled.Load(),
+		Dropped:       dropped.Load(),
+		ForcedSampled: forcedSampled.Load(),
+		ForcedDropped: forcedDropped.Load(),
+	}
+}
@@ -0,0 +1,55 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type overrideSampler struct {
+	next sdktrace.Sampler
+}
+
+// OverrideSampler returns a sampler that honors ForceSample and ForceDrop in the context spans are created from,
+// and otherwise defers to next. InitTracing wraps its default parent-based sampler in it; wrap samplers passed to
+// InitTracing to keep the overrides:
+//
+//	tracing.InitTracing(ctx, "myservice", resourceAttrs, sdktrace.WithSampler(
+//		tracing.OverrideSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1)))))
+func OverrideSampler(next sdktrace.Sampler) sdktrace.Sampler {
+	return overrideSampler{next: next}
+}
+
+func (s overrideSampler) ShouldSample(parameters sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	sample, ok := parameters.ParentContext.Value(samplingOverrideKey{}).(bool)
+	if !ok {
+		result := s.next.ShouldSample(parameters)
+		if result.Decision == sdktrace.RecordAndSample {
+			sampled.Add(1)
+		} else {
+			dropped.Add(1)
+		}
+
+		return result
+	}
+
+	decision := sdktrace.Drop
+	if sample {
+		decision = sdktrace.RecordAndSample
+		forcedSampled.Add(1)
+	} else {
+		forcedDropped.Add(1)
+	}
+
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(parameters.ParentContext).TraceState(),
+	}
+}
+
+func (s overrideSampler) Description() string {
+	return fmt.Sprintf("OverrideSampler{%s}", s.next.Description())
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"runtime/metrics"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+var resourceDeltas atomic.Bool
+
+// SetResourceDeltas enables the experimental recording of runtime resource use between span start and end.
+// Spans get runtime.alloc_bytes, runtime.alloc_objects, and runtime.cpu_seconds attributes.
+// The runtime reports these for the whole process, so concurrent work is included and the values are only a coarse
+// attribution; the CPU time is also only updated by the runtime at garbage collections.
+func SetResourceDeltas(enabled bool) {
+	resourceDeltas.Store(enabled)
+}
+
+type resourceUsage struct {
+	allocBytes   uint64
+	allocObjects uint64
+	cpuSeconds   float64
+}
+
+func readResourceUsage() *resourceUsage {
+	samples := []metrics.Sample{
+		{Name: "/gc/heap/allocs:bytes"},
+		{Name: "/gc/heap/allocs:objects"},
+		{Name: "/cpu/classes/total:cpu-seconds"},
+	}
+	metrics.Read(samples)
+
+	usage := &resourceUsage{}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		usage.allocBytes = samples[0].Value.Uint64()
+	}
+
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		usage.allocObjects = samples[1].Value.Uint64()
+	}
+
+	if samples[2].Value.Kind() == metrics.KindFloat64 {
+		usage.cpuSeconds = samples[2].Value.Float64()
+	}
+
+	return usage
+}
+
+func (s *Span) recordResourceDeltas() {
+	if s.resources == nil {
+		return
+	}
+
+	end := readResourceUsage()
+
+	s.traceSpan.SetAttributes(
+		attribute.New("runtime.alloc_bytes", int64(end.allocBytes-s.resources.allocBytes)).KeyValue,
+		attribute.New("runtime.alloc_objects", int64(end.allocObjects-s.resources.allocObjects)).KeyValue,
+		attribute.New("runtime.cpu_seconds", end.cpuSeconds-s.resources.cpuSeconds).KeyValue,
+	)
+}
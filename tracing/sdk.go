@@ -0,0 +1,103 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/export"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// Option configures the tracer provider created by InitTracing.
+type Option = sdktrace.TracerProviderOption
+
+var exporters atomic.Pointer[[]sdktrace.SpanExporter]
+
+// SetExporters exports spans to exporters, such as a vendor or test exporter, from the next InitTracing call, in
+// addition to the OTLP exporter created when OTEL_EXPORTER_OTLP_ENDPOINT is set. Each exporter has its own batch
+// processor. gotel.WithSpanExporter sets them from gotel.Init.
+func SetExporters(exporter ...sdktrace.SpanExporter) {
+	exporters.Store(&exporter)
+}
+
+// newBatchProcessor returns the batch processor of exporter, keeping errors and slow spans if SetKeepErrorsAndSlow
+// is enabled.
+func newBatchProcessor(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+	processor := export.NewBatchSpanProcessor(export.WrapSpanExporter(exporter))
+	if keepErrorsAndSlow.Load() {
+		processor = KeepErrorsAndSlow(processor)
+	}
+
+	return processor
+}
+
+// newTraceExporter returns the OTLP exporter of the configured protocol, exporting to endpoint, or to the configured
+// endpoint if it is empty.
+func newTraceExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
+
+	switch export.Protocol() {
+	case "http":
+		return newHttpTraceExporter(ctx, insecure, endpoint)
+	case "http/json":
+		return newJSONTraceExporter(endpoint)
+	case "file":
+		return newFileTraceExporter()
+	default:
+		return newGrpcTraceExporter(ctx, insecure, endpoint)
+	}
+}
+
+// InitTracing initializes the tracer with OTLP exporters, routed by the routes of export.SetEndpointRoutes, and the
+// exporters of SetExporters.
+// With OTEL_SDK_DISABLED=true, it calls InitPropagation instead, creating no provider or exporter.
+// Returns a shutdown function to flush and close the tracer provider.
+func InitTracing(ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, options ...Option) (func(context.Context) error, error) {
+	if export.SDKDisabled() {
+		InitPropagation()
+
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if export.Endpoint() != "" || export.Protocol() == "file" {
+		exporter, err := newTraceExporter(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+
+		newExporter := func(endpoint string) (sdktrace.SpanExporter, error) { return newTraceExporter(ctx, endpoint) }
+		if exporter, err = export.RouteEndpoints(ctx, exporter, newExporter, export.RouteSpans); err != nil {
+			_ = exporter.Shutdown(ctx)
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithSpanProcessor(newBatchProcessor(exporter)))
+	}
+
+	if e := exporters.Load(); e != nil {
+		for _, exporter := range *e {
+			options = append(options, sdktrace.WithSpanProcessor(newBatchProcessor(exporter)))
+		}
+	}
+
+	options = append(options, sdktrace.WithSpanProcessor(newDevCheckProcessor()))
+
+	// Options are applied in order, so a sampler passed by the caller replaces the default.
+	// The SDK reads OTEL_TRACES_SAMPLER itself when no sampler is passed.
+	if os.Getenv("OTEL_TRACES_SAMPLER") == "" {
+		options = append([]sdktrace.TracerProviderOption{sdktrace.WithSampler(OverrideSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())))}, options...)
+	}
+	options = append(options, sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
+	provider := sdktrace.NewTracerProvider(options...)
+	tracerProvider = provider
+	tracer = provider.Tracer(serviceName)
+	propagationOnly = false
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,23 @@
+//go:build gotel_disabled
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+// Option configures the tracer provider created by InitTracing. The gotel_disabled build leaves the OpenTelemetry
+// SDK and its options out, so there are none.
+type Option interface {
+	tracerOption()
+}
+
+// InitTracing calls InitPropagation, as the gotel_disabled build tag leaves the tracer provider and its exporters out
+// of the binary. The shutdown function does nothing.
+func InitTracing(context.Context, string, []attribute.Attr, ...Option) (func(context.Context) error, error) {
+	InitPropagation()
+
+	return func(context.Context) error { return nil }, nil
+}
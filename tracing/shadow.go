@@ -0,0 +1,51 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type shadowSampler struct {
+	active    sdktrace.Sampler
+	candidate sdktrace.Sampler
+}
+
+// ShadowSampler returns a sampler that makes decisions with active and also evaluates candidate, counting both
+// decisions for every span in the sampler_shadow_decisions counter with sampler.active and sampler.candidate
+// attributes of "keep" or "drop". The ratio of spans candidate would keep predicts the cost of switching to it
+// before changing the sampling policy; candidate never affects which spans are recorded.
+// A parent-based candidate sees the parent decisions made by active, so it predicts child spans less accurately:
+//
+//	tracing.InitTracing(ctx, "myservice", resourceAttrs, sdktrace.WithSampler(
+//		tracing.ShadowSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()), sdktrace.TraceIDRatioBased(0.1))))
+func ShadowSampler(active sdktrace.Sampler, candidate sdktrace.Sampler) sdktrace.Sampler {
+	return shadowSampler{active: active, candidate: candidate}
+}
+
+func samplingOutcome(result sdktrace.SamplingResult) string {
+	if result.Decision == sdktrace.RecordAndSample {
+		return "keep"
+	}
+
+	return "drop"
+}
+
+func (s shadowSampler) ShouldSample(parameters sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.active.ShouldSample(parameters)
+	candidateResult := s.candidate.ShouldSample(parameters)
+
+	getMetrics().SamplerShadowDecisions.Add(parameters.ParentContext, 1,
+		attribute.New("sampler.active", samplingOutcome(result)),
+		attribute.New("sampler.candidate", samplingOutcome(candidateResult)),
+	)
+
+	return result
+}
+
+func (s shadowSampler) Description() string {
+	return fmt.Sprintf("ShadowSampler{active:%s,candidate:%s}", s.active.Description(), s.candidate.Description())
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AllSpans is the SetSlowThresholds key for the threshold applied to spans without their own entry.
+const AllSpans = "*"
+
+type tracingMetrics struct {
+	SlowOperations         *metrics.Int64Counter
+	SamplerShadowDecisions *metrics.Int64Counter
+	SamplerAdaptiveRate    *metrics.Float64ObservableGauge
+	SpanCalls              *metrics.Int64Counter
+	SpanDuration           *metrics.Float64Histogram `unit:"s"`
+}
+
+var (
+	slowThresholds    atomic.Pointer[map[string]time.Duration]
+	keepErrorsAndSlow atomic.Bool
+	getMetrics        = metrics.Scoped[tracingMetrics]("github.com/tinybluerobots/gotel/tracing")
+)
+
+// SetKeepErrorsAndSlow makes InitTracing wrap the processor of its OTLP exporter with KeepErrorsAndSlow, so spans
+// sampled out by AdaptiveSampler are still exported when they end with an error or are slow. It is off by default,
+// as other samplers' recorded but unsampled spans would be exported too. Call it before InitTracing;
+// gotel.WithAdaptiveSampling enables it.
+func SetKeepErrorsAndSlow(enabled bool) {
+	keepErrorsAndSlow.Store(enabled)
+}
+
+// SetSlowThresholds flags spans that take longer than the threshold for their name, or the AllSpans threshold.
+// Slow spans get a slow=true attribute, a WARN log, and an increment of the slow_operations counter.
+// Passing nil removes the thresholds.
+func SetSlowThresholds(thresholds map[string]time.Duration) {
+	if len(thresholds) == 0 {
+		slowThresholds.Store(nil)
+		return
+	}
+
+	copied := make(map[string]time.Duration, len(thresholds))
+	for name, threshold := range thresholds {
+		copied[name] = threshold
+	}
+
+	slowThresholds.Store(&copied)
+}
+
+func slowThreshold(name string) (time.Duration, bool) {
+	thresholds := slowThresholds.Load()
+	if thresholds == nil {
+		return 0, false
+	}
+
+	if threshold, ok := (*thresholds)[name]; ok {
+		return threshold, true
+	}
+
+	threshold, ok := (*thresholds)[AllSpans]
+
+	return threshold, ok
+}
+
+func (s *Span) flagSlow() {
+	if s.start.IsZero() {
+		return
+	}
+
+	threshold, ok := slowThreshold(s.name)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(s.start)
+	if duration <= threshold {
+		return
+	}
+
+	s.traceSpan.SetAttributes(attribute.New("slow", true).KeyValue)
+
+	ctx := trace.ContextWithSpan(context.Background(), s.traceSpan)
+	getMetrics().SlowOperations.Add(ctx, 1, attribute.New("span.name", s.name))
+	log.Warn(ctx, "slow operation",
+		attribute.New("span.name", s.name),
+		attribute.New("duration_ms", duration.Milliseconds()),
+		attribute.New("threshold_ms", threshold.Milliseconds()),
+	)
+}
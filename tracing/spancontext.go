@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errInvalidSpanContext = errors.New("invalid span context")
+
+// MarshalSpanContext returns the current span context as a single token, the W3C traceparent value, to print in
+// error messages or support tickets. Returns an empty string if ctx holds no valid span.
+func MarshalSpanContext(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
+// ParseSpanContext parses a token from MarshalSpanContext back into a span context, e.g. to link follow-up work
+// with NewLinkedSpan.
+func ParseSpanContext(token string) (trace.SpanContext, error) {
+	carrier := propagation.MapCarrier{"traceparent": strings.TrimSpace(token)}
+	spanContext := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+
+	if !spanContext.IsValid() {
+		return trace.SpanContext{}, fmt.Errorf("%w: %q", errInvalidSpanContext, token)
+	}
+
+	return spanContext, nil
+}
+
+// NewLinkedSpan creates a span linked to another span context, such as one parsed from a support ticket, so the
+// follow-up work can be navigated to from the original trace without becoming part of it.
+func NewLinkedSpan(ctx context.Context, link trace.SpanContext, name string, attrs ...attribute.Attr) (context.Context, Span) {
+	return newSpanWithOptions(ctx, SpanKindInternal, name, attrs, []trace.SpanStartOption{trace.WithLinks(trace.Link{SpanContext: link})})
+}
+
+// NewSpanWithLinks creates a span of the given kind linked to each valid span context in links, such as the
+// producers of a batch of messages processed together.
+func NewSpanWithLinks(ctx context.Context, kind SpanKind, name string, links []trace.SpanContext, attrs ...attribute.Attr) (context.Context, Span) {
+	traceLinks := make([]trace.Link, 0, len(links))
+
+	for _, link := range links {
+		if link.IsValid() {
+			traceLinks = append(traceLinks, trace.Link{SpanContext: link})
+		}
+	}
+
+	return newSpanWithOptions(ctx, kind, name, attrs, []trace.SpanStartOption{trace.WithLinks(traceLinks...)})
+}
@@ -0,0 +1,47 @@
+//go:build !gotel_disabled
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanMetricsProcessor struct{}
+
+// SpanMetricsProcessor returns a span processor that derives RED metrics from ended spans: the span_calls counter and
+// the span_duration histogram in seconds, with span.name, span.kind, and status.code attributes, recorded on the
+// provider created by metrics.InitMetrics. Services that only instrument traces get request rates, error rates, and
+// latencies without instrumenting metrics, and durations keep exemplars of their spans.
+// Only recorded spans are counted, so a sampler that drops spans undercounts; AdaptiveSampler records every span.
+// Span names become attribute values, so they must have bounded cardinality:
+//
+//	tracing.InitTracing(ctx, "myservice", resourceAttrs, sdktrace.WithSpanProcessor(tracing.SpanMetricsProcessor()))
+func SpanMetricsProcessor() sdktrace.SpanProcessor {
+	return spanMetricsProcessor{}
+}
+
+func (spanMetricsProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (spanMetricsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	ctx := trace.ContextWithSpanContext(context.Background(), s.SpanContext())
+	attrs := []attribute.Attr{
+		attribute.New("span.name", s.Name()),
+		attribute.New("span.kind", s.SpanKind().String()),
+		attribute.New("status.code", s.Status().Code.String()),
+	}
+
+	getMetrics().SpanCalls.Add(ctx, 1, attrs...)
+	getMetrics().SpanDuration.Record(ctx, s.EndTime().Sub(s.StartTime()).Seconds(), attrs...)
+}
+
+func (spanMetricsProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (spanMetricsProcessor) ForceFlush(context.Context) error {
+	return nil
+}
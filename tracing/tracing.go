@@ -4,18 +4,17 @@ package tracing
 
 import (
 	"context"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/identity"
+	"github.com/tinybluerobots/gotel/implicit"
+	"github.com/tinybluerobots/gotel/requestid"
 	"go.opentelemetry.io/otel"
 	otelattribute "go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
@@ -33,113 +32,161 @@ const (
 	StatusOk StatusCode = StatusCode(codes.Ok)
 )
 
+func toKeyValues(attrs []attribute.Attr) []otelattribute.KeyValue {
+	return attribute.ToKeyValues(attribute.ApplyHashing(attrs))
+}
+
+// SpanKind describes the relationship of a span to its parent and children.
+type SpanKind trace.SpanKind
+
+const (
+	// SpanKindInternal is the default kind, for operations internal to the service.
+	SpanKindInternal SpanKind = SpanKind(trace.SpanKindInternal)
+	// SpanKindServer indicates the span handles a request from a remote client.
+	SpanKindServer SpanKind = SpanKind(trace.SpanKindServer)
+	// SpanKindClient indicates the span makes a request to a remote service.
+	SpanKindClient SpanKind = SpanKind(trace.SpanKindClient)
+	// SpanKindProducer indicates the span sends a message to a broker.
+	SpanKindProducer SpanKind = SpanKind(trace.SpanKindProducer)
+	// SpanKindConsumer indicates the span processes a message from a broker.
+	SpanKindConsumer SpanKind = SpanKind(trace.SpanKindConsumer)
+)
+
 // Span wraps an OpenTelemetry span with a simplified API.
 type Span struct {
 	traceSpan trace.Span
+	name      string
+	start     time.Time
+	resources *resourceUsage
 }
 
 // AddEvent adds an event to the span with optional attributes.
 func (s *Span) AddEvent(name string, attrs ...attribute.Attr) {
-	otelAttrs := make([]otelattribute.KeyValue, len(attrs))
-	for i, attr := range attrs {
-		otelAttrs[i] = attr.KeyValue
-	}
+	s.checkEnded("AddEvent")
+
+	otelAttrs := toKeyValues(attrs)
 
 	s.traceSpan.AddEvent(name, trace.WithAttributes(otelAttrs...))
 }
 
 // RecordError records an error on the span without setting status.
+// The exception event carries an error.fingerprint attribute grouping identical failures.
 func (s *Span) RecordError(err error) {
-	s.traceSpan.RecordError(err)
+	s.checkEnded("RecordError")
+
+	s.recordError(err)
 }
 
 // RecordErrorAndSetStatus records an error and sets the span status to Error.
 func (s *Span) RecordErrorAndSetStatus(err error) {
-	s.RecordError(err)
+	s.checkEnded("RecordErrorAndSetStatus")
+
+	s.recordError(err)
 	s.traceSpan.SetStatus(codes.Error, err.Error())
 }
 
+// recordError must be called directly from the exported methods, so the fingerprint starts at their caller.
+func (s *Span) recordError(err error) {
+	s.traceSpan.RecordError(err, trace.WithAttributes(attribute.ErrorFingerprint(err, 2).KeyValue))
+}
+
 // SetStatus sets the span status with a code and description.
 func (s *Span) SetStatus(code StatusCode, description string) {
+	s.checkEnded("SetStatus")
+
 	s.traceSpan.SetStatus(codes.Code(code), description)
 }
 
 // SetOk sets the span status to Ok.
 func (s *Span) SetOk() {
+	s.checkEnded("SetOk")
+
 	s.traceSpan.SetStatus(codes.Ok, "")
 }
 
+// SetHTTPStatus sets the http.response.status_code attribute and the span status for an HTTP response.
+// Per semantic conventions, server spans are errors for 5xx responses and client spans for 4xx and 5xx responses;
+// codes outside 100-599 are always errors. The status is otherwise left unset.
+func (s *Span) SetHTTPStatus(code int, kind SpanKind) {
+	s.checkEnded("SetHTTPStatus")
+
+	s.traceSpan.SetAttributes(semconv.HTTPResponseStatusCode(code))
+
+	if code < 100 || code >= 500 || (code >= 400 && kind == SpanKindClient) {
+		s.traceSpan.SetStatus(codes.Error, "")
+	}
+}
+
 // SetAttributes sets attributes on the span.
 func (s *Span) SetAttributes(attrs ...attribute.Attr) {
-	otelAttrs := make([]otelattribute.KeyValue, len(attrs))
-	for i, attr := range attrs {
-		otelAttrs[i] = attr.KeyValue
-	}
+	s.checkEnded("SetAttributes")
+
+	otelAttrs := toKeyValues(attrs)
 
 	s.traceSpan.SetAttributes(otelAttrs...)
 }
 
-// End completes the span.
+// SetName replaces the span name, e.g. once the route of a request is known.
+func (s *Span) SetName(name string) {
+	s.checkEnded("SetName")
+
+	s.name = name
+	s.traceSpan.SetName(name)
+}
+
+// IsRecording reports whether the span records data, so callers can skip computing attributes for unsampled spans.
+func (s *Span) IsRecording() bool {
+	return s.traceSpan.IsRecording()
+}
+
+// End completes the span, flagging it if it exceeded its SetSlowThresholds threshold.
 func (s *Span) End() {
+	s.checkEnded("End")
+
+	s.recordResourceDeltas()
+	s.flagSlow()
 	s.traceSpan.End()
 }
 
-var tracer = noop.NewTracerProvider().Tracer("noop")
+var (
+	tracerProvider trace.TracerProvider = noop.NewTracerProvider()
+	tracer                              = tracerProvider.Tracer("noop")
+	// propagationOnly skips span creation, set by InitPropagation.
+	propagationOnly bool
+)
 
 func init() {
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 }
 
-func newGrpcTraceExporter(ctx context.Context, insecure bool) (sdktrace.SpanExporter, error) {
-	options := []otlptracegrpc.Option{}
+// InitPropagation configures a propagation-only mode for thin proxies that must preserve trace context and baggage
+// without emitting telemetry. No exporters are created, and span constructors return the span of the propagated
+// context without creating a new one, so Extract and TraceHeaders pass trace context and baggage through unchanged.
+func InitPropagation() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	if insecure {
-		options = append(options, otlptracegrpc.WithInsecure())
-	}
-
-	return otlptracegrpc.New(ctx, options...)
+	tracerProvider = noop.NewTracerProvider()
+	tracer = tracerProvider.Tracer("noop")
+	propagationOnly = true
 }
 
-func newHttpTraceExporter(ctx context.Context, insecure bool) (sdktrace.SpanExporter, error) {
-	options := []otlptracehttp.Option{}
-
-	if insecure {
-		options = append(options, otlptracehttp.WithInsecure())
+// ForceFlush exports all ended spans now, e.g. before the process exits after a crash.
+// It does nothing if InitTracing has not been called.
+func ForceFlush(ctx context.Context) error {
+	provider, ok := tracerProvider.(interface {
+		ForceFlush(ctx context.Context) error
+	})
+	if !ok {
+		return nil
 	}
 
-	return otlptracehttp.New(ctx, options...)
+	return provider.ForceFlush(ctx)
 }
 
-// InitTracing initializes the tracer with OTLP exporters.
-// Returns a shutdown function to flush and close the tracer provider.
-func InitTracing(ctx context.Context, serviceName string, resourceAttrs []attribute.Attr, options ...sdktrace.TracerProviderOption) (func(context.Context) error, error) {
-	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
-		insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true"
-		useHttp := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") == "http"
-
-		var (
-			exporter sdktrace.SpanExporter
-			err      error
-		)
-
-		if useHttp {
-			exporter, err = newHttpTraceExporter(ctx, insecure)
-		} else {
-			exporter, err = newGrpcTraceExporter(ctx, insecure)
-		}
-
-		if err != nil {
-			return nil, err
-		}
-
-		options = append(options, sdktrace.WithBatcher(exporter))
-	}
-
-	options = append(options, sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attribute.ToKeyValues(resourceAttrs)...)))
-	provider := sdktrace.NewTracerProvider(options...)
-	tracer = provider.Tracer(serviceName)
-
-	return provider.Shutdown, nil
+// TracerProvider returns the provider created by InitTracing, for bridging other instrumentation APIs into the same traces.
+// Returns a no-op provider if InitTracing has not been called.
+func TracerProvider() trace.TracerProvider {
+	return tracerProvider
 }
 
 // TraceHeaders extracts W3C trace context headers for propagation to downstream services.
@@ -151,25 +198,62 @@ func TraceHeaders(ctx context.Context) map[string]string {
 	return metadata
 }
 
-func newSpan(ctx context.Context, name string, attrs ...attribute.Attr) (context.Context, Span) {
-	otelAttrs := make([]otelattribute.KeyValue, len(attrs))
-	for i, attr := range attrs {
-		otelAttrs[i] = attr.KeyValue
+func newSpan(ctx context.Context, kind SpanKind, name string, attrs ...attribute.Attr) (context.Context, Span) {
+	return newSpanWithOptions(ctx, kind, name, attrs, nil)
+}
+
+func newSpanWithOptions(ctx context.Context, kind SpanKind, name string, attrs []attribute.Attr, options []trace.SpanStartOption) (context.Context, Span) {
+	ctx = implicit.Resolve(ctx)
+
+	if propagationOnly {
+		return ctx, SpanFromContext(ctx)
+	}
+
+	otelAttrs := toKeyValues(append(identity.Attributes(ctx), attrs...))
+	if id := requestid.FromContext(ctx); id != "" {
+		otelAttrs = append(otelAttrs, otelattribute.String(requestid.Key, id))
 	}
 
-	ctx, traceSpan := tracer.Start(ctx, name, trace.WithAttributes(otelAttrs...))
+	start := time.Now()
+	options = append(options, trace.WithAttributes(otelAttrs...), trace.WithSpanKind(trace.SpanKind(kind)))
+	ctx, traceSpan := tracer.Start(ctx, name, options...)
+
+	span := Span{traceSpan: traceSpan, name: name, start: start}
+	if resourceDeltas.Load() && traceSpan.IsRecording() {
+		span.resources = readResourceUsage()
+	}
 
-	return ctx, Span{traceSpan}
+	return ctx, span
 }
 
 // NewSpan creates a new span with the given name and optional attributes.
 func NewSpan(ctx context.Context, name string, attrs ...attribute.Attr) (context.Context, Span) {
-	return newSpan(ctx, name, attrs...)
+	return newSpan(ctx, SpanKindInternal, name, attrs...)
 }
 
-// NewChildSpan creates a child span from propagated trace context headers.
-func NewChildSpan(ctx context.Context, carrier map[string]string,
-	name string, attrs ...attribute.Attr) (context.Context, Span) {
+// NewSpanWithKind creates a new span of the given kind with the given name and optional attributes.
+func NewSpanWithKind(ctx context.Context, kind SpanKind, name string, attrs ...attribute.Attr) (context.Context, Span) {
+	return newSpan(ctx, kind, name, attrs...)
+}
+
+// SpanFromContext returns the current span from the context.
+// A non-recording span is returned when the context holds no span.
+func SpanFromContext(ctx context.Context) Span {
+	return Span{traceSpan: trace.SpanFromContext(ctx)}
+}
+
+// CurrentSpan returns the span of the context bound to the calling goroutine by the experimental implicit package,
+// for code without a context to pass to SpanFromContext. A non-recording span is returned when none is bound.
+func CurrentSpan() Span {
+	return SpanFromContext(implicit.Context())
+}
+
+// Extract returns a context carrying the trace context and baggage propagated in carrier, such as request headers.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return extract(ctx, carrier)
+}
+
+func extract(ctx context.Context, carrier map[string]string) context.Context {
 	// Normalize keys to lowercase for W3C Trace Context compatibility
 	// (Go's http.Header canonicalizes to "Traceparent" but propagators expect "traceparent")
 	normalized := make(map[string]string, len(carrier))
@@ -177,7 +261,17 @@ func NewChildSpan(ctx context.Context, carrier map[string]string,
 		normalized[strings.ToLower(k)] = v
 	}
 
-	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(normalized))
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(normalized))
+}
 
-	return newSpan(ctx, name, attrs...)
+// NewChildSpan creates a child span from propagated trace context headers.
+func NewChildSpan(ctx context.Context, carrier map[string]string,
+	name string, attrs ...attribute.Attr) (context.Context, Span) {
+	return newSpan(extract(ctx, carrier), SpanKindInternal, name, attrs...)
+}
+
+// NewChildSpanWithKind creates a child span of the given kind from propagated trace context headers.
+func NewChildSpanWithKind(ctx context.Context, carrier map[string]string, kind SpanKind,
+	name string, attrs ...attribute.Attr) (context.Context, Span) {
+	return newSpan(extract(ctx, carrier), kind, name, attrs...)
 }
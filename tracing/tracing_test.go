@@ -1,15 +1,47 @@
+//go:build !gotel_disabled
+
 package tracing
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/implicit"
+	"github.com/tinybluerobots/gotel/log"
+	"github.com/tinybluerobots/gotel/metrics"
+	"github.com/tinybluerobots/gotel/metrics/metrictest"
+	"go.opentelemetry.io/otel"
+	otelattribute "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
 )
 
+var reader = sdkmetric.NewManualReader()
+
+// TestMain initializes metrics once, as instruments bind to the provider on first use
+func TestMain(m *testing.M) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	if _, err := metrics.InitMetrics[struct{}](context.Background(), "test-service", resourceAttrs, nil, sdkmetric.WithReader(reader)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
 // setupTestTracer creates a tracer with an in-memory exporter for testing
 func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
 	exporter := tracetest.NewInMemoryExporter()
@@ -37,6 +69,33 @@ func TestNewSpan(t *testing.T) {
 	assert.Equal(t, "test-span", spans[0].Name)
 }
 
+func TestCurrentSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	implicit.Enable(true)
+	t.Cleanup(func() { implicit.Enable(false) })
+
+	ctx, parent := NewSpan(t.Context(), "parent")
+	restore := implicit.Bind(ctx)
+
+	current := CurrentSpan()
+	assert.True(t, current.IsRecording())
+
+	// Legacy code without a context still parents its spans
+	_, child := NewSpan(context.Background(), "child")
+	child.End()
+
+	restore()
+	parent.End()
+
+	current = CurrentSpan()
+	assert.False(t, current.IsRecording())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
 func TestSpan_AddEvent(t *testing.T) {
 	exporter := setupTestTracer(t)
 	ctx := t.Context()
@@ -140,6 +199,37 @@ func TestSpan_SetOk(t *testing.T) {
 	assert.Equal(t, "Ok", spans[0].Status.Code.String())
 }
 
+func TestSpan_SetHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         int
+		kind         SpanKind
+		expectedCode string
+	}{
+		{"server success", 200, SpanKindServer, "Unset"},
+		{"server client error", 404, SpanKindServer, "Unset"},
+		{"server error", 503, SpanKindServer, "Error"},
+		{"client error", 404, SpanKindClient, "Error"},
+		{"client server error", 500, SpanKindClient, "Error"},
+		{"invalid code", 42, SpanKindServer, "Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := setupTestTracer(t)
+
+			_, span := NewSpanWithKind(t.Context(), tt.kind, "test-span")
+			span.SetHTTPStatus(tt.code, tt.kind)
+			span.End()
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.expectedCode, spans[0].Status.Code.String())
+			assert.Contains(t, spans[0].Attributes, semconv.HTTPResponseStatusCode(tt.code))
+		})
+	}
+}
+
 func TestStartChildSpan(t *testing.T) {
 	exporter := setupTestTracer(t)
 	ctx := t.Context()
@@ -230,3 +320,610 @@ func TestNestedChildSpans(t *testing.T) {
 	spans := exporter.GetSpans()
 	require.Len(t, spans, 3, "expected 3 spans")
 }
+
+func TestNewChildSpanWithKind(t *testing.T) {
+	exporter := setupTestTracer(t)
+	ctx := t.Context()
+
+	ctx, parentSpan := NewSpanWithKind(ctx, SpanKindClient, "client")
+	_, serverSpan := NewChildSpanWithKind(t.Context(), TraceHeaders(ctx), SpanKindServer, "server")
+	serverSpan.SetName("GET /users/{id}")
+	serverSpan.End()
+	parentSpan.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2, "expected 2 spans")
+	assert.Equal(t, "GET /users/{id}", spans[0].Name)
+	assert.Equal(t, "server", spans[0].SpanKind.String())
+	assert.Equal(t, "client", spans[1].SpanKind.String())
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID(), "server should reference client")
+}
+
+func TestTracerProvider(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	_, span := TracerProvider().Tracer("bridge").Start(t.Context(), "bridged-span")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "spans from the provider should reach the exporter")
+	assert.Equal(t, "bridged-span", spans[0].Name)
+}
+
+func TestSetSlowThresholds(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	SetSlowThresholds(map[string]time.Duration{"db.query": time.Millisecond, AllSpans: time.Hour})
+	t.Cleanup(func() { SetSlowThresholds(nil) })
+
+	_, slow := NewSpan(t.Context(), "db.query")
+	time.Sleep(2 * time.Millisecond)
+	slow.End()
+
+	_, fast := NewSpan(t.Context(), "handler")
+	fast.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	slowFlagged := false
+
+	for _, kv := range spans[0].Attributes {
+		if kv.Key == "slow" {
+			slowFlagged = kv.Value.AsBool()
+		}
+	}
+
+	assert.True(t, slowFlagged, "db.query should be flagged as slow")
+
+	for _, kv := range spans[1].Attributes {
+		assert.NotEqual(t, "slow", string(kv.Key), "handler is within the default threshold")
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	assert.NotNil(t, metrictest.Find(rm, "slow_operations"), "slow_operations should be incremented")
+}
+
+var allocSink [][]byte
+
+func TestSetResourceDeltas(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	_, untracked := NewSpan(t.Context(), "untracked")
+	untracked.End()
+
+	SetResourceDeltas(true)
+	t.Cleanup(func() { SetResourceDeltas(false) })
+
+	_, span := NewSpan(t.Context(), "allocating")
+
+	for range 100 {
+		allocSink = append(allocSink, make([]byte, 1024))
+	}
+
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	for _, kv := range spans[0].Attributes {
+		assert.NotContains(t, string(kv.Key), "runtime.", "resource deltas are opt-in")
+	}
+
+	attrs := map[string]any{}
+	for _, kv := range spans[1].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+
+	// The runtime flushes per-processor allocation counts lazily, so only check some were seen
+	assert.Positive(t, attrs["runtime.alloc_bytes"])
+	assert.Positive(t, attrs["runtime.alloc_objects"])
+	assert.Contains(t, attrs, "runtime.cpu_seconds")
+}
+
+func TestInitPropagation(t *testing.T) {
+	InitPropagation()
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		propagationOnly = false
+	})
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	headers := map[string]string{"Traceparent": traceparent, "Baggage": "tenant=acme"}
+
+	ctx, span := NewChildSpan(t.Context(), headers, "proxy")
+	span.SetAttributes(attribute.New("ignored", true))
+	span.End()
+
+	assert.False(t, span.IsRecording())
+
+	outgoing := TraceHeaders(ctx)
+	assert.Equal(t, traceparent, outgoing["traceparent"], "trace context should pass through unchanged")
+	assert.Equal(t, "tenant=acme", outgoing["baggage"])
+
+	assert.Equal(t, outgoing, TraceHeaders(Extract(t.Context(), headers)))
+}
+
+func TestSpanContextToken(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	ctx, span := NewSpan(t.Context(), "failing operation")
+	token := MarshalSpanContext(ctx)
+	span.End()
+
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, token)
+	assert.Empty(t, MarshalSpanContext(t.Context()))
+
+	spanContext, err := ParseSpanContext(" " + token + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, span.traceSpan.SpanContext().TraceID(), spanContext.TraceID())
+	assert.Equal(t, span.traceSpan.SpanContext().SpanID(), spanContext.SpanID())
+
+	_, err = ParseSpanContext("not-a-token")
+	require.ErrorIs(t, err, errInvalidSpanContext)
+
+	_, followUp := NewLinkedSpan(t.Context(), spanContext, "support follow-up")
+	followUp.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Len(t, spans[1].Links, 1)
+	assert.Equal(t, spanContext.SpanID(), spans[1].Links[0].SpanContext.SpanID())
+	assert.NotEqual(t, spanContext.TraceID(), spans[1].SpanContext.TraceID(), "linked spans start their own trace")
+}
+
+func TestSpan_ErrorFingerprint(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	_, span := NewSpan(t.Context(), "test-span")
+	span.RecordError(assert.AnError)
+	span.RecordErrorAndSetStatus(assert.AnError)
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events, 2)
+
+	fingerprints := []string{}
+
+	for _, event := range spans[0].Events {
+		for _, kv := range event.Attributes {
+			if kv.Key == attribute.ErrorFingerprintKey {
+				fingerprints = append(fingerprints, kv.Value.AsString())
+			}
+		}
+	}
+
+	require.Len(t, fingerprints, 2)
+	assert.Equal(t, fingerprints[0], fingerprints[1], "both methods fingerprint from their caller")
+	assert.Equal(t, attribute.ErrorFingerprint(assert.AnError, 0).Value.AsString(), fingerprints[0])
+}
+
+func TestShadowSampler(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	sampler := ShadowSampler(sdktrace.AlwaysSample(), sdktrace.NeverSample())
+	_, err := InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sampler))
+	require.NoError(t, err)
+
+	for range 3 {
+		_, span := NewSpan(t.Context(), "operation")
+		span.End()
+	}
+
+	assert.Len(t, exporter.GetSpans(), 3, "the candidate sampler doesn't affect recording")
+	assert.Contains(t, sampler.Description(), "AlwaysOnSampler")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	found := metrictest.Find(rm, "sampler_shadow_decisions")
+	require.NotNil(t, found, "sampler_shadow_decisions metric not found")
+
+	decisions, ok := found.Data.(metricdata.Sum[int64])
+	require.True(t, ok, "expected Sum[int64], got %T", found.Data)
+	require.Len(t, decisions.DataPoints, 1)
+
+	active, _ := decisions.DataPoints[0].Attributes.Value("sampler.active")
+	candidate, _ := decisions.DataPoints[0].Attributes.Value("sampler.candidate")
+	assert.Equal(t, "keep", active.AsString())
+	assert.Equal(t, "drop", candidate.AsString())
+	assert.Equal(t, int64(3), decisions.DataPoints[0].Value)
+}
+
+func TestSpanMetricsProcessor(t *testing.T) {
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	_, err := InitTracing(t.Context(), "test-service", resourceAttrs, sdktrace.WithSpanProcessor(SpanMetricsProcessor()))
+	require.NoError(t, err)
+
+	for range 2 {
+		_, span := NewSpanWithKind(t.Context(), SpanKindServer, "GET /orders")
+		span.End()
+	}
+
+	_, failed := NewSpanWithKind(t.Context(), SpanKindServer, "GET /orders")
+	failed.RecordErrorAndSetStatus(assert.AnError)
+	failed.End()
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	var (
+		calls     metricdata.Sum[int64]
+		durations metricdata.Histogram[float64]
+	)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case "span_calls":
+				calls, _ = m.Data.(metricdata.Sum[int64])
+			case "span_duration":
+				assert.Equal(t, "s", m.Unit)
+
+				durations, _ = m.Data.(metricdata.Histogram[float64])
+			}
+		}
+	}
+
+	counts := map[string]int64{}
+
+	for _, dp := range calls.DataPoints {
+		name, _ := dp.Attributes.Value("span.name")
+		kind, _ := dp.Attributes.Value("span.kind")
+		status, _ := dp.Attributes.Value("status.code")
+		assert.Equal(t, "GET /orders", name.AsString())
+		assert.Equal(t, "server", kind.AsString())
+
+		counts[status.AsString()] = dp.Value
+	}
+
+	assert.Equal(t, map[string]int64{"Unset": 2, "Error": 1}, counts)
+
+	require.Len(t, durations.DataPoints, 2)
+
+	for _, dp := range durations.DataPoints {
+		assert.NotEmpty(t, dp.Exemplars, "durations link to their spans")
+	}
+}
+
+func TestAdaptiveSampler(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	now := time.Now()
+	sampler, _ := AdaptiveSampler(10).(*adaptiveSampler)
+	sampler.now = func() time.Time { return now }
+
+	_, err := InitTracing(t.Context(), "test-service", resourceAttrs,
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(KeepErrorsAndSlow(sdktrace.NewSimpleSpanProcessor(exporter))),
+	)
+	require.NoError(t, err)
+
+	for range 100 {
+		_, span := NewSpan(t.Context(), "poll")
+		span.End()
+	}
+
+	assert.Len(t, exporter.GetSpans(), 100, "names are sampled at rate 1 in their first minute")
+	exporter.Reset()
+
+	// 100 spans in the last minute against a budget of 10
+	now = now.Add(time.Minute)
+
+	for range 1000 {
+		_, span := NewSpan(t.Context(), "poll")
+		span.End()
+	}
+
+	assert.InDelta(t, 100, len(exporter.GetSpans()), 50, "about a tenth of spans should be sampled")
+
+	for _, attr := range exporter.GetSpans()[0].Attributes {
+		if attr.Key == AdaptiveRateKey {
+			assert.InDelta(t, 0.1, attr.Value.AsFloat64(), 1e-9)
+		}
+	}
+	exporter.Reset()
+
+	for range 20 {
+		ctx, span := NewSpan(t.Context(), "poll")
+		_, child := NewSpan(ctx, "fetch")
+		child.SetStatus(StatusError, "timeout")
+		child.End()
+		span.End()
+	}
+
+	failed := 0
+	kept := 0
+
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "fetch" {
+			continue
+		}
+
+		failed++
+
+		for _, attr := range span.Attributes {
+			if attr.Key == "sampler.adaptive.kept" && attr.Value.AsString() == "error" {
+				kept++
+			}
+		}
+	}
+
+	assert.Equal(t, 20, failed, "failed spans are kept whatever the rate")
+	assert.Positive(t, kept, "sampled out failed spans are marked as kept")
+	exporter.Reset()
+
+	SetSlowThresholds(map[string]time.Duration{"poll": time.Nanosecond})
+	t.Cleanup(func() { SetSlowThresholds(nil) })
+
+	for range 20 {
+		_, span := NewSpan(t.Context(), "poll")
+		time.Sleep(time.Microsecond)
+		span.End()
+	}
+
+	assert.Len(t, exporter.GetSpans(), 20, "slow spans are kept whatever the rate")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	found := false
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			gauge, ok := m.Data.(metricdata.Gauge[float64])
+			if !ok || m.Name != "sampler_adaptive_rate" {
+				continue
+			}
+
+			for _, dp := range gauge.DataPoints {
+				if name, _ := dp.Attributes.Value("span.name"); name.AsString() == "poll" {
+					found = true
+
+					assert.InDelta(t, 0.1, dp.Value, 1e-9)
+				}
+			}
+		}
+	}
+
+	assert.True(t, found, "the rate of each span name should be observed")
+	assert.Contains(t, sampler.Description(), "AdaptiveSampler")
+}
+
+func TestAdaptiveSampler_Evict(t *testing.T) {
+	now := time.Now()
+	sampler, _ := AdaptiveSampler(10).(*adaptiveSampler)
+	sampler.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	for i := range maxAdaptiveNames + 10 {
+		sampler.rate(fmt.Sprintf("job-%d", i))
+	}
+
+	assert.Len(t, sampler.rates, maxAdaptiveNames, "the rates are bounded")
+	assert.NotContains(t, sampler.rates, "job-0", "the least recently used names are evicted")
+	assert.Contains(t, sampler.rates, fmt.Sprintf("job-%d", maxAdaptiveNames+9))
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+
+	series := 0
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[float64]); ok && m.Name == "sampler_adaptive_rate" {
+				for _, dp := range gauge.DataPoints {
+					if name, _ := dp.Attributes.Value("span.name"); strings.HasPrefix(name.AsString(), "job-") {
+						series++
+					}
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, maxAdaptiveNames, series, "the callbacks of evicted names are unregistered")
+}
+
+func TestResumeSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	ctx, root := NewSpan(t.Context(), "import")
+	checkpoint := NewContinuation(ctx)
+	root.End()
+
+	assert.Equal(t, 0, checkpoint.Count)
+
+	for range 2 {
+		ctx, span, err := ResumeSpan(t.Context(), checkpoint, "import")
+		require.NoError(t, err)
+
+		checkpoint = NewContinuation(ctx)
+		span.End()
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, 2, checkpoint.Count)
+
+	for i, span := range spans[1:] {
+		assert.NotEqual(t, spans[0].SpanContext.TraceID(), span.SpanContext.TraceID(), "each run is a new trace")
+		require.Len(t, span.Links, 1)
+		assert.Equal(t, spans[0].SpanContext.SpanID(), span.Links[0].SpanContext.SpanID(), "every run links to the original root")
+		assert.Contains(t, span.Attributes, otelattribute.Int(ContinuationCountKey, i+1))
+	}
+
+	ctx, span, err := ResumeSpan(t.Context(), Continuation{}, "import")
+	require.Error(t, err)
+	span.End()
+
+	assert.Equal(t, MarshalSpanContext(ctx), NewContinuation(ctx).SpanContext, "later runs link to the first traced run")
+}
+
+func TestWithSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	value, err := WithSpan(t.Context(), "lookup", func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	_, err = WithSpan(t.Context(), "failing", func(ctx context.Context) (int, error) {
+		return 0, assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond)
+	defer cancel()
+
+	_, err = WithSpan(ctx, "slow", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithDeadlineStatus())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "Unset", spans[0].Status.Code.String())
+	assert.Equal(t, "Error", spans[1].Status.Code.String())
+	assert.Equal(t, assert.AnError.Error(), spans[1].Status.Description)
+	assert.Equal(t, "Error", spans[2].Status.Code.String())
+	assert.Equal(t, "context deadline exceeded", spans[2].Status.Description)
+	assert.Contains(t, spans[2].Attributes, otelattribute.String("context.error", "deadline_exceeded"))
+	assert.Len(t, spans[2].Events, 1, "the error returned by fn is still recorded")
+}
+
+func TestInitTracing_JSON(t *testing.T) {
+	var contentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	shutdown, err := InitTracing(t.Context(), "test-service", resourceAttrs)
+	require.NoError(t, err)
+
+	_, span := NewSpan(t.Context(), "test-span")
+	span.End()
+
+	require.NoError(t, shutdown(t.Context()))
+	assert.Equal(t, "application/json", contentType)
+}
+
+func TestInitTracing_SDKDisabled(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		propagationOnly = false
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+
+	shutdown, err := InitTracing(t.Context(), "test-service", nil, sdktrace.WithSyncer(exporter))
+	require.NoError(t, err)
+
+	_, span := NewSpan(t.Context(), "dropped")
+	span.End()
+
+	require.NoError(t, shutdown(t.Context()))
+	assert.Empty(t, exporter.GetSpans())
+	_, isSDK := TracerProvider().(*sdktrace.TracerProvider)
+	assert.False(t, isSDK, "no SDK provider is created")
+}
+
+func TestOverrideSampler(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	resourceAttrs := attribute.ResourceAttributes("test-service", "1.0.0", "test", "testhost")
+
+	_, err := InitTracing(t.Context(), "test-service", resourceAttrs,
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(OverrideSampler(sdktrace.NeverSample())),
+	)
+	require.NoError(t, err)
+
+	_, span := NewSpan(t.Context(), "dropped")
+	span.End()
+
+	ctx, span := NewSpan(ForceSample(t.Context()), "forced")
+	_, child := NewSpan(ctx, "child")
+	child.End()
+	span.End()
+
+	_, span = NewSpan(ForceDrop(ForceSample(t.Context())), "forced-drop")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name, "children of forced spans follow the parent")
+	assert.Equal(t, "forced", spans[1].Name)
+	assert.Contains(t, OverrideSampler(sdktrace.NeverSample()).Description(), "AlwaysOffSampler")
+}
+
+func TestSetDevChecks(t *testing.T) {
+	warnings := map[string][]attribute.Attr{}
+	warn := log.Warn
+	log.Warn = func(_ context.Context, message string, attrs ...attribute.Attr) { warnings[message] = attrs }
+
+	t.Cleanup(func() { log.Warn = warn })
+
+	SetDevChecks(true)
+	t.Cleanup(func() { SetDevChecks(false) })
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithSpanProcessor(newDevCheckProcessor()))
+	previous := tracer
+	tracer = provider.Tracer("test")
+
+	t.Cleanup(func() { tracer = previous })
+
+	ctx, parent := NewSpan(t.Context(), "parent")
+	_, child := NewSpan(ctx, "child")
+	_, done := NewSpan(ctx, "done")
+	done.End()
+	_, _ = NewSpan(ctx, "forgotten")
+
+	parent.End()
+	child.End()
+	child.SetAttributes(attribute.New("late", true))
+
+	assert.NotContains(t, warnings, "span never ended", "open spans are reported on shutdown")
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	attrValue := func(message string, key string) string {
+		for _, attr := range warnings[message] {
+			if string(attr.Key) == key {
+				return attr.Value.Emit()
+			}
+		}
+
+		return ""
+	}
+
+	require.Contains(t, warnings, "span ended after its parent")
+	assert.Equal(t, "child", attrValue("span ended after its parent", "span.name"))
+	assert.Equal(t, "parent", attrValue("span ended after its parent", "parent.name"))
+	assert.Contains(t, attrValue("span ended after its parent", "span.ended_at"), "tracing_test.go:")
+
+	require.Contains(t, warnings, "span modified after End")
+	assert.Equal(t, "SetAttributes", attrValue("span modified after End", "span.method"))
+	assert.Contains(t, attrValue("span modified after End", "span.called_at"), "tracing_test.go:")
+
+	require.Contains(t, warnings, "span never ended")
+	assert.Equal(t, "forgotten", attrValue("span never ended", "span.name"))
+	assert.Contains(t, attrValue("span never ended", "span.started_at"), "tracing_test.go:")
+}
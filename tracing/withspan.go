@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tinybluerobots/gotel/attribute"
+)
+
+type spanConfig struct {
+	deadlineStatus bool
+}
+
+// SpanOption configures WithSpan.
+type SpanOption func(*spanConfig)
+
+// WithDeadlineStatus marks the span as timed out if its context is done when fn returns: the status is set to
+// Error with the context error as its description, e.g. "context deadline exceeded", and a context.error
+// attribute of deadline_exceeded or canceled distinguishes timeouts and cancellations from other failures.
+// An error returned by fn is still recorded as an exception event.
+func WithDeadlineStatus() SpanOption {
+	return func(c *spanConfig) {
+		c.deadlineStatus = true
+	}
+}
+
+func contextErrorType(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "deadline_exceeded"
+	}
+
+	return "canceled"
+}
+
+// WithSpan runs fn in a new span and ends it when fn returns, recording the error fn returns and setting the
+// span status to Error. fn runs in the caller's goroutine.
+func WithSpan[T any](ctx context.Context, name string, fn func(ctx context.Context) (T, error), options ...SpanOption) (T, error) {
+	c := &spanConfig{}
+	for _, option := range options {
+		option(c)
+	}
+
+	ctx, span := NewSpan(ctx, name)
+	defer span.End()
+
+	result, err := fn(ctx)
+
+	if ctxErr := ctx.Err(); c.deadlineStatus && ctxErr != nil {
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		span.SetAttributes(attribute.New("context.error", contextErrorType(ctxErr)))
+		span.SetStatus(StatusError, ctxErr.Error())
+
+		return result, err
+	}
+
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+	}
+
+	return result, err
+}
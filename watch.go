@@ -0,0 +1,45 @@
+package gotel
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/tinybluerobots/gotel/attribute"
+	"github.com/tinybluerobots/gotel/log"
+)
+
+// goroutineStacks returns the stacks of all goroutines.
+func goroutineStacks() string {
+	buf := make([]byte, 64*1024)
+
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// Watch starts a watchdog for an operation and returns a function to call when the operation completes.
+// If it isn't called within timeout, a WARN log with the stacks of all goroutines is written and the
+// watchdog_timeouts counter is incremented, to catch stuck handlers.
+// Cancelling ctx doesn't stop the watchdog, as a stuck operation may be ignoring cancellation.
+func Watch(ctx context.Context, name string, timeout time.Duration) func() {
+	ctx = context.WithoutCancel(ctx)
+
+	timer := time.AfterFunc(timeout, func() {
+		getMetrics().WatchdogTimeouts.Add(ctx, 1, attribute.New("watch.name", name))
+		log.Warn(ctx, "watchdog timeout",
+			attribute.New("watch.name", name),
+			attribute.New("timeout_ms", timeout.Milliseconds()),
+			attribute.New("goroutine_stacks", goroutineStacks()),
+		)
+	})
+
+	return func() {
+		timer.Stop()
+	}
+}